@@ -0,0 +1,540 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/state"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var adoptApply bool
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt",
+	Short: "Take over an Envoy Gateway and AI Gateway installed without the installer",
+	Long: `Find Envoy Gateway and AI Gateway installations the installer did not create,
+and make them its own so that install upgrades them in place instead of
+failing on existing objects or uninstalling releases it does not know.
+
+adopt looks for the releases of gateway-helm, ai-gateway-crds-helm and
+ai-gateway-helm under any name and namespace, for the AI Gateway CRDs, and
+for controllers applied with kubectl. Then, for each release:
+
+- a helm release with the name and namespace install expects is recorded
+  in the installer state, with its chart version
+- objects applied with kubectl, in the namespace install expects, are
+  labelled and annotated as part of the release install would create, so
+  that helm takes them over on the next install
+- anything else, such as a release under another name or several releases
+  of the same chart, gets a step-by-step migration plan to follow by hand
+
+adopt only reports what it would do unless --apply is given, and never
+deletes anything: steps that remove a release are part of the printed plan.`,
+	RunE: runAdopt,
+}
+
+func init() {
+	adoptCmd.Flags().BoolVar(&adoptApply, "apply", false,
+		"adopt what can be adopted automatically (default: only report)")
+}
+
+const (
+	// adoptRecord records a helm release with the expected name in the
+	// installer state.
+	adoptRecord = "record"
+	// adoptRelabel makes objects applied without helm part of the release.
+	adoptRelabel = "relabel"
+	// adoptManual needs the migration plan to be followed by hand.
+	adoptManual = "manual"
+)
+
+// adoptSource tells how to recognise an installation of a managed release
+// that was applied without helm.
+type adoptSource struct {
+	// selector finds the controller deployment.
+	selector string
+	// crdGroup finds the CRDs, for charts made of CRDs only.
+	crdGroup string
+	// tagFlag is the install flag that pins the release's version.
+	tagFlag string
+	// versionFrom is the release whose controller image tells the version.
+	versionFrom string
+}
+
+var adoptSources = map[string]adoptSource{
+	"eg":       {selector: "control-plane=envoy-gateway", tagFlag: "--envoy-gateway-tag", versionFrom: "eg"},
+	"aieg-crd": {crdGroup: aiGatewayCRDGroup, tagFlag: "--ai-gateway-tag", versionFrom: "aieg"},
+	"aieg":     {selector: "app.kubernetes.io/name=ai-gateway-controller", tagFlag: "--ai-gateway-tag", versionFrom: "aieg"},
+}
+
+// adoptScan is what adopt found in the cluster.
+type adoptScan struct {
+	releases    []helm.Release
+	deployments map[string][]k8s.Object
+	crds        []k8s.Object
+}
+
+// adoptFinding is how one managed release was installed, and what adopt
+// does about it.
+type adoptFinding struct {
+	release managedRelease
+	action  string
+	found   string
+	// version is the installed version, when known.
+	version string
+	// objects are the objects of a kubectl-applied install to relabel.
+	objects []k8s.Object
+	// steps are the migration plan for adoptManual.
+	steps []string
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := fetchValuesExtra(cfg); err != nil {
+		return err
+	}
+	isDryRun := !adoptApply || viper.GetBool("dry_run")
+
+	output.Println("🔎 Scanning for existing Envoy Gateway and AI Gateway installations")
+	scan, err := scanInstallations()
+	if err != nil {
+		return err
+	}
+
+	findings := classifyInstallations(cfg, scan)
+	if err := planRelabels(cfg, findings); err != nil {
+		return err
+	}
+
+	adoptable := 0
+	var actions []string
+	for _, f := range findings {
+		output.Printf("  %-33s %s\n", strings.TrimPrefix(f.release.chart, "envoyproxy/")+":", f.found)
+		switch f.action {
+		case adoptRecord:
+			actions = append(actions, fmt.Sprintf("record release %s (%s) in the installer state", f.release.name, f.version))
+		case adoptRelabel:
+			actions = append(actions, fmt.Sprintf("label %d objects as part of release %s in %s", len(f.objects), f.release.name, f.release.namespace))
+		}
+		if f.action == adoptRecord || f.action == adoptRelabel {
+			adoptable++
+		}
+	}
+
+	manual := 0
+	for _, f := range findings {
+		if f.action != adoptManual {
+			continue
+		}
+		manual++
+		output.Printf("\n📋 %s cannot be adopted automatically. To migrate it:\n", f.release.name)
+		for i, step := range f.steps {
+			output.Printf("   %d. %s\n", i+1, step)
+		}
+	}
+
+	if adoptable == 0 {
+		if manual == 0 {
+			output.Println("\nℹ️  Nothing to adopt: no existing installation found")
+		}
+		return nil
+	}
+
+	if isDryRun {
+		output.Println()
+		for _, action := range actions {
+			output.Printf("[DRY-RUN] %s\n", action)
+		}
+		if !adoptApply {
+			output.Println("\nRun 'adopt --apply' to adopt the installations above")
+		}
+		return nil
+	}
+
+	kubeContext := k8s.CurrentContext()
+	if kubeContext == "" {
+		kubeContext = "(none; in-cluster or default configuration)"
+	}
+	if err := prompter().ConfirmActions("🏷️  The following installations will be adopted:", actions,
+		"Kube context: "+kubeContext); err != nil {
+		return err
+	}
+
+	if err := adoptInstallations(cfg, findings); err != nil {
+		return err
+	}
+
+	output.Println("\n✅ Installations adopted")
+	if hint := adoptInstallHint(findings); hint != "" {
+		output.Printf("   Upgrade them in place with: envoy-ai-installer install%s\n", hint)
+	}
+	if manual > 0 {
+		output.Printf("   ⚠️  %d releases still need the manual steps above\n", manual)
+	}
+	return nil
+}
+
+// scanInstallations lists the helm releases of every namespace, the
+// controller deployments and the AI Gateway CRDs.
+func scanInstallations() (*adoptScan, error) {
+	helmCmd := helm.NewHelmCommand(false)
+	releases, err := helmCmd.ListAllReleases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list helm releases: %w", err)
+	}
+
+	scan := &adoptScan{releases: releases, deployments: map[string][]k8s.Object{}}
+	for id, source := range adoptSources {
+		if source.selector == "" {
+			continue
+		}
+		deployments, err := k8s.ListObjects("deployments", source.selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		scan.deployments[id] = deployments
+	}
+
+	crds, err := k8s.ListObjects("customresourcedefinitions", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CRDs: %w", err)
+	}
+	scan.crds = crds
+	return scan, nil
+}
+
+// classifyInstallations decides, for each managed release, how it was
+// installed and whether it can be adopted.
+func classifyInstallations(cfg *config.Config, scan *adoptScan) []adoptFinding {
+	var findings []adoptFinding
+	for _, r := range managedReleases(cfg) {
+		findings = append(findings, classifyInstallation(cfg, r, scan))
+	}
+	return findings
+}
+
+func classifyInstallation(cfg *config.Config, r managedRelease, scan *adoptScan) adoptFinding {
+	f := adoptFinding{release: r}
+	chart := strings.TrimPrefix(r.chart, "envoyproxy/")
+
+	var releases []helm.Release
+	for _, rel := range scan.releases {
+		if rel.ChartName() == chart {
+			releases = append(releases, rel)
+		}
+	}
+
+	switch {
+	case len(releases) > 1:
+		names := make([]string, 0, len(releases))
+		for _, rel := range releases {
+			names = append(names, rel.Name+" in "+rel.Namespace)
+		}
+		f.action = adoptManual
+		f.found = fmt.Sprintf("%d helm releases: %s", len(releases), strings.Join(names, ", "))
+		f.steps = multipleReleaseSteps(r, releases)
+		return f
+
+	case len(releases) == 1:
+		rel := releases[0]
+		f.version = rel.ChartVersion()
+		f.found = fmt.Sprintf("helm release %s in %s (%s)", rel.Name, rel.Namespace, f.version)
+		if rel.Name == r.name && rel.Namespace == r.namespace {
+			f.action = adoptRecord
+			return f
+		}
+		f.action = adoptManual
+		f.steps = renamedReleaseSteps(cfg, r, rel)
+		return f
+	}
+
+	source := adoptSources[r.id]
+	f.version = installedVersion(scan.deployments[source.versionFrom])
+
+	if source.crdGroup != "" {
+		var crds []string
+		for _, crd := range scan.crds {
+			if strings.HasSuffix(crd.Name, "."+source.crdGroup) {
+				crds = append(crds, crd.Name)
+			}
+		}
+		if len(crds) == 0 {
+			f.found = "not installed"
+			return f
+		}
+		f.action = adoptRelabel
+		f.found = fmt.Sprintf("%d CRDs applied without helm", len(crds))
+		return f
+	}
+
+	deployments := scan.deployments[r.id]
+	switch {
+	case len(deployments) == 0:
+		f.found = "not installed"
+		return f
+	case len(deployments) > 1:
+		names := make([]string, 0, len(deployments))
+		for _, d := range deployments {
+			names = append(names, d.Namespace+"/"+d.Name)
+		}
+		f.action = adoptManual
+		f.found = fmt.Sprintf("%d controllers applied without helm: %s", len(deployments), strings.Join(names, ", "))
+		f.steps = []string{
+			"Keep one controller and delete the others once nothing routes through them; controllers watching the same resources conflict",
+			"Run 'adopt' again",
+		}
+		return f
+	}
+
+	d := deployments[0]
+	f.found = fmt.Sprintf("deployment %s in %s applied without helm", d.Name, d.Namespace)
+	if f.version != "" {
+		f.found += " (" + f.version + ")"
+	}
+	if d.Namespace != r.namespace {
+		flag := "--namespace-ai"
+		if r.namespace == cfg.NamespaceGateway {
+			flag = "--namespace-gateway"
+		}
+		f.action = adoptManual
+		f.steps = []string{
+			fmt.Sprintf("The controller runs in %s, not in %s where install expects it, and objects cannot move between namespaces", d.Namespace, r.namespace),
+			fmt.Sprintf("Keep its namespace: run 'adopt %s %s', and pass the same flag to install, or set it with 'config set %s %s'",
+				flag, d.Namespace, strings.ReplaceAll(strings.TrimPrefix(flag, "--"), "-", "_"), d.Namespace),
+		}
+		return f
+	}
+	f.action = adoptRelabel
+	return f
+}
+
+// installedVersion returns the image tag of a kubectl-applied controller,
+// when it names a release.
+func installedVersion(deployments []k8s.Object) string {
+	for _, d := range deployments {
+		for _, image := range d.Images {
+			if tag := imageTag(image); releaseTagPattern.MatchString(tag) {
+				return tag
+			}
+		}
+	}
+	return ""
+}
+
+var releaseTagPattern = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+`)
+
+// imageTag returns the tag of an image reference, or "".
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, ok := strings.Cut(name, ":"); ok {
+		return tag
+	}
+	return ""
+}
+
+// renamedReleaseSteps is the migration plan for a release installed under
+// another name or namespace than install expects.
+func renamedReleaseSteps(cfg *config.Config, r managedRelease, rel helm.Release) []string {
+	var steps []string
+
+	var flags []string
+	if prefix, ok := strings.CutSuffix(rel.Name, r.id); ok && prefix != cfg.ReleasePrefix {
+		flags = append(flags, "--release-prefix "+prefix)
+	}
+	if rel.Namespace != r.namespace {
+		if r.namespace == cfg.NamespaceGateway {
+			flags = append(flags, "--namespace-gateway "+rel.Namespace)
+		} else {
+			flags = append(flags, "--namespace-ai "+rel.Namespace)
+		}
+	}
+	alternative := func(step string) string {
+		if len(steps) == 0 {
+			return strings.ToUpper(step[:1]) + step[1:]
+		}
+		return "Or " + step
+	}
+	if strings.HasSuffix(rel.Name, r.id) {
+		steps = append(steps, fmt.Sprintf("Either keep the release as it is: run 'adopt %s', and pass the same flags to every command or set them with 'config set'",
+			strings.Join(flags, " ")))
+	}
+
+	if rel.Namespace != r.namespace {
+		return append(steps,
+			alternative(fmt.Sprintf("reinstall in %s, as objects cannot move between namespaces:", r.namespace)),
+			"Back up the gateway resources with 'envoy-ai-installer backup'",
+			fmt.Sprintf("Keep the CRDs and their objects through the uninstall: helm get manifest %s -n %s | kubectl annotate -f - -n %s --overwrite helm.sh/resource-policy=keep",
+				rel.Name, rel.Namespace, rel.Namespace),
+			fmt.Sprintf("Uninstall the old release, which stops its controller: helm uninstall %s -n %s", rel.Name, rel.Namespace),
+			"Run 'envoy-ai-installer install', then 'envoy-ai-installer restore' if objects are missing",
+		)
+	}
+
+	return append(steps,
+		alternative(fmt.Sprintf("rename it to %s without downtime:", r.name)),
+		"Back up the gateway resources with 'envoy-ai-installer backup'",
+		fmt.Sprintf("Hand its objects over to %s and keep them through the uninstall: helm get manifest %s -n %s | kubectl annotate -f - -n %s --overwrite helm.sh/resource-policy=keep %s=%s %s=%s",
+			r.name, rel.Name, rel.Namespace, rel.Namespace,
+			k8s.HelmReleaseNameAnnotation, r.name, k8s.HelmReleaseNamespaceAnnotation, r.namespace),
+		fmt.Sprintf("Remove the old release record, leaving the objects in place: helm uninstall %s -n %s", rel.Name, rel.Namespace),
+		fmt.Sprintf("Run 'envoy-ai-installer install', which takes the objects over as %s", r.name),
+	)
+}
+
+// multipleReleaseSteps is the migration plan for a chart installed several
+// times.
+func multipleReleaseSteps(r managedRelease, releases []helm.Release) []string {
+	steps := []string{
+		"Decide which release to keep; controllers of the same chart watch the same resources and conflict",
+	}
+	for _, rel := range releases {
+		if rel.Name == r.name && rel.Namespace == r.namespace {
+			steps = append(steps, fmt.Sprintf("Release %s in %s is the one install manages", rel.Name, rel.Namespace))
+		}
+	}
+	return append(steps,
+		"Back up the gateway resources with 'envoy-ai-installer backup'",
+		"Uninstall the other releases once nothing routes through them, with helm uninstall <release> -n <namespace>",
+		"Run 'adopt' again",
+	)
+}
+
+// planRelabels renders the charts of the kubectl-applied releases and
+// finds which of their objects exist in the cluster. A release whose
+// objects belong to another helm release is left to be migrated by hand.
+func planRelabels(cfg *config.Config, findings []adoptFinding) error {
+	var resolved []resolvedRelease
+	for _, f := range findings {
+		if f.action == adoptRelabel {
+			var err error
+			if resolved, err = resolveReleases(cfg); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	helmCmd := helm.NewHelmCommand(false)
+	for i := range findings {
+		f := &findings[i]
+		if f.action != adoptRelabel {
+			continue
+		}
+
+		var r resolvedRelease
+		for _, rr := range resolved {
+			if rr.id == f.release.id {
+				r = rr
+			}
+		}
+		opts := r.helmOptions()
+		if f.version != "" {
+			opts.Version = f.version
+		}
+		manifest, err := helmCmd.Template(r.name, crdChartRef(r.managedRelease), r.namespace, opts)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", r.name, err)
+		}
+		objects, err := k8s.GetManifestObjects(r.namespace, manifest)
+		if err != nil {
+			return fmt.Errorf("failed to read the objects of %s: %w", r.name, err)
+		}
+
+		var conflicts []string
+		for _, o := range objects {
+			owner, ns := o.HelmOwner()
+			switch {
+			case owner == "":
+				f.objects = append(f.objects, o)
+			case owner != r.name || ns != r.namespace:
+				conflicts = append(conflicts, fmt.Sprintf("%s belongs to release %s in %s", o, owner, ns))
+			}
+		}
+		sort.Strings(conflicts)
+
+		if len(conflicts) > 0 {
+			f.action = adoptManual
+			f.objects = nil
+			f.steps = append([]string{"Some objects of the chart already belong to another helm release:"}, conflicts...)
+			f.steps = append(f.steps, "Uninstall or adopt that release first, then run 'adopt' again")
+		}
+	}
+	return nil
+}
+
+// adoptInstallations relabels the kubectl-applied objects and records the
+// releases with the expected names in the installer state.
+func adoptInstallations(cfg *config.Config, findings []adoptFinding) error {
+	record := false
+	for _, f := range findings {
+		switch f.action {
+		case adoptRelabel:
+			output.Printf("🏷️  Labelling %-21s", f.release.name+":")
+			for _, o := range f.objects {
+				if err := k8s.SetHelmOwner(o, f.release.name, f.release.namespace); err != nil {
+					output.Println("❌ FAILED")
+					return fmt.Errorf("failed to label %s: %w", o, err)
+				}
+			}
+			output.Printf("✅ %d objects\n", len(f.objects))
+		case adoptRecord:
+			record = true
+		}
+	}
+	if !record {
+		return nil
+	}
+
+	output.Print("📝 Recording state:      ")
+	var err error
+	previousState, err = state.Load(cfg.NamespaceAI, cfg.ReleasePrefix)
+	if err != nil {
+		output.Println("❌ FAILED")
+		return err
+	}
+	if previousState != nil {
+		withRedis = previousState.WithRedis
+	}
+	if err := recordInstallState(cfg); err != nil {
+		output.Println("❌ FAILED")
+		return err
+	}
+	output.Printf("✅ %s/%s\n", cfg.NamespaceAI, state.ConfigMapNameFor(cfg.ReleasePrefix))
+	return nil
+}
+
+// adoptInstallHint returns the install flags that keep the adopted
+// releases at their installed versions.
+func adoptInstallHint(findings []adoptFinding) string {
+	tags := map[string]string{}
+	for _, f := range findings {
+		if f.action != adoptRecord && f.action != adoptRelabel || f.version == "" || f.version == chartVersion {
+			continue
+		}
+		tag := f.version
+		if !strings.HasPrefix(tag, "v") {
+			tag = "v" + tag
+		}
+		tags[adoptSources[f.release.id].tagFlag] = tag
+	}
+
+	var hint string
+	for _, flag := range []string{"--envoy-gateway-tag", "--ai-gateway-tag"} {
+		if tag, ok := tags[flag]; ok {
+			hint += " " + flag + " " + tag
+		}
+	}
+	return hint
+}
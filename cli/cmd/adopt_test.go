@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+)
+
+// fakeKubectl records its arguments to $FAKE_KUBECTL_LOG and answers the
+// lists adopt and the CRD checks make: Envoy Gateway and AI Gateway
+// controller deployments from $FAKE_EG_DEPLOYMENTS and
+// $FAKE_AIEG_DEPLOYMENTS, the list of CRDs from $FAKE_CRDS, and the versions
+// of one CRD from the file named after it in $FAKE_CRD_VERSIONS, or
+// NotFound.
+const fakeKubectl = `#!/bin/sh
+echo "$*" >> "$FAKE_KUBECTL_LOG"
+case "$1 $2" in
+"get deployments")
+  case "$*" in
+  *control-plane=envoy-gateway*) printf '%s' "$FAKE_EG_DEPLOYMENTS" ;;
+  *ai-gateway-controller*) printf '%s' "$FAKE_AIEG_DEPLOYMENTS" ;;
+  esac ;;
+"get customresourcedefinitions") printf '%s' "$FAKE_CRDS" ;;
+"get crd")
+  if [ "$3" = "-o" ]; then
+    printf '%s' "$FAKE_CRDS"
+  elif [ -f "$FAKE_CRD_VERSIONS/$3" ]; then
+    cat "$FAKE_CRD_VERSIONS/$3"
+  else
+    echo "Error from server (NotFound): customresourcedefinitions.apiextensions.k8s.io \"$3\" not found" >&2
+    exit 1
+  fi ;;
+esac
+`
+
+// useFakeKubectl puts fakeKubectl first on PATH and returns its log.
+func useFakeKubectl(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl is a shell script")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte(fakeKubectl), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	log := filepath.Join(t.TempDir(), "kubectl.log")
+	t.Setenv("FAKE_KUBECTL_LOG", log)
+	return log
+}
+
+func deploymentList(namespace, name, image string) string {
+	return `{"kind": "List", "items": [{"apiVersion": "apps/v1", "kind": "Deployment",
+		"metadata": {"namespace": "` + namespace + `", "name": "` + name + `"},
+		"spec": {"template": {"spec": {"containers": [{"image": "` + image + `"}]}}}}]}`
+}
+
+func TestAdoptScenarios(t *testing.T) {
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system"}
+	release := func(name, namespace, chart string) string {
+		return `{"name": "` + name + `", "namespace": "` + namespace + `", "status": "deployed", "chart": "` + chart + `"}`
+	}
+
+	tests := []struct {
+		name        string
+		releases    []string
+		egDeploy    string
+		aiegDeploy  string
+		crds        string
+		wantActions map[string]string
+		wantVersion map[string]string
+		wantStep    map[string]string
+	}{
+		{
+			name: "helm releases with the expected names",
+			releases: []string{
+				release("eg", "envoy-gateway-system", "gateway-helm-v1.4.1"),
+				release("aieg-crd", "envoy-ai-gateway-system", "ai-gateway-crds-helm-v0.2.0"),
+				release("aieg", "envoy-ai-gateway-system", "ai-gateway-helm-v0.2.0"),
+			},
+			wantActions: map[string]string{"eg": adoptRecord, "aieg-crd": adoptRecord, "aieg": adoptRecord},
+			wantVersion: map[string]string{"eg": "v1.4.1", "aieg": "v0.2.0"},
+		},
+		{
+			name: "helm releases under other names",
+			releases: []string{
+				release("my-eg", "gateway", "gateway-helm-v1.4.1"),
+				release("ai-gateway", "envoy-ai-gateway-system", "ai-gateway-helm-v0.2.0"),
+			},
+			wantActions: map[string]string{"eg": adoptManual, "aieg-crd": "", "aieg": adoptManual},
+			wantStep: map[string]string{
+				"eg":   "run 'adopt --release-prefix my- --namespace-gateway gateway'",
+				"aieg": "Rename it to aieg without downtime",
+			},
+		},
+		{
+			name:        "applied with kubectl",
+			egDeploy:    deploymentList("envoy-gateway-system", "envoy-gateway", "docker.io/envoyproxy/gateway:v1.4.1"),
+			aiegDeploy:  deploymentList("envoy-ai-gateway-system", "ai-gateway-controller", "docker.io/envoyproxy/ai-gateway-controller:v0.2.0"),
+			crds:        `{"kind": "List", "items": [{"apiVersion": "apiextensions.k8s.io/v1", "kind": "CustomResourceDefinition", "metadata": {"name": "aigatewayroutes.aigateway.envoyproxy.io"}}]}`,
+			wantActions: map[string]string{"eg": adoptRelabel, "aieg-crd": adoptRelabel, "aieg": adoptRelabel},
+			wantVersion: map[string]string{"eg": "v1.4.1", "aieg-crd": "v0.2.0", "aieg": "v0.2.0"},
+		},
+		{
+			name:        "applied with kubectl in another namespace",
+			egDeploy:    deploymentList("gateway", "envoy-gateway", "envoyproxy/gateway:v1.4.1"),
+			wantActions: map[string]string{"eg": adoptManual, "aieg-crd": "", "aieg": ""},
+			wantStep:    map[string]string{"eg": "run 'adopt --namespace-gateway gateway'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeHelm(t)
+			useFakeKubectl(t)
+			t.Setenv("FAKE_HELM_LIST", "["+strings.Join(tt.releases, ",")+"]")
+			t.Setenv("FAKE_EG_DEPLOYMENTS", tt.egDeploy)
+			t.Setenv("FAKE_AIEG_DEPLOYMENTS", tt.aiegDeploy)
+			t.Setenv("FAKE_CRDS", tt.crds)
+
+			scan, err := scanInstallations()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, f := range classifyInstallations(cfg, scan) {
+				id := f.release.id
+				if f.action != tt.wantActions[id] {
+					t.Errorf("%s: action %q (%s), want %q", id, f.action, f.found, tt.wantActions[id])
+				}
+				if want, ok := tt.wantVersion[id]; ok && f.version != want {
+					t.Errorf("%s: version %q, want %q", id, f.version, want)
+				}
+				if want, ok := tt.wantStep[id]; ok && !strings.Contains(strings.Join(f.steps, "\n"), want) {
+					t.Errorf("%s: migration plan lacks %q:\n%s", id, want, strings.Join(f.steps, "\n"))
+				}
+			}
+		})
+	}
+}
+
+func TestAdoptRelabel(t *testing.T) {
+	log := useFakeKubectl(t)
+	captureStdout(t)
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system"}
+
+	findings := []adoptFinding{{
+		release: managedReleases(cfg)[0],
+		action:  adoptRelabel,
+		version: "v1.4.1",
+		objects: []k8s.Object{
+			{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "envoy-gateway-system", Name: "envoy-gateway"},
+			{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "envoy-gateway-role"},
+		},
+	}}
+	if err := adoptInstallations(cfg, findings); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := string(data)
+	for _, want := range []string{
+		"label deployment.apps/envoy-gateway --overwrite app.kubernetes.io/managed-by=Helm -n envoy-gateway-system",
+		"annotate deployment.apps/envoy-gateway --overwrite meta.helm.sh/release-name=eg meta.helm.sh/release-namespace=envoy-gateway-system -n envoy-gateway-system",
+		"label clusterrole.rbac.authorization.k8s.io/envoy-gateway-role --overwrite app.kubernetes.io/managed-by=Helm\n",
+	} {
+		if !strings.Contains(calls, want) {
+			t.Errorf("kubectl calls lack %q:\n%s", want, calls)
+		}
+	}
+
+	if hint := adoptInstallHint(findings); hint != " --envoy-gateway-tag v1.4.1" {
+		t.Errorf("install hint %q, want the installed Envoy Gateway tag", hint)
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/backup"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+var backupOutputDir string
+
+var aiGatewayResources = []string{
+	"aigatewayroutes.aigateway.envoyproxy.io",
+	"aiservicebackends.aigateway.envoyproxy.io",
+	"backendsecuritypolicies.aigateway.envoyproxy.io",
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Snapshot release values and state into a timestamped archive",
+	Long: `Capture the current state of the Envoy AI Gateway installation before
+an upgrade.
+
+The backup archive contains:
+- user-supplied values of every managed Helm release
+- the full release state (helm get all)
+- AI Gateway custom resources from the target namespaces`,
+	RunE: runBackup,
+}
+
+func init() {
+	backupCmd.Flags().StringVarP(&backupOutputDir, "output-dir", "o", ".",
+		"directory to write the backup archive to")
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	output.Println("💾 Backing up Envoy AI Gateway installation")
+	output.Printf("  Namespace (Gateway): %s\n", cfg.NamespaceGateway)
+	output.Printf("  Namespace (AI):      %s\n", cfg.NamespaceAI)
+	output.Println()
+
+	path, err := createBackup(cfg, backupOutputDir)
+	if err != nil {
+		return err
+	}
+
+	output.Printf("\n✅ Backup written to %s\n", path)
+	return nil
+}
+
+func createBackup(cfg *config.Config, outputDir string) (string, error) {
+	helmCmd := helm.NewHelmCommand(false)
+	now := time.Now()
+
+	archive := backup.NewArchive(backup.Metadata{
+		CreatedAt:        now,
+		CLIVersion:       version.Get().Version,
+		NamespaceGateway: cfg.NamespaceGateway,
+		NamespaceAI:      cfg.NamespaceAI,
+		ReleasePrefix:    cfg.ReleasePrefix,
+	})
+
+	releases := append(managedReleases(cfg), redisRelease(cfg))
+	for _, r := range releases {
+		output.Printf("🔍 Release %-10s ", r.name)
+
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil {
+			output.Println("❌ FAILED")
+			return "", fmt.Errorf("failed to look up release %s: %w", r.name, err)
+		}
+		if rel == nil {
+			output.Println("⏭️  not installed")
+			continue
+		}
+
+		values, err := helmCmd.GetValues(r.name, r.namespace)
+		if err != nil {
+			output.Println("❌ FAILED")
+			return "", fmt.Errorf("failed to get values for %s: %w", r.name, err)
+		}
+
+		all, err := helmCmd.GetAll(r.name, r.namespace)
+		if err != nil {
+			output.Println("❌ FAILED")
+			return "", fmt.Errorf("failed to get release state for %s: %w", r.name, err)
+		}
+
+		valuesFile := fmt.Sprintf("releases/%s/values.yaml", r.name)
+		archive.Add(valuesFile, []byte(values))
+		archive.Add(fmt.Sprintf("releases/%s/all.yaml", r.name), []byte(all))
+		archive.Metadata.Releases = append(archive.Metadata.Releases, backup.Release{
+			Name:       rel.Name,
+			Namespace:  r.namespace,
+			Chart:      rel.ChartName(),
+			Version:    rel.ChartVersion(),
+			AppVersion: rel.AppVersion,
+			ValuesFile: valuesFile,
+		})
+
+		output.Printf("✅ %s\n", rel.Chart)
+	}
+
+	for _, ns := range uniqueNamespaces(cfg) {
+		output.Printf("🔍 Resources in '%s': ", ns)
+
+		resources, err := k8s.Kubectl("get", strings.Join(aiGatewayResources, ","),
+			"-n", ns, "-o", "yaml", "--ignore-not-found").Output()
+		if err != nil {
+			output.Println("⚠️  skipped (AI Gateway CRDs not available)")
+			continue
+		}
+
+		archive.Add(fmt.Sprintf("resources/%s.yaml", ns), resources)
+		output.Println("✅ captured")
+	}
+
+	path := filepath.Join(outputDir, backup.FileName(now))
+	if err := archive.Write(path); err != nil {
+		return "", fmt.Errorf("failed to write backup archive: %w", err)
+	}
+
+	return path, nil
+}
+
+// backupBeforeUpgrade writes a backup to dir, for install
+// --backup-before-upgrade, when any managed release is already installed.
+// A failed backup stops the upgrade.
+func backupBeforeUpgrade(cfg *config.Config, dir string, isDryRun bool) error {
+	helmCmd := helm.NewHelmCommand(false)
+	installed := false
+	for _, r := range append(managedReleases(cfg), redisRelease(cfg)) {
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil {
+			return fmt.Errorf("failed to look up release %s: %w", r.name, err)
+		}
+		if rel != nil {
+			installed = true
+			break
+		}
+	}
+
+	switch {
+	case !installed:
+		output.Println("\nℹ️  Nothing is installed yet; no backup before upgrade needed")
+		return nil
+	case isDryRun:
+		output.Printf("\n[DRY-RUN] Would back up the installation to %s\n", dir)
+		return nil
+	}
+
+	output.Println("\n💾 Backing up the installation before upgrading it")
+	path, err := createBackup(cfg, dir)
+	if err != nil {
+		return fmt.Errorf("backup before upgrade failed: %w", err)
+	}
+	output.Printf("✅ Backup written to %s; undo the upgrade with: envoy-ai-installer restore %s\n", path, path)
+	return nil
+}
+
+func uniqueNamespaces(cfg *config.Config) []string {
+	if cfg.NamespaceGateway == cfg.NamespaceAI {
+		return []string{cfg.NamespaceGateway}
+	}
+	return []string{cfg.NamespaceGateway, cfg.NamespaceAI}
+}
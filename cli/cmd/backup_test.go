@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/backup"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+)
+
+// fakeHelm lists release eg as failed, which only helm list -a shows, when
+// FAKE_HELM_INSTALLED is set.
+const fakeHelm = `#!/bin/sh
+case "$*" in
+"list -a -n envoy-gateway-system -o json")
+	if [ -n "$FAKE_HELM_INSTALLED" ]; then
+		echo '[{"name": "eg", "namespace": "envoy-gateway-system", "status": "failed", "chart": "gateway-helm-v1.4.0", "app_version": "v1.4.0"}]'
+	else
+		echo '[]'
+	fi ;;
+list*) echo '[]' ;;
+"get values eg"*) echo 'replicas: 2' ;;
+"get all eg"*) echo 'MANIFEST:' ;;
+*) exit 1 ;;
+esac
+`
+
+func TestBackupBeforeUpgrade(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake helm is a shell script")
+	}
+	bin := t.TempDir()
+	for name, script := range map[string]string{"helm": fakeHelm, "kubectl": "#!/bin/sh\nexit 1\n"} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system"}
+
+	tests := []struct {
+		name      string
+		installed bool
+		dryRun    bool
+		wantFile  bool
+	}{
+		{name: "nothing installed"},
+		{name: "failed release installed", installed: true, wantFile: true},
+		{name: "dry run", installed: true, dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installed := ""
+			if tt.installed {
+				installed = "1"
+			}
+			t.Setenv("FAKE_HELM_INSTALLED", installed)
+			dir := t.TempDir()
+
+			if err := backupBeforeUpgrade(cfg, dir, tt.dryRun); err != nil {
+				t.Fatal(err)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, "envoy-ai-backup-*.tar.gz"))
+			if got := len(files) == 1; got != tt.wantFile {
+				t.Fatalf("got backups %v, want one: %v", files, tt.wantFile)
+			}
+			if !tt.wantFile {
+				return
+			}
+
+			archive, err := backup.Read(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(archive.Files["releases/eg/values.yaml"]); got != "replicas: 2\n" {
+				t.Errorf("got values %q", got)
+			}
+			if len(archive.Metadata.Releases) != 1 || archive.Metadata.Releases[0].Version != "v1.4.0" {
+				t.Errorf("got releases %+v", archive.Metadata.Releases)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const checksumPrefix = "sha256:"
+
+// integrityError means a values file could not be verified. Unlike plain
+// download failures it aborts the installation.
+type integrityError struct {
+	err error
+}
+
+func (e *integrityError) Error() string { return e.err.Error() }
+func (e *integrityError) Unwrap() error { return e.err }
+
+// parseChecksum validates a "sha256:<hex>" checksum and returns the
+// lower-case hex digest.
+func parseChecksum(checksum string) (string, error) {
+	digest, ok := strings.CutPrefix(strings.TrimSpace(checksum), checksumPrefix)
+	if !ok {
+		return "", fmt.Errorf("checksum %q must be in sha256:<hex> format", checksum)
+	}
+
+	digest = strings.ToLower(digest)
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != 32 {
+		return "", fmt.Errorf("checksum %q is not a valid SHA-256 digest", checksum)
+	}
+	return digest, nil
+}
+
+// splitChecksumFragment strips a "#sha256=<hex>" fragment from url and
+// returns it as a "sha256:<hex>" checksum.
+func splitChecksumFragment(url string) (string, string) {
+	base, fragment, found := strings.Cut(url, "#")
+	if !found {
+		return url, ""
+	}
+	digest, ok := strings.CutPrefix(fragment, "sha256=")
+	if !ok {
+		return url, ""
+	}
+	return base, checksumPrefix + digest
+}
+
+// fetchChecksumFile returns the digest published at url in sha256sum
+// format, or "" when there is no checksum file.
+func fetchChecksumFile(url string) (string, error) {
+	var data []byte
+	err := withRetries("download of "+url, func() error {
+		resp, err := get(url, http.StatusNotFound)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			data = nil
+			return nil
+		}
+
+		data, err = io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if err != nil {
+			return &retryableError{err}
+		}
+		return nil
+	})
+	if err != nil || data == nil {
+		return "", err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("checksum file %s is empty", url)
+	}
+	return parseChecksum(checksumPrefix + fields[0])
+}
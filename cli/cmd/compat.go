@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/compat"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+var skipCompatCheck bool
+
+// compatibilityVerdict checks an Envoy Gateway / AI Gateway version pair
+// against the compatibility table.
+func compatibilityVerdict(ctx context.Context, envoyGateway, aiGateway string) (compat.Verdict, error) {
+	// Development builds are never in the table; don't fetch it for them.
+	if verdict := (&compat.Matrix{}).Check(envoyGateway, aiGateway); verdict.Status == compat.Skipped {
+		return verdict, nil
+	}
+
+	matrix, _, err := compat.Load(ctx, compat.DefaultURL)
+	if err != nil {
+		return compat.Verdict{}, err
+	}
+	return matrix.Check(envoyGateway, aiGateway), nil
+}
+
+// checkInstallCompatibility validates the chart versions about to be
+// installed. Incompatible pairs abort the install unless --skip-compat-check
+// is set.
+func checkInstallCompatibility(ctx context.Context, cfg *config.Config) error {
+	if skipCompatCheck {
+		output.Println("  Compatibility:       ⚠️  check skipped (--skip-compat-check)")
+		return nil
+	}
+
+	eg, _ := releaseByID(cfg, "eg")
+	aieg, _ := releaseByID(cfg, "aieg")
+	verdict, err := compatibilityVerdict(ctx, eg.version, aieg.version)
+	if err != nil {
+		return err
+	}
+
+	switch verdict.Status {
+	case compat.Compatible:
+		output.Printf("  Compatibility:       ✅ %s\n", verdict.Message)
+	case compat.Incompatible:
+		return &ExitError{Code: ExitVerification, Err: fmt.Errorf(
+			"incompatible versions: %s (supported Envoy Gateway versions: %s); pass --skip-compat-check to install anyway",
+			verdict.Message, verdict.Supported)}
+	default:
+		output.Printf("  Compatibility:       ⚠️  not checked, %s\n", verdict.Message)
+	}
+	return nil
+}
+
+func checkInstalledCompatibility(ctx context.Context, cfg *config.Config) bool {
+	output.Print("🔍 Compatibility:      ")
+
+	helmCmd := helm.NewHelmCommand(false)
+	versions := map[string]string{}
+	for _, id := range []string{"eg", "aieg"} {
+		r, _ := releaseByID(cfg, id)
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil || rel == nil {
+			output.Println("ℹ️  Envoy Gateway and AI Gateway are not both installed")
+			return true
+		}
+		versions[id] = rel.ChartVersion()
+	}
+
+	verdict, err := compatibilityVerdict(ctx, versions["eg"], versions["aieg"])
+	if err != nil {
+		output.Printf("⚠️  %v\n", err)
+		return true
+	}
+
+	switch verdict.Status {
+	case compat.Compatible:
+		output.Printf("✅ %s\n", verdict.Message)
+	case compat.Incompatible:
+		output.Printf("❌ %s\n", verdict.Message)
+		output.Printf("   Install an Envoy Gateway version in %s\n", verdict.Supported)
+		return false
+	default:
+		output.Printf("⚠️  not checked, %s\n", verdict.Message)
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// completionTimeout bounds the cluster requests made while completing, so
+// that an unreachable cluster does not hang the shell.
+const completionTimeout = 2 * time.Second
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// flagCompletions complete flag values from the cluster, the kubeconfig and
+// the release cache. Failures complete to nothing rather than printing
+// errors into the shell.
+var flagCompletions = map[string]completionFunc{
+	"namespace-gateway":    completeNamespaces,
+	"namespace-ai":         completeNamespaces,
+	"monitoring-namespace": completeNamespaces,
+	"context":              completeContexts,
+	"tag":                  completeTags("gateway", "ai-gateway"),
+	"envoy-gateway-tag":    completeTags("gateway"),
+	"ai-gateway-tag":       completeTags("ai-gateway"),
+}
+
+// isCompletionCommand reports whether cmd prints shell completions or a
+// completion script, which must work without a valid config and print
+// nothing else.
+func isCompletionCommand(cmd *cobra.Command) bool {
+	if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		return true
+	}
+	return cmd.HasParent() && cmd.Parent().Name() == "completion" && !cmd.Parent().Parent().HasParent()
+}
+
+// registerCompletions registers the flag completions of c and its
+// subcommands, and completes config keys as the first argument of config
+// get, set and unset.
+func registerCompletions(c *cobra.Command) {
+	for name, fn := range flagCompletions {
+		if c.LocalFlags().Lookup(name) != nil {
+			c.RegisterFlagCompletionFunc(name, fn)
+		}
+	}
+	for _, sub := range c.Commands() {
+		registerCompletions(sub)
+	}
+}
+
+func init() {
+	for _, c := range []*cobra.Command{configGetCmd, configSetCmd, configUnsetCmd} {
+		c.ValidArgsFunction = completeConfigKeys
+	}
+}
+
+// setUpCompletion loads the config and applies --kubeconfig and --context,
+// which the root command does for other commands. Errors are ignored: the
+// defaults still give useful completions.
+func setUpCompletion() {
+	config.Init(cfgFiles, configEnv, configProfile, currentKubeContext)
+	k8s.Configure(resolveKubeconfig(), viper.GetString("kube_context"))
+	upstream.ConfigureCache(upstream.CacheOptions{
+		Dir:      upstream.DefaultCacheDir(),
+		Disabled: viper.GetBool("no_cache"),
+	})
+}
+
+func completeNamespaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	setUpCompletion()
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	names, err := k8s.NamespaceNames(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return withPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	setUpCompletion()
+
+	names, err := k8s.Contexts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return withPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTags completes the release tags found in the release cache for
+// all of the given envoyproxy repositories, newest first. It never queries
+// GitHub; 'versions list' fills the cache.
+func completeTags(repos ...string) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		setUpCompletion()
+
+		tags := upstream.CachedTags("envoyproxy", repos[0])
+		for _, repo := range repos[1:] {
+			other := upstream.CachedTags("envoyproxy", repo)
+			tags = slices.DeleteFunc(tags, func(tag string) bool { return !slices.Contains(other, tag) })
+		}
+		return withPrefix(tags, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+}
+
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return withPrefix(config.Keys(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func withPrefix(candidates []string, prefix string) []string {
+	var matched []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matched = append(matched, candidate)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var configEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List the supported EAIG_* environment variables and their values",
+	Long: `List every EAIG_* environment variable the installer reads, with the config
+key or flag it sets and its current value. Config keys come first, then the
+flags of individual commands. Credentials are masked.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigEnv,
+}
+
+func init() {
+	configCmd.AddCommand(configEnvCmd)
+}
+
+type envVariable struct {
+	name    string
+	setting string
+}
+
+// envVariables returns the environment variables of the config keys,
+// followed by those of the flags that have no config key, sorted by name.
+func envVariables() []envVariable {
+	var variables []envVariable
+	seen := map[string]bool{}
+	for _, key := range config.Keys() {
+		env := config.EnvVar(key)
+		variables = append(variables, envVariable{env, key})
+		seen[env] = true
+	}
+
+	var flagVariables []envVariable
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			env := flagEnvVar(cmd, f)
+			if f.Name == "help" || seen[env] {
+				return
+			}
+			seen[env] = true
+			flagVariables = append(flagVariables, envVariable{env, "--" + f.Name})
+		})
+		for _, child := range cmd.Commands() {
+			visit(child)
+		}
+	}
+	visit(rootCmd)
+
+	sort.Slice(flagVariables, func(i, j int) bool { return flagVariables[i].name < flagVariables[j].name })
+	return append(variables, flagVariables...)
+}
+
+func runConfigEnv(cmd *cobra.Command, args []string) error {
+	variables := envVariables()
+
+	nameWidth, settingWidth := 0, 0
+	for _, v := range variables {
+		nameWidth = max(nameWidth, len(v.name))
+		settingWidth = max(settingWidth, len(v.setting))
+	}
+
+	for _, v := range variables {
+		value := "-"
+		if raw, ok := os.LookupEnv(v.name); ok {
+			key := strings.ToLower(strings.TrimPrefix(v.name, config.EnvPrefix+"_"))
+			value = fmt.Sprint(maskSecret(key, raw))
+		}
+		fmt.Printf("%-*s  %-*s  %s\n", nameWidth, v.name, settingWidth, v.setting, value)
+	}
+	return nil
+}
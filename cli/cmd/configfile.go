@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a value stored in the config file",
+	Long: `Print a value stored in the config file. Nested keys are separated by dots,
+e.g. global_labels.team or profiles.prod.namespace_ai. Flags, environment
+variables and defaults are not considered; use 'config view' for the
+effective configuration.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a value in the config file",
+	Long: `Store a value in the config file, creating the file if needed. The value is
+parsed as YAML, so true, 3 and [a, b] keep their type. Unknown keys and
+values of the wrong type are rejected.`,
+	Example: `  envoy-ai-installer config set namespace_ai ai-prod
+  envoy-ai-installer config set global_labels.team platform
+  envoy-ai-installer config set profiles.prod.with_redis true`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a value from the config file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the config file in use",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigPath,
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configPathCmd)
+}
+
+// configFilePath returns the config file in use: the last one merged, as
+// that is where a value takes effect, the last --config path, the file
+// viper found, or the default location where config set creates one.
+func configFilePath() (string, error) {
+	if files := config.Files(); len(files) > 1 {
+		return files[len(files)-1], nil
+	}
+	if len(cfgFiles) > 0 {
+		return cfgFiles[len(cfgFiles)-1], nil
+	}
+	if file := viper.ConfigFileUsed(); file != "" {
+		return file, nil
+	}
+	return config.DefaultPath()
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	node, err := config.GetFileValue(path, args[0])
+	if err != nil {
+		return err
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		fmt.Println(node.Value)
+		return nil
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := config.SetFileValue(path, args[0], args[1]); err != nil {
+		return err
+	}
+
+	output.Printf("✅ Set %s in %s\n", args[0], path)
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	removed, err := config.UnsetFileValue(path, args[0])
+	if err != nil {
+		return err
+	}
+	if !removed {
+		output.Printf("ℹ️  %s is not set in %s\n", args[0], path)
+		return nil
+	}
+
+	output.Printf("✅ Removed %s from %s\n", args[0], path)
+	return nil
+}
+
+func runConfigPath(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		fmt.Printf("%s (does not exist yet)\n", path)
+		return nil
+	}
+	fmt.Println(path)
+	return nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureData collects what run writes to os.Stdout, where commands print
+// data such as config values.
+func captureData(t *testing.T, run func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	runErr := run()
+	os.Stdout = saved
+	w.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), runErr
+}
+
+func TestConfigSetGetUnset(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	savedFiles := cfgFiles
+	cfgFiles = nil
+	t.Cleanup(func() { cfgFiles = savedFiles })
+	messages := captureStdout(t)
+
+	path := filepath.Join(home, ".envoy-ai-installer", "config.yaml")
+	if got, err := configFilePath(); err != nil || got != path {
+		t.Fatalf("configFilePath() = %q, %v, want %q", got, err, path)
+	}
+
+	get := func(key string) (string, error) {
+		return captureData(t, func() error { return runConfigGet(configGetCmd, []string{key}) })
+	}
+
+	if err := runConfigSet(configSetCmd, []string{"namespace_ai", "ai-prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runConfigSet(configSetCmd, []string{"global_labels.team", "platform"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(messages.String(), "Set namespace_ai in "+path) {
+		t.Errorf("set did not report the file it wrote:\n%s", messages)
+	}
+
+	if got, err := get("namespace_ai"); err != nil || got != "ai-prod\n" {
+		t.Errorf("get namespace_ai = %q, %v, want ai-prod", got, err)
+	}
+	if got, err := get("global_labels"); err != nil || got != "team: platform\n" {
+		t.Errorf("get global_labels = %q, %v, want the map as YAML", got, err)
+	}
+
+	if err := runConfigUnset(configUnsetCmd, []string{"namespace_ai"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(messages.String(), "Removed namespace_ai from "+path) {
+		t.Errorf("unset did not report the removal:\n%s", messages)
+	}
+	if _, err := get("namespace_ai"); err == nil || !strings.Contains(err.Error(), "namespace_ai is not set") {
+		t.Errorf("get after unset: got error %v, want it not set", err)
+	}
+	if got, err := get("global_labels.team"); err != nil || got != "platform\n" {
+		t.Errorf("unset removed other keys: get global_labels.team = %q, %v", got, err)
+	}
+
+	if err := runConfigUnset(configUnsetCmd, []string{"namespace_ai"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(messages.String(), "namespace_ai is not set in "+path) {
+		t.Errorf("unset of a missing key not reported:\n%s", messages)
+	}
+
+	err := runConfigSet(configSetCmd, []string{"namespace_aii", "ai-prod"})
+	if err == nil || !strings.Contains(err.Error(), `unknown key "namespace_aii" (did you mean "namespace_ai"?)`) {
+		t.Errorf("set of an unknown key: got error %v, want the schema suggestion", err)
+	}
+	if code := ExitCode(err); code != ExitUsage {
+		t.Errorf("set of an unknown key exits with %d, want %d", code, ExitUsage)
+	}
+	if _, err := get("namespace_aii"); err == nil {
+		t.Error("the unknown key was written to the config file")
+	}
+}
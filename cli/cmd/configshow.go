@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configEntry is a config key as config show prints it: its flag, if it has
+// one, and how to get its resolved value.
+type configEntry struct {
+	key   string
+	flag  string
+	value func(cfg *config.Config) interface{}
+}
+
+// configFlags names the flags of the keys whose flag is not the key with
+// dashes, or "" for keys only set in the config file.
+var configFlags = map[string]string{
+	"kube_context":  "context",
+	"global_labels": "",
+	"node_selector": "",
+	"tolerations":   "",
+	"affinity":      "",
+}
+
+// configValues prints the keys best shown as the loaded Config has them:
+// merged, normalised or masked. Other keys print their setting.
+var configValues = map[string]func(cfg *config.Config) interface{}{
+	"namespace_gateway":      func(cfg *config.Config) interface{} { return cfg.NamespaceGateway },
+	"namespace_ai":           func(cfg *config.Config) interface{} { return cfg.NamespaceAI },
+	"release_prefix":         func(cfg *config.Config) interface{} { return cfg.ReleasePrefix },
+	"skip_clean":             func(cfg *config.Config) interface{} { return cfg.SkipClean },
+	"dry_run":                func(cfg *config.Config) interface{} { return cfg.DryRun },
+	"values_extra":           func(cfg *config.Config) interface{} { return cfg.ValuesExtra },
+	"image_pull_secrets":     func(cfg *config.Config) interface{} { return cfg.ImagePullSecrets },
+	"node_selector":          func(cfg *config.Config) interface{} { return cfg.NodeSelector },
+	"tolerations":            func(cfg *config.Config) interface{} { return plainValue(cfg.Tolerations) },
+	"affinity":               func(cfg *config.Config) interface{} { return plainValue(cfg.Affinity) },
+	"pod_security_standards": func(cfg *config.Config) interface{} { return formatPodSecurity(cfg.PodSecurity) },
+	"resource_limits":        func(cfg *config.Config) interface{} { return plainValue(cfg.Resources) },
+	"ip_family":              func(cfg *config.Config) interface{} { return cfg.IPFamily },
+	"redis_version":          func(cfg *config.Config) interface{} { return cfg.RedisVersion },
+	"redis_ha":               func(cfg *config.Config) interface{} { return cfg.RedisHA },
+	"redis_ha_replicas":      func(cfg *config.Config) interface{} { return cfg.RedisHAReplicas },
+	"redis_external_password": func(cfg *config.Config) interface{} {
+		return redact.Secret(viper.GetString("redis_external_password"))
+	},
+	"local":              func(cfg *config.Config) interface{} { return cfg.Local },
+	"openshift":          func(cfg *config.Config) interface{} { return cfg.OpenShift },
+	"with_observability": func(cfg *config.Config) interface{} { return cfg.Observability },
+}
+
+// configEntries returns an entry for every key of the config file schema,
+// in schema order.
+func configEntries() []configEntry {
+	keys := config.Keys()
+	entries := make([]configEntry, 0, len(keys))
+	for _, key := range keys {
+		flag, ok := configFlags[key]
+		if !ok {
+			flag = strings.ReplaceAll(key, "_", "-")
+		}
+		value, ok := configValues[key]
+		if !ok {
+			value = func(*config.Config) interface{} { return config.Value(key) }
+		}
+		entries = append(entries, configEntry{key, flag, value})
+	}
+	return entries
+}
+
+// plainValue renders Kubernetes API types with their manifest field names,
+// rather than the lowercased keys viper keeps.
+func plainValue(v interface{}) interface{} {
+	plain, err := values.Plain(v)
+	if err != nil {
+		return v
+	}
+	return plain
+}
+
+var secretKeyPattern = regexp.MustCompile(`(_token|_password|_secret|_api_key)$`)
+
+// maskSecret hides the values of credential keys, the passwords of URLs and
+// tokens recognised by redact.Text, so that config view output can be
+// shared.
+func maskSecret(key string, value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return value
+	}
+	if secretKeyPattern.MatchString(key) {
+		return "********"
+	}
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return redact.Text(s)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the installer configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:     "show",
+	Aliases: []string{"view"},
+	Short:   "Print the resolved configuration and where each value comes from",
+	Long: `Print the configuration in effect after merging flags, EAIG_* environment
+variables, the --profile section of the config file, the contexts section of
+the kube context, its top-level keys and defaults. Each key is annotated with
+its source.`,
+	RunE: runConfigShow,
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, entry := range configEntries() {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: entry.key}
+		value := &yaml.Node{}
+		if err := value.Encode(maskSecret(entry.key, entry.value(cfg))); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", entry.key, err)
+		}
+
+		source := "# " + configSource(cmd, entry)
+		if value.Kind == yaml.ScalarNode || len(value.Content) == 0 {
+			value.LineComment = source
+		} else {
+			key.LineComment = source
+		}
+		doc.Content = append(doc.Content, key, value)
+	}
+
+	if files := config.Files(); len(files) > 0 {
+		doc.HeadComment = "config file: " + files[0]
+		if len(files) > 1 {
+			doc.HeadComment = "config files: " + strings.Join(files, ", ")
+		}
+		if name := config.ActiveContext(); name != "" {
+			doc.HeadComment += ", context: " + name
+		}
+		if name := config.ActiveProfile(); name != "" {
+			doc.HeadComment += ", profile: " + name
+		} else if profiles := config.Profiles(); len(profiles) > 0 {
+			doc.HeadComment += ", profile: none (defined: " + strings.Join(profiles, ", ") + ")"
+		}
+	}
+	if doc.HeadComment == "" {
+		doc.HeadComment = "no config file loaded"
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func configSource(cmd *cobra.Command, entry configEntry) string {
+	if entry.flag != "" {
+		if f := cmd.Flags().Lookup(entry.flag); f != nil && f.Changed {
+			return "flag --" + entry.flag
+		}
+	}
+
+	env := config.EnvVar(entry.key)
+	if _, ok := os.LookupEnv(env); ok {
+		return "env " + env
+	}
+
+	if config.InProfile(entry.key) {
+		return "profile " + config.ActiveProfile()
+	}
+
+	if config.InContext(entry.key) {
+		return "context " + config.ActiveContext()
+	}
+
+	if viper.InConfig(entry.key) {
+		if files := config.Files(); len(files) > 1 {
+			if file := config.FileOf(entry.key); file != "" {
+				return "config file " + file
+			}
+		}
+		return "config file"
+	}
+
+	return "default"
+}
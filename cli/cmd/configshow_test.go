@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// TestConfigEntryFlags checks that config show attributes every key to a
+// flag some command has, so a flag that sets a key is reported as its
+// source.
+func TestConfigEntryFlags(t *testing.T) {
+	flags := map[string]bool{}
+	var collect func(cmd *cobra.Command)
+	collect = func(cmd *cobra.Command) {
+		cmd.Flags().VisitAll(func(f *pflag.Flag) { flags[f.Name] = true })
+		cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) { flags[f.Name] = true })
+		for _, sub := range cmd.Commands() {
+			collect(sub)
+		}
+	}
+	collect(rootCmd)
+
+	for _, entry := range configEntries() {
+		if entry.flag != "" && !flags[entry.flag] {
+			t.Errorf("key %s: no command has flag --%s", entry.key, entry.flag)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration for unknown keys and invalid values",
+	Long: `Check the config file, EAIG_* environment variables and flags, and list
+every problem found: unknown keys (with their line), values of the wrong
+type, invalid namespace names, missing values files and conflicting options.
+Exits non-zero when the configuration is invalid, for use in CI.`,
+	RunE: runConfigValidate,
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	source := "no config file loaded"
+	if files := config.Files(); len(files) > 0 {
+		source = strings.Join(files, ", ")
+		if name := config.ActiveContext(); name != "" {
+			source += ", context " + name
+		}
+		if name := config.ActiveProfile(); name != "" {
+			source += ", profile " + name
+		}
+	}
+
+	cfg, err := config.Check()
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		output.Printf("❌ %d problem(s) in the configuration (%s):\n", len(invalid.Problems), source)
+		for _, problem := range invalid.Problems {
+			output.Printf("   - %s\n", problem)
+		}
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return &ExitError{Code: ExitUsage}
+	}
+	if err != nil {
+		return err
+	}
+
+	output.Printf("✅ Configuration is valid (%s)\n", source)
+	printConfigWarnings(cfg)
+	return nil
+}
+
+// printConfigWarnings prints the settings that are valid but most likely
+// not what was meant.
+func printConfigWarnings(cfg *config.Config) {
+	for _, err := range config.Validate(cfg) {
+		if config.IsWarning(err) {
+			output.Printf("⚠️  %v\n", err)
+		}
+	}
+}
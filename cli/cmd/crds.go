@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/viper"
+)
+
+// crdCharts are the releases whose charts ship CRDs: gateway-helm in its
+// crds/ directory, ai-gateway-crds-helm in its templates.
+var crdCharts = []string{"eg", "aieg-crd"}
+
+// crdMismatch is a CRD in the cluster that does not serve what a chart
+// about to be installed expects.
+type crdMismatch struct {
+	name string
+	// missing are the versions the chart serves and the cluster does not.
+	missing []string
+	// storage is the storage version in the cluster, when the chart no
+	// longer serves it.
+	storage string
+}
+
+// checkCRDVersions compares the served versions of the CRDs in the cluster
+// with those of the charts install would use. With --skip-crds install
+// leaves outdated CRDs in place, and the controllers then fail on versions
+// the API server does not serve.
+func checkCRDVersions(cfg *config.Config) {
+	output.Print("🔍 CRD versions:       ")
+
+	helmCmd := helm.NewHelmCommand(false)
+	var mismatches []crdMismatch
+	var charts []managedRelease
+	checked, absent := 0, 0
+
+	for _, id := range crdCharts {
+		r, _ := releaseByID(cfg, id)
+		manifest, err := renderChartCRDs(helmCmd, r)
+		if err != nil {
+			output.Printf("⚠️  could not render %s %s: %v\n", r.chart, r.version, err)
+			return
+		}
+		expected, err := k8s.ManifestCRDs(manifest)
+		if err != nil {
+			output.Printf("⚠️  could not read the CRDs of %s %s: %v\n", r.chart, r.version, err)
+			return
+		}
+
+		names := make([]string, 0, len(expected))
+		for name := range expected {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		outdated := false
+		for _, name := range names {
+			installed, err := k8s.GetCRDVersions(name)
+			if err != nil {
+				output.Printf("⚠️  could not read CRD %s: %v\n", name, err)
+				return
+			}
+			if installed == nil {
+				absent++
+				continue
+			}
+			checked++
+
+			if m := compareCRDVersions(name, expected[name], installed); m != nil {
+				mismatches = append(mismatches, *m)
+				outdated = true
+			}
+		}
+		if outdated {
+			charts = append(charts, r)
+		}
+	}
+
+	switch {
+	case checked == 0 && viper.GetBool("skip_crds"):
+		output.Println("⚠️  CRDs not installed, and skip_crds is set")
+		output.Println("   Install without --skip-crds, or install the CRDs before the charts")
+		return
+	case checked == 0:
+		output.Println("ℹ️  not installed yet, install will create them")
+		return
+	case len(mismatches) == 0:
+		output.Printf("✅ %d CRDs serve the versions the charts expect\n", checked)
+		if absent > 0 && viper.GetBool("skip_crds") {
+			output.Printf("   ⚠️  %d CRDs are missing and skip_crds is set\n", absent)
+		}
+		return
+	}
+
+	output.Printf("⚠️  %d of %d CRDs are outdated\n", len(mismatches), checked)
+	for _, m := range mismatches {
+		if len(m.missing) > 0 {
+			output.Printf("   %s does not serve %s\n", m.name, strings.Join(m.missing, ", "))
+		}
+		if m.storage != "" {
+			output.Printf("   %s stores objects as %s, which the new chart no longer serves\n", m.name, m.storage)
+		}
+	}
+
+	output.Println("   Upgrade the CRDs before the charts: run 'migrate', install without --skip-crds, or apply them with")
+	for _, r := range charts {
+		output.Printf("     helm template %s %s --version %s -n %s --include-crds | kubectl apply --server-side --force-conflicts -f -\n",
+			r.name, crdChartRef(r), r.version, r.namespace)
+	}
+	for _, m := range mismatches {
+		if m.storage != "" {
+			output.Println("   Stored objects must be migrated first: read and write them back once the new")
+			output.Println("   storage version is served, e.g. kubectl get <kind> -A -o yaml | kubectl replace -f -")
+			break
+		}
+	}
+}
+
+// compareCRDVersions returns what an installed CRD lacks compared to the
+// CRD of the chart, or nil when it serves everything the chart does.
+func compareCRDVersions(name string, expected, installed []k8s.CRDVersion) *crdMismatch {
+	served := map[string]bool{}
+	for _, v := range k8s.ServedVersions(installed) {
+		served[v] = true
+	}
+	wanted := map[string]bool{}
+	for _, v := range k8s.ServedVersions(expected) {
+		wanted[v] = true
+	}
+
+	m := crdMismatch{name: name}
+	for _, v := range k8s.ServedVersions(expected) {
+		if !served[v] {
+			m.missing = append(m.missing, v)
+		}
+	}
+	if storage := k8s.StorageVersion(installed); storage != "" && !wanted[storage] {
+		m.storage = storage
+	}
+
+	if len(m.missing) == 0 && m.storage == "" {
+		return nil
+	}
+	return &m
+}
+
+// renderChartCRDs renders a release's chart with its crds/ directory, at the
+// version install would use.
+func renderChartCRDs(helmCmd *helm.HelmCommand, r managedRelease) (string, error) {
+	return helmCmd.Template(r.name, crdChartRef(r), r.namespace,
+		&helm.HelmOptions{Version: r.version, IncludeCRDs: true})
+}
+
+// crdChartRef is the OCI reference of a release's chart, which needs no
+// repository to be added.
+func crdChartRef(r managedRelease) string {
+	return "oci://" + registryHost + "/" + strings.TrimPrefix(r.chart, "envoyproxy/")
+}
+
+// aiGatewayCRDGroup is the API group of the CRDs of the aieg-crd release.
+const aiGatewayCRDGroup = "aigateway.envoyproxy.io"
+
+// waitAIGatewayCRDs waits until the API server serves the AI Gateway CRDs,
+// so that the controller installed next does not start before them and
+// crash-loop on slow API servers.
+func waitAIGatewayCRDs(isDryRun bool) error {
+	if isDryRun {
+		output.Printf("[DRY-RUN] wait up to %s for the %s CRDs to be established\n", crdEstablishTimeout, aiGatewayCRDGroup)
+		return nil
+	}
+
+	crds, err := k8s.GetGroupCRDs(aiGatewayCRDGroup)
+	if err != nil {
+		return fmt.Errorf("failed to read the AI Gateway CRDs: %w", err)
+	}
+	if len(crds) == 0 {
+		return fmt.Errorf("no %s CRD found after installing them", aiGatewayCRDGroup)
+	}
+	names := make([]string, len(crds))
+	for i, crd := range crds {
+		names[i] = crd.Name
+	}
+
+	output.Printf("⏳ Waiting for %d CRDs to be established...\n", len(names))
+	if err := waitCRDsEstablished(names); err != nil {
+		return err
+	}
+	output.Printf("✅ %d CRDs established\n", len(names))
+	return nil
+}
+
+// checkCRDChartVersion compares the chart version of the deployed aieg-crd
+// release with the controller version about to be installed. A controller
+// newer than its CRDs fails on fields and versions they lack. It returns
+// the mismatch, or "".
+func checkCRDChartVersion(helmCmd *helm.HelmCommand, cfg *config.Config) (string, error) {
+	crdRelease, _ := releaseByID(cfg, "aieg-crd")
+	rel, err := helmCmd.FindRelease(crdRelease.name, crdRelease.namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up release %s: %w", crdRelease.name, err)
+	}
+
+	controller := releaseVersion(cfg, "aieg")
+	switch {
+	case rel == nil:
+		return fmt.Sprintf("release %s is not installed; make sure the %s CRDs match the controller %s", crdRelease.name, aiGatewayCRDGroup, controller), nil
+	case rel.ChartVersion() != controller:
+		return fmt.Sprintf("release %s is at chart %s, the controller is being installed at %s", crdRelease.name, rel.ChartVersion(), controller), nil
+	}
+	return "", nil
+}
+
+// checkAIGatewayCRDs reports whether the AI Gateway CRDs are established,
+// and warns when the deployed CRD and controller releases are at different
+// chart versions. It returns false when a CRD is not served.
+func checkAIGatewayCRDs(helmCmd *helm.HelmCommand, cfg *config.Config) bool {
+	output.Print("🔍 AI Gateway CRDs:    ")
+
+	crds, err := k8s.GetGroupCRDs(aiGatewayCRDGroup)
+	if err != nil {
+		output.Printf("⚠️  could not read the CRDs: %v\n", err)
+		return true
+	}
+	if len(crds) == 0 {
+		output.Println("ℹ️  not installed")
+		return true
+	}
+
+	var pending []string
+	for _, crd := range crds {
+		if !crd.Established {
+			pending = append(pending, crd.Name)
+		}
+	}
+	ok := len(pending) == 0
+	if ok {
+		output.Printf("✅ %d CRDs established\n", len(crds))
+	} else {
+		output.Printf("❌ %d of %d CRDs not established: %s\n", len(pending), len(crds), strings.Join(pending, ", "))
+		output.Printf("   Check their status with: kubectl describe crd %s\n", pending[0])
+	}
+
+	crdRelease, _ := releaseByID(cfg, "aieg-crd")
+	controller, _ := releaseByID(cfg, "aieg")
+	crdRel, err := helmCmd.FindRelease(crdRelease.name, crdRelease.namespace)
+	if err != nil {
+		output.Printf("   ⚠️  Could not look up release %s: %v\n", crdRelease.name, err)
+		return ok
+	}
+	controllerRel, err := helmCmd.FindRelease(controller.name, controller.namespace)
+	if err != nil {
+		output.Printf("   ⚠️  Could not look up release %s: %v\n", controller.name, err)
+		return ok
+	}
+
+	switch {
+	case controllerRel == nil:
+	case crdRel == nil:
+		output.Printf("   ℹ️  Not installed by release %s; their version is not checked against the controller\n", crdRelease.name)
+	case crdRel.ChartVersion() != controllerRel.ChartVersion():
+		output.Printf("   ⚠️  Release %s is at chart %s but the controller %s is at %s\n",
+			crdRel.Name, crdRel.ChartVersion(), controllerRel.Name, controllerRel.ChartVersion())
+		output.Println("   Upgrade the CRDs with 'migrate', or install without --skip-crds")
+	}
+	return ok
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/spf13/viper"
+)
+
+const (
+	egCRDManifest = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: envoyproxies.gateway.envoyproxy.io
+spec:
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+`
+	aiegCRDManifest = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: aigatewayroutes.aigateway.envoyproxy.io
+spec:
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+  - name: v1beta1
+    served: true
+    storage: true
+`
+)
+
+// useFakeCRDs serves the CRDs of the eg and aieg-crd charts from helm
+// template, and the given served versions of installed CRDs from kubectl.
+func useFakeCRDs(t *testing.T, installed map[string]string) {
+	t.Helper()
+	useFakeHelm(t)
+	useFakeKubectl(t)
+
+	templates := t.TempDir()
+	for release, manifest := range map[string]string{"eg": egCRDManifest, "aieg-crd": aiegCRDManifest} {
+		if err := os.WriteFile(filepath.Join(templates, release), []byte(manifest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("FAKE_HELM_TEMPLATES", templates)
+
+	versions := t.TempDir()
+	for name, v := range installed {
+		if err := os.WriteFile(filepath.Join(versions, name), []byte(v), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("FAKE_CRD_VERSIONS", versions)
+}
+
+func TestCheckCRDVersions(t *testing.T) {
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system"}
+	const (
+		egCRD   = "envoyproxies.gateway.envoyproxy.io"
+		aiegCRD = "aigatewayroutes.aigateway.envoyproxy.io"
+	)
+	current := map[string]string{
+		egCRD:   `[{"name": "v1alpha1", "served": true, "storage": true}]`,
+		aiegCRD: `[{"name": "v1alpha1", "served": true, "storage": false}, {"name": "v1beta1", "served": true, "storage": true}]`,
+	}
+
+	tests := []struct {
+		name      string
+		installed map[string]string
+		skipCRDs  bool
+		want      []string
+		unwanted  []string
+	}{
+		{
+			name:      "present",
+			installed: current,
+			want:      []string{"✅ 2 CRDs serve the versions the charts expect"},
+			unwanted:  []string{"outdated"},
+		},
+		{
+			name: "missing",
+			want: []string{"not installed yet, install will create them"},
+		},
+		{
+			name:     "missing with skip_crds",
+			skipCRDs: true,
+			want:     []string{"CRDs not installed, and skip_crds is set"},
+		},
+		{
+			name:      "one missing with skip_crds",
+			installed: map[string]string{egCRD: current[egCRD]},
+			skipCRDs:  true,
+			want:      []string{"✅ 1 CRDs serve", "1 CRDs are missing and skip_crds is set"},
+		},
+		{
+			name: "wrong version",
+			installed: map[string]string{
+				egCRD:   current[egCRD],
+				aiegCRD: `[{"name": "v1alpha0", "served": true, "storage": true}]`,
+			},
+			want: []string{
+				"1 of 2 CRDs are outdated",
+				aiegCRD + " does not serve v1alpha1, v1beta1",
+				aiegCRD + " stores objects as v1alpha0, which the new chart no longer serves",
+				"helm template aieg-crd oci://",
+				"Stored objects must be migrated first",
+			},
+			unwanted: []string{"helm template eg "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeCRDs(t, tt.installed)
+			viper.Set("skip_crds", tt.skipCRDs)
+			t.Cleanup(func() { viper.Set("skip_crds", nil) })
+			out := captureStdout(t)
+
+			checkCRDVersions(cfg)
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output lacks %q:\n%s", want, out)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(out.String(), unwanted) {
+					t.Errorf("output has %q:\n%s", unwanted, out)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckAIGatewayCRDs(t *testing.T) {
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system"}
+	crd := func(name, group, established string) string {
+		return `{"metadata": {"name": "` + name + `"}, "spec": {"group": "` + group + `"},
+			"status": {"conditions": [{"type": "Established", "status": "` + established + `"}]}}`
+	}
+	release := func(name, chart string) string {
+		return `{"name": "` + name + `", "namespace": "envoy-ai-gateway-system", "status": "deployed", "chart": "` + chart + `"}`
+	}
+	other := crd("gateways.gateway.networking.k8s.io", "gateway.networking.k8s.io", "True")
+
+	tests := []struct {
+		name     string
+		crds     []string
+		releases []string
+		wantOK   bool
+		want     string
+	}{
+		{
+			name:   "present",
+			crds:   []string{other, crd("aigatewayroutes.aigateway.envoyproxy.io", aiGatewayCRDGroup, "True")},
+			wantOK: true,
+			want:   "✅ 1 CRDs established",
+		},
+		{
+			name:   "missing",
+			crds:   []string{other},
+			wantOK: true,
+			want:   "ℹ️  not installed",
+		},
+		{
+			name: "not established",
+			crds: []string{
+				crd("aigatewayroutes.aigateway.envoyproxy.io", aiGatewayCRDGroup, "True"),
+				crd("backendsecuritypolicies.aigateway.envoyproxy.io", aiGatewayCRDGroup, "False"),
+			},
+			want: "❌ 1 of 2 CRDs not established: backendsecuritypolicies.aigateway.envoyproxy.io",
+		},
+		{
+			name: "CRD chart behind the controller",
+			crds: []string{crd("aigatewayroutes.aigateway.envoyproxy.io", aiGatewayCRDGroup, "True")},
+			// The fake lists both releases for each lookup; FindRelease
+			// picks the release by name.
+			releases: []string{release("aieg-crd", "ai-gateway-crds-helm-v0.2.0"), release("aieg", "ai-gateway-helm-v0.3.0")},
+			wantOK:   true,
+			want:     "Release aieg-crd is at chart v0.2.0 but the controller aieg is at v0.3.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeCRDs(t, nil)
+			t.Setenv("FAKE_CRDS", `{"kind": "List", "items": [`+strings.Join(tt.crds, ",")+`]}`)
+			t.Setenv("FAKE_HELM_LIST", "["+strings.Join(tt.releases, ",")+"]")
+			out := captureStdout(t)
+
+			if ok := checkAIGatewayCRDs(helm.NewHelmCommand(false), cfg); ok != tt.wantOK {
+				t.Errorf("checkAIGatewayCRDs() = %v, want %v", ok, tt.wantOK)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output lacks %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/diff"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	diffSummary  bool
+	diffOutput   string
+	diffMaxLines int
+	diffContext  int
+)
+
+type releaseDiff struct {
+	Release   string              `json:"release"`
+	Namespace string              `json:"namespace"`
+	Installed bool                `json:"installed"`
+	Added     int                 `json:"added"`
+	Changed   int                 `json:"changed"`
+	Removed   int                 `json:"removed"`
+	Resources []diff.ResourceDiff `json:"resources"`
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what an install would change in the cluster",
+	Long: `Render the charts that 'install' would deploy with the merged values and
+compare them against the manifests of the currently deployed releases.
+Exits with code 2 when there are differences.`,
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringSliceVar(&valuesExtra, "values-extra", nil,
+		"additional values files; prefix with gateway=, ai= or redis= to target a single release (repeatable)")
+	diffCmd.Flags().StringSliceVar(&labels, "labels", nil,
+		"labels to add to all created resources, as key=value pairs (repeatable)")
+	diffCmd.Flags().BoolVar(&withRedis, "with-redis", false,
+		"also diff the Redis release")
+	diffCmd.Flags().BoolVar(&diffSummary, "summary", false,
+		"only show added/changed/removed resource counts")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "text",
+		"output format: text or json")
+	diffCmd.Flags().IntVar(&diffMaxLines, "max-lines", 500,
+		"maximum diff lines to print per release (0 for no limit)")
+	diffCmd.Flags().IntVarP(&diffContext, "context-lines", "U", 3,
+		"number of context lines around each change")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	viper.BindPFlag("values_extra", cmd.Flags().Lookup("values-extra"))
+	viper.BindPFlag("labels", cmd.Flags().Lookup("labels"))
+
+	if diffOutput != "text" && diffOutput != "json" {
+		return usageError(fmt.Errorf("unsupported output format %q (expected text or json)", diffOutput))
+	}
+
+	if diffOutput == "json" {
+		defer output.MessagesToStderr()()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := fetchValuesExtra(cfg); err != nil {
+		return err
+	}
+	if err := resolveTags(cmd.Context(), cfg); err != nil {
+		return err
+	}
+
+	helmCmd := helm.NewHelmCommand(false)
+
+	if err := helmCmd.RepoAdd("envoyproxy", "oci://docker.io/envoyproxy"); err != nil {
+		return err
+	}
+	if withRedis {
+		if err := helmCmd.RepoAdd("bitnami", "https://charts.bitnami.com/bitnami"); err != nil {
+			return err
+		}
+	}
+	if err := helmCmd.RepoUpdate(); err != nil {
+		return err
+	}
+
+	releases, err := resolveReleases(cfg)
+	if err != nil {
+		return err
+	}
+
+	var results []releaseDiff
+	hasChanges := false
+
+	for _, r := range releases {
+		// Hooks are not part of the deployed manifest either.
+		opts := r.helmOptions()
+		opts.NoHooks = true
+		target, err := helmCmd.Template(r.name, r.chart, r.namespace, opts)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", r.name, err)
+		}
+
+		result := releaseDiff{Release: r.name, Namespace: r.namespace}
+
+		deployed := ""
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil {
+			return fmt.Errorf("failed to look up release %s: %w", r.name, err)
+		}
+		if rel != nil {
+			result.Installed = true
+			deployed, err = helmCmd.GetManifest(r.name, r.namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get manifest for %s: %w", r.name, err)
+			}
+		}
+
+		result.Resources, err = diff.Compare(deployed, target, diffContext)
+		if err != nil {
+			return fmt.Errorf("failed to diff %s: %w", r.name, err)
+		}
+		result.Added, result.Changed, result.Removed = diff.Summarize(result.Resources)
+
+		if len(result.Resources) > 0 {
+			hasChanges = true
+		}
+		if diffSummary {
+			for i := range result.Resources {
+				result.Resources[i].Diff = ""
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	if diffOutput == "json" {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"changed":  hasChanges,
+			"releases": results,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		printDiff(results)
+	}
+
+	if hasChanges {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return &ExitError{Code: ExitChanges}
+	}
+
+	return nil
+}
+
+func printDiff(results []releaseDiff) {
+	for _, result := range results {
+		status := "installed"
+		if !result.Installed {
+			status = "not installed"
+		}
+
+		output.Printf("\n📋 %s (%s, %s): +%d ~%d -%d\n", result.Release, result.Namespace, status,
+			result.Added, result.Changed, result.Removed)
+
+		if diffSummary {
+			for _, r := range result.Resources {
+				output.Printf("   %-8s %s\n", r.Status, r.ID())
+			}
+			continue
+		}
+
+		var lines []string
+		for _, r := range result.Resources {
+			lines = append(lines, strings.Split(strings.TrimSuffix(r.Diff, "\n"), "\n")...)
+		}
+
+		if diffMaxLines > 0 && len(lines) > diffMaxLines {
+			truncated := len(lines) - diffMaxLines
+			lines = append(lines[:diffMaxLines],
+				fmt.Sprintf("... %d more lines truncated (use --max-lines 0 to show all)", truncated))
+		}
+
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+	}
+
+	output.Println()
+	for _, result := range results {
+		if len(result.Resources) > 0 {
+			output.Println("⚠️  Differences found")
+			return
+		}
+	}
+	output.Println("✅ No differences")
+}
@@ -1,13 +1,33 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"os/exec"
+	"runtime"
+	"strings"
+	"time"
 
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+)
+
+const (
+	registryHost         = "docker.io/envoyproxy"
+	registryCheckChart   = "oci://" + registryHost + "/gateway-helm"
+	registryCheckTimeout = 10 * time.Second
+
+	// lowRateLimit is the number of GitHub API requests left below which
+	// doctor warns; an install makes a handful.
+	lowRateLimit = 5
 )
 
 var doctorCmd = &cobra.Command{
@@ -19,127 +39,527 @@ for installing Envoy AI Gateway are met.
 This command verifies:
 - kubectl connectivity and cluster access
 - helm installation and functionality
-- kubernetes namespaces
-- optional components (Redis, etc.)`,
+- access to the OCI registry serving the upstream charts
+- access to the GitHub API and the remaining rate limit
+- kubernetes namespaces, and whether they are terminating
+- Pod Security Admission levels of the namespaces against the running pods
+- PodDisruptionBudgets that would block evictions in the target namespaces
+- AWS credentials, when values files are read from s3:// URLs
+- that the install hooks of the config file can run on this platform
+- Envoy Gateway / AI Gateway version compatibility
+- served versions of the installed CRDs against those of the charts
+- AI Gateway CRDs established and at the chart version of the controller
+- the Prometheus Operator CRDs, with --prometheus-operator
+- optional components (Redis, etc.), and the installed Redis chart against
+  --redis-version
+- with --redis-external-host, that the external Redis answers PING
+- with --ip-family, that the cluster has Service CIDRs and node pod CIDRs
+  of that IP family`,
 	RunE: runDoctor,
 }
 
+func init() {
+	doctorCmd.Flags().Bool("prometheus-operator", false,
+		"also check that the Prometheus Operator CRDs that install --prometheus-operator needs are installed")
+	doctorCmd.Flags().String("redis-version", "",
+		"Redis chart version the installed Redis release is expected to run")
+	doctorCmd.Flags().String("redis-external-host", "",
+		"external Redis that install points rate limiting at; doctor checks that it answers PING")
+	doctorCmd.Flags().Int("redis-external-port", config.DefaultRedisPort,
+		"port of --redis-external-host")
+	doctorCmd.Flags().String("redis-external-password", "",
+		"password of --redis-external-host (prefer EAIG_REDIS_EXTERNAL_PASSWORD)")
+	doctorCmd.Flags().String("ip-family", "",
+		"IP family install configures the gateway Services with (ipv4, ipv6 or dual); doctor checks that the cluster supports it")
+}
+
 func runDoctor(cmd *cobra.Command, args []string) error {
-	fmt.Println("🏥 System Health Check")
-	fmt.Println()
+	viper.BindPFlag("prometheus_operator", cmd.Flags().Lookup("prometheus-operator"))
+	viper.BindPFlag("redis_version", cmd.Flags().Lookup("redis-version"))
+	viper.BindPFlag("redis_external_host", cmd.Flags().Lookup("redis-external-host"))
+	viper.BindPFlag("redis_external_port", cmd.Flags().Lookup("redis-external-port"))
+	viper.BindPFlag("redis_external_password", cmd.Flags().Lookup("redis-external-password"))
+	viper.BindPFlag("ip_family", cmd.Flags().Lookup("ip-family"))
+
+	output.Println("🏥 System Health Check")
+	output.Println()
 
 	var allHealthy = true
+	// code is the exit code of the most fundamental failed check.
+	code := ExitFailure
 
 	if !checkKubectl() {
 		allHealthy = false
+		code = ExitPrerequisite
+	}
+
+	// The registry is checked with helm, so there is nothing to learn
+	// without it.
+	helmOK := checkHelm()
+	if !helmOK {
+		allHealthy = false
+		code = ExitPrerequisite
+	} else if !checkRegistry(cmd.Context()) {
+		allHealthy = false
+	}
+
+	if !checkGitHubAPI(cmd.Context()) {
+		allHealthy = false
 	}
 
-	if !checkHelm() {
+	if !checkS3Credentials(cmd.Context()) {
 		allHealthy = false
 	}
 
-	if !checkKubernetesConnection() {
+	if !checkHooks() {
 		allHealthy = false
 	}
 
+	// Without a cluster client, the checks of the cluster are skipped and
+	// the local ones still run.
+	client, err := k8s.NewKubeClient()
+	if err != nil {
+		output.Printf("🔍 Kubernetes cluster: ❌ %v\n", err)
+		output.Println("   Configure your kubeconfig or check cluster connectivity")
+		client = nil
+	}
+	if client == nil || !checkKubernetesConnection(client) {
+		allHealthy = false
+		if code == ExitFailure {
+			code = ExitCluster
+		}
+	}
+
 	namespaceGW := viper.GetString("namespace_gateway")
 	namespaceAI := viper.GetString("namespace_ai")
+	cfg, cfgErr := config.Load()
 
-	if !checkNamespace(namespaceGW) {
-		allHealthy = false
+	if client != nil {
+		checkLocalCluster()
+		checkOpenShift()
+
+		if !checkNamespace(client, namespaceGW) {
+			allHealthy = false
+		}
+
+		if !checkNamespace(client, namespaceAI) {
+			allHealthy = false
+		}
+
+		checkPodDisruptionBudgets(client, namespaceGW, namespaceAI)
+	}
+
+	if client != nil && cfgErr == nil {
+		checkPodSecurity(client, cfg)
+		if cfg.IPFamily != "" && !checkIPFamily(cmd.Context(), cfg) {
+			allHealthy = false
+			if code == ExitFailure {
+				code = ExitCluster
+			}
+		}
+		if !checkInstalledCompatibility(cmd.Context(), cfg) {
+			allHealthy = false
+		}
+		if helmOK {
+			checkCRDVersions(cfg)
+			if !checkAIGatewayCRDs(helm.NewHelmCommand(false), cfg) {
+				allHealthy = false
+			}
+		}
 	}
 
-	if !checkNamespace(namespaceAI) {
+	if client != nil && prometheusOperatorRequired() && !checkPrometheusOperator() {
 		allHealthy = false
+		if code == ExitFailure {
+			code = ExitPrerequisite
+		}
 	}
 
-	if !checkRedis(namespaceAI) {
-		fmt.Println("⚠️  Redis:              Not installed (optional - install with --with-redis if needed)")
+	if cfgErr == nil && cfg.RedisExternal != nil {
+		if !checkRedisExternal(cmd.Context(), cfg.RedisExternal) {
+			allHealthy = false
+			if code == ExitFailure {
+				code = ExitPrerequisite
+			}
+		}
+	} else if client != nil && !checkRedis(client, namespaceAI) {
+		output.Println("⚠️  Redis:              Not installed (optional - install with --with-redis if needed)")
+	}
+	if client != nil && cfgErr == nil && helmOK && cfg.RedisVersion != "" {
+		checkRedisVersion(helm.NewHelmCommand(false), cfg)
 	}
 
-	fmt.Println()
+	output.Println()
 	if allHealthy {
-		fmt.Println("✅ All checks passed! You're ready to install Envoy AI Gateway.")
+		output.Println("✅ All checks passed! You're ready to install Envoy AI Gateway.")
 	} else {
-		fmt.Println("❌ Some checks failed. Please address the issues above.")
-		return fmt.Errorf("system health check failed")
+		output.Println("❌ Some checks failed. Please address the issues above.")
+		return &ExitError{Code: code, Err: errors.New("system health check failed")}
 	}
 
 	return nil
 }
 
+// kubectlInstallHints and helmInstallHints tell how to install the tools
+// on each runtime.GOOS, with "" for the other platforms.
+var (
+	kubectlInstallHints = map[string]string{
+		"linux":   "https://kubernetes.io/docs/tasks/tools/install-kubectl-linux/",
+		"darwin":  "brew install kubectl, or https://kubernetes.io/docs/tasks/tools/install-kubectl-macos/",
+		"windows": "winget install -e --id Kubernetes.kubectl, or https://kubernetes.io/docs/tasks/tools/install-kubectl-windows/",
+		"":        "https://kubernetes.io/docs/tasks/tools/",
+	}
+	helmInstallHints = map[string]string{
+		"darwin":  "brew install helm, or https://helm.sh/docs/intro/install/",
+		"windows": "winget install Helm.Helm, or https://helm.sh/docs/intro/install/",
+		"":        "https://helm.sh/docs/intro/install/",
+	}
+)
+
+func installHint(hints map[string]string) string {
+	if hint, ok := hints[runtime.GOOS]; ok {
+		return hint
+	}
+	return hints[""]
+}
+
 func checkKubectl() bool {
-	fmt.Print("🔍 kubectl:            ")
+	output.Print("🔍 kubectl:            ")
 	if _, err := exec.LookPath("kubectl"); err != nil {
-		fmt.Println("❌ NOT FOUND")
-		fmt.Println("   Install kubectl: https://kubernetes.io/docs/tasks/tools/")
+		output.Println("❌ NOT FOUND")
+		output.Printf("   Install kubectl: %s\n", installHint(kubectlInstallHints))
 		return false
 	}
 
-	cmd := exec.Command("kubectl", "version", "--client", "--short")
-	if output, err := cmd.Output(); err != nil {
-		fmt.Println("❌ FAILED")
+	version, err := kubectlClientVersion()
+	if err != nil {
+		output.Println("❌ FAILED")
+		output.Printf("   %v\n", err)
 		return false
-	} else {
-		fmt.Printf("✅ %s", string(output))
 	}
+	output.Printf("✅ Client Version: %s\n", version)
 	return true
 }
 
+// kubectlClientVersion returns the version of the kubectl client. Its JSON
+// output is the same on every platform and kubectl version, unlike
+// --short, which kubectl 1.28 removed.
+func kubectlClientVersion() (string, error) {
+	output, err := exec.Command("kubectl", "version", "--client", "--output=json").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("kubectl version: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("kubectl version: %w", err)
+	}
+
+	var version struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err := json.Unmarshal(output, &version); err != nil || version.ClientVersion.GitVersion == "" {
+		return "", fmt.Errorf("unexpected output of kubectl version: %s", strings.TrimSpace(string(output)))
+	}
+	return version.ClientVersion.GitVersion, nil
+}
+
 func checkHelm() bool {
-	fmt.Print("🔍 Helm:               ")
+	output.Print("🔍 Helm:               ")
 	if err := helm.ValidateHelmInstalled(); err != nil {
-		fmt.Println("❌ NOT FOUND")
-		fmt.Println("   Install Helm: https://helm.sh/docs/intro/install/")
+		output.Println("❌ NOT FOUND")
+		output.Printf("   Install Helm: %s\n", installHint(helmInstallHints))
 		return false
 	}
 
 	helmCmd := helm.NewHelmCommand(false)
 	version, err := helmCmd.Version()
 	if err != nil {
-		fmt.Println("❌ FAILED")
+		output.Println("❌ FAILED")
+		return false
+	}
+
+	output.Printf("✅ %s", version)
+	return true
+}
+
+// checkRegistry pulls the metadata of the Envoy Gateway chart to tell a
+// blocked registry apart from the helm errors install would otherwise fail
+// with.
+func checkRegistry(ctx context.Context) bool {
+	output.Print("🔍 OCI registry:       ")
+
+	ctx, cancel := context.WithTimeout(ctx, registryCheckTimeout)
+	defer cancel()
+
+	helmCmd := helm.NewHelmCommand(false)
+	if _, err := helmCmd.ShowChart(ctx, registryCheckChart); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("no response after %s", registryCheckTimeout)
+		}
+		output.Printf("❌ %s is not reachable\n", registryHost)
+		var helmErr *helm.CommandError
+		if errors.As(err, &helmErr) && helmErr.Message != "" {
+			err = errors.New(helmErr.Message)
+		}
+		output.Printf("   %v\n", err)
+		output.Println("   Allow HTTPS to docker.io and registry-1.docker.io, or set HTTPS_PROXY for helm")
+		return false
+	}
+
+	output.Printf("✅ %s reachable\n", registryHost)
+	return true
+}
+
+// checkGitHubAPI reports how many GitHub API requests are left for release
+// lookups. Running low only warns: cached lookups are still used.
+func checkGitHubAPI(ctx context.Context) bool {
+	output.Print("🔍 GitHub API:         ")
+
+	rate, err := upstream.GetRateLimit(ctx)
+	if err != nil {
+		output.Println("❌ NOT REACHABLE")
+		output.Printf("   %v\n", err)
+		output.Println("   Check HTTPS_PROXY and --ca-bundle; release lookups cached within --cache-ttl are still used")
 		return false
 	}
 
-	fmt.Printf("✅ %s", version)
+	auth := "anonymous"
+	if rate.Authenticated {
+		auth = "authenticated"
+	}
+	if rate.Remaining < lowRateLimit {
+		output.Printf("⚠️  %d/%d requests left (%s), resets at %s\n",
+			rate.Remaining, rate.Limit, auth, rate.Reset.Local().Format("15:04:05"))
+		if !rate.Authenticated {
+			output.Println("   Set GITHUB_TOKEN to raise the limit to 5000 requests per hour")
+		}
+		return true
+	}
+
+	output.Printf("✅ %d/%d requests left (%s)\n", rate.Remaining, rate.Limit, auth)
 	return true
 }
 
-func checkKubernetesConnection() bool {
-	fmt.Print("🔍 Kubernetes cluster: ")
-	cmd := exec.Command("kubectl", "cluster-info")
-	if err := cmd.Run(); err != nil {
-		fmt.Println("❌ NOT CONNECTED")
-		fmt.Println("   Configure your kubeconfig or check cluster connectivity")
+// checkS3Credentials verifies the AWS credential chain when values_extra
+// references s3:// files. Without such files there is nothing to check.
+func checkS3Credentials(ctx context.Context) bool {
+	cfg, err := config.Load()
+	if err != nil {
+		return true
+	}
+
+	s3Files := map[string]bool{}
+	for _, files := range cfg.ValuesExtra {
+		for _, file := range files {
+			if values.IsS3(file) {
+				s3Files[file] = true
+			}
+		}
+	}
+	if len(s3Files) == 0 {
+		return true
+	}
+
+	output.Print("🔍 AWS credentials:    ")
+	source, err := values.CheckS3Credentials(ctx)
+	if err != nil {
+		output.Println("❌ NOT FOUND")
+		output.Printf("   Needed for %d values file(s) on S3: %v\n", len(s3Files), err)
+		output.Println("   Configure credentials: https://docs.aws.amazon.com/sdkref/latest/guide/standardized-credentials.html")
 		return false
 	}
-	fmt.Println("✅ CONNECTED")
+	output.Printf("✅ %s\n", source)
 	return true
 }
 
-func checkNamespace(namespace string) bool {
-	fmt.Printf("🔍 Namespace '%s':    ", namespace)
-	cmd := exec.Command("kubectl", "get", "namespace", namespace)
-	if err := cmd.Run(); err != nil {
-		fmt.Println("❌ NOT FOUND")
-		fmt.Printf("   Will be created during installation\n")
+func checkKubernetesConnection(client k8s.KubeClient) bool {
+	output.Print("🔍 Kubernetes cluster: ")
+	if _, err := client.ClusterInfo(); err != nil {
+		output.Println("❌ NOT CONNECTED")
+		output.Println("   Configure your kubeconfig or check cluster connectivity")
+		return false
+	}
+	output.Println("✅ CONNECTED")
+	return true
+}
+
+func checkLocalCluster() {
+	output.Print("🔍 Cluster type:       ")
+	cluster := k8s.DetectLocalCluster()
+	if cluster == nil {
+		output.Println("ℹ️  not a kind or minikube cluster")
+		return
+	}
+	output.Printf("💻 %s (detected from %s)\n", cluster.Provider, cluster.Reason)
+	output.Println("   Use 'install --local' for a profile tuned for local clusters")
+}
+
+func checkOpenShift() {
+	output.Print("🔍 OpenShift:          ")
+	detected, err := k8s.IsOpenShift()
+	switch {
+	case err != nil:
+		output.Println("⚠️  could not detect")
+	case detected:
+		output.Println("✅ detected (install enables --openshift automatically)")
+	default:
+		output.Println("ℹ️  not detected (--openshift mode disabled)")
+	}
+}
+
+// checkPrometheusOperator checks that the ServiceMonitor CRD that
+// --prometheus-operator needs is installed.
+func checkPrometheusOperator() bool {
+	output.Print("🔍 Prometheus Operator: ")
+	versions, err := k8s.GetCRDVersions(serviceMonitorCRD)
+	switch {
+	case err != nil:
+		output.Printf("❌ %v\n", err)
+		return false
+	case versions == nil:
+		output.Printf("❌ CRD %s not installed\n", serviceMonitorCRD)
+		output.Printf("   Install the Prometheus Operator: %s\n", prometheusOperatorInstallURL)
+		output.Println("   or install with --install-prometheus, or --prometheus-operator=false for a scrape config")
+		return false
+	}
+	output.Printf("✅ %s installed\n", serviceMonitorCRD)
+	return true
+}
+
+// checkHooks checks that the install hooks set in the config file can run
+// on this platform; on Windows only files with an executable extension can.
+func checkHooks() bool {
+	ok := true
+	for _, hook := range installHooks {
+		path := viper.GetString(hook.key)
+		if path == "" {
+			continue
+		}
+		output.Printf("🔍 %-20s", strings.ToUpper(hook.name[:1])+hook.name[1:]+" hook:")
+		if err := checkHookExecutable(path); err != nil {
+			output.Println("❌ CANNOT RUN")
+			output.Printf("   %v\n", err)
+			ok = false
+			continue
+		}
+		output.Printf("✅ %s\n", path)
+	}
+	return ok
+}
+
+// checkNamespace reports whether a target namespace exists. A namespace
+// that is terminating is only a warning: install waits for it.
+func checkNamespace(client k8s.KubeClient, namespace string) bool {
+	output.Printf("🔍 Namespace '%s':    ", namespace)
+	status, err := client.GetNamespaceStatus(namespace)
+	switch {
+	case err != nil || status == nil:
+		output.Println("❌ NOT FOUND")
+		output.Printf("   Will be created during installation\n")
+		return true
+	case status.Terminating():
+		output.Println("⚠️  TERMINATING")
+		for _, condition := range status.Conditions {
+			output.Printf("   %s\n", condition)
+		}
+		output.Println("   install waits for it to be deleted (--terminating-timeout); --force-finalize removes the finalizers holding it")
 		return true
 	}
-	fmt.Println("✅ EXISTS")
+	output.Println("✅ EXISTS")
 	return true
 }
 
-func checkRedis(namespace string) bool {
-	fmt.Print("🔍 Redis:              ")
+// checkPodDisruptionBudgets warns about budgets that never allow an
+// eviction. Helm upgrades that need to reschedule pods, and node drains,
+// wait on them indefinitely.
+func checkPodDisruptionBudgets(client k8s.KubeClient, namespaces ...string) {
+	output.Print("🔍 PDBs:               ")
+
+	var blocking []string
+	seen := map[string]bool{}
+	for _, namespace := range namespaces {
+		if seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+
+		pdbs, err := client.GetPodDisruptionBudgets(namespace)
+		if err != nil {
+			output.Printf("⚠️  could not list: %v\n", err)
+			return
+		}
+		for _, pdb := range pdbs {
+			if ok, reason := pdb.Blocking(); ok {
+				blocking = append(blocking, fmt.Sprintf("%s/%s: %s", pdb.Namespace, pdb.Name, reason))
+			}
+		}
+	}
+
+	if len(blocking) == 0 {
+		output.Println("✅ none blocking evictions")
+		return
+	}
+
+	output.Printf("⚠️  %d could block upgrades and node drains\n", len(blocking))
+	for _, line := range blocking {
+		output.Printf("   %s\n", line)
+	}
+	output.Println("   Lower minAvailable or raise maxUnavailable so at least one pod can be evicted")
+}
 
-	cmd := exec.Command("kubectl", "get", "pod", "-n", namespace,
-		"-l", "app=redis", "-o", "jsonpath={.items[0].metadata.name}")
+func checkRedis(client k8s.KubeClient, namespace string) bool {
+	output.Print("🔍 Redis:              ")
 
-	output, err := cmd.Output()
-	if err != nil || len(output) == 0 {
+	pods, err := client.GetPods(namespace, "app=redis")
+	if err != nil || len(pods) == 0 {
 		return false
 	}
 
-	fmt.Printf("✅ Pod: %s\n", string(output))
+	output.Printf("✅ Pod: %s\n", pods[0].Name)
+	return true
+}
+
+// checkRedisVersion compares the chart version of the Redis release with
+// the configured redis_version. A mismatch is only a warning, as Redis is
+// optional and install --with-redis brings it in line.
+func checkRedisVersion(helmCmd *helm.HelmCommand, cfg *config.Config) {
+	output.Print("🔍 Redis version:      ")
+
+	r := redisRelease(cfg)
+	rel, err := helmCmd.FindRelease(r.name, r.namespace)
+	switch {
+	case err != nil:
+		output.Printf("⚠️  could not read release %s: %v\n", r.name, err)
+		return
+	case rel == nil:
+		output.Printf("ℹ️  release %s not installed; install --with-redis installs chart %s\n", r.name, cfg.RedisVersion)
+		return
+	}
+
+	installed := rel.ChartVersion()
+	if strings.TrimPrefix(installed, "v") != strings.TrimPrefix(cfg.RedisVersion, "v") {
+		output.Printf("⚠️  chart %s installed, %s configured\n", installed, cfg.RedisVersion)
+		output.Printf("   Run 'envoy-ai-installer install --with-redis --redis-version %s' to change it\n", cfg.RedisVersion)
+		return
+	}
+	output.Printf("✅ chart %s\n", installed)
+}
+
+// checkRedisExternal checks that the external Redis answers PING, with its
+// password if one is set. Rate limiting cannot work without it, so a
+// failure fails the health check.
+func checkRedisExternal(ctx context.Context, redis *config.RedisExternal) bool {
+	output.Print("🔍 Redis (external):   ")
+
+	address := redisExternalAddress(redis)
+	if err := pingRedis(ctx, redis); err != nil {
+		output.Printf("❌ %s does not answer PING\n", address)
+		output.Printf("   %v\n", err)
+		output.Println("   Check the host, port and password, and that this machine can reach it as the cluster does")
+		return false
+	}
+	output.Printf("✅ %s answers PING\n", address)
 	return true
 }
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+func TestDoctorClusterChecks(t *testing.T) {
+	const namespace = "envoy-ai-gateway-system"
+	connected := &k8s.FakeClient{
+		Info:       "Kubernetes control plane is running at https://127.0.0.1:6443",
+		Namespaces: map[string]bool{namespace: true},
+		Pods:       map[string][]k8s.Pod{namespace: {{Namespace: namespace, Name: "redis-master-0", Phase: "Running", Ready: true}}},
+		PDBs: map[string][]k8s.PodDisruptionBudget{namespace: {
+			{Namespace: namespace, Name: "ai-gateway-controller", MinAvailable: "1", ExpectedPods: 1},
+			{Namespace: namespace, Name: "redis", MaxUnavailable: "1", ExpectedPods: 1},
+		}},
+	}
+	empty := &k8s.FakeClient{}
+	unreachable := &k8s.FakeClient{Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name   string
+		check  func() bool
+		wantOK bool
+		want   []string
+	}{
+		{
+			name:   "connected",
+			check:  func() bool { return checkKubernetesConnection(connected) },
+			wantOK: true,
+			want:   []string{"✅ CONNECTED"},
+		},
+		{
+			name:  "not connected",
+			check: func() bool { return checkKubernetesConnection(unreachable) },
+			want:  []string{"❌ NOT CONNECTED", "Configure your kubeconfig"},
+		},
+		{
+			name:   "namespace exists",
+			check:  func() bool { return checkNamespace(connected, namespace) },
+			wantOK: true,
+			want:   []string{"✅ EXISTS"},
+		},
+		{
+			name:   "namespace missing",
+			check:  func() bool { return checkNamespace(empty, namespace) },
+			wantOK: true,
+			want:   []string{"❌ NOT FOUND", "Will be created during installation"},
+		},
+		{
+			name:   "redis running",
+			check:  func() bool { return checkRedis(connected, namespace) },
+			wantOK: true,
+			want:   []string{"✅ Pod: redis-master-0"},
+		},
+		{
+			name:  "redis missing",
+			check: func() bool { return checkRedis(empty, namespace) },
+		},
+		{
+			name: "blocking PDB",
+			check: func() bool {
+				checkPodDisruptionBudgets(connected, namespace, namespace)
+				return true
+			},
+			wantOK: true,
+			want:   []string{"⚠️  1 could block", namespace + "/ai-gateway-controller: minAvailable 1 equals the replica count (1)"},
+		},
+		{
+			name: "PDBs not listed",
+			check: func() bool {
+				checkPodDisruptionBudgets(unreachable, namespace)
+				return true
+			},
+			wantOK: true,
+			want:   []string{"could not list: connection refused"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := captureStdout(t)
+			if ok := tt.check(); ok != tt.wantOK {
+				t.Errorf("check returned %v, want %v", ok, tt.wantOK)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("got output %q, want %q", out.String(), want)
+				}
+			}
+		})
+	}
+}
+
+// captureStdout redirects output.Stdout to a buffer for the rest of the test.
+func captureStdout(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var out bytes.Buffer
+	saved := output.Stdout
+	output.Stdout = &out
+	t.Cleanup(func() { output.Stdout = saved })
+	return &out
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+)
+
+// printDryRunKubectl prints the kubectl command a dry run skips, with
+// credentials masked.
+func printDryRunKubectl(args ...string) {
+	output.Printf("[DRY-RUN] kubectl %s\n", strings.Join(redact.Args(args), " "))
+}
+
+// printDryRunApply prints the manifest a dry run would apply, with Secret
+// data and credential-like values masked.
+func printDryRunApply(manifest string) {
+	output.Printf("[DRY-RUN] kubectl apply -f - <<EOF\n%sEOF\n", redactManifest(manifest))
+}
+
+// redactManifest masks Secret data and credential-like values in a YAML
+// stream, falling back to text redaction rather than printing an
+// unparsable one as is.
+func redactManifest(manifest string) string {
+	redacted, err := redact.YAML(manifest)
+	if err != nil {
+		return redact.Text(manifest)
+	}
+	return redacted
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	envoyProxySelector     = "app.kubernetes.io/component=proxy,app.kubernetes.io/managed-by=envoy-gateway"
+	owningGatewayLabel     = "gateway.envoyproxy.io/owning-gateway-name"
+	owningGatewayNamespace = "gateway.envoyproxy.io/owning-gateway-namespace"
+)
+
+var gatewayGVR = schema.GroupVersionResource{
+	Group:    "gateway.networking.k8s.io",
+	Version:  "v1",
+	Resource: "gateways",
+}
+
+var (
+	endpointsOutput string
+	endpointsModel  string
+)
+
+type endpointListener struct {
+	Name     string `json:"name"`
+	Protocol string `json:"protocol"`
+	Port     int32  `json:"port"`
+	NodePort int32  `json:"node_port,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+type endpoint struct {
+	Gateway     string             `json:"gateway,omitempty"`
+	Namespace   string             `json:"namespace"`
+	Service     string             `json:"service,omitempty"`
+	ServiceType string             `json:"service_type,omitempty"`
+	Address     string             `json:"address,omitempty"`
+	Pending     bool               `json:"pending"`
+	Listeners   []endpointListener `json:"listeners"`
+}
+
+var endpointsCmd = &cobra.Command{
+	Use:   "endpoints",
+	Short: "Show the gateway's external address and example requests",
+	Long: `Look up the Gateway resources and their addresses (or the Envoy proxy
+Service when no Gateway exists yet), list their listeners and print example
+curl commands for OpenAI-compatible chat completions.`,
+	RunE: runEndpoints,
+}
+
+func init() {
+	endpointsCmd.Flags().StringVarP(&endpointsOutput, "output", "o", "text",
+		"output format: text or json")
+	endpointsCmd.Flags().StringVar(&endpointsModel, "model", "gpt-4o-mini",
+		"model name used in the example requests")
+}
+
+func runEndpoints(cmd *cobra.Command, args []string) error {
+	if endpointsOutput != "text" && endpointsOutput != "json" {
+		return usageError(fmt.Errorf("unsupported output format %q (expected text or json)", endpointsOutput))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := discoverEndpoints(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	if endpointsOutput == "json" {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"endpoints": endpoints,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printEndpoints(cfg, endpoints)
+	return nil
+}
+
+func discoverEndpoints(ctx context.Context, cfg *config.Config) ([]endpoint, error) {
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := k8s.NewDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := clientset.CoreV1().Services(cfg.NamespaceGateway).List(ctx, metav1.ListOptions{
+		LabelSelector: envoyProxySelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Envoy services: %w", err)
+	}
+
+	gateways, err := dynamicClient.Resource(gatewayGVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to list Gateways: %w", err)
+	}
+
+	var endpoints []endpoint
+	if gateways != nil {
+		for _, gw := range gateways.Items {
+			endpoints = append(endpoints, gatewayEndpoint(gw, services.Items))
+		}
+	}
+
+	if len(endpoints) == 0 {
+		for _, svc := range services.Items {
+			endpoints = append(endpoints, serviceEndpoint(svc))
+		}
+	}
+
+	return endpoints, nil
+}
+
+func gatewayEndpoint(gw unstructured.Unstructured, services []corev1.Service) endpoint {
+	ep := endpoint{Gateway: gw.GetName(), Namespace: gw.GetNamespace()}
+
+	addresses, _, _ := unstructured.NestedSlice(gw.Object, "status", "addresses")
+	for _, a := range addresses {
+		if addr, ok := a.(map[string]interface{}); ok {
+			if value, _ := addr["value"].(string); value != "" {
+				ep.Address = value
+				break
+			}
+		}
+	}
+
+	var svc *corev1.Service
+	for i := range services {
+		labels := services[i].Labels
+		if labels[owningGatewayLabel] == gw.GetName() && labels[owningGatewayNamespace] == gw.GetNamespace() {
+			svc = &services[i]
+			break
+		}
+	}
+	if svc != nil {
+		ep.Service = svc.Name
+		ep.ServiceType = string(svc.Spec.Type)
+		if ep.Address == "" {
+			ep.Address = loadBalancerAddress(*svc)
+		}
+	}
+
+	listeners, _, _ := unstructured.NestedSlice(gw.Object, "spec", "listeners")
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		el := endpointListener{}
+		el.Name, _ = listener["name"].(string)
+		el.Protocol, _ = listener["protocol"].(string)
+		el.Hostname, _ = listener["hostname"].(string)
+		if port, ok := listener["port"].(int64); ok {
+			el.Port = int32(port)
+		}
+		if svc != nil {
+			for _, p := range svc.Spec.Ports {
+				if p.Port == el.Port {
+					el.NodePort = p.NodePort
+				}
+			}
+		}
+
+		ep.Listeners = append(ep.Listeners, el)
+	}
+
+	finishEndpoint(&ep)
+	return ep
+}
+
+func serviceEndpoint(svc corev1.Service) endpoint {
+	ep := endpoint{
+		Namespace:   svc.Namespace,
+		Service:     svc.Name,
+		ServiceType: string(svc.Spec.Type),
+		Address:     loadBalancerAddress(svc),
+	}
+
+	for _, p := range svc.Spec.Ports {
+		protocol := "HTTP"
+		if p.Port == 443 || strings.Contains(p.Name, "https") {
+			protocol = "HTTPS"
+		}
+		ep.Listeners = append(ep.Listeners, endpointListener{
+			Name:     p.Name,
+			Protocol: protocol,
+			Port:     p.Port,
+			NodePort: p.NodePort,
+		})
+	}
+
+	finishEndpoint(&ep)
+	return ep
+}
+
+func loadBalancerAddress(svc corev1.Service) string {
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+		if ingress.IP != "" {
+			return ingress.IP
+		}
+	}
+	return ""
+}
+
+func finishEndpoint(ep *endpoint) {
+	ep.Pending = ep.Address == ""
+	if ep.Pending || ep.ServiceType == string(corev1.ServiceTypeNodePort) {
+		return
+	}
+
+	for i := range ep.Listeners {
+		ep.Listeners[i].URL = listenerURL(ep.Address, ep.Listeners[i].Protocol, ep.Listeners[i].Port)
+	}
+}
+
+func listenerURL(address, protocol string, port int32) string {
+	scheme := "http"
+	defaultPort := int32(80)
+	if protocol == "HTTPS" || protocol == "TLS" {
+		scheme = "https"
+		defaultPort = 443
+	}
+
+	if port == defaultPort {
+		return fmt.Sprintf("%s://%s", scheme, address)
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(address, strconv.Itoa(int(port))))
+}
+
+func printEndpoints(cfg *config.Config, endpoints []endpoint) {
+	if len(endpoints) == 0 {
+		output.Println("ℹ️  No Gateway resources or Envoy proxy services found.")
+		output.Printf("   Create a Gateway that uses the Envoy Gateway GatewayClass, then check the services in %s.\n",
+			cfg.NamespaceGateway)
+		return
+	}
+
+	for _, ep := range endpoints {
+		if ep.Gateway != "" {
+			output.Printf("🌐 Gateway %s/%s\n", ep.Namespace, ep.Gateway)
+		} else {
+			output.Printf("🌐 Service %s/%s\n", ep.Namespace, ep.Service)
+		}
+		if ep.Service != "" && ep.Gateway != "" {
+			output.Printf("  Service:  %s (%s)\n", ep.Service, ep.ServiceType)
+		} else if ep.ServiceType != "" {
+			output.Printf("  Type:     %s\n", ep.ServiceType)
+		}
+
+		switch {
+		case ep.ServiceType == string(corev1.ServiceTypeNodePort):
+			output.Println("  Address:  NodePort service; reach it through a node IP and the node port below")
+		case ep.Pending:
+			output.Println("  Address:  ⏳ pending (the load balancer has not been provisioned yet)")
+		default:
+			output.Printf("  Address:  %s\n", ep.Address)
+		}
+
+		output.Println("  Listeners:")
+		for _, l := range ep.Listeners {
+			hostname := l.Hostname
+			if hostname == "" {
+				hostname = "*"
+			}
+			line := fmt.Sprintf("    - %-12s %-5s port %d, host %s", l.Name, l.Protocol, l.Port, hostname)
+			if l.NodePort != 0 && ep.ServiceType == string(corev1.ServiceTypeNodePort) {
+				line += fmt.Sprintf(", node port %d", l.NodePort)
+			}
+			output.Println(line)
+		}
+
+		l, ok := exampleListener(ep)
+		if !ok {
+			output.Println()
+			continue
+		}
+
+		output.Println("\n  Example request:")
+		if l.URL != "" {
+			printCurl(l.URL, l.Hostname)
+		} else if ep.Service != "" {
+			output.Println("    # No external address is available; use a port-forward instead:")
+			output.Printf("    kubectl port-forward -n %s svc/%s 8080:%d\n", cfg.NamespaceGateway, ep.Service, l.Port)
+			printCurl("http://localhost:8080", l.Hostname)
+		}
+		output.Println()
+	}
+}
+
+func exampleListener(ep endpoint) (endpointListener, bool) {
+	for _, l := range ep.Listeners {
+		if l.Protocol == "HTTP" || l.Protocol == "HTTPS" {
+			return l, true
+		}
+	}
+	return endpointListener{}, false
+}
+
+func printCurl(baseURL, hostname string) {
+	output.Printf("    curl -sS %s/v1/chat/completions \\\n", baseURL)
+	if hostname != "" && !strings.HasPrefix(hostname, "*") {
+		output.Printf("      -H \"Host: %s\" \\\n", hostname)
+	}
+	output.Println("      -H \"Content-Type: application/json\" \\")
+	output.Printf("      -d '{\"model\": \"%s\", \"messages\": [{\"role\": \"user\", \"content\": \"Hello!\"}]}'\n",
+		endpointsModel)
+}
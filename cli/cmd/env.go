@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const envPrefix = "EAIG_"
+
+// Persistent flags whose config key differs from the flag name use the
+// config key for their environment variable.
+var persistentFlagEnvKeys = map[string]string{
+	"context": "kube_context",
+}
+
+// flagEnvVar returns the environment variable of a flag: EAIG_ followed by
+// its name in upper case with dashes replaced by underscores.
+func flagEnvVar(cmd *cobra.Command, f *pflag.Flag) string {
+	key := strings.ReplaceAll(f.Name, "-", "_")
+	if alias, ok := persistentFlagEnvKeys[f.Name]; ok && cmd.Root().PersistentFlags().Lookup(f.Name) == f {
+		key = alias
+	}
+	return envPrefix + strings.ToUpper(key)
+}
+
+// applyEnvFlags sets every flag of cmd that was not given on the command
+// line from its EAIG_* environment variable. Flags keep Changed unset so
+// command-line values still win and config show reports the env source.
+func applyEnvFlags(cmd *cobra.Command) error {
+	var errs []error
+
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || f.Name == "help" || f == cmd.Root().Flags().Lookup("version") {
+			return
+		}
+
+		env := flagEnvVar(cmd, f)
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return
+		}
+
+		if err := f.Value.Set(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s=%q for --%s: %w", env, value, f.Name, err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
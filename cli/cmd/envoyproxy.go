@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/telemetry"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"gopkg.in/yaml.v3"
+)
+
+// The proxy Service type and IP family, pull secrets, scheduling, resources
+// and telemetry are not chart values; Envoy Gateway takes them from an
+// EnvoyProxy resource referenced by the GatewayClass. The installer manages a single EnvoyProxy
+// that combines the enabled profiles.
+const installerEnvoyProxyName = "envoy-ai-installer"
+
+func needsEnvoyProxy(cfg *config.Config) bool {
+	return cfg.Local || cfg.Telemetry != nil || len(cfg.ImagePullSecrets) > 0 ||
+		hasScheduling(cfg) || cfg.Resources != nil || cfg.IPFamily != ""
+}
+
+func envoyProxyManifest(cfg *config.Config) (string, error) {
+	spec := map[string]interface{}{}
+	kubernetes := map[string]interface{}{}
+	deployment := map[string]interface{}{}
+
+	if cfg.Local {
+		deployment["replicas"] = 1
+		deployment["container"] = map[string]interface{}{
+			"resources": map[string]interface{}{
+				"requests": map[string]string{"cpu": "50m", "memory": "64Mi"},
+			},
+		}
+		kubernetes["envoyService"] = map[string]interface{}{
+			"type": "NodePort",
+		}
+	}
+
+	// Configured resources replace the small requests of the local profile.
+	if cfg.Resources != nil {
+		resources, err := values.Plain(cfg.Resources)
+		if err != nil {
+			return "", err
+		}
+		deployment["container"] = map[string]interface{}{"resources": resources}
+	}
+
+	pod := map[string]interface{}{}
+	if len(cfg.ImagePullSecrets) > 0 {
+		secrets := make([]interface{}, 0, len(cfg.ImagePullSecrets))
+		for _, name := range cfg.ImagePullSecrets {
+			secrets = append(secrets, map[string]string{"name": name})
+		}
+		pod["imagePullSecrets"] = secrets
+	}
+	if len(cfg.NodeSelector) > 0 {
+		pod["nodeSelector"] = cfg.NodeSelector
+	}
+	if len(cfg.Tolerations) > 0 {
+		tolerations, err := values.Plain(cfg.Tolerations)
+		if err != nil {
+			return "", err
+		}
+		pod["tolerations"] = tolerations
+	}
+	if cfg.Affinity != nil {
+		affinity, err := values.Plain(cfg.Affinity)
+		if err != nil {
+			return "", err
+		}
+		pod["affinity"] = affinity
+	}
+	if len(pod) > 0 {
+		deployment["pod"] = pod
+	}
+
+	if len(deployment) > 0 {
+		kubernetes["envoyDeployment"] = deployment
+	}
+	if len(kubernetes) > 0 {
+		spec["provider"] = map[string]interface{}{
+			"type":       "Kubernetes",
+			"kubernetes": kubernetes,
+		}
+	}
+
+	if cfg.IPFamily != "" {
+		spec["ipFamily"] = ipFamilies[cfg.IPFamily].envoyProxy
+	}
+	if cfg.Telemetry != nil {
+		spec["telemetry"] = telemetry.EnvoyProxyTelemetry(cfg.Telemetry)
+	}
+
+	return marshalYAML(map[string]interface{}{
+		"apiVersion": "gateway.envoyproxy.io/v1alpha1",
+		"kind":       "EnvoyProxy",
+		"metadata": map[string]interface{}{
+			"name":      installerEnvoyProxyName,
+			"namespace": cfg.NamespaceGateway,
+			"labels": map[string]string{
+				"app.kubernetes.io/managed-by": "envoy-ai-installer",
+			},
+		},
+		"spec": spec,
+	})
+}
+
+func applyEnvoyProxy(cfg *config.Config, isDryRun bool) error {
+	manifest, err := envoyProxyManifest(cfg)
+	if err != nil {
+		return err
+	}
+
+	if isDryRun {
+		printDryRunApply(manifest)
+		return nil
+	}
+
+	cmd := k8s.Kubectl("apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(manifest)
+	cmd.Stdout = output.Stdout
+	cmd.Stderr = output.Stderr
+
+	return cmd.Run()
+}
+
+// printEnvoyProxyHints prints what the installer's EnvoyProxy configures,
+// one line per enabled setting, and how to reference it. The local profile
+// prints the reference with its own instructions.
+func printEnvoyProxyHints(cfg *config.Config) {
+	ref := cfg.NamespaceGateway + "/" + installerEnvoyProxyName
+	var hints []string
+	if cfg.Telemetry != nil {
+		hints = append(hints, fmt.Sprintf("📡 Gateways using the EnvoyProxy %s export traces and access logs to %s.",
+			ref, cfg.Telemetry.URL()))
+	}
+	if len(cfg.ImagePullSecrets) > 0 {
+		hints = append(hints, fmt.Sprintf("🔑 Gateways using the EnvoyProxy %s pull the proxy image with %s.",
+			ref, strings.Join(cfg.ImagePullSecrets, ", ")))
+	}
+	if hasScheduling(cfg) {
+		hints = append(hints, fmt.Sprintf("📍 Gateways using the EnvoyProxy %s schedule their proxies with the configured node selector, tolerations and affinity.",
+			ref))
+	}
+	if cfg.Resources != nil {
+		hints = append(hints, fmt.Sprintf("📏 Gateways using the EnvoyProxy %s run their proxies with %s.",
+			ref, formatResources(cfg.Resources)))
+	}
+	if cfg.IPFamily != "" {
+		hints = append(hints, fmt.Sprintf("🌐 Gateways using the EnvoyProxy %s get %s Services.",
+			ref, ipFamilies[cfg.IPFamily].envoyProxy))
+	}
+	if len(hints) == 0 {
+		return
+	}
+
+	output.Println()
+	for _, hint := range hints {
+		output.Println(hint)
+	}
+	if !cfg.Local {
+		printEnvoyProxyReference(cfg)
+	}
+}
+
+func printEnvoyProxyReference(cfg *config.Config) {
+	if gc := ensuredGatewayClass; gc != nil && gc.EnvoyProxy == cfg.NamespaceGateway+"/"+installerEnvoyProxyName {
+		output.Printf("   The GatewayClass %s references it.\n", gc.Name)
+		return
+	}
+	output.Println("   Reference it from your GatewayClass:")
+	output.Println("     spec:")
+	output.Println("       parametersRef:")
+	output.Println("         group: gateway.envoyproxy.io")
+	output.Println("         kind: EnvoyProxy")
+	output.Printf("         name: %s\n", installerEnvoyProxyName)
+	output.Printf("         namespace: %s\n", cfg.NamespaceGateway)
+}
+
+func marshalYAML(v interface{}) (string, error) {
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/telemetry"
+)
+
+func TestPrintEnvoyProxyHints(t *testing.T) {
+	const reference = "Reference it from your GatewayClass:"
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want []string
+	}{
+		{name: "no EnvoyProxy settings"},
+		{
+			name: "every setting",
+			cfg: config.Config{
+				Telemetry:        &telemetry.Options{Endpoint: "otel-collector.observability:4317", Insecure: true},
+				ImagePullSecrets: []string{"registry-creds"},
+				NodeSelector:     map[string]string{"pool": "gateways"},
+				IPFamily:         config.IPFamilyDual,
+			},
+			want: []string{
+				"📡 Gateways using the EnvoyProxy envoy-gateway-system/envoy-ai-installer export traces and access logs to http://otel-collector.observability:4317.",
+				"🔑 Gateways using the EnvoyProxy envoy-gateway-system/envoy-ai-installer pull the proxy image with registry-creds.",
+				"📍 Gateways using the EnvoyProxy envoy-gateway-system/envoy-ai-installer schedule their proxies",
+				"🌐 Gateways using the EnvoyProxy envoy-gateway-system/envoy-ai-installer get DualStack Services.",
+				reference,
+			},
+		},
+		{
+			name: "local",
+			cfg:  config.Config{Local: true, ImagePullSecrets: []string{"registry-creds"}},
+			want: []string{"🔑 Gateways using the EnvoyProxy envoy-gateway-system/envoy-ai-installer pull the proxy image with registry-creds."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := captureStdout(t)
+			tt.cfg.NamespaceGateway = "envoy-gateway-system"
+
+			printEnvoyProxyHints(&tt.cfg)
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output lacks %q:\n%s", want, out)
+				}
+			}
+			wantReferences := 0
+			if len(tt.want) > 0 && !tt.cfg.Local {
+				wantReferences = 1
+			}
+			if got := strings.Count(out.String(), reference); got != wantReferences {
+				t.Errorf("printed the reference %d times, want %d:\n%s", got, wantReferences, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes, so that scripts can tell failures apart. They are listed in
+// the help of every command.
+const (
+	ExitFailure      = 1
+	ExitChanges      = 2
+	ExitUsage        = 3
+	ExitPrerequisite = 4
+	ExitCluster      = 5
+	ExitHelm         = 6
+	ExitVerification = 7
+	ExitCancelled    = 130
+)
+
+const exitCodesHelp = `
+Exit codes:
+  0    success
+  1    any other error
+  2    diff: the cluster differs from the target
+  3    invalid flags, arguments or configuration
+  4    a prerequisite such as helm, kubectl or cosign is missing
+  5    the cluster is unreachable
+  6    a helm command failed
+  7    a checksum, signature or compatibility check failed, or a timeout expired
+  130  interrupted, or declined at a confirmation prompt
+`
+
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for an error returned by Execute: the code
+// of an ExitError in its chain, or else the code of the first known error
+// it wraps.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	var invalid *config.ValidationError
+	var integrityErr *integrityError
+	var helmTimeout *helm.TimeoutError
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.Is(err, context.Canceled), errors.Is(err, ui.ErrAborted):
+		return ExitCancelled
+	case errors.As(err, &invalid), errors.Is(err, ui.ErrNotInteractive):
+		return ExitUsage
+	case errors.Is(err, exec.ErrNotFound):
+		return ExitPrerequisite
+	case errors.Is(err, k8s.ErrClusterUnreachable):
+		return ExitCluster
+	case errors.As(err, &integrityErr), errors.As(err, &helmTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ExitVerification
+	case errors.Is(err, helm.ErrCommandFailed):
+		return ExitHelm
+	}
+	return ExitFailure
+}
+
+func usageError(err error) error {
+	return &ExitError{Code: ExitUsage, Err: err}
+}
+
+// classifyUsageErrors makes flag and argument errors of c and its
+// subcommands exit with ExitUsage.
+func classifyUsageErrors(c *cobra.Command) {
+	c.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError(err)
+	})
+	if validate := c.Args; validate != nil {
+		c.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		}
+	}
+
+	for _, sub := range c.Commands() {
+		classifyUsageErrors(sub)
+	}
+}
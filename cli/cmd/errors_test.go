@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func TestExitCode(t *testing.T) {
+	helmFailed := &helm.CommandError{Args: []string{"upgrade"}, Message: "release failed"}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", want: 0},
+		{name: "unknown", err: errors.New("boom"), want: ExitFailure},
+		{name: "exit error", err: &ExitError{Code: ExitChanges}, want: ExitChanges},
+		{name: "usage", err: usageError(errors.New("unknown flag")), want: ExitUsage},
+		{name: "invalid configuration", err: &config.ValidationError{Problems: []string{"bad"}}, want: ExitUsage},
+		{name: "not interactive", err: ui.ErrNotInteractive, want: ExitUsage},
+		{name: "prerequisite", err: &exec.Error{Name: "helm", Err: exec.ErrNotFound}, want: ExitPrerequisite},
+		{name: "cluster", err: fmt.Errorf("listing nodes: %w", k8s.ErrClusterUnreachable), want: ExitCluster},
+		{name: "helm", err: helmFailed, want: ExitHelm},
+		{name: "helm timeout", err: &helm.TimeoutError{CommandError: helmFailed}, want: ExitVerification},
+		{name: "verification", err: &integrityError{err: errors.New("checksum mismatch")}, want: ExitVerification},
+		{name: "timeout", err: context.DeadlineExceeded, want: ExitVerification},
+		{name: "cancelled", err: context.Canceled, want: ExitCancelled},
+		{name: "declined", err: ui.ErrAborted, want: ExitCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+			if tt.err == nil {
+				return
+			}
+			// Steps and commands wrap the errors they return.
+			wrapped := fmt.Errorf("step failed: %w", fmt.Errorf("install: %w", tt.err))
+			if got := ExitCode(wrapped); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", wrapped, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidOutputIsUsageError(t *testing.T) {
+	tests := []struct {
+		cmd    *cobra.Command
+		output *string
+	}{
+		{diffCmd, &diffOutput},
+		{endpointsCmd, &endpointsOutput},
+		{versionCmd, &versionOutput},
+		{versionsListCmd, &versionsOutput},
+		{checkUpdateCmd, &checkUpdateOutput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmd.CommandPath(), func(t *testing.T) {
+			saved := *tt.output
+			*tt.output = "yaml"
+			t.Cleanup(func() { *tt.output = saved })
+
+			err := tt.cmd.RunE(tt.cmd, nil)
+			if code := ExitCode(err); code != ExitUsage {
+				t.Errorf("got exit code %d (error: %v), want %d", code, err, ExitUsage)
+			}
+		})
+	}
+}
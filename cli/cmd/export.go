@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/gitops"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	exportFormat      string
+	exportOutputDir   string
+	exportSingleFile  bool
+	exportValuesFiles bool
+	exportNamespace   string
+	exportProject     string
+	exportInterval    string
+	exportGitRepo     string
+	exportGitRevision string
+	exportGitPath     string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the installation in other formats",
+}
+
+var exportGitopsCmd = &cobra.Command{
+	Use:   "gitops",
+	Short: "Write Argo CD or Flux manifests instead of installing",
+	Long: `Write the manifests that make Argo CD or Flux install what 'install' would,
+for clusters where releases must come from git rather than helm commands.
+
+--format argocd writes an Argo CD Application per release, and a repository
+Secret for the envoyproxy OCI registry. --format flux writes HelmRepository
+sources and a HelmRelease per release. Each release is pinned to the chart
+version install would use and carries the values install would pass to
+helm, merged into one document: inline, or in a separate file per release
+with --values-files.
+
+Nothing is applied to the cluster. The output is stable: running the
+command again with the same inputs writes the same files.`,
+	RunE: runExportGitops,
+}
+
+func init() {
+	exportGitopsCmd.Flags().StringVar(&exportFormat, "format", gitops.FormatArgoCD,
+		"manifest format: argocd or flux")
+	exportGitopsCmd.Flags().StringVarP(&exportOutputDir, "output-dir", "o", "gitops",
+		"directory to write the manifests to")
+	exportGitopsCmd.Flags().BoolVar(&exportSingleFile, "single-file", false,
+		"write all manifests to a single envoy-ai-gateway.yaml")
+	exportGitopsCmd.Flags().BoolVar(&exportValuesFiles, "values-files", false,
+		"write the values of each release to values/<release>.yaml instead of inlining them")
+	exportGitopsCmd.Flags().StringVar(&exportNamespace, "namespace", "",
+		"namespace of the Applications or Flux objects (default argocd or flux-system)")
+	exportGitopsCmd.Flags().StringVar(&exportProject, "project", "default",
+		"Argo CD project of the Applications")
+	exportGitopsCmd.Flags().StringVar(&exportInterval, "interval", "10m",
+		"how often Flux reconciles the sources and releases")
+	exportGitopsCmd.Flags().StringVar(&exportGitRepo, "git-repo", "",
+		"URL of the git repository the manifests are committed to (required for argocd with --values-files)")
+	exportGitopsCmd.Flags().StringVar(&exportGitRevision, "git-revision", "HEAD",
+		"git revision Argo CD reads the values files from")
+	exportGitopsCmd.Flags().StringVar(&exportGitPath, "git-path", "",
+		"path of the output directory in the git repository (default --output-dir)")
+
+	exportGitopsCmd.Flags().StringSliceVar(&valuesExtra, "values-extra", nil,
+		"additional values files; prefix with gateway=, ai= or redis= to target a single release (repeatable)")
+	exportGitopsCmd.Flags().StringSliceVar(&labels, "labels", nil,
+		"labels to add to all created resources, as key=value pairs (repeatable)")
+	exportGitopsCmd.Flags().BoolVar(&withRedis, "with-redis", false,
+		"also export the Redis release")
+	exportGitopsCmd.Flags().StringVar(&pinTag, "tag", "",
+		"GitHub release tag to pin both Envoy Gateway and AI Gateway to (default: latest stable release)")
+	exportGitopsCmd.Flags().StringVar(&envoyGatewayTag, "envoy-gateway-tag", "",
+		"GitHub release tag of envoyproxy/gateway to pin, overriding --tag")
+	exportGitopsCmd.Flags().StringVar(&aiGatewayTag, "ai-gateway-tag", "",
+		"GitHub release tag of envoyproxy/ai-gateway to pin, overriding --tag")
+	exportGitopsCmd.Flags().BoolVar(&skipCompatCheck, "skip-compat-check", false,
+		"export even if the Envoy Gateway and AI Gateway versions are not a supported pair")
+	exportGitopsCmd.Flags().BoolVar(&skipCRDs, "skip-crds", false,
+		"leave out the CRD release and the CRDs of the other charts")
+
+	exportCmd.AddCommand(exportGitopsCmd)
+}
+
+func runExportGitops(cmd *cobra.Command, args []string) error {
+	for key, flag := range map[string]string{
+		"values_extra":      "values-extra",
+		"labels":            "labels",
+		"tag":               "tag",
+		"envoy_gateway_tag": "envoy-gateway-tag",
+		"ai_gateway_tag":    "ai-gateway-tag",
+		"skip_crds":         "skip-crds",
+	} {
+		viper.BindPFlag(key, cmd.Flags().Lookup(flag))
+	}
+
+	opts := gitops.Options{
+		Format:      exportFormat,
+		ValuesFiles: exportValuesFiles,
+		Namespace:   exportNamespace,
+		Project:     exportProject,
+		Interval:    exportInterval,
+		GitRepo:     exportGitRepo,
+		GitRevision: exportGitRevision,
+		GitPath:     exportGitPath,
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = map[string]string{gitops.FormatArgoCD: "argocd", gitops.FormatFlux: "flux-system"}[opts.Format]
+	}
+	if opts.GitPath == "" {
+		opts.GitPath = filepath.ToSlash(filepath.Clean(exportOutputDir))
+	}
+	if err := opts.Validate(); err != nil {
+		return usageError(err)
+	}
+	if _, err := time.ParseDuration(exportInterval); err != nil {
+		return usageError(fmt.Errorf("invalid --interval %q: %w", exportInterval, err))
+	}
+	if exportSingleFile && exportValuesFiles && opts.Format == gitops.FormatArgoCD {
+		return usageError(fmt.Errorf("--single-file cannot be combined with --values-files for argocd, which reads the values files from git"))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	for _, files := range cfg.ValuesExtra {
+		for _, file := range files {
+			if values.IsVault(file) {
+				output.Printf("⚠️  Values from %s are written to the manifests in clear text; do not commit them to a shared repository\n", file)
+			}
+		}
+	}
+	if err := fetchValuesExtra(cfg); err != nil {
+		return err
+	}
+
+	output.Printf("📦 Exporting Envoy AI Gateway as %s manifests\n", opts.Format)
+	if err := resolveTags(cmd.Context(), cfg); err != nil {
+		return err
+	}
+	if err := checkInstallCompatibility(cmd.Context(), cfg); err != nil {
+		return err
+	}
+
+	releases, err := gitopsReleases(cfg)
+	if err != nil {
+		return err
+	}
+	files, err := gitops.Render(releases, opts)
+	if err != nil {
+		return err
+	}
+
+	if exportSingleFile {
+		files = []gitops.File{{Path: "envoy-ai-gateway.yaml", Content: gitops.Concat(files)}}
+	}
+	for _, file := range files {
+		path := filepath.Join(exportOutputDir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to write manifests: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(file.Content), 0o644); err != nil {
+			return fmt.Errorf("failed to write manifests: %w", err)
+		}
+		output.Printf("  ✅ %s\n", path)
+	}
+
+	output.Printf("\n✅ Wrote %d files to %s\n", len(files), exportOutputDir)
+	if withRedis {
+		output.Printf("   ℹ️  Redis follows the latest bitnami/redis chart (%s); pin its version in the manifest to freeze it\n", redisChartVersion)
+	}
+	return nil
+}
+
+// redisChartVersion is the version constraint of the Redis chart, which
+// install does not pin either.
+const redisChartVersion = "*"
+
+// gitopsReleases returns the releases install would create, with their
+// chart versions and merged values.
+func gitopsReleases(cfg *config.Config) ([]gitops.Release, error) {
+	releases, err := resolveReleases(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []gitops.Release
+	for _, r := range releases {
+		if r.id == "aieg-crd" && viper.GetBool("skip_crds") {
+			continue
+		}
+
+		merged, err := values.Merge(r.values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		if err := values.ApplySet(merged, r.set, false); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		if err := values.ApplySet(merged, r.setString, true); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+
+		repo, chart, _ := strings.Cut(r.chart, "/")
+		release := gitops.Release{
+			Name:       r.name,
+			Namespace:  r.namespace,
+			Repository: gitops.Repository{Name: repo, URL: chartRepos[repo]},
+			Chart:      chart,
+			Version:    r.version,
+			Values:     merged,
+			SkipCRDs:   viper.GetBool("skip_crds") && r.id != redisReleaseName,
+		}
+		if release.Version == "" {
+			release.Version = redisChartVersion
+		}
+		if r.id == "aieg" {
+			eg, _ := releaseByID(cfg, "eg")
+			release.DependsOn = []string{eg.name}
+			if !viper.GetBool("skip_crds") {
+				crds, _ := releaseByID(cfg, "aieg-crd")
+				release.DependsOn = append(release.DependsOn, crds.name)
+			}
+		}
+		result = append(result, release)
+	}
+	return result, nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"github.com/spf13/viper"
+)
+
+const fetchInitialBackoff = time.Second
+
+var fetchRetries int
+
+// retryableError marks a failure worth retrying: network errors, 429 and
+// 5xx responses.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// withRetries runs fn until it succeeds, fails with a non-retryable error or
+// fetch_retries retries are used up, doubling the wait between attempts. It
+// stops waiting when the command's deadline passes.
+func withRetries(what string, fn func() error) error {
+	retries := viper.GetInt("fetch_retries")
+	backoff := fetchInitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		retryable, ok := err.(*retryableError)
+		if !ok {
+			return err
+		}
+		if attempt >= retries {
+			return retryable.err
+		}
+
+		if viper.GetBool("verbose") {
+			output.Printf("  ↻ %s failed (%v); retry %d/%d in %s\n", what, retryable.err, attempt+1, retries, backoff)
+		}
+		select {
+		case <-commandContext.Done():
+			return fmt.Errorf("%w; stopped retrying: %w", retryable.err, commandContext.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// get fetches url, classifying failures for withRetries. The caller closes
+// the body of a successful response.
+func get(url string, accept ...int) (*http.Response, error) {
+	resp, err := httpclient.Get(commandContext, url)
+	if err != nil {
+		return nil, &retryableError{err}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	for _, code := range accept {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+
+	err = fmt.Errorf("failed to fetch %s: HTTP %d", url, resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, &retryableError{err}
+	}
+	return nil, err
+}
+
+// fetchRemoteValuesFile downloads url to a temporary file and verifies its
+// SHA-256 against checksum ("sha256:<hex>") or, when checksum is empty,
+// against a "<url>.sha256" file published alongside it, if there is one.
+// Transient failures are retried with exponential backoff. s3:// and
+// vault:// URLs are downloaded by pkg/values and only verified when checksum
+// is set.
+func fetchRemoteValuesFile(url, checksum string) (string, error) {
+	expected := ""
+	if checksum != "" {
+		digest, err := parseChecksum(checksum)
+		if err != nil {
+			return "", &integrityError{err}
+		}
+		expected = digest
+	} else if !values.IsRemote(url) {
+		digest, err := fetchChecksumFile(url + ".sha256")
+		if err != nil {
+			return "", err
+		}
+		expected = digest
+	}
+
+	var file, actual string
+	err := withRetries("download of "+url, func() error {
+		var err error
+		file, actual, err = downloadFile(url)
+		return err
+	})
+	if err != nil {
+		return "", verificationFailure(expected, err)
+	}
+
+	if expected != "" && actual != expected {
+		os.Remove(file)
+		return "", &integrityError{fmt.Errorf("checksum mismatch for %s: expected sha256:%s, got sha256:%s", url, expected, actual)}
+	}
+
+	return file, nil
+}
+
+// fetchValuesExtra downloads the s3:// and vault:// entries of
+// --values-extra and replaces them with the local copies.
+func fetchValuesExtra(cfg *config.Config) error {
+	for target, files := range cfg.ValuesExtra {
+		for i, file := range files {
+			if !values.IsRemote(file) {
+				continue
+			}
+
+			local, err := values.Fetch(commandContext, file)
+			if err != nil {
+				return err
+			}
+			files[i] = local
+		}
+		cfg.ValuesExtra[target] = files
+	}
+	return nil
+}
+
+// downloadFile writes url to a temporary file and returns its path and
+// SHA-256.
+func downloadFile(url string) (string, string, error) {
+	if values.IsRemote(url) {
+		return downloadRemoteFile(url)
+	}
+
+	resp, err := get(url)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	tmpFile, err := values.CreateTemp("envoy-ai-values-*.yaml")
+	if err != nil {
+		return "", "", err
+	}
+	defer tmpFile.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmpFile, hash), resp.Body); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", "", &retryableError{err}
+	}
+
+	return tmpFile.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func downloadRemoteFile(url string) (string, string, error) {
+	file, err := values.Fetch(commandContext, url)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return file, hex.EncodeToString(sum[:]), nil
+}
+
+// verificationFailure makes a download error fatal when a checksum was
+// expected, since the install must not silently go ahead without the
+// pinned file.
+func verificationFailure(expected string, err error) error {
+	if expected == "" {
+		return err
+	}
+	return &integrityError{err}
+}
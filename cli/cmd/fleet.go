@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/fleet"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var (
+	fleetFile        string
+	fleetConcurrency int
+	fleetFailFast    bool
+	fleetReport      string
+	stepsFile        string
+)
+
+// fleetFlags are not passed on to the installation of each cluster, as
+// flags or as environment variables: the flags of the fleet itself, and the
+// log file, which the fleet writes with the output of every cluster.
+var fleetFlags = map[string]bool{
+	"fleet":        true,
+	"concurrency":  true,
+	"fail-fast":    true,
+	"fleet-report": true,
+	"steps-file":   true,
+	"log-file":     true,
+	"debug-log":    true,
+}
+
+func init() {
+	installCmd.Flags().StringVar(&fleetFile, "fleet", "",
+		"manifest of clusters to install on, each with its context or kubeconfig and optional overrides")
+	installCmd.Flags().IntVar(&fleetConcurrency, "concurrency", 1,
+		"with --fleet, how many clusters to install on at once")
+	installCmd.Flags().BoolVar(&fleetFailFast, "fail-fast", false,
+		"with --fleet, start no more clusters after one fails")
+	installCmd.Flags().StringVar(&fleetReport, "fleet-report", "fleet-report.json",
+		"with --fleet, file to write the JSON report of every cluster to")
+	installCmd.Flags().StringVar(&stepsFile, "steps-file", "",
+		"write the results of the installation steps as JSON to this file")
+	installCmd.Flags().MarkHidden("steps-file")
+}
+
+// checkFleetFlags rejects the fleet options without --fleet, and the
+// flags a fleet manifest sets per cluster.
+func checkFleetFlags(cmd *cobra.Command) error {
+	if fleetFile == "" {
+		for _, name := range []string{"concurrency", "fail-fast", "fleet-report"} {
+			if cmd.Flags().Changed(name) {
+				return usageError(fmt.Errorf("--%s requires --fleet", name))
+			}
+		}
+		return nil
+	}
+	if fleetConcurrency < 1 {
+		return usageError(fmt.Errorf("invalid --concurrency %d (expected at least 1)", fleetConcurrency))
+	}
+	for _, name := range []string{"context", "kubeconfig"} {
+		if cmd.Flags().Changed(name) {
+			return usageError(fmt.Errorf("--%s cannot be used with --fleet; set it per cluster in the manifest", name))
+		}
+	}
+	return nil
+}
+
+// runFleetInstall installs on every cluster of the --fleet manifest, each
+// in a child process so that its kube context, config and output stay
+// apart, and reports how each went.
+func runFleetInstall(cmd *cobra.Command) error {
+	if err := checkFleetFlags(cmd); err != nil {
+		return err
+	}
+	manifest, err := fleet.Load(fleetFile)
+	if err != nil {
+		return usageError(err)
+	}
+	if err := checkSkipSteps(); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	isDryRun := viper.GetBool("dry_run")
+
+	output.Println("🚀 Envoy AI Gateway Fleet Installer")
+	output.Printf("  Manifest:            %s\n", fleetFile)
+	output.Printf("  Clusters:            %d\n", len(manifest.Targets))
+	output.Printf("  Concurrency:         %d\n", fleetConcurrency)
+	output.Printf("  Fail Fast:           %v\n", fleetFailFast)
+	output.Printf("  Dry Run:             %v\n", isDryRun)
+
+	if !isDryRun {
+		var actions []string
+		for _, t := range manifest.Targets {
+			actions = append(actions, "install on "+describeFleetTarget(t))
+		}
+		if err := prompter().ConfirmActions("⚠️  Installing may uninstall previous releases and cause downtime on each cluster:", actions); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "envoy-ai-fleet-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	ctx, stop := notifyInterrupt(cmd.Context())
+	defer stop()
+
+	out := fleet.NewOutput(output.Stdout)
+	out.Println()
+	started := time.Now()
+	results := fleet.Run(ctx, manifest.Targets, fleet.Options{
+		Concurrency: fleetConcurrency,
+		FailFast:    fleetFailFast,
+		Out:         out,
+		Install: func(ctx context.Context, t fleet.Target, w *fleet.Writer) fleet.Outcome {
+			return installFleetTarget(cmd, self, cfg, tmp, t, w)
+		},
+		OnDone: func(r fleet.Result) {
+			if r.Status == fleet.StatusSucceeded {
+				out.Println(fmt.Sprintf("✅ %s: installed in %s", r.Target.Name, r.Duration.Round(time.Second)))
+			} else {
+				out.Println(fmt.Sprintf("❌ %s: %s", r.Target.Name, r.Error))
+			}
+		},
+	})
+
+	fleet.PrintTable(output.Stdout, results)
+	if err := fleet.WriteReport(fleetReport, started, isDryRun, results); err != nil {
+		output.Printf("\n⚠️  %v\n", err)
+	} else {
+		output.Printf("\n📄 Report written to %s\n", fleetReport)
+	}
+
+	failed := fleet.Failed(results)
+	if len(failed) == 0 {
+		output.Printf("\n✅ Installed on all %d clusters\n", len(results))
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	cmd.SilenceUsage = true
+	var names []string
+	for _, r := range failed {
+		names = append(names, r.Target.Name)
+	}
+	return &ExitError{Code: ExitFailure, Err: fmt.Errorf("installation did not succeed on %d of %d clusters: %s",
+		len(failed), len(results), strings.Join(names, ", "))}
+}
+
+// installFleetTarget runs install for one cluster in a child process and
+// reads back the results of its steps.
+func installFleetTarget(cmd *cobra.Command, self string, cfg *config.Config, tmp string, t fleet.Target, w *fleet.Writer) fleet.Outcome {
+	f, err := os.CreateTemp(tmp, "steps-*.json")
+	if err != nil {
+		return fleet.Outcome{ExitCode: ExitFailure, Err: err}
+	}
+	f.Close()
+	resultsFile := f.Name()
+
+	// The error of the install is its last "Error: " line and the detail
+	// lines that follow it, such as the problems of an invalid config.
+	var errorLines []string
+	w.OnLine(func(line string) {
+		if msg, ok := strings.CutPrefix(line, "Error: "); ok {
+			errorLines = []string{msg}
+		} else if len(errorLines) > 0 && strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "Full log: ") {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	})
+
+	child := exec.Command(self, fleetTargetArgs(cmd, cfg, t, resultsFile)...)
+	child.Env = fleetTargetEnv()
+	child.Stdout = w
+	child.Stderr = w
+	err = child.Run()
+	w.Flush()
+
+	outcome := fleet.Outcome{}
+	if data, readErr := os.ReadFile(resultsFile); readErr == nil {
+		json.Unmarshal(data, &outcome.Steps)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		outcome.ExitCode = exitErr.ExitCode()
+		outcome.Err = fmt.Errorf("exit status %d", outcome.ExitCode)
+		if len(errorLines) > 0 {
+			outcome.Err = errors.New(strings.Join(errorLines, " "))
+		}
+	case err != nil:
+		outcome.ExitCode = ExitFailure
+		outcome.Err = err
+	}
+	return outcome
+}
+
+// fleetTargetArgs returns the arguments of the install of one cluster: the
+// flags given on the command line, except those of the fleet and those the
+// manifest sets for the cluster, followed by the cluster's own settings.
+func fleetTargetArgs(cmd *cobra.Command, cfg *config.Config, t fleet.Target, resultsFile string) []string {
+	overrides := []struct {
+		flag, value string
+	}{
+		{"context", t.Context},
+		{"kubeconfig", t.Kubeconfig},
+		{"namespace-gateway", t.NamespaceGateway},
+		{"namespace-ai", t.NamespaceAI},
+		{"release-prefix", t.ReleasePrefix},
+		{"tag", t.Tag},
+		{"envoy-gateway-tag", t.EnvoyGatewayTag},
+		{"ai-gateway-tag", t.AIGatewayTag},
+	}
+	overridden := map[string]bool{}
+	for _, o := range overrides {
+		overridden[o.flag] = o.value != ""
+	}
+	overridden["values-extra"] = len(t.ValuesExtra) > 0
+
+	args := []string{cmd.Name()}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if fleetFlags[f.Name] || overridden[f.Name] || f.Name == "yes" || f.Name == "non-interactive" {
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			for _, item := range slice.GetSlice() {
+				args = append(args, "--"+f.Name+"="+item)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+
+	for _, o := range overrides {
+		if o.value != "" {
+			args = append(args, "--"+o.flag+"="+o.value)
+		}
+	}
+	if len(t.ValuesExtra) > 0 {
+		for _, file := range append(valuesExtraEntries(cfg), t.ValuesExtra...) {
+			args = append(args, "--values-extra="+file)
+		}
+	}
+
+	// The fleet asked for confirmation once for all clusters.
+	return append(args, "--yes", "--steps-file="+resultsFile)
+}
+
+// valuesExtraEntries returns the values files of the whole fleet as
+// --values-extra entries, so that a cluster's files add to them.
+func valuesExtraEntries(cfg *config.Config) []string {
+	targets := make([]string, 0, len(cfg.ValuesExtra))
+	for target := range cfg.ValuesExtra {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	var entries []string
+	for _, target := range targets {
+		for _, file := range cfg.ValuesExtra[target] {
+			entries = append(entries, target+"="+file)
+		}
+	}
+	return entries
+}
+
+// fleetTargetEnv returns the environment of the install of one cluster,
+// without the EAIG_* variables of fleetFlags; EAIG_FLEET would make it
+// install the whole fleet again.
+func fleetTargetEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		flag := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, envPrefix), "_", "-"))
+		if strings.HasPrefix(name, envPrefix) && fleetFlags[flag] {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// describeFleetTarget names a cluster and where it is, for the confirmation.
+func describeFleetTarget(t fleet.Target) string {
+	var where []string
+	if t.Context != "" {
+		where = append(where, "context "+t.Context)
+	}
+	if t.Kubeconfig != "" {
+		where = append(where, "kubeconfig "+t.Kubeconfig)
+	}
+	return fmt.Sprintf("%s (%s)", t.Name, strings.Join(where, ", "))
+}
+
+// writeStepsFile writes the results of the steps to --steps-file, for the
+// fleet that started this install.
+func writeStepsFile(results []steps.Result) {
+	if stepsFile == "" {
+		return
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return
+	}
+	os.WriteFile(stepsFile, data, 0o600)
+}
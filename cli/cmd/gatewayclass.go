@@ -0,0 +1,492 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// envoyGatewayControllerName is the controllerName Envoy Gateway
+	// reconciles GatewayClasses for.
+	envoyGatewayControllerName = "gateway.envoyproxy.io/gatewayclass-controller"
+	defaultGatewayClassName    = "envoy-ai-gateway"
+	installerManagedBy         = "envoy-ai-installer"
+)
+
+var gatewayClassGVR = schema.GroupVersionResource{
+	Group:    "gateway.networking.k8s.io",
+	Version:  "v1",
+	Resource: "gatewayclasses",
+}
+
+var envoyProxyGVR = schema.GroupVersionResource{
+	Group:    "gateway.envoyproxy.io",
+	Version:  "v1alpha1",
+	Resource: "envoyproxies",
+}
+
+var (
+	gatewayClassName       string
+	gatewayClassEnvoyProxy string
+	skipGatewayClass       bool
+
+	// ensuredGatewayClass is the GatewayClass the install step ensured, for
+	// the summary and the installation state.
+	ensuredGatewayClass *gatewayClass
+)
+
+var gatewayClassCmd = &cobra.Command{
+	Use:   "gatewayclass",
+	Short: "Manage the GatewayClass that points Gateways at Envoy Gateway",
+	Long: `Gateways only get a proxy once they name a GatewayClass whose
+controllerName is Envoy Gateway's (` + envoyGatewayControllerName + `) and
+that Envoy Gateway accepted. install creates one unless --skip-gatewayclass is
+set; these commands create it on an existing installation and list the
+GatewayClasses of the cluster.`,
+}
+
+var gatewayClassCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create the installer's GatewayClass and wait until it is accepted",
+	Long: `Create the GatewayClass named by --gatewayclass-name for Envoy Gateway,
+referencing the EnvoyProxy of --gatewayclass-envoyproxy (default: the
+installer's EnvoyProxy when it exists), then wait up to --wait-timeout for
+Envoy Gateway to accept it.
+
+A GatewayClass of that name that the installer did not create is left as is,
+with a warning. One the installer created is updated to the current
+parametersRef.`,
+	RunE: runGatewayClassCreate,
+}
+
+var gatewayClassListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the GatewayClasses, their controller and whether they are accepted",
+	RunE:  runGatewayClassList,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{installCmd, gatewayClassCreateCmd} {
+		cmd.Flags().StringVar(&gatewayClassName, "gatewayclass-name", defaultGatewayClassName,
+			"name of the GatewayClass to create for Envoy Gateway")
+		cmd.Flags().StringVar(&gatewayClassEnvoyProxy, "gatewayclass-envoyproxy", "",
+			"EnvoyProxy in the gateway namespace for the GatewayClass to reference in parametersRef (default: the installer's EnvoyProxy when there is one)")
+	}
+	installCmd.Flags().BoolVar(&skipGatewayClass, "skip-gatewayclass", false,
+		"do not create a GatewayClass for Envoy Gateway")
+	gatewayClassCreateCmd.Flags().DurationVar(&waitTimeout, "wait-timeout", 5*time.Minute,
+		"how long to wait for Envoy Gateway to accept the GatewayClass")
+
+	gatewayClassCmd.AddCommand(gatewayClassCreateCmd, gatewayClassListCmd)
+}
+
+// gatewayClass is what the installer reads from a GatewayClass.
+type gatewayClass struct {
+	Name       string
+	Controller string
+	// EnvoyProxy is the namespace/name of the EnvoyProxy in parametersRef.
+	EnvoyProxy string
+	Accepted   bool
+	// Reason explains a GatewayClass that is not accepted.
+	Reason  string
+	Managed bool
+}
+
+func readGatewayClass(obj *unstructured.Unstructured) gatewayClass {
+	gc := gatewayClass{
+		Name:    obj.GetName(),
+		Managed: obj.GetLabels()[k8s.ManagedByLabel] == installerManagedBy,
+	}
+	gc.Controller, _, _ = unstructured.NestedString(obj.Object, "spec", "controllerName")
+
+	ref, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "parametersRef")
+	if ref["kind"] == "EnvoyProxy" {
+		gc.EnvoyProxy = ref["namespace"] + "/" + ref["name"]
+	}
+
+	gc.Reason = "not reconciled yet"
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Accepted" {
+			continue
+		}
+		// Gateway API sets an Unknown/Pending condition until a controller
+		// reconciles the class.
+		if observed, ok := condition["observedGeneration"].(int64); ok && observed < obj.GetGeneration() {
+			continue
+		}
+		gc.Accepted = condition["status"] == "True"
+		gc.Reason = fmt.Sprintf("%v: %v", condition["reason"], condition["message"])
+	}
+	if gc.Accepted {
+		gc.Reason = ""
+	}
+	return gc
+}
+
+// String describes the GatewayClass in one line.
+func (gc gatewayClass) String() string {
+	switch {
+	case gc.Controller != envoyGatewayControllerName:
+		return fmt.Sprintf("%s (controller %s)", gc.Name, gc.Controller)
+	case gc.Accepted:
+		return gc.Name + " (Accepted)"
+	}
+	return fmt.Sprintf("%s (not accepted yet: %s)", gc.Name, gc.Reason)
+}
+
+// gatewayClassEnvoyProxyName returns the EnvoyProxy the GatewayClass
+// references: --gatewayclass-envoyproxy, or the installer's own when install
+// creates it or it exists.
+func gatewayClassEnvoyProxyName(ctx context.Context, cfg *config.Config) string {
+	if gatewayClassEnvoyProxy != "" {
+		return gatewayClassEnvoyProxy
+	}
+	if needsEnvoyProxy(cfg) {
+		return installerEnvoyProxyName
+	}
+	if viper.GetBool("dry_run") {
+		return ""
+	}
+	client, err := k8s.NewDynamicClient()
+	if err != nil {
+		return ""
+	}
+	if _, err := client.Resource(envoyProxyGVR).Namespace(cfg.NamespaceGateway).Get(ctx, installerEnvoyProxyName, metav1.GetOptions{}); err != nil {
+		return ""
+	}
+	return installerEnvoyProxyName
+}
+
+// gatewayClassObject returns the GatewayClass the installer manages.
+func gatewayClassObject(cfg *config.Config, envoyProxy string) *unstructured.Unstructured {
+	labels := map[string]interface{}{}
+	for key, value := range cfg.Labels {
+		labels[key] = value
+	}
+	labels[k8s.ManagedByLabel] = installerManagedBy
+
+	spec := map[string]interface{}{
+		"controllerName": envoyGatewayControllerName,
+	}
+	if envoyProxy != "" {
+		spec["parametersRef"] = envoyProxyParametersRef(cfg, envoyProxy)
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "GatewayClass",
+		"metadata": map[string]interface{}{
+			"name":   gatewayClassName,
+			"labels": labels,
+		},
+		"spec": spec,
+	}}
+}
+
+func envoyProxyParametersRef(cfg *config.Config, envoyProxy string) map[string]interface{} {
+	return map[string]interface{}{
+		"group":     "gateway.envoyproxy.io",
+		"kind":      "EnvoyProxy",
+		"name":      envoyProxy,
+		"namespace": cfg.NamespaceGateway,
+	}
+}
+
+// ensureGatewayClass creates the installer's GatewayClass, or updates the
+// parametersRef of one it created before, and with wait waits until Envoy
+// Gateway accepts it. A GatewayClass of the same name that the installer
+// did not create is only reported: it belongs to someone else.
+func ensureGatewayClass(ctx context.Context, cfg *config.Config, wait bool, timeout time.Duration, isDryRun bool) (*gatewayClass, error) {
+	desired := gatewayClassObject(cfg, gatewayClassEnvoyProxyName(ctx, cfg))
+	if isDryRun {
+		manifest, err := marshalYAML(desired.Object)
+		if err != nil {
+			return nil, err
+		}
+		printDryRunApply(manifest)
+		return nil, nil
+	}
+
+	client, err := k8s.NewDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	classes := client.Resource(gatewayClassGVR)
+
+	obj, err := classes.Get(ctx, gatewayClassName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		obj, err = classes.Create(ctx, desired, metav1.CreateOptions{FieldManager: installerManagedBy})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GatewayClass %s: %w", gatewayClassName, err)
+		}
+		output.Printf("  ✅ Created GatewayClass %s\n", gatewayClassName)
+	case err != nil:
+		return nil, fmt.Errorf("failed to read GatewayClass %s: %w", gatewayClassName, err)
+	default:
+		existing := readGatewayClass(obj)
+		if !existing.Managed {
+			output.Printf("  ⚠️  GatewayClass %s already exists and was not created by the installer; leaving it as is\n", existing)
+			return &existing, nil
+		}
+		if existing.Controller != envoyGatewayControllerName {
+			output.Printf("  ⚠️  GatewayClass %s cannot be changed to Envoy Gateway's controller; delete it to have it recreated\n", existing)
+			return &existing, nil
+		}
+		if obj, err = updateGatewayClass(ctx, classes, obj, desired); err != nil {
+			return nil, err
+		}
+	}
+
+	gc := readGatewayClass(obj)
+	if !wait {
+		return &gc, nil
+	}
+	return waitGatewayClassAccepted(ctx, classes, timeout)
+}
+
+// updateGatewayClass brings the labels and parametersRef of a GatewayClass
+// the installer created up to date.
+func updateGatewayClass(ctx context.Context, classes dynamic.ResourceInterface, obj, desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	ref, _, _ := unstructured.NestedMap(obj.Object, "spec", "parametersRef")
+	wantRef, _, _ := unstructured.NestedMap(desired.Object, "spec", "parametersRef")
+	labels := obj.GetLabels()
+	upToDate := fmt.Sprint(ref) == fmt.Sprint(wantRef)
+	for key, value := range desired.GetLabels() {
+		if labels[key] != value {
+			upToDate = false
+		}
+	}
+	if upToDate {
+		output.Printf("  ✅ GatewayClass %s is up to date\n", obj.GetName())
+		return obj, nil
+	}
+
+	updated := obj.DeepCopy()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range desired.GetLabels() {
+		labels[key] = value
+	}
+	updated.SetLabels(labels)
+	if wantRef == nil {
+		unstructured.RemoveNestedField(updated.Object, "spec", "parametersRef")
+	} else if err := unstructured.SetNestedMap(updated.Object, wantRef, "spec", "parametersRef"); err != nil {
+		return nil, err
+	}
+
+	updated, err := classes.Update(ctx, updated, metav1.UpdateOptions{FieldManager: installerManagedBy})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update GatewayClass %s: %w", obj.GetName(), err)
+	}
+	output.Printf("  ✅ Updated GatewayClass %s\n", obj.GetName())
+	return updated, nil
+}
+
+// waitGatewayClassAccepted waits until Envoy Gateway accepts the
+// installer's GatewayClass.
+func waitGatewayClassAccepted(parent context.Context, classes dynamic.ResourceInterface, timeout time.Duration) (*gatewayClass, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	var gc gatewayClass
+	err := retryWithBackoff(ctx, time.Second, 10*time.Second, func() error {
+		obj, err := classes.Get(ctx, gatewayClassName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		gc = readGatewayClass(obj)
+		if !gc.Accepted {
+			return fmt.Errorf("%s", gc.Reason)
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		output.Printf("  ✅ GatewayClass %s is accepted by Envoy Gateway\n", gatewayClassName)
+		return &gc, nil
+	case parent.Err() != nil:
+		return nil, fmt.Errorf("stopped waiting for GatewayClass %s: %w", gatewayClassName, parent.Err())
+	}
+	return &gc, &ExitError{Code: ExitVerification, Err: fmt.Errorf(
+		"GatewayClass %s was not accepted after %s: %w", gatewayClassName, timeout, err)}
+}
+
+func runGatewayClassCreate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	isDryRun := viper.GetBool("dry_run")
+	if waitTimeout, err = bindWaitTimeout(cmd); err != nil {
+		return err
+	}
+
+	output.Println("🚪 Creating GatewayClass")
+	output.Printf("  Name:                %s\n", gatewayClassName)
+	output.Printf("  Dry Run:             %v\n", isDryRun)
+
+	ctx, stop := notifyInterrupt(cmd.Context())
+	defer stop()
+
+	gc, err := ensureGatewayClass(ctx, cfg, true, waitTimeout, isDryRun)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+	if gc != nil && gc.Managed {
+		output.Printf("\n✅ Gateways with gatewayClassName: %s get an Envoy proxy\n", gc.Name)
+	}
+	return nil
+}
+
+func runGatewayClassList(cmd *cobra.Command, args []string) error {
+	classes, err := listGatewayClasses(cmd.Context())
+	if err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+	if len(classes) == 0 {
+		output.Println("ℹ️  No GatewayClasses found; run 'envoy-ai-installer gatewayclass create' to create one")
+		return nil
+	}
+
+	width := len("NAME")
+	for _, gc := range classes {
+		width = max(width, len(gc.Name))
+	}
+	fmt.Printf("%-*s  %-8s  %-7s  %-40s  %s\n", width, "NAME", "ACCEPTED", "MANAGED", "ENVOYPROXY", "CONTROLLER")
+	for _, gc := range classes {
+		accepted, managed, envoyProxy := "False", "no", gc.EnvoyProxy
+		if gc.Accepted {
+			accepted = "True"
+		}
+		if gc.Managed {
+			managed = "yes"
+		}
+		if envoyProxy == "" {
+			envoyProxy = "-"
+		}
+		fmt.Printf("%-*s  %-8s  %-7s  %-40s  %s\n", width, gc.Name, accepted, managed, envoyProxy, gc.Controller)
+	}
+	return nil
+}
+
+// listGatewayClasses returns the GatewayClasses of the cluster sorted by
+// name, or none when the Gateway API CRDs are not installed.
+func listGatewayClasses(ctx context.Context) ([]gatewayClass, error) {
+	client, err := k8s.NewDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.Resource(gatewayClassGVR).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GatewayClasses: %w", err)
+	}
+
+	var classes []gatewayClass
+	for i := range list.Items {
+		classes = append(classes, readGatewayClass(&list.Items[i]))
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i].Name < classes[j].Name })
+	return classes, nil
+}
+
+// checkGatewayClasses reports the GatewayClasses of Envoy Gateway for
+// status.
+func checkGatewayClasses(ctx context.Context) {
+	output.Print("🚪 GatewayClass:       ")
+	classes, err := listGatewayClasses(ctx)
+	if err != nil {
+		output.Printf("⚠️  %v\n", err)
+		return
+	}
+
+	var ours []string
+	accepted := false
+	for _, gc := range classes {
+		if gc.Controller != envoyGatewayControllerName {
+			continue
+		}
+		ours = append(ours, gc.String())
+		accepted = accepted || gc.Accepted
+	}
+	switch {
+	case len(ours) == 0:
+		output.Println("❌ none for Envoy Gateway; Gateways get no proxy (run 'envoy-ai-installer gatewayclass create')")
+	case accepted:
+		output.Printf("✅ %s\n", strings.Join(ours, ", "))
+	default:
+		output.Printf("⚠️  %s\n", strings.Join(ours, ", "))
+	}
+}
+
+// deleteGatewayClass deletes the GatewayClass recorded in the installation
+// state, unless it no longer carries the installer's label or Gateways
+// still use it.
+func deleteGatewayClass(ctx context.Context, name string, isDryRun bool) error {
+	if isDryRun {
+		output.Printf("[DRY-RUN] Would delete GatewayClass %s\n", name)
+		return nil
+	}
+
+	client, err := k8s.NewDynamicClient()
+	if err != nil {
+		return err
+	}
+	obj, err := client.Resource(gatewayClassGVR).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read GatewayClass %s: %w", name, err)
+	}
+	if !readGatewayClass(obj).Managed {
+		output.Printf("  ⚠️  GatewayClass %s is no longer labelled as managed by the installer; leaving it\n", name)
+		return nil
+	}
+
+	gateways, err := client.Resource(gatewayGVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to list Gateways: %w", err)
+	}
+	if gateways != nil {
+		var users []string
+		for _, gw := range gateways.Items {
+			if class, _, _ := unstructured.NestedString(gw.Object, "spec", "gatewayClassName"); class == name {
+				users = append(users, gw.GetNamespace()+"/"+gw.GetName())
+			}
+		}
+		if len(users) > 0 {
+			output.Printf("  ⚠️  GatewayClass %s is still used by %s; leaving it\n", name, strings.Join(users, ", "))
+			return nil
+		}
+	}
+
+	if err := client.Resource(gatewayClassGVR).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete GatewayClass %s: %w", name, err)
+	}
+	output.Printf("  ✅ Deleted GatewayClass %s\n", name)
+	return nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+	"github.com/spf13/viper"
+)
+
+var (
+	preInstallHook  string
+	postInstallHook string
+)
+
+// installHooks are the hooks install runs, and their config keys.
+var installHooks = []struct{ name, key string }{
+	{"pre-install", "pre_install_hook"},
+	{"post-install", "post_install_hook"},
+}
+
+// checkInstallHooks fails before anything is installed when a hook cannot
+// run.
+func checkInstallHooks() error {
+	if viper.GetString("post_install_hook") != "" && !waitReady {
+		return usageError(errors.New("--post-install-hook runs once all pods are ready and cannot be combined with --wait=false"))
+	}
+
+	for _, hook := range installHooks {
+		path := viper.GetString(hook.key)
+		if path == "" {
+			continue
+		}
+		if err := checkHookExecutable(path); err != nil {
+			return usageError(fmt.Errorf("%s hook: %w", hook.name, err))
+		}
+	}
+	return nil
+}
+
+// checkHookExecutable returns why the file at path cannot run as a hook on
+// this platform. Windows has no executable bit: it runs files by their
+// extension, so scripts that rely on a shebang cannot run there.
+func checkHookExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	if runtime.GOOS == "windows" {
+		if !windowsExecutable(path) {
+			return fmt.Errorf("%s cannot run on Windows, which only runs %s files; call the script from a .cmd file that starts its interpreter",
+				path, strings.Join(windowsExecutableExts, ", "))
+		}
+		return nil
+	}
+	if info.Mode()&0o111 == 0 {
+		return fmt.Errorf("%s is not executable (chmod +x %s)", path, path)
+	}
+	return nil
+}
+
+// windowsExecutableExts are the extensions of the files Windows can start
+// as a process. PATHEXT also lists the likes of .vbs and .js, which only
+// the shell opens, through their file association.
+var windowsExecutableExts = []string{".exe", ".com", ".bat", ".cmd"}
+
+// windowsExecutable reports whether Windows can start path as a process.
+func windowsExecutable(path string) bool {
+	return slices.Contains(windowsExecutableExts, strings.ToLower(filepath.Ext(path)))
+}
+
+// hookStep returns the step that runs the named hook at path, or only prints
+// it in a dry run.
+func hookStep(cfg *config.Config, name, path string, isDryRun bool) steps.Step {
+	return steps.Step{
+		Name: "Run " + name + " hook",
+		Run: func(ctx context.Context) error {
+			if isDryRun {
+				output.Printf("[DRY-RUN] %s\n", path)
+				return nil
+			}
+			return runHook(ctx, cfg, name, path)
+		},
+	}
+}
+
+// runHook runs a hook with the installation in its environment. A non-zero
+// exit status of the hook becomes the exit status of the installer.
+func runHook(ctx context.Context, cfg *config.Config, name, path string) error {
+	hook := exec.CommandContext(ctx, path)
+	hook.Env = append(os.Environ(), hookEnv(cfg)...)
+	hook.Stdin = os.Stdin
+	hook.Stdout = output.Stdout
+	hook.Stderr = output.Stderr
+
+	err := hook.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return &ExitError{Code: exitErr.ExitCode(), Err: fmt.Errorf("%s hook %s failed: %w", name, path, err)}
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook %s failed: %w", name, path, err)
+	}
+	return nil
+}
+
+// hookEnv describes the installation to hooks. The namespace, prefix and
+// kubeconfig variables are the ones the installer reads, so that a hook
+// running the installer targets the same installation.
+func hookEnv(cfg *config.Config) []string {
+	env := []string{
+		"EAIG_NAMESPACE_GATEWAY=" + cfg.NamespaceGateway,
+		"EAIG_NAMESPACE_AI=" + cfg.NamespaceAI,
+		"EAIG_RELEASE_PREFIX=" + cfg.ReleasePrefix,
+		"EAIG_ENVOY_GATEWAY_VERSION=" + releaseVersion(cfg, "eg"),
+		"EAIG_AI_GATEWAY_VERSION=" + releaseVersion(cfg, "aieg"),
+		fmt.Sprintf("EAIG_WITH_REDIS=%t", withRedis),
+	}
+	if kubeconfig := resolveKubeconfig(); kubeconfig != "" {
+		env = append(env, "KUBECONFIG="+kubeconfig, "EAIG_KUBECONFIG="+kubeconfig)
+	}
+	if kubeContext := viper.GetString("kube_context"); kubeContext != "" {
+		env = append(env, "EAIG_KUBE_CONTEXT="+kubeContext)
+	}
+	return env
+}
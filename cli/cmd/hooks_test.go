@@ -0,0 +1,29 @@
+package cmd
+
+import "testing"
+
+func TestWindowsExecutable(t *testing.T) {
+	t.Setenv("PATHEXT", ".COM;.EXE;.BAT;.CMD;.VBS;.VBE;.JS;.JSE;.WSF;.WSH;.MSC;.PY")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{`C:\hooks\pre-install.exe`, true},
+		{`C:\hooks\pre-install.COM`, true},
+		{`C:\hooks\pre-install.bat`, true},
+		{`C:\hooks\Pre-Install.CMD`, true},
+		{`C:\hooks\pre-install.vbs`, false},
+		{`C:\hooks\pre-install.js`, false},
+		{`C:\hooks\pre-install.py`, false},
+		{`C:\hooks\pre-install.ps1`, false},
+		{`C:\hooks\pre-install.sh`, false},
+		{`C:\hooks\pre-install`, false},
+	}
+
+	for _, tt := range tests {
+		if got := windowsExecutable(tt.path); got != tt.want {
+			t.Errorf("windowsExecutable(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,23 +1,61 @@
 package cmd
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
-	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
 	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
-	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/state"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	redisReleaseName  = "envoy-redis"
+	chartVersion      = "v0.0.0-latest"
+	officialValuesURL = "https://raw.githubusercontent.com/envoyproxy/ai-gateway/main/manifests/envoy-gateway-values.yaml"
+)
+
+var (
+	valuesExtra    []string
+	withRedis      bool
+	chartRepo      string
+	valuesURL      string
+	valuesChecksum string
+	forceHelm      bool
+	backupUpgrade  bool
+	backupDir      string
+	showNotes      bool
+	labels         []string
+	skipCRDs       bool
+	skipSteps      []int
+
+	localMode      bool
+	openShift      bool
+	openShiftRoute bool
+	waitReady      bool
+	waitTimeout    time.Duration
+	pollInterval   time.Duration
 )
 
 var (
-	valuesExtra string
-	withRedis   bool
-	chartRepo   string
+	previousState *state.State
+	releaseInputs = map[string]string{}
 )
 
 var installCmd = &cobra.Command{
@@ -28,91 +66,814 @@ var installCmd = &cobra.Command{
 This command implements the official 4-step installation process:
 1. Clean previous installations (unless --skip-clean)
 2. Install Envoy Gateway with official values
-3. Install Envoy AI Gateway CRDs
+3. Install Envoy AI Gateway CRDs (unless --skip-crds)
 4. Install Envoy AI Gateway controller
 
+--skip-steps skips any of these steps by number, e.g. --skip-steps 3.
+
+Target namespaces that are still terminating, after a kubectl delete or a
+previous uninstall, are waited for before installing into them. A namespace
+still terminating after --terminating-timeout is reported with the resources
+whose finalizers hold it; --force-finalize removes those finalizers.
+
+Optional steps (pod security labels, pull secret, Redis, observability, ...)
+run in between when configured, and a summary of each step and how long it
+took is printed at the end.
+
 All steps support customization via flags and config files.`,
 	RunE: runInstall,
 }
 
 func init() {
-	installCmd.Flags().StringVar(&valuesExtra, "values-extra", "",
-		"comma-separated list of additional values files to use")
+	installCmd.Flags().StringSliceVar(&valuesExtra, "values-extra", nil,
+		"additional values files; prefix with gateway=, ai= or redis= to target a single release (repeatable)")
 	installCmd.Flags().BoolVar(&withRedis, "with-redis", false,
 		"install Redis for rate limiting (optional)")
+	installCmd.Flags().String("redis-version", "",
+		"Bitnami Redis chart version to install with --with-redis (default: latest)")
+	installCmd.Flags().Bool("redis-ha", false,
+		"run Redis in high availability mode, with Sentinel and --redis-ha-replicas replicas")
+	installCmd.Flags().Int("redis-ha-replicas", config.MinRedisHAReplicas+1,
+		"number of Redis replicas with --redis-ha")
+	installCmd.Flags().String("redis-external-host", "",
+		"use this existing Redis for rate limiting instead of installing one")
+	installCmd.Flags().Int("redis-external-port", config.DefaultRedisPort,
+		"port of --redis-external-host")
+	installCmd.Flags().String("redis-external-password", "",
+		"password of --redis-external-host (prefer EAIG_REDIS_EXTERNAL_PASSWORD, which stays out of the process list)")
+	installCmd.Flags().StringVar(&valuesURL, "values-url", officialValuesURL,
+		"Envoy Gateway values file to install with; append #sha256=<hex> to pin its checksum")
+	installCmd.Flags().StringVar(&valuesChecksum, "values-checksum", "",
+		"expected checksum of the official values file, as sha256:<hex> (default: verify against <url>.sha256 when published)")
+	installCmd.Flags().IntVar(&fetchRetries, "fetch-retries", 3,
+		"how often to retry downloading the official values file after transient failures (exponential backoff from 1s)")
 	installCmd.Flags().StringVar(&chartRepo, "chart-repo", "",
 		"optional pre-built chart repository URL")
+	installCmd.Flags().StringVar(&pinTag, "tag", "",
+		"GitHub release tag to pin both Envoy Gateway and AI Gateway to (default: latest stable release)")
+	installCmd.Flags().StringVar(&envoyGatewayTag, "envoy-gateway-tag", "",
+		"GitHub release tag of envoyproxy/gateway to install, overriding --tag")
+	installCmd.Flags().StringVar(&aiGatewayTag, "ai-gateway-tag", "",
+		"GitHub release tag of envoyproxy/ai-gateway to install, overriding --tag")
+	installCmd.Flags().BoolVar(&verifySignatures, "verify-signatures", false,
+		"verify the cosign signatures of the charts before installing (unsigned charts only warn)")
+	installCmd.Flags().BoolVar(&requireSignatures, "require-signatures", false,
+		"like --verify-signatures, but fail when a chart is not signed or cosign is missing")
+	installCmd.Flags().BoolVar(&skipCompatCheck, "skip-compat-check", false,
+		"install even if the Envoy Gateway and AI Gateway versions are not a supported pair")
+	installCmd.Flags().BoolVar(&showNotes, "show-notes", false,
+		"print the upstream release notes between the installed and the latest versions before installing")
+	installCmd.Flags().IntVar(&notesMaxLength, "notes-max-length", defaultNotesMaxLength,
+		"truncate each release's notes to this many characters (0 for no limit)")
+	installCmd.Flags().BoolVar(&forceUnlock, "force-unlock", false,
+		"take the installation lock over even if another installer holds it")
+	installCmd.Flags().BoolVar(&forceHelm, "force", false,
+		"reinstall releases that are already up to date and pass --force to helm to replace resources that cannot be upgraded (destructive)")
+	installCmd.Flags().BoolVar(&backupUpgrade, "backup-before-upgrade", false,
+		"back up the installed releases and AI Gateway resources, as the backup command does, before upgrading them")
+	installCmd.Flags().StringVar(&backupDir, "backup-dir", ".",
+		"directory to write the --backup-before-upgrade archive to")
+	installCmd.Flags().DurationVar(&terminatingTimeout, "terminating-timeout", 2*time.Minute,
+		"how long to wait for target namespaces that are being deleted to disappear before installing into them")
+	installCmd.Flags().BoolVar(&forceFinalize, "force-finalize", false,
+		"remove the finalizers of the resources that keep a target namespace terminating (their controllers may be gone)")
+	installCmd.Flags().BoolVar(&skipCRDs, "skip-crds", false,
+		"do not install or remove any CRDs, for clusters whose CRDs are managed separately (skips step 3 and passes --skip-crds to helm)")
+	installCmd.Flags().IntSliceVar(&skipSteps, "skip-steps", nil,
+		"official installation steps to skip, by number: 1 clean, 2 Envoy Gateway, 3 AI Gateway CRDs, 4 AI Gateway controller (comma-separated)")
+
+	installCmd.Flags().StringSliceVar(&labels, "labels", nil,
+		"labels to add to all created resources, as key=value pairs (repeatable)")
+	installCmd.Flags().StringSliceVar(&imagePullSecrets, "image-pull-secrets", nil,
+		"image pull secrets to add to all deployed workloads (comma-separated)")
+	installCmd.Flags().StringSliceVar(&resourceLimits, "resource-limits", nil,
+		"CPU and memory limits for the installed containers, e.g. cpu=500m,memory=512Mi; requests.cpu and requests.memory set requests, which default to the limits")
+	installCmd.Flags().String("ip-family", "",
+		"IP family of the gateway proxy Services: ipv4, ipv6 or dual (default: the cluster's)")
+	installCmd.Flags().StringSliceVar(&podSecurityStandards, "pod-security-standards", nil,
+		"Pod Security Admission levels to label the namespaces with before installing, e.g. enforce=restricted,warn=restricted")
+	installCmd.Flags().StringVar(&dockerConfigJSON, "docker-config-json", "",
+		"Docker config file (e.g. ~/.docker/config.json) to create the first --image-pull-secrets secret from")
+	installCmd.Flags().BoolVar(&localMode, "local", false,
+		"use a profile tuned for kind and minikube (small resources, single replicas, NodePort proxy service)")
+	installCmd.Flags().BoolVar(&openShift, "openshift", false,
+		"apply OpenShift-compatible security contexts (auto-detected from the route.openshift.io API group)")
+	installCmd.Flags().BoolVar(&openShiftRoute, "openshift-route", false,
+		"create an OpenShift Route for the Envoy proxy service (requires --openshift)")
+	installCmd.Flags().StringVar(&otlpEndpoint, "otlp-endpoint", "",
+		"OTLP collector to export proxy traces and access logs to, as host:port or http://host:port")
+	installCmd.Flags().StringVar(&otlpProtocol, "otlp-protocol", "grpc",
+		"OTLP protocol: only grpc, which the Envoy proxies export over, is supported")
+	installCmd.Flags().BoolVar(&otlpInsecure, "otlp-insecure", false,
+		"connect to the OTLP collector in plaintext; required, as the Envoy proxies cannot export over TLS")
+	installCmd.Flags().Float64Var(&tracingSampleRate, "tracing-sample-rate", 1.0,
+		"fraction of requests to trace, between 0 and 1")
+	installCmd.Flags().BoolVar(&waitReady, "wait", true,
+		"wait for all pods and the AI Gateway webhooks to become ready after installing")
+	installCmd.Flags().DurationVar(&waitTimeout, "wait-timeout", 5*time.Minute,
+		"how long to wait for pods, and then for the webhooks, to become ready")
+	installCmd.Flags().DurationVar(&pollInterval, "poll-interval", 2*time.Second,
+		"how often to check pod readiness while waiting")
+	installCmd.Flags().StringVar(&preInstallHook, "pre-install-hook", "",
+		"executable to run before the first helm command; installation is aborted if it fails")
+	installCmd.Flags().StringVar(&postInstallHook, "post-install-hook", "",
+		"executable to run once all pods are ready; its exit status becomes the installer's")
 
 	viper.BindPFlag("values_extra", installCmd.Flags().Lookup("values-extra"))
+	viper.BindPFlag("labels", installCmd.Flags().Lookup("labels"))
+	viper.BindPFlag("values_url", installCmd.Flags().Lookup("values-url"))
+	viper.BindPFlag("values_checksum", installCmd.Flags().Lookup("values-checksum"))
+	viper.BindPFlag("tag", installCmd.Flags().Lookup("tag"))
+	viper.BindPFlag("envoy_gateway_tag", installCmd.Flags().Lookup("envoy-gateway-tag"))
+	viper.BindPFlag("ai_gateway_tag", installCmd.Flags().Lookup("ai-gateway-tag"))
+	viper.BindPFlag("fetch_retries", installCmd.Flags().Lookup("fetch-retries"))
+	viper.BindPFlag("notes_max_length", installCmd.Flags().Lookup("notes-max-length"))
+	viper.BindPFlag("image_pull_secrets", installCmd.Flags().Lookup("image-pull-secrets"))
+	viper.BindPFlag("resource_limits", installCmd.Flags().Lookup("resource-limits"))
+	viper.BindPFlag("ip_family", installCmd.Flags().Lookup("ip-family"))
+	viper.BindPFlag("pod_security_standards", installCmd.Flags().Lookup("pod-security-standards"))
+	viper.BindPFlag("docker_config_json", installCmd.Flags().Lookup("docker-config-json"))
 	viper.BindPFlag("with_redis", installCmd.Flags().Lookup("with-redis"))
+	viper.BindPFlag("redis_version", installCmd.Flags().Lookup("redis-version"))
+	viper.BindPFlag("redis_ha", installCmd.Flags().Lookup("redis-ha"))
+	viper.BindPFlag("redis_ha_replicas", installCmd.Flags().Lookup("redis-ha-replicas"))
+	viper.BindPFlag("redis_external_host", installCmd.Flags().Lookup("redis-external-host"))
+	viper.BindPFlag("redis_external_port", installCmd.Flags().Lookup("redis-external-port"))
+	viper.BindPFlag("redis_external_password", installCmd.Flags().Lookup("redis-external-password"))
+	viper.BindPFlag("local", installCmd.Flags().Lookup("local"))
+	viper.BindPFlag("openshift", installCmd.Flags().Lookup("openshift"))
+	viper.BindPFlag("otlp_endpoint", installCmd.Flags().Lookup("otlp-endpoint"))
+	viper.BindPFlag("otlp_protocol", installCmd.Flags().Lookup("otlp-protocol"))
+	viper.BindPFlag("otlp_insecure", installCmd.Flags().Lookup("otlp-insecure"))
+	viper.BindPFlag("tracing_sample_rate", installCmd.Flags().Lookup("tracing-sample-rate"))
+	viper.BindPFlag("pre_install_hook", installCmd.Flags().Lookup("pre-install-hook"))
+	viper.BindPFlag("post_install_hook", installCmd.Flags().Lookup("post-install-hook"))
+	viper.BindPFlag("skip_crds", installCmd.Flags().Lookup("skip-crds"))
+	viper.BindPFlag("skip_steps", installCmd.Flags().Lookup("skip-steps"))
+}
+
+// Official installation steps, as numbered in the install help and by
+// --skip-steps.
+const (
+	stepClean = iota + 1
+	stepEnvoyGateway
+	stepAIGatewayCRDs
+	stepAIGatewayController
+)
+
+// checkSkipSteps rejects --skip-steps numbers that are not official steps.
+func checkSkipSteps() error {
+	for _, n := range viper.GetIntSlice("skip_steps") {
+		if n < stepClean || n > stepAIGatewayController {
+			return usageError(fmt.Errorf("invalid --skip-steps %d (expected 1 to 4)", n))
+		}
+	}
+	return nil
+}
+
+// skippedStep returns why official step n is skipped, or "" to run it.
+func skippedStep(n int) string {
+	if n == stepAIGatewayCRDs && viper.GetBool("skip_crds") {
+		return "--skip-crds is set"
+	}
+	if slices.Contains(viper.GetIntSlice("skip_steps"), n) {
+		return fmt.Sprintf("--skip-steps includes %d", n)
+	}
+	return ""
+}
+
+// cleanedReleases returns the releases the clean step uninstalls. With
+// --skip-crds the CRD release is kept, as uninstalling it deletes the CRDs
+// and every resource of their kinds.
+func cleanedReleases(cfg *config.Config) []managedRelease {
+	var releases []managedRelease
+	for _, r := range managedReleases(cfg) {
+		if r.id == "aieg-crd" && viper.GetBool("skip_crds") {
+			continue
+		}
+		releases = append(releases, r)
+	}
+	return releases
 }
 
 func runInstall(cmd *cobra.Command, args []string) error {
-	cfg := config.Load()
-	isDryRun := viper.GetBool("dry_run")
-	isVerbose := viper.GetBool("verbose")
+	if fleetFile != "" {
+		return runFleetInstall(cmd)
+	}
+	if err := checkFleetFlags(cmd); err != nil {
+		return err
+	}
+	timeout, err := bindWaitTimeout(cmd)
+	if err != nil {
+		return err
+	}
+	waitTimeout = timeout
+	if err := checkObservabilityFlags(cmd); err != nil {
+		return err
+	}
+	if err := checkRedisFlags(cmd); err != nil {
+		return err
+	}
 
-	fmt.Println("🚀 Envoy AI Gateway Installer")
-	fmt.Printf("  Namespace (Gateway): %s\n", cfg.NamespaceGateway)
-	fmt.Printf("  Namespace (AI):      %s\n", cfg.NamespaceAI)
-	fmt.Printf("  Dry Run:             %v\n", isDryRun)
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := fetchValuesExtra(cfg); err != nil {
+		return err
+	}
+	isDryRun := viper.GetBool("dry_run")
 
-	if !cfg.SkipClean {
-		fmt.Println("\n📋 Step 1/4: Cleaning up previous installations...")
-		if err := cleanPreviousInstall(cfg, isDryRun); err != nil {
-			return fmt.Errorf("cleanup failed: %w", err)
+	var dockerConfig redact.Secret
+	if path := viper.GetString("docker_config_json"); path != "" {
+		if dockerConfig, err = loadDockerConfigJSON(path); err != nil {
+			return err
 		}
 	}
+	if _, _, err := valuesSource(); err != nil {
+		return err
+	}
+	if err := checkInstallHooks(); err != nil {
+		return err
+	}
+	if err := checkSkipSteps(); err != nil {
+		return err
+	}
+	if err := startTelemetry(cmd, cfg); err != nil {
+		return err
+	}
 
-	helmCmd := helm.NewHelmCommand(isDryRun)
+	output.Println("🚀 Envoy AI Gateway Installer")
+	output.Printf("  Namespace (Gateway): %s\n", cfg.NamespaceGateway)
+	output.Printf("  Namespace (AI):      %s\n", cfg.NamespaceAI)
+	output.Printf("  Dry Run:             %v\n", isDryRun)
+	if cfg.ReleasePrefix != "" {
+		output.Printf("  Release Prefix:      %s\n", cfg.ReleasePrefix)
+	}
+	if viper.GetBool("skip_crds") {
+		output.Println("  CRDs:                not installed (--skip-crds)")
+	}
+	printValuesExtra(cfg)
+	printLabels(cfg)
+	if len(cfg.ImagePullSecrets) > 0 {
+		output.Printf("  Pull Secrets:        %s\n", strings.Join(cfg.ImagePullSecrets, ", "))
+	}
+	if len(cfg.PodSecurity) > 0 {
+		output.Printf("  Pod Security:        %s\n", formatPodSecurity(cfg.PodSecurity))
+	}
+	if cfg.Resources != nil {
+		output.Printf("  Resources:           %s\n", formatResources(cfg.Resources))
+	}
+	if cfg.IPFamily != "" {
+		output.Printf("  IP Family:           %s\n", ipFamilies[cfg.IPFamily].envoyProxy)
+	}
+	if hasScheduling(cfg) {
+		output.Println("  Scheduling:          node selector, tolerations and affinity from the config file")
+	}
+	if withRedis && cfg.RedisHA {
+		output.Printf("  Redis:               HA, %d replicas with Sentinel\n", cfg.RedisHAReplicas)
+	}
+	if cfg.RedisExternal != nil {
+		output.Printf("  Redis:               external, %s\n", redisExternalAddress(cfg.RedisExternal))
+	}
+	if cfg.Local {
+		printLocalCluster(k8s.DetectLocalCluster())
+	}
+	resolveOpenShift(cfg)
+	if cfg.OpenShift {
+		output.Println("  OpenShift:           enabled")
+	}
+	if observabilityRequested() {
+		cfg.Observability = true
+	}
+	if cfg.Observability {
+		output.Println("  Observability:       enabled")
+	}
+	printTelemetry(cfg, isDryRun)
+	if err := resolveTags(cmd.Context(), cfg); err != nil {
+		return err
+	}
+	if err := checkInstallCompatibility(cmd.Context(), cfg); err != nil {
+		return err
+	}
+	if verifySignatures || requireSignatures {
+		output.Println("\n🔏 Verifying chart signatures...")
+		if err := verifyChartSignatures(cfg); err != nil {
+			return err
+		}
+	}
+	if showNotes {
+		printReleaseNotes(cmd.Context(), cfg)
+	}
+	if err := verifyPrometheusOperatorCRDs(); err != nil {
+		return err
+	}
 
-	fmt.Println("\n📋 Step 2/4: Installing Envoy Gateway...")
-	if err := installEnvoyGateway(helmCmd, cfg); err != nil {
-		return fmt.Errorf("failed to install Envoy Gateway: %w", err)
+	unlock, err := lockInstallation(cmd, cfg, true)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	fmt.Println("\n📋 Step 3/4: Installing Envoy AI Gateway CRDs...")
-	if err := installAIGatewayCRDs(helmCmd, cfg); err != nil {
-		return fmt.Errorf("failed to install AI Gateway CRDs: %w", err)
+	previousState, err = state.Load(cfg.NamespaceAI, cfg.ReleasePrefix)
+	if err != nil {
+		output.Printf("  ⚠️  Could not read installation state: %v\n", err)
 	}
 
-	fmt.Println("\n📋 Step 4/4: Installing Envoy AI Gateway controller...")
-	if err := installAIGatewayController(helmCmd, cfg); err != nil {
-		return fmt.Errorf("failed to install AI Gateway controller: %w", err)
+	needsClean := !cfg.SkipClean && skippedStep(stepClean) == "" && cleanNeeded(cfg)
+
+	if !isDryRun {
+		var actions []string
+		if needsClean {
+			for _, r := range cleanedReleases(cfg) {
+				actions = append(actions, fmt.Sprintf("uninstall release %s in namespace %s", r.name, r.namespace))
+			}
+		}
+		if forceHelm {
+			actions = append(actions, "force-replace resources that cannot be upgraded (workloads are recreated)")
+		}
+
+		if len(actions) > 0 {
+			if err := confirmDestructive(actions); err != nil {
+				return err
+			}
+		}
 	}
 
-	if withRedis {
-		fmt.Println("\n📦 Installing Redis for rate limiting...")
-		if err := installRedis(helmCmd, cfg); err != nil {
-			return fmt.Errorf("failed to install Redis: %w", err)
+	if backupUpgrade {
+		if err := backupBeforeUpgrade(cfg, backupDir, isDryRun); err != nil {
+			return err
 		}
 	}
 
-	fmt.Println("\n✅ Installation complete!")
+	if err := runSteps(cmd, installSteps(cfg, dockerConfig, needsClean, isDryRun)); err != nil {
+		return err
+	}
+	if !isDryRun {
+		recordInstalledCharts(cfg)
+	}
+
+	output.Println("\n✅ Installation complete!")
 	if isDryRun {
-		fmt.Println("   This was a dry run. Use 'envoy-ai-installer install' without --dry-run to execute.")
-	} else {
-		fmt.Printf("   Verify installation: kubectl get pods -n %s\n", cfg.NamespaceGateway)
+		output.Println("   This was a dry run. Use 'envoy-ai-installer install' without --dry-run to execute.")
+	} else if !waitReady {
+		output.Printf("   Verify installation: kubectl get pods -n %s\n", cfg.NamespaceGateway)
+	}
+	if ensuredGatewayClass != nil {
+		output.Printf("   GatewayClass:        %s\n", ensuredGatewayClass)
+		if ensuredGatewayClass.Managed {
+			output.Printf("   Create Gateways with gatewayClassName: %s\n", ensuredGatewayClass.Name)
+		}
+	}
+	printEnvoyProxyHints(cfg)
+	if cfg.Local {
+		printLocalInstructions(cfg)
 	}
 
 	return nil
 }
 
-func cleanPreviousInstall(cfg *config.Config, isDryRun bool) error {
+// installSteps returns the steps of an installation. Optional steps are
+// only included when configured.
+func installSteps(cfg *config.Config, dockerConfig redact.Secret, needsClean, isDryRun bool) []steps.Step {
 	helmCmd := helm.NewHelmCommand(isDryRun)
 
-	releases := []struct {
-		name      string
-		namespace string
-	}{
-		{"eg", cfg.NamespaceGateway},
-		{"aieg-crd", cfg.NamespaceAI},
-		{"aieg", cfg.NamespaceAI},
+	var list []steps.Step
+	if preHook := viper.GetString("pre_install_hook"); preHook != "" {
+		list = append(list, hookStep(cfg, "pre-install", preHook, isDryRun))
+	}
+
+	list = append(list, steps.Step{
+		Name: "Clean up previous installations",
+		Skip: func() string {
+			switch {
+			case cfg.SkipClean:
+				return "--skip-clean is set"
+			case skippedStep(stepClean) != "":
+				return skippedStep(stepClean)
+			case !needsClean:
+				return "existing releases are managed by this installer and healthy"
+			}
+			return ""
+		},
+		Run: func(ctx context.Context) error {
+			if err := cleanPreviousInstall(cfg, isDryRun); err != nil {
+				return fmt.Errorf("cleanup failed: %w", err)
+			}
+			return nil
+		},
+	})
+
+	list = append(list, steps.Step{
+		Name: "Wait for terminating namespaces",
+		Run: func(ctx context.Context) error {
+			namespaces := installNamespaces(cfg)
+			if isDryRun {
+				output.Printf("[DRY-RUN] Would wait up to %s for namespaces %s to finish terminating, if they are\n",
+					terminatingTimeout, strings.Join(namespaces, ", "))
+				return nil
+			}
+			client, err := k8s.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			return waitTerminatingNamespaces(ctx, client, clusterFinalizers, namespaces, terminatingTimeout, forceFinalize)
+		},
+	})
+
+	if len(cfg.PodSecurity) > 0 {
+		list = append(list, steps.Step{
+			Name: "Apply Pod Security Standards",
+			Run: func(ctx context.Context) error {
+				if err := applyPodSecurityLabels(cfg, isDryRun); err != nil {
+					return fmt.Errorf("failed to label namespaces: %w", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	if dockerConfig != "" {
+		list = append(list, steps.Step{
+			Name: "Create image pull secret " + cfg.ImagePullSecrets[0],
+			Run: func(ctx context.Context) error {
+				if err := createPullSecret(cfg, dockerConfig, isDryRun); err != nil {
+					return fmt.Errorf("failed to create image pull secret: %w", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	if cfg.RedisExternal != nil && cfg.RedisExternal.Password != "" {
+		list = append(list, steps.Step{
+			Name: "Create Redis password secret " + redisAuthSecret(cfg),
+			Run: func(ctx context.Context) error {
+				if err := createRedisAuthSecret(cfg, isDryRun); err != nil {
+					return fmt.Errorf("failed to create Redis password secret: %w", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	list = append(list, steps.Step{
+		Name: "Install Envoy Gateway",
+		Skip: func() string { return skippedStep(stepEnvoyGateway) },
+		Run: func(ctx context.Context) error {
+			if err := installEnvoyGateway(helmCmd, cfg); err != nil {
+				return fmt.Errorf("failed to install Envoy Gateway: %w", err)
+			}
+			return nil
+		},
+	})
+
+	if needsEnvoyProxy(cfg) {
+		list = append(list, steps.Step{
+			Name: "Create EnvoyProxy",
+			Run: func(ctx context.Context) error {
+				if err := applyEnvoyProxy(cfg, isDryRun); err != nil {
+					return fmt.Errorf("failed to create EnvoyProxy: %w", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	list = append(list, steps.Step{
+		Name: "Install Envoy AI Gateway CRDs",
+		Skip: func() string { return skippedStep(stepAIGatewayCRDs) },
+		Run: func(ctx context.Context) error {
+			if err := installAIGatewayCRDs(helmCmd, cfg); err != nil {
+				return fmt.Errorf("failed to install AI Gateway CRDs: %w", err)
+			}
+			return waitAIGatewayCRDs(isDryRun)
+		},
+	}, steps.Step{
+		Name: "Install Envoy AI Gateway controller",
+		Skip: func() string { return skippedStep(stepAIGatewayController) },
+		Run: func(ctx context.Context) error {
+			if err := verifyCRDChartVersion(helmCmd, cfg, isDryRun); err != nil {
+				return err
+			}
+			if err := installAIGatewayController(helmCmd, cfg); err != nil {
+				return fmt.Errorf("failed to install AI Gateway controller: %w", err)
+			}
+			return nil
+		},
+	})
+
+	if cfg.OpenShift && openShiftRoute {
+		list = append(list, steps.Step{
+			Name: "Create OpenShift Route",
+			Run: func(ctx context.Context) error {
+				return createOpenShiftRoute(cfg, isDryRun)
+			},
+		})
+	}
+
+	if withRedis {
+		list = append(list, steps.Step{
+			Name: "Install Redis",
+			Run: func(ctx context.Context) error {
+				if err := installRedis(helmCmd, cfg); err != nil {
+					return fmt.Errorf("failed to install Redis: %w", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	if cfg.Observability {
+		list = append(list, steps.Step{
+			Name: "Set up observability",
+			Run: func(ctx context.Context) error {
+				return setupObservability(helmCmd, cfg, isDryRun)
+			},
+		})
+	}
+
+	if !skipGatewayClass {
+		// Without --wait the class is created and not waited for; the
+		// pods of Envoy Gateway may not be running yet either.
+		list = append(list, steps.Step{
+			Name: "Create GatewayClass " + gatewayClassName,
+			Run: func(ctx context.Context) error {
+				gc, err := ensureGatewayClass(ctx, cfg, waitReady, waitTimeout, isDryRun)
+				ensuredGatewayClass = gc
+				return err
+			},
+		})
+	}
+
+	postHook := viper.GetString("post_install_hook")
+	if isDryRun {
+		if postHook != "" {
+			list = append(list, hookStep(cfg, "post-install", postHook, isDryRun))
+		}
+		return list
+	}
+
+	// A failure to record the state is only a warning: the releases are
+	// installed.
+	list = append(list, steps.Step{
+		Name: "Record installation state",
+		Run: func(ctx context.Context) error {
+			if err := recordInstallState(cfg); err != nil {
+				output.Printf("⚠️  Could not record installation state: %v\n", err)
+			}
+			return nil
+		},
+	})
+
+	if waitReady {
+		list = append(list, steps.Step{
+			Name: "Wait for pods to become ready",
+			Run: func(ctx context.Context) error {
+				return waitForPods(ctx, cfg, waitTimeout, pollInterval)
+			},
+		}, steps.Step{
+			Name: "Wait for the AI Gateway webhooks",
+			Skip: func() string { return skippedStep(stepAIGatewayController) },
+			Run: func(ctx context.Context) error {
+				return waitAIGatewayWebhooks(ctx, cfg, waitTimeout)
+			},
+		})
+	}
+
+	if postHook != "" {
+		list = append(list, hookStep(cfg, "post-install", postHook, isDryRun))
+	}
+
+	return list
+}
+
+type managedRelease struct {
+	id        string
+	name      string
+	namespace string
+	chart     string
+	version   string
+}
+
+func managedReleases(cfg *config.Config) []managedRelease {
+	return []managedRelease{
+		{"eg", cfg.ReleasePrefix + "eg", cfg.NamespaceGateway, "envoyproxy/gateway-helm", releaseVersion(cfg, "eg")},
+		{"aieg-crd", cfg.ReleasePrefix + "aieg-crd", cfg.NamespaceAI, "envoyproxy/ai-gateway-crds-helm", releaseVersion(cfg, "aieg-crd")},
+		{"aieg", cfg.ReleasePrefix + "aieg", cfg.NamespaceAI, "envoyproxy/ai-gateway-helm", releaseVersion(cfg, "aieg")},
+	}
+}
+
+func redisRelease(cfg *config.Config) managedRelease {
+	return managedRelease{redisReleaseName, cfg.ReleasePrefix + redisReleaseName, cfg.NamespaceAI, "bitnami/redis", cfg.RedisVersion}
+}
+
+// releaseByID returns the release with id, and false when there is none.
+func releaseByID(cfg *config.Config, id string) (managedRelease, bool) {
+	for _, r := range append(managedReleases(cfg), redisRelease(cfg)) {
+		if r.id == id {
+			return r, true
+		}
+	}
+	return managedRelease{}, false
+}
+
+func releaseValues(cfg *config.Config) (map[string][]string, error) {
+	egValues, err := envoyGatewayValues(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	aiValues, err := overlayValues(cfg, "aieg")
+	if err != nil {
+		return nil, err
+	}
+	redisValues, err := overlayValues(cfg, redisReleaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]string{
+		"eg":             egValues,
+		"aieg":           append(aiValues, cfg.ValuesExtra[config.ValuesTargetAI]...),
+		redisReleaseName: append(redisValues, cfg.ValuesExtra[config.ValuesTargetRedis]...),
+	}, nil
+}
+
+func recordInstallState(cfg *config.Config) error {
+	helmCmd := helm.NewHelmCommand(false)
+	now := time.Now().UTC()
+
+	st := &state.State{
+		CLIVersion:       version.Get().Version,
+		InstalledAt:      now,
+		UpdatedAt:        now,
+		NamespaceGateway: cfg.NamespaceGateway,
+		NamespaceAI:      cfg.NamespaceAI,
+		WithRedis:        withRedis,
+		ReleasePrefix:    cfg.ReleasePrefix,
+		Releases:         map[string]state.Release{},
+		Telemetry:        telemetryState(cfg),
+	}
+
+	if previousState != nil {
+		st.InstalledAt = previousState.InstalledAt
+		st.Observability = previousState.Observability
+		st.GatewayClass = previousState.GatewayClass
+	}
+	if ensuredGatewayClass != nil && ensuredGatewayClass.Managed {
+		st.GatewayClass = ensuredGatewayClass.Name
+	}
+	if cfg.Observability {
+		st.Observability = observabilityState(cfg, st.Observability)
+	}
+
+	releases := managedReleases(cfg)
+	if withRedis {
+		releases = append(releases, redisRelease(cfg))
 	}
 
 	for _, r := range releases {
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil {
+			return err
+		}
+		if rel == nil {
+			continue
+		}
+
+		values, err := helmCmd.GetValues(r.name, r.namespace)
+		if err != nil {
+			return err
+		}
+
+		st.Releases[r.name] = state.Release{
+			Namespace:  r.namespace,
+			Chart:      rel.ChartName(),
+			Version:    rel.ChartVersion(),
+			ValuesHash: state.HashValues(values),
+			InputHash:  releaseInputs[r.name],
+		}
+	}
+
+	return state.Save(cfg.NamespaceAI, st)
+}
+
+func cleanNeeded(cfg *config.Config) bool {
+	if previousState == nil {
+		return true
+	}
+
+	helmCmd := helm.NewHelmCommand(false)
+	for _, r := range managedReleases(cfg) {
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil {
+			return true
+		}
+		if rel == nil {
+			continue
+		}
+		if _, ok := previousState.Releases[r.name]; !ok || rel.Status != "deployed" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func installRelease(helmCmd *helm.HelmCommand, r managedRelease, opts *helm.HelmOptions) error {
+	inputHash := hashInputs(r.chart, opts)
+	releaseInputs[r.name] = inputHash
+
+	if !forceHelm && releaseUpToDate(r.name, r.namespace, opts.Version, inputHash) {
+		output.Printf("  ✅ %s is already up to date, skipping\n", r.name)
+		return nil
+	}
+
+	err := helmCmd.Install(r.name, r.chart, r.namespace, opts)
+	var inUse *helm.ReleaseAlreadyExistsError
+	var notFound *helm.ChartNotFoundError
+	var timeout *helm.TimeoutError
+	switch {
+	case errors.As(err, &inUse):
+		return fmt.Errorf("%w; run 'envoy-ai-installer uninstall' or remove the release with helm, then retry", err)
+	case errors.As(err, &notFound) && opts.Version != "":
+		return fmt.Errorf("%w; check that version %s of %s is published (see 'envoy-ai-installer versions')", err, opts.Version, r.chart)
+	case errors.As(err, &timeout):
+		return fmt.Errorf("%w; check the pods with 'envoy-ai-installer status' and retry", err)
+	}
+	return err
+}
+
+func releaseUpToDate(name, namespace, version, inputHash string) bool {
+	if previousState == nil || inputHash == "" {
+		return false
+	}
+
+	recorded, ok := previousState.Releases[name]
+	if !ok || recorded.InputHash != inputHash {
+		return false
+	}
+
+	helmCmd := helm.NewHelmCommand(false)
+	rel, err := helmCmd.FindRelease(name, namespace)
+	if err != nil || rel == nil || rel.Status != "deployed" {
+		return false
+	}
+	if version != "" && rel.ChartVersion() != version {
+		return false
+	}
+
+	values, err := helmCmd.GetValues(name, namespace)
+	if err != nil {
+		return false
+	}
+
+	return state.HashValues(values) == recorded.ValuesHash
+}
+
+// hashInputs hashes everything that decides what an upgrade of chart with
+// opts deploys: the chart and its resolved version, the overrides in order,
+// the contents of the values files and the flags that change how helm
+// applies them. It returns "" when a values file cannot be read, so that the
+// release is never taken to be up to date.
+func hashInputs(chart string, opts *helm.HelmOptions) string {
+	h := sha256.New()
+	// Each field is prefixed with its length, so that no two sets of inputs
+	// hash the same input stream.
+	field := func(name, value string) {
+		fmt.Fprintf(h, "%s %d:%s\n", name, len(value), value)
+	}
+
+	field("chart", chart)
+	field("version", opts.Version)
+	field("namespace", opts.Namespace)
+	for _, value := range opts.Set {
+		field("set", value)
+	}
+	for _, value := range opts.SetString {
+		field("set-string", value)
+	}
+	for _, file := range opts.Values {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return ""
+		}
+		field("values", string(data))
+	}
+	field("force", strconv.FormatBool(opts.Force))
+	field("reuse-values", strconv.FormatBool(opts.ReuseValues))
+	field("skip-crds", strconv.FormatBool(opts.SkipCRDs))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func cleanPreviousInstall(cfg *config.Config, isDryRun bool) error {
+	helmCmd := helm.NewHelmCommand(isDryRun)
+
+	for _, r := range cleanedReleases(cfg) {
 		if err := helmCmd.Uninstall(r.name, r.namespace); err != nil {
-			fmt.Printf("  Note: %s was not previously installed\n", r.name)
+			output.Printf("  Note: %s was not previously installed\n", r.name)
 		}
 	}
 
@@ -128,37 +889,76 @@ func installEnvoyGateway(helmCmd *helm.HelmCommand, cfg *config.Config) error {
 		return err
 	}
 
-	valuesFile, err := fetchRemoteValuesFile(
-		"https://raw.githubusercontent.com/envoyproxy/ai-gateway/main/manifests/envoy-gateway-values.yaml",
-	)
+	values, err := envoyGatewayValues(cfg)
 	if err != nil {
-		fmt.Printf("Warning: Could not fetch official values file: %v\n", err)
-		valuesFile = ""
+		return err
+	}
+	set, setString, err := releaseOverrides(cfg, "eg")
+	if err != nil {
+		return err
+	}
+
+	opts := &helm.HelmOptions{
+		DryRun:    false,
+		Force:     forceHelm,
+		Namespace: cfg.NamespaceGateway,
+		Values:    values,
+		Set:       set,
+		SetString: setString,
+		Version:   releaseVersion(cfg, "eg"),
+		SkipCRDs:  viper.GetBool("skip_crds"),
 	}
 
+	r, _ := releaseByID(cfg, "eg")
+	return installRelease(helmCmd, r, opts)
+}
+
+func envoyGatewayValues(cfg *config.Config) ([]string, error) {
 	values := []string{}
-	if valuesFile != "" {
+
+	url, checksum, err := valuesSource()
+	if err != nil {
+		return nil, fmt.Errorf("official values file failed verification: %w", err)
+	}
+
+	valuesFile, err := fetchRemoteValuesFile(url, checksum)
+	var integrityErr *integrityError
+	if errors.As(err, &integrityErr) {
+		return nil, fmt.Errorf("official values file failed verification: %w", err)
+	} else if err != nil {
+		output.Printf("Warning: Could not fetch official values file: %v\n", err)
+	} else {
 		values = append(values, valuesFile)
 	}
+	overlays, err := overlayValues(cfg, "eg")
+	if err != nil {
+		return nil, err
+	}
+	values = append(values, overlays...)
 
-	if valuesExtra != "" {
-		extraValues := strings.Split(valuesExtra, ",")
-		for _, v := range extraValues {
-			v = strings.TrimSpace(v)
-			if v != "" {
-				values = append(values, v)
-			}
-		}
+	return append(values, cfg.ValuesExtra[config.ValuesTargetGateway]...), nil
+}
+
+// valuesSource returns the URL of the Envoy Gateway values file and its
+// expected checksum, from --values-checksum or a #sha256=<hex> fragment of
+// --values-url.
+func valuesSource() (string, string, error) {
+	url := viper.GetString("values_url")
+	if url == "" {
+		url = officialValuesURL
 	}
+	url, fragment := splitChecksumFragment(url)
 
-	opts := &helm.HelmOptions{
-		DryRun:    false,
-		Namespace: cfg.NamespaceGateway,
-		Values:    values,
-		Version:   "v0.0.0-latest",
+	checksum := viper.GetString("values_checksum")
+	switch {
+	case fragment == "":
+	case checksum == "":
+		checksum = fragment
+	case !strings.EqualFold(strings.TrimSpace(checksum), fragment):
+		return "", "", fmt.Errorf("--values-checksum %s does not match the checksum in --values-url (%s)", checksum, fragment)
 	}
 
-	return helmCmd.Install("eg", "envoyproxy/gateway-helm", cfg.NamespaceGateway, opts)
+	return url, checksum, nil
 }
 
 func installAIGatewayCRDs(helmCmd *helm.HelmCommand, cfg *config.Config) error {
@@ -170,14 +970,41 @@ func installAIGatewayCRDs(helmCmd *helm.HelmCommand, cfg *config.Config) error {
 		return err
 	}
 
+	set, setString, err := releaseOverrides(cfg, "aieg-crd")
+	if err != nil {
+		return err
+	}
+
 	opts := &helm.HelmOptions{
 		DryRun:    false,
+		Force:     forceHelm,
 		Namespace: cfg.NamespaceAI,
 		Values:    []string{},
-		Version:   "v0.0.0-latest",
+		Set:       set,
+		SetString: setString,
+		Version:   releaseVersion(cfg, "aieg-crd"),
 	}
 
-	return helmCmd.Install("aieg-crd", "envoyproxy/ai-gateway-crds-helm", cfg.NamespaceAI, opts)
+	r, _ := releaseByID(cfg, "aieg-crd")
+	return installRelease(helmCmd, r, opts)
+}
+
+// verifyCRDChartVersion fails when the CRD release is at another chart
+// version than the controller about to be installed. When step 3 was
+// skipped the CRDs are managed separately, and a mismatch only warns.
+func verifyCRDChartVersion(helmCmd *helm.HelmCommand, cfg *config.Config, isDryRun bool) error {
+	if isDryRun {
+		return nil
+	}
+	mismatch, err := checkCRDChartVersion(helmCmd, cfg)
+	if err != nil || mismatch == "" {
+		return err
+	}
+	if reason := skippedStep(stepAIGatewayCRDs); reason != "" {
+		output.Printf("⚠️  CRD version not verified: %s (%s)\n", mismatch, reason)
+		return nil
+	}
+	return &integrityError{fmt.Errorf("AI Gateway CRDs do not match the controller: %s; run 'migrate' to upgrade them", mismatch)}
 }
 
 func installAIGatewayController(helmCmd *helm.HelmCommand, cfg *config.Config) error {
@@ -189,25 +1016,30 @@ func installAIGatewayController(helmCmd *helm.HelmCommand, cfg *config.Config) e
 		return err
 	}
 
-	values := []string{}
-	if valuesExtra != "" {
-		extraValues := strings.Split(valuesExtra, ",")
-		for _, v := range extraValues {
-			v = strings.TrimSpace(v)
-			if v != "" {
-				values = append(values, v)
-			}
-		}
+	values, err := overlayValues(cfg, "aieg")
+	if err != nil {
+		return err
+	}
+	values = append(values, cfg.ValuesExtra[config.ValuesTargetAI]...)
+
+	set, setString, err := releaseOverrides(cfg, "aieg")
+	if err != nil {
+		return err
 	}
 
 	opts := &helm.HelmOptions{
 		DryRun:    false,
+		Force:     forceHelm,
 		Namespace: cfg.NamespaceAI,
 		Values:    values,
-		Version:   "v0.0.0-latest",
+		Set:       set,
+		SetString: setString,
+		Version:   releaseVersion(cfg, "aieg"),
+		SkipCRDs:  viper.GetBool("skip_crds"),
 	}
 
-	return helmCmd.Install("aieg", "envoyproxy/ai-gateway-helm", cfg.NamespaceAI, opts)
+	r, _ := releaseByID(cfg, "aieg")
+	return installRelease(helmCmd, r, opts)
 }
 
 func installRedis(helmCmd *helm.HelmCommand, cfg *config.Config) error {
@@ -219,35 +1051,129 @@ func installRedis(helmCmd *helm.HelmCommand, cfg *config.Config) error {
 		return err
 	}
 
+	values, err := overlayValues(cfg, redisReleaseName)
+	if err != nil {
+		return err
+	}
+	values = append(values, cfg.ValuesExtra[config.ValuesTargetRedis]...)
+
+	set, setString, err := releaseOverrides(cfg, redisReleaseName)
+	if err != nil {
+		return err
+	}
+
 	opts := &helm.HelmOptions{
 		DryRun:    false,
+		Force:     forceHelm,
 		Namespace: cfg.NamespaceAI,
-		Values:    []string{},
+		Values:    values,
+		Set:       set,
+		SetString: setString,
+		Version:   cfg.RedisVersion,
 	}
 
-	return helmCmd.Install("envoy-redis", "bitnami/redis", cfg.NamespaceAI, opts)
+	r, _ := releaseByID(cfg, redisReleaseName)
+	return installRelease(helmCmd, r, opts)
 }
 
-func fetchRemoteValuesFile(url string) (string, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", err
+// checkRedisFlags rejects Redis HA flags that would be ignored.
+func checkRedisFlags(cmd *cobra.Command) error {
+	haFlags := cmd.Flags().Changed("redis-ha") || cmd.Flags().Changed("redis-ha-replicas")
+	if haFlags && !withRedis {
+		return usageError(fmt.Errorf("--redis-ha and --redis-ha-replicas need --with-redis"))
+	}
+	if cmd.Flags().Changed("redis-ha-replicas") && !viper.GetBool("redis_ha") {
+		return usageError(fmt.Errorf("--redis-ha-replicas needs --redis-ha"))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("failed to fetch remote file: HTTP %d", resp.StatusCode)
+	external := viper.GetString("redis_external_host") != ""
+	if (cmd.Flags().Changed("redis-external-port") || cmd.Flags().Changed("redis-external-password")) && !external {
+		return usageError(fmt.Errorf("--redis-external-port and --redis-external-password need --redis-external-host"))
 	}
+	if external && withRedis {
+		return usageError(fmt.Errorf("--redis-external-host and --with-redis cannot be combined: use an existing Redis or install one"))
+	}
+	return nil
+}
 
-	tmpFile, err := os.CreateTemp("", "envoy-ai-values-*.yaml")
+// redisHAValues returns the Bitnami chart values that run Redis as a
+// primary and replicas watched by Sentinel, which promotes a replica when
+// the primary fails. The quorum is a majority of the Sentinels.
+func redisHAValues(cfg *config.Config, id string) []string {
+	if id != redisReleaseName || !cfg.RedisHA {
+		return nil
+	}
+	return []string{
+		"architecture=replication",
+		"sentinel.enabled=true",
+		fmt.Sprintf("replica.replicaCount=%d", cfg.RedisHAReplicas),
+		fmt.Sprintf("sentinel.quorum=%d", cfg.RedisHAReplicas/2+1),
+	}
+}
+
+// releaseOverrides returns the --set and --set-string overrides of a
+// release: the common labels, its image pull secrets, its scheduling
+// settings, its resources, its Redis HA settings and, for Envoy Gateway,
+// the address of an external Redis.
+func releaseOverrides(cfg *config.Config, id string) (set, setString []string, err error) {
+	set, scheduling, err := schedulingValues(cfg, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources, err := resourceValues(cfg, id)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+	set = append(set, redisHAValues(cfg, id)...)
+
+	setString = append(labelValues(cfg), imagePullSecretValues(cfg, id)...)
+	setString = append(setString, scheduling...)
+	setString = append(setString, redisExternalValues(cfg, id)...)
+	return set, append(setString, resources...), nil
+}
+
+// labelValues turns the configured labels into commonLabels overrides, which
+// the charts apply to every resource they render.
+func labelValues(cfg *config.Config) []string {
+	keys := make([]string, 0, len(cfg.Labels))
+	for key := range cfg.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, fmt.Sprintf("commonLabels.%s=%s",
+			strings.ReplaceAll(key, ".", `\.`), cfg.Labels[key]))
+	}
+	return values
+}
+
+func printValuesExtra(cfg *config.Config) {
+	if len(cfg.ValuesExtra) == 0 {
+		return
+	}
+
+	output.Println("  Extra values:")
+	for _, target := range config.ValuesTargets() {
+		files := cfg.ValuesExtra[target]
+		if len(files) == 0 {
+			continue
+		}
+		output.Printf("    %-8s %s\n", target+":", strings.Join(files, ", "))
+	}
+}
+
+func printLabels(cfg *config.Config) {
+	if len(cfg.Labels) == 0 {
+		return
 	}
-	defer tmpFile.Close()
 
-	if _, err := tmpFile.ReadFrom(resp.Body); err != nil {
-		return "", err
+	pairs := make([]string, 0, len(cfg.Labels))
+	for key, value := range cfg.Labels {
+		pairs = append(pairs, key+"="+value)
 	}
+	sort.Strings(pairs)
 
-	return tmpFile.Name(), nil
+	output.Printf("  Labels:              %s\n", strings.Join(pairs, ", "))
 }
@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/state"
+)
+
+// fakeHelmLog records the arguments of every helm command to $FAKE_HELM_LOG.
+// helm list prints $FAKE_HELM_LIST, helm get values $FAKE_HELM_VALUES and
+// helm template the file named after the release in $FAKE_HELM_TEMPLATES.
+const fakeHelmLog = `#!/bin/sh
+echo "$*" >> "$FAKE_HELM_LOG"
+case "$1" in
+list) echo "${FAKE_HELM_LIST:-[]}" ;;
+get) printf '%s' "$FAKE_HELM_VALUES" ;;
+template) cat "$FAKE_HELM_TEMPLATES/$2" 2>/dev/null ;;
+esac
+`
+
+// useFakeHelm puts fakeHelmLog first on PATH and returns its log.
+func useFakeHelm(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake helm is a shell script")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "helm"), []byte(fakeHelmLog), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	log := filepath.Join(t.TempDir(), "helm.log")
+	t.Setenv("FAKE_HELM_LOG", log)
+	return log
+}
+
+func TestInstallOverlayArgs(t *testing.T) {
+	useFakeHelm(t)
+	t.Setenv("TMPDIR", t.TempDir())
+
+	install := map[string]func(*helm.HelmCommand, *config.Config) error{
+		"aieg":           installAIGatewayController,
+		redisReleaseName: installRedis,
+	}
+
+	tests := []struct {
+		name      string
+		local     bool
+		openShift bool
+		want      []string
+	}{
+		{name: "no profile", want: []string{"user.yaml"}},
+		{name: "local", local: true, want: []string{"local", "user.yaml"}},
+		{name: "openshift", openShift: true, want: []string{"openshift", "user.yaml"}},
+		{name: "local on openshift", local: true, openShift: true, want: []string{"local", "openshift", "user.yaml"}},
+	}
+
+	for _, tt := range tests {
+		for id, installFunc := range install {
+			t.Run(tt.name+"/"+id, func(t *testing.T) {
+				resetOverlayFiles(t)
+				log := filepath.Join(t.TempDir(), "helm.log")
+				t.Setenv("FAKE_HELM_LOG", log)
+
+				cfg := &config.Config{
+					NamespaceAI:  "envoy-ai-gateway-system",
+					RedisVersion: "20.0.0",
+					Local:        tt.local,
+					OpenShift:    tt.openShift,
+					ValuesExtra: map[string][]string{
+						config.ValuesTargetAI:    {"user.yaml"},
+						config.ValuesTargetRedis: {"user.yaml"},
+					},
+				}
+				if err := installFunc(helm.NewHelmCommand(false), cfg); err != nil {
+					t.Fatal(err)
+				}
+
+				args := upgradeArgs(t, log)
+				r, _ := releaseByID(cfg, id)
+				prefix := []string{"upgrade", "--install", r.name, r.chart, "-n", r.namespace, "--create-namespace", "--version", r.version}
+				if got := strings.Join(args[:min(len(prefix), len(args))], " "); got != strings.Join(prefix, " ") {
+					t.Fatalf("got helm %s, want it to start with %s", strings.Join(args, " "), strings.Join(prefix, " "))
+				}
+
+				var got []string
+				for i := len(prefix); i < len(args); i++ {
+					if args[i] != "-f" || i+1 == len(args) {
+						continue
+					}
+					i++
+					got = append(got, valuesProfile(args[i], id))
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("got values files %v in helm %s, want %v", got, strings.Join(args, " "), tt.want)
+				}
+			})
+		}
+	}
+}
+
+// upgradeArgs returns the arguments of the one helm upgrade in log.
+func upgradeArgs(t *testing.T, log string) []string {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upgrades [][]string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.HasPrefix(line, "upgrade ") {
+			upgrades = append(upgrades, strings.Fields(line))
+		}
+	}
+	if len(upgrades) != 1 {
+		t.Fatalf("got helm commands %q, want one upgrade", data)
+	}
+	return upgrades[0]
+}
+
+// valuesProfile returns the profile whose overlay for release id file is,
+// or file itself when it is no overlay.
+func valuesProfile(file, id string) string {
+	for _, profile := range []string{"local", "openshift", "observability"} {
+		if strings.HasPrefix(filepath.Base(file), "envoy-ai-"+profile+"-"+id+"-") {
+			return profile
+		}
+	}
+	return file
+}
+
+func TestHashInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeValues := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a, b := writeValues("a.yaml", "replicas: 2\n"), writeValues("b.yaml", "replicas: 3\n")
+
+	base := func() *helm.HelmOptions {
+		return &helm.HelmOptions{
+			Namespace: "envoy-ai-gateway-system",
+			Version:   "v0.3.0",
+			Values:    []string{a, b},
+			Set:       []string{"controller.logLevel=debug"},
+			SetString: []string{"podLabels.team=ai"},
+		}
+	}
+	const chart = "envoyproxy/ai-gateway-helm"
+	want := hashInputs(chart, base())
+	if want == "" || hashInputs(chart, base()) != want {
+		t.Fatal("same inputs hash differently")
+	}
+
+	tests := []struct {
+		name   string
+		chart  string
+		change func(*helm.HelmOptions)
+	}{
+		{name: "chart", chart: "mirror.example.com/ai-gateway-helm"},
+		{name: "version", change: func(o *helm.HelmOptions) { o.Version = "v0.3.1" }},
+		{name: "namespace", change: func(o *helm.HelmOptions) { o.Namespace = "ai" }},
+		{name: "set", change: func(o *helm.HelmOptions) { o.Set = []string{"controller.logLevel=info"} }},
+		{name: "set moved to set-string", change: func(o *helm.HelmOptions) {
+			o.Set, o.SetString = nil, append(o.SetString, o.Set...)
+		}},
+		{name: "set split differently", change: func(o *helm.HelmOptions) {
+			o.Set = []string{"controller.logLevel=debug", "podLabels.team=ai"}
+			o.SetString = nil
+		}},
+		{name: "values order", change: func(o *helm.HelmOptions) { o.Values = []string{b, a} }},
+		{name: "values content", change: func(o *helm.HelmOptions) { writeValues("b.yaml", "replicas: 4\n") }},
+		{name: "force", change: func(o *helm.HelmOptions) { o.Force = true }},
+		{name: "reuse values", change: func(o *helm.HelmOptions) { o.ReuseValues = true }},
+		{name: "skip CRDs", change: func(o *helm.HelmOptions) { o.SkipCRDs = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { writeValues("b.yaml", "replicas: 3\n") })
+			opts := base()
+			if tt.change != nil {
+				tt.change(opts)
+			}
+			chart := chart
+			if tt.chart != "" {
+				chart = tt.chart
+			}
+			if got := hashInputs(chart, opts); got == want {
+				t.Errorf("changing the %s leaves the hash unchanged", tt.name)
+			}
+		})
+	}
+
+	missing := base()
+	missing.Values = append(missing.Values, filepath.Join(dir, "missing.yaml"))
+	if got := hashInputs(chart, missing); got != "" {
+		t.Errorf("got hash %q with a missing values file, want none", got)
+	}
+}
+
+func TestInstallReleaseUpToDate(t *testing.T) {
+	const values = "controller:\n  logLevel: debug\n"
+	r := managedRelease{id: "aieg", name: "aieg", namespace: "envoy-ai-gateway-system", chart: "envoyproxy/ai-gateway-helm", version: "v0.3.0"}
+	opts := func() *helm.HelmOptions {
+		return &helm.HelmOptions{Namespace: r.namespace, Version: r.version, Set: []string{"controller.logLevel=debug"}}
+	}
+	recorded := state.Release{Namespace: r.namespace, Version: "v0.3.0", ValuesHash: state.HashValues(values), InputHash: hashInputs(r.chart, opts())}
+	deployed := `[{"name": "aieg", "namespace": "envoy-ai-gateway-system", "status": "deployed", "chart": "ai-gateway-helm-v0.3.0"}]`
+
+	tests := []struct {
+		name        string
+		noState     bool
+		recorded    *state.Release
+		list        string
+		values      string
+		change      func(*helm.HelmOptions)
+		force       bool
+		wantUpgrade bool
+	}{
+		{name: "matched", recorded: &recorded, list: deployed, values: values},
+		{name: "no state", noState: true, list: deployed, values: values, wantUpgrade: true},
+		{name: "not in the state", list: deployed, values: values, wantUpgrade: true},
+		{name: "not installed", recorded: &recorded, list: "[]", wantUpgrade: true},
+		{name: "failed", recorded: &recorded, list: strings.Replace(deployed, "deployed", "failed", 1), values: values, wantUpgrade: true},
+		{name: "other chart version deployed", recorded: &recorded, list: strings.Replace(deployed, "v0.3.0", "v0.2.1", 1), values: values, wantUpgrade: true},
+		{name: "values changed in the cluster", recorded: &recorded, list: deployed, values: "controller:\n  logLevel: info\n", wantUpgrade: true},
+		{name: "new version", recorded: &recorded, list: deployed, values: values, change: func(o *helm.HelmOptions) { o.Version = "v0.3.1" }, wantUpgrade: true},
+		{name: "new override", recorded: &recorded, list: deployed, values: values, change: func(o *helm.HelmOptions) { o.Set = nil }, wantUpgrade: true},
+		{name: "new flag", recorded: &recorded, list: deployed, values: values, change: func(o *helm.HelmOptions) { o.SkipCRDs = true }, wantUpgrade: true},
+		{name: "forced", recorded: &recorded, list: deployed, values: values, force: true, wantUpgrade: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := useFakeHelm(t)
+			t.Setenv("FAKE_HELM_LIST", tt.list)
+			t.Setenv("FAKE_HELM_VALUES", tt.values)
+
+			savedState, savedForce := previousState, forceHelm
+			t.Cleanup(func() { previousState, forceHelm = savedState, savedForce })
+			previousState, forceHelm = nil, tt.force
+			if !tt.noState {
+				previousState = &state.State{Releases: map[string]state.Release{}}
+				if tt.recorded != nil {
+					previousState.Releases[r.name] = *tt.recorded
+				}
+			}
+
+			o := opts()
+			if tt.change != nil {
+				tt.change(o)
+			}
+			if err := installRelease(helm.NewHelmCommand(false), r, o); err != nil {
+				t.Fatal(err)
+			}
+
+			data, _ := os.ReadFile(log)
+			if upgraded := strings.Contains(string(data), "upgrade --install"); upgraded != tt.wantUpgrade {
+				t.Errorf("upgraded = %v, want %v (helm commands %q)", upgraded, tt.wantUpgrade, data)
+			}
+			if releaseInputs[r.name] != hashInputs(r.chart, o) {
+				t.Error("input hash not recorded for the state")
+			}
+		})
+	}
+}
+
+func TestCleanNeeded(t *testing.T) {
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system"}
+	release := func(name, status string) string {
+		return `{"name": "` + name + `", "status": "` + status + `", "chart": "chart-v1.0.0"}`
+	}
+	all := map[string]state.Release{"eg": {}, "aieg-crd": {}, "aieg": {}}
+
+	tests := []struct {
+		name     string
+		releases map[string]state.Release
+		list     []string
+		force    bool
+		want     bool
+	}{
+		{name: "all managed and deployed", releases: all, list: []string{release("eg", "deployed"), release("aieg-crd", "deployed"), release("aieg", "deployed")}},
+		{name: "partially installed", releases: all, list: []string{release("eg", "deployed")}},
+		{name: "nothing installed", releases: all},
+		{name: "no state", list: []string{release("eg", "deployed")}, want: true},
+		{name: "installed outside the installer", releases: map[string]state.Release{"eg": {}}, list: []string{release("eg", "deployed"), release("aieg", "deployed")}, want: true},
+		{name: "failed release", releases: all, list: []string{release("eg", "deployed"), release("aieg", "failed")}, want: true},
+		// --force reinstalls the releases but must not delete the CRDs
+		// and the resources of a healthy installation.
+		{name: "forced", releases: all, list: []string{release("eg", "deployed"), release("aieg-crd", "deployed"), release("aieg", "deployed")}, force: true},
+		{name: "forced and failed", releases: all, list: []string{release("eg", "deployed"), release("aieg", "failed")}, force: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeHelm(t)
+			// The fake lists every release in each namespace; FindRelease
+			// only looks at the name.
+			t.Setenv("FAKE_HELM_LIST", "["+strings.Join(tt.list, ",")+"]")
+
+			savedState, savedForce := previousState, forceHelm
+			t.Cleanup(func() { previousState, forceHelm = savedState, savedForce })
+			previousState, forceHelm = nil, tt.force
+			if tt.releases != nil {
+				previousState = &state.State{Releases: tt.releases}
+			}
+
+			if got := cleanNeeded(cfg); got != tt.want {
+				t.Errorf("cleanNeeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseValuesRouting(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	resetOverlayFiles(t)
+	useOfficialValues(t, "deployment:\n  envoyGateway:\n    replicas: 1\n")
+
+	valuesExtra, err := config.ParseValuesExtra([]string{
+		"common.yaml", "gateway=gw.yaml", "ai=ai.yaml", "redis=redis.yaml", "./env=prod.yaml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system", ValuesExtra: valuesExtra}
+
+	files, err := releaseValues(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"eg":             {"common.yaml", "gw.yaml", "./env=prod.yaml"},
+		"aieg":           {"common.yaml", "ai.yaml", "./env=prod.yaml"},
+		redisReleaseName: {"redis.yaml"},
+	}
+	for id, extra := range want {
+		got := files[id]
+		// The official values and the overlays come first.
+		if len(got) < len(extra) || !reflect.DeepEqual(got[len(got)-len(extra):], extra) {
+			t.Errorf("%s gets values files %v, want them to end with %v", id, got, extra)
+		}
+	}
+}
+
+func TestReleaseByIDUnknown(t *testing.T) {
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system", ReleasePrefix: "prod-"}
+
+	if r, ok := releaseByID(cfg, "aieg"); !ok || r.name != "prod-aieg" {
+		t.Errorf("releaseByID(aieg) = %+v, %v, want prod-aieg", r, ok)
+	}
+	if r, ok := releaseByID(cfg, "bogus"); ok {
+		t.Errorf("releaseByID(bogus) = %+v, want no release", r)
+	}
+	if c, ok := componentOf(redisReleaseName); ok {
+		t.Errorf("componentOf(redis) = %+v, want no component", c)
+	}
+	if v := releaseVersion(cfg, redisReleaseName); v != chartVersion {
+		t.Errorf("releaseVersion(redis) = %q, want %q", v, chartVersion)
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+)
+
+// interruptible is set once the command handles interrupts itself, by
+// stopping what it is doing and returning.
+var interruptible atomic.Bool
+
+// notifyInterrupt returns a copy of parent that is canceled on the first
+// interrupt or SIGTERM, which the command handles by returning. A second
+// one stops the process.
+func notifyInterrupt(parent context.Context) (context.Context, context.CancelFunc) {
+	interruptible.Store(true)
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
+
+// handleInterrupts removes the temporary values files, which can hold
+// secrets, when an interrupt or SIGTERM stops the process: on the first one,
+// unless the command handles it with notifyInterrupt, and otherwise on the
+// second. The returned function stops the handling.
+func handleInterrupts() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		received := 0
+		for range signals {
+			received++
+			if received == 1 && interruptible.Load() {
+				continue
+			}
+			values.RemoveTempFiles()
+			output.Close()
+			os.Exit(ExitCancelled)
+		}
+	}()
+	return func() { signal.Stop(signals) }
+}
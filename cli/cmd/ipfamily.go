@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ipFamily is an ip_family value as the ipFamily of the EnvoyProxy, and as
+// the policy and families of the Services Envoy Gateway creates from it.
+type ipFamily struct {
+	envoyProxy string
+	policy     corev1.IPFamilyPolicy
+	families   []corev1.IPFamily
+}
+
+var ipFamilies = map[string]ipFamily{
+	config.IPFamilyIPv4: {"IPv4", corev1.IPFamilyPolicySingleStack, []corev1.IPFamily{corev1.IPv4Protocol}},
+	config.IPFamilyIPv6: {"IPv6", corev1.IPFamilyPolicySingleStack, []corev1.IPFamily{corev1.IPv6Protocol}},
+	config.IPFamilyDual: {"DualStack", corev1.IPFamilyPolicyRequireDualStack, []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}},
+}
+
+// checkIPFamily checks that the cluster can serve gateway Services of the
+// configured IP family: that the API server has Service CIDRs of its
+// families, and that the nodes give pods addresses of them, without which
+// the Services have no endpoints of that family.
+func checkIPFamily(ctx context.Context, cfg *config.Config) bool {
+	output.Print("🔍 IP family:          ")
+	family := ipFamilies[cfg.IPFamily]
+
+	err := k8s.CheckServiceIPFamilies(ctx, metav1.NamespaceDefault, family.policy, family.families)
+	switch {
+	case apierrors.IsInvalid(err):
+		output.Printf("❌ %s Services rejected\n", family.envoyProxy)
+		output.Printf("   %v\n", err)
+		output.Println("   The cluster has no Service CIDR of every family; choose another --ip-family or enable dual-stack networking")
+		return false
+	case err != nil:
+		output.Printf("⚠️  could not check %s Services: %v\n", family.envoyProxy, err)
+		return true
+	}
+
+	nodeFamilies, err := k8s.NodeIPFamilies(ctx)
+	switch {
+	case err != nil:
+		output.Printf("⚠️  %s Services accepted; could not check the nodes: %v\n", family.envoyProxy, err)
+		return true
+	case len(nodeFamilies) == 0:
+		output.Printf("✅ %s Services accepted (the nodes report no pod CIDRs)\n", family.envoyProxy)
+		return true
+	}
+
+	var missing []string
+	for _, f := range family.families {
+		if !slices.Contains(nodeFamilies, f) {
+			missing = append(missing, string(f))
+		}
+	}
+	if len(missing) > 0 {
+		output.Printf("❌ nodes have no %s pod CIDRs\n", strings.Join(missing, " or "))
+		output.Printf("   Node pod CIDRs are %s only; %s Services would have no endpoints of the missing family\n",
+			formatIPFamilies(nodeFamilies), family.envoyProxy)
+		return false
+	}
+	output.Printf("✅ %s (node pod CIDRs: %s)\n", family.envoyProxy, formatIPFamilies(nodeFamilies))
+	return true
+}
+
+func formatIPFamilies(families []corev1.IPFamily) string {
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
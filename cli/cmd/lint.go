@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Run helm lint on the charts that install would deploy",
+	Long: `Run 'helm lint' on every chart that 'install' would deploy, using the
+same chart references, versions and values files.
+
+The values files of each release are parsed and merged first, so syntax
+errors are reported before helm runs. Lint errors and warnings are reported
+per chart. The command exits with a non-zero status if any chart has lint
+errors.`,
+	RunE: runLint,
+}
+
+func init() {
+	lintCmd.Flags().StringSliceVar(&valuesExtra, "values-extra", nil,
+		"additional values files; prefix with gateway=, ai= or redis= to target a single release (repeatable)")
+	lintCmd.Flags().StringSliceVar(&labels, "labels", nil,
+		"labels to add to all created resources, as key=value pairs (repeatable)")
+	lintCmd.Flags().BoolVar(&withRedis, "with-redis", false,
+		"also lint the Redis chart")
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	viper.BindPFlag("values_extra", cmd.Flags().Lookup("values-extra"))
+	viper.BindPFlag("labels", cmd.Flags().Lookup("labels"))
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := fetchValuesExtra(cfg); err != nil {
+		return err
+	}
+
+	helmCmd := helm.NewHelmCommand(viper.GetBool("dry_run"))
+
+	output.Println("🔎 Linting Envoy AI Gateway charts")
+	if err := resolveTags(cmd.Context(), cfg); err != nil {
+		return err
+	}
+
+	if err := helmCmd.RepoAdd("envoyproxy", "oci://docker.io/envoyproxy"); err != nil {
+		return err
+	}
+	if withRedis {
+		if err := helmCmd.RepoAdd("bitnami", "https://charts.bitnami.com/bitnami"); err != nil {
+			return err
+		}
+	}
+	if err := helmCmd.RepoUpdate(); err != nil {
+		return err
+	}
+
+	files, err := releaseValues(cfg)
+	if err != nil {
+		return err
+	}
+
+	releases := managedReleases(cfg)
+	if withRedis {
+		releases = append(releases, redisRelease(cfg))
+	}
+
+	var failed []string
+	for _, r := range releases {
+		output.Printf("\n📋 %s (%s)\n", r.name, r.chart)
+
+		if _, err := values.Merge(files[r.id]); err != nil {
+			output.Printf("❌ %s: %v\n", r.name, err)
+			failed = append(failed, r.name)
+			continue
+		}
+
+		set, setString, err := releaseOverrides(cfg, r.id)
+		if err != nil {
+			return err
+		}
+
+		opts := &helm.HelmOptions{
+			Namespace: r.namespace,
+			Values:    files[r.id],
+			Set:       set,
+			SetString: setString,
+			Version:   r.version,
+		}
+
+		if err := helmCmd.Lint(r.chart, opts); err != nil {
+			output.Printf("❌ %s: lint failed\n", r.name)
+			failed = append(failed, r.name)
+			continue
+		}
+		output.Printf("✅ %s: no lint errors\n", r.name)
+	}
+
+	output.Println()
+	if len(failed) > 0 {
+		return fmt.Errorf("lint failed for: %s", strings.Join(failed, ", "))
+	}
+
+	output.Println("✅ All charts passed lint")
+	return nil
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+func printLocalCluster(cluster *k8s.LocalCluster) {
+	if cluster != nil {
+		output.Printf("  Local Cluster:       %s (detected from %s)\n", cluster.Provider, cluster.Reason)
+	} else {
+		output.Println("  ⚠️  --local given but the cluster does not look like kind or minikube; applying the local profile anyway")
+	}
+}
+
+func printLocalInstructions(cfg *config.Config) {
+	output.Println("\n💻 Local cluster setup:")
+	output.Printf("   Gateways using the EnvoyProxy %s/%s get a NodePort service instead of a LoadBalancer.\n",
+		cfg.NamespaceGateway, installerEnvoyProxyName)
+	printEnvoyProxyReference(cfg)
+	output.Println("   Then reach the gateway with:")
+	output.Println("     envoy-ai-installer port-forward    # forwards localhost:8080 to the Envoy proxy")
+	output.Println("     envoy-ai-installer endpoints       # shows NodePorts and example requests")
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/lock"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var forceUnlock bool
+
+var lockCmd = &cobra.Command{
+	Use:    "lock",
+	Short:  "Inspect the lock that keeps installers from running concurrently",
+	Hidden: true,
+}
+
+var lockStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show who holds the installation lock",
+	RunE:  runLockStatus,
+}
+
+func init() {
+	lockCmd.AddCommand(lockStatusCmd)
+}
+
+func runLockStatus(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+
+	name := lock.NameFor(cfg.ReleasePrefix)
+	holder, err := lock.Status(cmd.Context(), clientset, cfg.NamespaceAI, name)
+	if err != nil {
+		return err
+	}
+	if holder == nil {
+		output.Printf("🔓 %s/%s is not held\n", cfg.NamespaceAI, name)
+		return nil
+	}
+
+	output.Printf("🔒 %s/%s\n", cfg.NamespaceAI, name)
+	output.Printf("  Holder:   %s\n", holder.Identity)
+	output.Printf("  Since:    %s\n", formatLockTime(holder.AcquiredAt))
+	output.Printf("  Renewed:  %s\n", formatLockTime(holder.RenewedAt))
+	if holder.Expired(time.Now()) {
+		output.Printf("  Expired:  %s, the next install takes it over\n", formatLockTime(holder.ExpiresAt()))
+	} else {
+		output.Printf("  Expires:  %s unless renewed\n", holder.ExpiresAt().Local().Format("15:04:05"))
+	}
+	return nil
+}
+
+// lockInstallation takes the lock that keeps two installers from changing
+// the installation at once, and returns the function that releases it.
+// Dry runs change nothing and take no lock. Without createNamespace, a
+// missing AI namespace means there is nothing to protect.
+func lockInstallation(cmd *cobra.Command, cfg *config.Config, createNamespace bool) (func(), error) {
+	if viper.GetBool("dry_run") {
+		return func() {}, nil
+	}
+	viper.BindPFlag("force_unlock", cmd.Flags().Lookup("force-unlock"))
+
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	name := lock.NameFor(cfg.ReleasePrefix)
+	l := lock.New(clientset, lock.Options{
+		Namespace:       cfg.NamespaceAI,
+		Name:            name,
+		CreateNamespace: createNamespace,
+		OnLost: func(holder lock.Holder) {
+			output.Printf("\n⚠️  The installation lock was taken over by %s; another installer may be running\n", holder.Identity)
+		},
+	})
+
+	err = l.Acquire(cmd.Context(), viper.GetBool("force_unlock"))
+	var held *lock.HeldError
+	switch {
+	case errors.Is(err, lock.ErrNoNamespace):
+		return func() {}, nil
+	case errors.As(err, &held):
+		return nil, fmt.Errorf("another installer is running: %w (lock %s/%s renewed %s, expires %s)\n"+
+			"   Wait for it to finish, or pass --force-unlock if it is no longer running",
+			err, cfg.NamespaceAI, name, formatLockTime(held.Holder.RenewedAt), held.Holder.ExpiresAt().Local().Format("15:04:05"))
+	case err != nil:
+		return nil, err
+	}
+
+	if previous := l.TookOver(); previous != nil {
+		reason := "it expired " + formatLockTime(previous.ExpiresAt())
+		if !previous.Expired(time.Now()) {
+			reason = "--force-unlock is set"
+		}
+		output.Printf("⚠️  Took over the installation lock of %s: %s\n", previous.Identity, reason)
+	}
+
+	return func() {
+		if err := l.Release(context.Background()); err != nil {
+			output.Printf("⚠️  %v; it expires in %s\n", err, lock.DefaultTTL)
+		}
+	}, nil
+}
+
+// formatLockTime formats a lock timestamp with how long ago it was.
+func formatLockTime(t time.Time) string {
+	return fmt.Sprintf("%s (%s ago)", t.Local().Format("2006-01-02 15:04:05 MST"), time.Since(t).Round(time.Second))
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	logsFollow    bool
+	logsTail      int64
+	logsSince     time.Duration
+	logsPrevious  bool
+	logsContainer string
+	logsGrep      string
+	logsColor     bool
+)
+
+type logComponent struct {
+	description string
+	selector    string
+	// release is the id of the release that installs the pods, if they are
+	// not created by a controller.
+	release   string
+	namespace func(cfg *config.Config) string
+}
+
+// selectorFor returns the label selector of the pods of c in the
+// installation of cfg. The pods of a release are also selected by its name,
+// which leaves out those of installs with another --release-prefix in the
+// same namespace.
+func (c logComponent) selectorFor(cfg *config.Config) string {
+	if c.release == "" {
+		return c.selector
+	}
+	return c.selector + ",app.kubernetes.io/instance=" + cfg.ReleasePrefix + c.release
+}
+
+var logComponents = map[string]logComponent{
+	"controller": {
+		description: "AI Gateway controller",
+		selector:    "app.kubernetes.io/name=ai-gateway-controller",
+		release:     "aieg",
+		namespace:   func(cfg *config.Config) string { return cfg.NamespaceAI },
+	},
+	"gateway": {
+		description: "Envoy Gateway control plane",
+		selector:    "control-plane=envoy-gateway",
+		release:     "eg",
+		namespace:   func(cfg *config.Config) string { return cfg.NamespaceGateway },
+	},
+	"proxy": {
+		description: "Envoy proxy",
+		selector:    "app.kubernetes.io/component=proxy,app.kubernetes.io/managed-by=envoy-gateway",
+		namespace:   func(cfg *config.Config) string { return cfg.NamespaceGateway },
+	},
+	"ratelimit": {
+		description: "Envoy rate limit service",
+		selector:    "app.kubernetes.io/component=ratelimit,app.kubernetes.io/managed-by=envoy-gateway",
+		namespace:   func(cfg *config.Config) string { return cfg.NamespaceGateway },
+	},
+}
+
+var logColors = []string{"\033[36m", "\033[33m", "\033[32m", "\033[35m", "\033[34m", "\033[31m"}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <controller|gateway|proxy|ratelimit>",
+	Short: "Show logs of the installed components",
+	Long: `Show logs of the AI Gateway controller, the Envoy Gateway control plane,
+the Envoy proxies or the rate limit service.
+
+Pods are resolved by label selector and the output of several pods is
+interleaved, with each line prefixed by the pod name.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"controller", "gateway", "proxy", "ratelimit"},
+	RunE:      runLogs,
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false,
+		"stream logs until interrupted")
+	logsCmd.Flags().Int64Var(&logsTail, "tail", -1,
+		"number of recent lines to show per pod (-1 for all)")
+	logsCmd.Flags().DurationVar(&logsSince, "since", 0,
+		"only show logs newer than a relative duration like 5m or 1h")
+	logsCmd.Flags().BoolVar(&logsPrevious, "previous", false,
+		"show logs of the previous container instance")
+	logsCmd.Flags().StringVarP(&logsContainer, "container", "c", "",
+		"container to show logs for (defaults to the pod's default container)")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "",
+		"only show lines matching this regular expression")
+	logsCmd.Flags().BoolVar(&logsColor, "color", false,
+		"color pod name prefixes")
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	component, ok := logComponents[args[0]]
+	if !ok {
+		return usageError(fmt.Errorf("unknown component %q (expected one of: %s)",
+			args[0], strings.Join(cmd.ValidArgs, ", ")))
+	}
+
+	var grep *regexp.Regexp
+	if logsGrep != "" {
+		var err error
+		grep, err = regexp.Compile(logsGrep)
+		if err != nil {
+			return fmt.Errorf("invalid --grep expression: %w", err)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := notifyInterrupt(context.Background())
+	defer stop()
+
+	namespace := component.namespace(cfg)
+	selector := component.selectorFor(cfg)
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list %s pods: %w", component.description, err)
+	}
+
+	if len(pods.Items) == 0 {
+		output.Printf("ℹ️  No %s pods found in namespace %s (selector %s)\n",
+			component.description, namespace, selector)
+		output.Println("   Is the component installed? Run 'envoy-ai-installer status' to check.")
+		return nil
+	}
+
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+
+	lines := make(chan string)
+	errs := make(chan error, len(pods.Items))
+	var wg sync.WaitGroup
+
+	for i, pod := range pods.Items {
+		prefix := fmt.Sprintf("[%s]", pod.Name)
+		if logsColor && output.ColorEnabled() {
+			prefix = logColors[i%len(logColors)] + prefix + "\033[0m"
+		}
+
+		wg.Add(1)
+		go func(pod corev1.Pod, prefix string) {
+			defer wg.Done()
+			if err := streamPodLogs(ctx, clientset, pod, prefix, grep, lines); err != nil {
+				errs <- err
+			}
+		}(pod, prefix)
+	}
+
+	go func() {
+		wg.Wait()
+		close(lines)
+		close(errs)
+	}()
+
+	for line := range lines {
+		fmt.Println(line)
+	}
+
+	for err := range errs {
+		if ctx.Err() == nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func streamPodLogs(ctx context.Context, clientset kubernetes.Interface, pod corev1.Pod,
+	prefix string, grep *regexp.Regexp, lines chan<- string) error {
+	opts := &corev1.PodLogOptions{
+		Container: logsContainer,
+		Follow:    logsFollow,
+		Previous:  logsPrevious,
+	}
+	if opts.Container == "" {
+		opts.Container = defaultContainer(pod)
+	}
+	if logsTail >= 0 {
+		opts.TailLines = &logsTail
+	}
+	if logsSince > 0 {
+		seconds := int64(logsSince.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+
+	stream, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get logs for pod %s: %w", pod.Name, err)
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if grep != nil && !grep.MatchString(line) {
+			continue
+		}
+
+		select {
+		case lines <- prefix + " " + line:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read logs for pod %s: %w", pod.Name, err)
+	}
+
+	return nil
+}
+
+func defaultContainer(pod corev1.Pod) string {
+	if name := pod.Annotations["kubectl.kubernetes.io/default-container"]; name != "" {
+		return name
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+	return ""
+}
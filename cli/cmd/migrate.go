@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// crdEstablishTimeout bounds the wait for applied CRDs to be served.
+const crdEstablishTimeout = 60 * time.Second
+
+var migrateBackupDir string
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the CRDs without upgrading the controllers",
+	Long: `Upgrade the Envoy Gateway and AI Gateway CRDs to the versions of the charts
+install would use, leaving the controllers as they are.
+
+Helm never upgrades the CRDs in a chart's crds/ directory, and CRDs are too
+large for client-side apply. migrate:
+
+1. backs up the CRDs installed in the cluster to a YAML file
+2. applies the new CRDs with kubectl apply --server-side
+3. waits for them to be established and, for CRDs that convert between
+   versions with a webhook, checks that the webhook service has ready
+   endpoints and that existing objects can be read at every served version
+
+Pin the versions with --tag, --envoy-gateway-tag and --ai-gateway-tag, then
+upgrade the controllers with 'install --skip-crds'. To roll back, apply the
+backup file with kubectl apply --server-side --force-conflicts.`,
+	RunE: runMigrate,
+}
+
+func init() {
+	migrateCmd.Flags().StringVar(&migrateBackupDir, "backup-dir", ".",
+		"directory to write the backup of the installed CRDs to")
+	migrateCmd.Flags().StringVar(&pinTag, "tag", "",
+		"GitHub release tag to pin both Envoy Gateway and AI Gateway to (default: latest stable release)")
+	migrateCmd.Flags().StringVar(&envoyGatewayTag, "envoy-gateway-tag", "",
+		"GitHub release tag of envoyproxy/gateway to pin, overriding --tag")
+	migrateCmd.Flags().StringVar(&aiGatewayTag, "ai-gateway-tag", "",
+		"GitHub release tag of envoyproxy/ai-gateway to pin, overriding --tag")
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	viper.BindPFlag("tag", cmd.Flags().Lookup("tag"))
+	viper.BindPFlag("envoy_gateway_tag", cmd.Flags().Lookup("envoy-gateway-tag"))
+	viper.BindPFlag("ai_gateway_tag", cmd.Flags().Lookup("ai-gateway-tag"))
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	isDryRun := viper.GetBool("dry_run")
+
+	output.Println("🔄 Migrating Envoy AI Gateway CRDs")
+	if err := resolveTags(cmd.Context(), cfg); err != nil {
+		return err
+	}
+
+	helmCmd := helm.NewHelmCommand(false)
+	var docs []string
+	var names []string
+	var sources []string
+	for _, id := range crdCharts {
+		r, _ := releaseByID(cfg, id)
+		manifest, err := renderChartCRDs(helmCmd, r)
+		if err != nil {
+			return fmt.Errorf("failed to render the CRDs of %s: %w", r.chart, err)
+		}
+		crds, crdNames, err := k8s.FilterCRDs(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to read the CRDs of %s: %w", r.chart, err)
+		}
+		output.Printf("  %-33s %s (%d CRDs)\n", r.chart+":", r.version, len(crdNames))
+
+		docs = append(docs, crds)
+		names = append(names, crdNames...)
+		sources = append(sources, fmt.Sprintf("%s %s", r.chart, r.version))
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("the charts contain no CRDs")
+	}
+	manifest := strings.Join(docs, "")
+
+	backupPath := filepath.Join(migrateBackupDir, crdBackupFileName(time.Now()))
+	applyArgs := []string{"apply", "--server-side", "--force-conflicts", "-f", "-"}
+
+	if isDryRun {
+		output.Printf("[DRY-RUN] kubectl get crd %s -o yaml --ignore-not-found > %s\n", strings.Join(names, " "), backupPath)
+		output.Printf("[DRY-RUN] kubectl %s (%d CRDs)\n", strings.Join(applyArgs, " "), len(names))
+		return nil
+	}
+
+	if err := confirmDestructive([]string{
+		fmt.Sprintf("back up the installed CRDs to %s", backupPath),
+		fmt.Sprintf("apply %d CRDs of %s with server-side apply", len(names), strings.Join(sources, " and ")),
+	}); err != nil {
+		return err
+	}
+
+	output.Print("\n💾 Backing up CRDs:     ")
+	backedUp, err := backupCRDs(names, backupPath)
+	if err != nil {
+		output.Println("❌ FAILED")
+		return err
+	}
+	if backedUp == 0 {
+		output.Println("⏭️  none installed")
+	} else {
+		output.Printf("✅ %d CRDs written to %s\n", backedUp, backupPath)
+	}
+
+	output.Println("📦 Applying CRDs...")
+	kubectl := k8s.Kubectl(applyArgs...)
+	kubectl.Stdin = strings.NewReader(manifest)
+	kubectl.Stdout = output.Stdout
+	kubectl.Stderr = output.Stderr
+	if err := kubectl.Run(); err != nil {
+		return fmt.Errorf("failed to apply CRDs: %w", err)
+	}
+
+	output.Print("⏳ Established:         ")
+	if err := waitCRDsEstablished(names); err != nil {
+		output.Println("❌ FAILED")
+		return err
+	}
+	output.Println("✅ all CRDs served")
+
+	if !verifyConversionWebhooks(names) {
+		return fmt.Errorf("conversion webhook verification failed; restore the previous CRDs with: kubectl apply --server-side --force-conflicts -f %s", backupPath)
+	}
+
+	output.Println("\n✅ CRDs migrated")
+	output.Println("   Upgrade the controllers with: envoy-ai-installer install --skip-crds")
+	return nil
+}
+
+// crdBackupFileName names the backup of the CRDs taken at t.
+func crdBackupFileName(t time.Time) string {
+	return fmt.Sprintf("envoy-ai-crds-%s.yaml", t.UTC().Format("20060102-150405"))
+}
+
+// backupCRDs writes the installed CRDs among names to path and returns how
+// many there were. Nothing is written when none is installed.
+func backupCRDs(names []string, path string) (int, error) {
+	args := append([]string{"get", "crd"}, names...)
+	out, err := k8s.Kubectl(append(args, "-o", "name", "--ignore-not-found")...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the installed CRDs: %w", err)
+	}
+	installed := strings.Fields(string(out))
+	if len(installed) == 0 {
+		return 0, nil
+	}
+
+	out, err = k8s.Kubectl(append([]string{"get"}, append(installed, "-o", "yaml")...)...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the installed CRDs: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("failed to write CRD backup: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		return 0, fmt.Errorf("failed to write CRD backup: %w", err)
+	}
+	return len(installed), nil
+}
+
+// waitCRDsEstablished waits until the API server serves the applied CRDs.
+func waitCRDsEstablished(names []string) error {
+	args := []string{"wait", "--for", "condition=established", "--timeout", crdEstablishTimeout.String()}
+	for _, name := range names {
+		args = append(args, "crd/"+name)
+	}
+
+	kubectl := k8s.Kubectl(args...)
+	if out, err := kubectl.CombinedOutput(); err != nil {
+		return fmt.Errorf("CRDs not established after %s: %s", crdEstablishTimeout, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// verifyConversionWebhooks checks the CRDs that convert between versions
+// with a webhook: the webhook must be reachable and every served version
+// of the existing objects readable, which makes the API server convert
+// them.
+func verifyConversionWebhooks(names []string) bool {
+	output.Print("🔍 Conversion webhooks: ")
+
+	webhooks := map[string]*k8s.ConversionWebhook{}
+	var webhookCRDs []string
+	for _, name := range names {
+		conversion, err := k8s.GetCRDConversion(name)
+		if err != nil {
+			output.Printf("❌ %v\n", err)
+			return false
+		}
+		if conversion.Strategy == "Webhook" {
+			webhooks[name] = conversion.Webhook
+			webhookCRDs = append(webhookCRDs, name)
+		}
+	}
+	if len(webhookCRDs) == 0 {
+		output.Println("ℹ️  none used")
+		return true
+	}
+	output.Printf("%d CRDs\n", len(webhookCRDs))
+
+	ok := true
+	for _, name := range webhookCRDs {
+		if problem := checkConversionWebhook(name, webhooks[name]); problem != "" {
+			output.Printf("   ❌ %s: %s\n", name, problem)
+			ok = false
+			continue
+		}
+		output.Printf("   ✅ %s\n", name)
+	}
+	return ok
+}
+
+// checkConversionWebhook returns what is wrong with the conversion webhook
+// of a CRD, or "".
+func checkConversionWebhook(name string, webhook *k8s.ConversionWebhook) string {
+	if webhook == nil {
+		return "Webhook strategy without a webhook"
+	}
+
+	client := webhook.ClientConfig
+	if client.Service != nil {
+		if client.CABundle == "" {
+			return fmt.Sprintf("no caBundle for service %s/%s; is the CA injector running?", client.Service.Namespace, client.Service.Name)
+		}
+		ready, err := k8s.ReadyEndpoints(client.Service.Namespace, client.Service.Name)
+		if err != nil {
+			return fmt.Sprintf("service %s/%s: %v", client.Service.Namespace, client.Service.Name, err)
+		}
+		if ready == 0 {
+			return fmt.Sprintf("service %s/%s has no ready endpoints", client.Service.Namespace, client.Service.Name)
+		}
+	}
+
+	versions, err := k8s.GetCRDVersions(name)
+	if err != nil {
+		return err.Error()
+	}
+	plural, group, _ := strings.Cut(name, ".")
+	for _, version := range k8s.ServedVersions(versions) {
+		resource := plural + "." + version + "." + group
+		if out, err := k8s.Kubectl("get", resource, "--all-namespaces", "-o", "name").CombinedOutput(); err != nil {
+			return fmt.Sprintf("reading objects as %s failed: %s", version, strings.TrimSpace(string(out)))
+		}
+	}
+	return ""
+}
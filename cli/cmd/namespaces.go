@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	terminatingTimeout time.Duration
+	forceFinalize      bool
+)
+
+// errNamespaceTerminating is returned while a namespace is still being
+// deleted.
+var errNamespaceTerminating = errors.New("namespace is terminating")
+
+// waitTerminatingNamespaces waits for the namespaces that are being deleted,
+// by the clean step or an earlier kubectl delete, to disappear: helm
+// --create-namespace and kubectl apply fail on them with "object is being
+// deleted". A namespace still terminating after timeout is reported with
+// the resources whose finalizers hold it, found through source, which force
+// removes.
+func waitTerminatingNamespaces(ctx context.Context, client k8s.KubeClient, source func() (*finalizerSource, error), namespaces []string, timeout time.Duration, force bool) error {
+	for _, namespace := range namespaces {
+		status, err := client.GetNamespaceStatus(namespace)
+		if err != nil {
+			return err
+		}
+		if !status.Terminating() {
+			continue
+		}
+
+		output.Printf("  ⏳ Namespace %s is terminating, waiting up to %s for it to be deleted\n", namespace, timeout)
+		if status, err = waitNamespaceDeleted(ctx, client, namespace, timeout); err != nil {
+			return err
+		}
+		if !status.Terminating() {
+			output.Printf("  ✅ Namespace %s deleted\n", namespace)
+			continue
+		}
+
+		finalizers, err := source()
+		if err != nil {
+			return err
+		}
+		blocking, err := finalizers.list(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		if !force || len(blocking) == 0 {
+			return stuckNamespaceError(namespace, status, blocking, timeout)
+		}
+
+		for _, s := range blocking {
+			if err := removeFinalizers(ctx, finalizers.client, s); err != nil {
+				return err
+			}
+		}
+		if status, err = waitNamespaceDeleted(ctx, client, namespace, timeout); err != nil {
+			return err
+		}
+		if status.Terminating() {
+			return fmt.Errorf("namespace %s is still terminating after removing the finalizers of its resources: %s",
+				namespace, strings.Join(status.Conditions, "; "))
+		}
+		output.Printf("  ✅ Namespace %s deleted\n", namespace)
+	}
+	return nil
+}
+
+// waitNamespaceDeleted waits up to timeout for a terminating namespace to
+// go, printing what the namespace controller still waits for whenever it
+// changes, and returns its last status.
+func waitNamespaceDeleted(parent context.Context, client k8s.KubeClient, namespace string, timeout time.Duration) (*k8s.NamespaceStatus, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	var status *k8s.NamespaceStatus
+	reported := ""
+	err := retryWithBackoff(ctx, time.Second, 5*time.Second, func() error {
+		var err error
+		if status, err = client.GetNamespaceStatus(namespace); err != nil {
+			return err
+		}
+		if !status.Terminating() {
+			return nil
+		}
+		if conditions := strings.Join(status.Conditions, "; "); conditions != reported {
+			output.Printf("     %s\n", conditions)
+			reported = conditions
+		}
+		return errNamespaceTerminating
+	})
+	switch {
+	case errors.Is(err, errNamespaceTerminating):
+		return status, nil
+	case err != nil:
+		return nil, err
+	}
+	return status, nil
+}
+
+// finalizerSource is where the finalizers holding a terminating namespace
+// are looked for: the namespaced resources of the cluster, read through a
+// dynamic client.
+type finalizerSource struct {
+	client    dynamic.Interface
+	resources []schema.GroupVersionResource
+}
+
+// clusterFinalizers returns the finalizerSource of the current cluster.
+func clusterFinalizers() (*finalizerSource, error) {
+	client, err := k8s.NewDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	resources, err := k8s.NamespacedResources()
+	if err != nil {
+		return nil, err
+	}
+	return &finalizerSource{client: client, resources: resources}, nil
+}
+
+// list returns the resources left in a namespace that carry finalizers.
+// Once their controllers are uninstalled nothing removes the finalizers,
+// and the namespace never finishes terminating.
+func (f *finalizerSource) list(ctx context.Context, namespace string) ([]stuckResource, error) {
+	var blocking []stuckResource
+	for _, gvr := range f.resources {
+		list, err := f.client.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			// Resources that cannot be listed, e.g. forbidden ones, are
+			// left to the conditions of the namespace.
+			continue
+		}
+		for i := range list.Items {
+			if len(list.Items[i].GetFinalizers()) > 0 {
+				blocking = append(blocking, stuckResource{gvr, &list.Items[i]})
+			}
+		}
+	}
+	return blocking, nil
+}
+
+func stuckNamespaceError(namespace string, status *k8s.NamespaceStatus, blocking []stuckResource, timeout time.Duration) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "namespace %s is still terminating after %s", namespace, timeout)
+	if len(status.Conditions) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(status.Conditions, "; "))
+	}
+	if len(blocking) == 0 {
+		b.WriteString("; check its remaining resources with 'kubectl api-resources --verbs=list --namespaced -o name | xargs -n 1 kubectl get --show-kind --ignore-not-found -n " + namespace + "'")
+		return &ExitError{Code: ExitCluster, Err: errors.New(b.String())}
+	}
+
+	b.WriteString("; it is held by the finalizers of:")
+	for _, s := range blocking {
+		fmt.Fprintf(&b, "\n  %s (%s)", objectRef(s.obj), strings.Join(s.obj.GetFinalizers(), ", "))
+	}
+	b.WriteString("\nTheir controllers may no longer be installed. Rerun with --force-finalize to remove these finalizers")
+	return &ExitError{Code: ExitCluster, Err: errors.New(b.String())}
+}
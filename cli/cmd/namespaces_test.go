@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const terminatingNamespace = "envoy-ai-gateway-system"
+
+// terminatingClient reports terminatingNamespace as terminating for its
+// first pending status reads, and then for as long as a resource in it has
+// finalizers, as the namespace controller would.
+type terminatingClient struct {
+	*k8s.FakeClient
+	dynamic dynamic.Interface
+	pending int
+}
+
+func (c *terminatingClient) GetNamespaceStatus(name string) (*k8s.NamespaceStatus, error) {
+	if name != terminatingNamespace {
+		return c.FakeClient.GetNamespaceStatus(name)
+	}
+	terminating := &k8s.NamespaceStatus{
+		Phase:      "Terminating",
+		Conditions: []string{"Some content in the namespace has finalizers remaining"},
+	}
+	if c.pending > 0 {
+		c.pending--
+		return terminating, nil
+	}
+
+	list, err := c.dynamic.Resource(routeGVR).Namespace(name).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range list.Items {
+		if len(item.GetFinalizers()) > 0 {
+			return terminating, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestWaitTerminatingNamespaces(t *testing.T) {
+	const finalizer = "aigateway.envoyproxy.io/finalizer"
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		pending    int
+		finalizers []string
+		force      bool
+		wantErr    []string
+		wantSource bool
+	}{
+		{name: "active namespace", namespaces: []string{"envoy-gateway-system"}},
+		{name: "deleted while waiting", namespaces: []string{terminatingNamespace}, pending: 1},
+		{
+			name:       "stuck on a finalizer",
+			namespaces: []string{terminatingNamespace},
+			finalizers: []string{finalizer},
+			wantErr:    []string{"held by the finalizers of", "AIGatewayRoute " + terminatingNamespace + "/chat (" + finalizer + ")", "--force-finalize"},
+			wantSource: true,
+		},
+		{
+			name:       "stuck on a finalizer with force",
+			namespaces: []string{terminatingNamespace},
+			finalizers: []string{finalizer},
+			force:      true,
+			wantSource: true,
+		},
+		{
+			name:       "stuck without finalizers found",
+			namespaces: []string{terminatingNamespace},
+			pending:    1 << 30,
+			force:      true,
+			wantErr:    []string{"still terminating after 20ms", "kubectl api-resources"},
+			wantSource: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynamicClient := newFinalizingClient(testRoute(terminatingNamespace, "chat", tt.finalizers...))
+			client := &terminatingClient{
+				FakeClient: &k8s.FakeClient{Namespaces: map[string]bool{"envoy-gateway-system": true}},
+				dynamic:    dynamicClient,
+				pending:    tt.pending,
+			}
+			sourced := false
+			source := func() (*finalizerSource, error) {
+				sourced = true
+				return &finalizerSource{client: dynamicClient, resources: []schema.GroupVersionResource{routeGVR}}, nil
+			}
+
+			err := waitTerminatingNamespaces(context.Background(), client, source, tt.namespaces, 20*time.Millisecond, tt.force)
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("got error %v, want one containing %q", err, want)
+				}
+			}
+			var exitErr *ExitError
+			if len(tt.wantErr) > 0 && (!errors.As(err, &exitErr) || exitErr.Code != ExitCluster) {
+				t.Errorf("got error %#v, want exit code %d", err, ExitCluster)
+			}
+			if sourced != tt.wantSource {
+				t.Errorf("finalizers looked up = %v, want %v", sourced, tt.wantSource)
+			}
+
+			if tt.force && len(tt.finalizers) > 0 {
+				route, err := dynamicClient.Resource(routeGVR).Namespace(terminatingNamespace).Get(context.Background(), "chat", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if finalizers := route.GetFinalizers(); len(finalizers) > 0 {
+					t.Errorf("finalizers %v left after --force-finalize", finalizers)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitTerminatingNamespacesClientError(t *testing.T) {
+	client := &k8s.FakeClient{Err: errors.New("connection refused")}
+	source := func() (*finalizerSource, error) {
+		t.Fatal("finalizers looked up without a namespace status")
+		return nil, nil
+	}
+
+	err := waitTerminatingNamespaces(context.Background(), client, source, []string{terminatingNamespace}, time.Second, false)
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("got error %v, want the client error", err)
+	}
+}
+
+func TestCheckNamespaceTerminating(t *testing.T) {
+	out := captureStdout(t)
+	client := &k8s.FakeClient{Statuses: map[string]*k8s.NamespaceStatus{
+		terminatingNamespace: {Phase: "Terminating", Conditions: []string{"Some resources are remaining: aigatewayroutes.aigateway.envoyproxy.io has 1 resource instances"}},
+	}}
+	if !checkNamespace(client, terminatingNamespace) {
+		t.Error("a terminating namespace failed the check, want a warning")
+	}
+	for _, want := range []string{"TERMINATING", "aigatewayroutes.aigateway.envoyproxy.io has 1 resource instances", "--force-finalize"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("got output %q, want %q", out.String(), want)
+		}
+	}
+}
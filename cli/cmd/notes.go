@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+	"github.com/spf13/viper"
+)
+
+const defaultNotesMaxLength = 2000
+
+var notesMaxLength int
+
+// releaseNoteSources maps the installed releases to the GitHub projects that
+// publish their release notes. The AI Gateway CRD chart shares the notes of
+// the controller.
+var releaseNoteSources = []struct {
+	id    string
+	owner string
+	repo  string
+	// collapse shows the full notes of the target release only and lists
+	// the intermediate releases, for projects that repeat their changes
+	// across release notes.
+	collapse bool
+}{
+	{"eg", "envoyproxy", "gateway", false},
+	{"aieg", "envoyproxy", "ai-gateway", true},
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s+`)
+	markdownLink    = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+)
+
+// printReleaseNotes prints the upstream release notes between the installed
+// version of each component and the latest release.
+func printReleaseNotes(ctx context.Context, cfg *config.Config) {
+	helmCmd := helm.NewHelmCommand(false)
+	maxLength := viper.GetInt("notes_max_length")
+
+	for _, source := range releaseNoteSources {
+		installed := ""
+		if cfg != nil {
+			r, _ := releaseByID(cfg, source.id)
+			if rel, err := helmCmd.FindRelease(r.name, r.namespace); err == nil && rel != nil {
+				installed = rel.ChartVersion()
+			}
+		}
+
+		repo := source.owner + "/" + source.repo
+		releases, err := upstream.ReleaseNotes(ctx, source.owner, source.repo, installed, "")
+		if err != nil {
+			output.Printf("\n⚠️  Could not fetch release notes for %s: %v\n", repo, err)
+			continue
+		}
+
+		if len(releases) == 0 {
+			output.Printf("\n📝 %s: %s is the latest release\n", repo, installed)
+			continue
+		}
+
+		if installed != "" {
+			output.Printf("\n📝 Release notes for %s (%s → %s)\n", repo, installed, releases[0].Tag)
+		} else {
+			output.Printf("\n📝 Release notes for %s %s\n", repo, releases[0].Tag)
+		}
+
+		shown := releases
+		if source.collapse && len(releases) > 1 {
+			shown = releases[:1]
+		}
+		for _, rel := range shown {
+			printRelease(rel, maxLength)
+		}
+
+		if len(shown) < len(releases) {
+			output.Printf("\n  Also included (%d earlier releases):\n", len(releases)-1)
+			for _, rel := range releases[1:] {
+				output.Printf("    %s (%s)  %s\n", rel.Tag, rel.PublishedAt.Local().Format("2006-01-02"), rel.URL)
+			}
+		}
+	}
+}
+
+func printRelease(rel upstream.Release, maxLength int) {
+	output.Printf("\n  ── %s (%s)\n", rel.Tag, rel.PublishedAt.Local().Format("2006-01-02"))
+
+	notes, truncated := upstream.TruncateNotes(rel.Notes, maxLength)
+	if notes == "" {
+		output.Println("  (no release notes)")
+	}
+	for _, line := range strings.Split(renderMarkdown(notes), "\n") {
+		output.Printf("  %s\n", line)
+	}
+	if truncated {
+		output.Printf("  … truncated, full notes: %s\n", rel.URL)
+	}
+}
+
+// renderMarkdown turns release notes into plain text: headings lose their
+// markers, links keep their text and emphasis markers are dropped.
+func renderMarkdown(notes string) string {
+	lines := strings.Split(notes, "\n")
+	for i, line := range lines {
+		line = markdownHeading.ReplaceAllString(line, "")
+		line = markdownLink.ReplaceAllString(line, "$1")
+		line = strings.NewReplacer("**", "", "__", "", "`", "").Replace(line)
+		if trimmed := strings.TrimLeft(line, " "); strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "- ") {
+			line = line[:len(line)-len(trimmed)] + "• " + trimmed[2:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/observability"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/state"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	serviceMonitorCRD = "servicemonitors." + observability.OperatorGroup
+	// prometheusOperatorInstallURL explains how to install the Operator and
+	// its CRDs.
+	prometheusOperatorInstallURL = "https://prometheus-operator.dev/docs/getting-started/installation/"
+)
+
+var (
+	withObservability   bool
+	installPrometheus   bool
+	monitoringNamespace string
+	generateOnly        bool
+	monitoringOutputDir string
+)
+
+var observabilityCmd = &cobra.Command{
+	Use:   "observability",
+	Short: "Enable metrics, Prometheus monitors and the Grafana dashboard on an existing install",
+	Long: `Enable observability on an existing installation, as install
+--with-observability does:
+
+- upgrades the Envoy Gateway and AI Gateway releases with the metrics values
+  (keeping their current values)
+- creates ServiceMonitor/PodMonitor resources when the Prometheus Operator
+  CRDs are present
+- loads the Envoy AI Gateway Grafana dashboard through a ConfigMap labelled
+  for the Grafana sidecar
+- installs kube-prometheus-stack ` + observability.PrometheusChartVersion + ` when --install-prometheus is given
+
+--prometheus-operator creates the monitors without detecting the Operator;
+--prometheus-operator=false writes a scrape config for a Prometheus without
+the Operator instead. --generate-only writes the resources to
+--monitoring-output-dir instead of applying them.
+
+Everything created here is removed by 'uninstall'.`,
+	RunE: runObservability,
+}
+
+func init() {
+	installCmd.Flags().BoolVar(&withObservability, "with-observability", false,
+		"enable metrics, Prometheus monitors and the Grafana dashboard")
+
+	for _, cmd := range []*cobra.Command{installCmd, observabilityCmd} {
+		cmd.Flags().BoolVar(&installPrometheus, "install-prometheus", false,
+			"also install kube-prometheus-stack "+observability.PrometheusChartVersion)
+		cmd.Flags().StringVar(&monitoringNamespace, "monitoring-namespace", observability.DefaultNamespace,
+			"namespace of Prometheus and Grafana, where the dashboard ConfigMap is created")
+		cmd.Flags().Bool("prometheus-operator", false,
+			"create ServiceMonitor/PodMonitor for the Prometheus Operator; false writes a Prometheus scrape config instead (default: detect the Operator)")
+		cmd.Flags().BoolVar(&generateOnly, "generate-only", false,
+			"write the monitors, dashboard and scrape config to --monitoring-output-dir instead of applying them")
+		cmd.Flags().StringVar(&monitoringOutputDir, "monitoring-output-dir", ".",
+			"directory --generate-only and the scrape config write their files to")
+	}
+
+	viper.BindPFlag("with_observability", installCmd.Flags().Lookup("with-observability"))
+}
+
+func runObservability(cmd *cobra.Command, args []string) error {
+	if err := checkObservabilityFlags(cmd); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cfg.Observability = true
+	isDryRun := viper.GetBool("dry_run")
+	if err := verifyPrometheusOperatorCRDs(); err != nil {
+		return err
+	}
+
+	output.Println("📈 Enabling observability")
+	output.Printf("  Monitoring Namespace: %s\n", monitoringNamespace)
+	if generateOnly {
+		output.Printf("  Generate Only:        %s\n", monitoringOutputDir)
+	}
+	output.Printf("  Dry Run:              %v\n", isDryRun)
+
+	helmCmd := helm.NewHelmCommand(isDryRun)
+
+	output.Println("\n📋 Enabling metrics on the installed releases...")
+	if err := enableReleaseMetrics(helmCmd, cfg); err != nil {
+		return err
+	}
+
+	if err := setupObservability(helmCmd, cfg, isDryRun); err != nil {
+		return err
+	}
+
+	if !isDryRun {
+		if err := recordObservabilityState(cfg); err != nil {
+			output.Printf("\n⚠️  Could not record installation state: %v\n", err)
+		}
+	}
+
+	output.Println("\n✅ Observability enabled!")
+	return nil
+}
+
+// enableReleaseMetrics applies the observability values overlay to the
+// releases that are already installed, keeping their current values.
+func enableReleaseMetrics(helmCmd *helm.HelmCommand, cfg *config.Config) error {
+	if err := helmCmd.RepoAdd("envoyproxy", "oci://docker.io/envoyproxy"); err != nil {
+		return err
+	}
+
+	lookup := helm.NewHelmCommand(false)
+	for _, id := range []string{"eg", "aieg"} {
+		r, _ := releaseByID(cfg, id)
+
+		rel, err := lookup.FindRelease(r.name, r.namespace)
+		if err != nil {
+			return err
+		}
+		if rel == nil {
+			output.Printf("  ℹ️  %s is not installed, skipping\n", r.name)
+			continue
+		}
+
+		values, err := overlayValues(cfg, id)
+		if err != nil {
+			return err
+		}
+		opts := &helm.HelmOptions{
+			Namespace:   r.namespace,
+			Values:      values,
+			Version:     rel.ChartVersion(),
+			ReuseValues: true,
+		}
+		if err := helmCmd.Install(r.name, r.chart, r.namespace, opts); err != nil {
+			return fmt.Errorf("failed to enable metrics on %s: %w", r.name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkObservabilityFlags binds --prometheus-operator of the running command
+// and rejects combinations that contradict each other.
+func checkObservabilityFlags(cmd *cobra.Command) error {
+	viper.BindPFlag("prometheus_operator", cmd.Flags().Lookup("prometheus-operator"))
+	if installPrometheus && viper.IsSet("prometheus_operator") && !viper.GetBool("prometheus_operator") {
+		return usageError(errors.New("--install-prometheus installs the Prometheus Operator and cannot be used with --prometheus-operator=false"))
+	}
+	return nil
+}
+
+// prometheusOperatorRequired reports whether --prometheus-operator asks for
+// the monitors of an Operator the installer does not install itself.
+func prometheusOperatorRequired() bool {
+	return !installPrometheus && viper.IsSet("prometheus_operator") && viper.GetBool("prometheus_operator")
+}
+
+// verifyPrometheusOperatorCRDs fails before anything is installed when
+// --prometheus-operator is set and the ServiceMonitor CRD is missing, as
+// applying the monitors would fail after the releases were upgraded.
+func verifyPrometheusOperatorCRDs() error {
+	if !prometheusOperatorRequired() {
+		return nil
+	}
+	versions, err := k8s.GetCRDVersions(serviceMonitorCRD)
+	if err != nil {
+		output.Printf("  ⚠️  Could not check for CRD %s: %v\n", serviceMonitorCRD, err)
+		return nil
+	}
+	if versions == nil {
+		return &ExitError{Code: ExitPrerequisite, Err: fmt.Errorf(
+			"--prometheus-operator is set but CRD %s is not installed; install the Prometheus Operator (%s), use --install-prometheus, or pass --prometheus-operator=false for a scrape config",
+			serviceMonitorCRD, prometheusOperatorInstallURL)}
+	}
+	return nil
+}
+
+// observabilityRequested reports whether a flag that only makes sense with
+// observability is set, so that it implies --with-observability.
+func observabilityRequested() bool {
+	return installPrometheus || generateOnly || viper.IsSet("prometheus_operator")
+}
+
+// setupObservability installs kube-prometheus-stack when requested and
+// applies the monitors and the Grafana dashboard. Without the Prometheus
+// Operator it can write a scrape config for a plain Prometheus instead.
+func setupObservability(helmCmd *helm.HelmCommand, cfg *config.Config, isDryRun bool) error {
+	opts := observability.Options{
+		NamespaceGateway: cfg.NamespaceGateway,
+		Namespace:        monitoringNamespace,
+		ReleasePrefix:    cfg.ReleasePrefix,
+		Labels:           cfg.Labels,
+	}
+
+	if installPrometheus {
+		output.Println("\n📦 Installing kube-prometheus-stack...")
+		if err := installPrometheusStack(helmCmd, cfg); err != nil {
+			return fmt.Errorf("failed to install kube-prometheus-stack: %w", err)
+		}
+		opts.PrometheusRelease = prometheusReleaseName(cfg)
+		opts.Monitors = true
+	} else if viper.IsSet("prometheus_operator") {
+		opts.Monitors = viper.GetBool("prometheus_operator")
+	} else {
+		present, err := k8s.HasAPIGroup(observability.OperatorGroup)
+		if err != nil {
+			output.Printf("  ⚠️  Could not detect the Prometheus Operator: %v\n", err)
+		}
+		opts.Monitors = present
+	}
+
+	output.Println("\n📈 Creating Prometheus monitors and Grafana dashboard...")
+	scrapeConfig := ""
+	switch {
+	case opts.Monitors:
+	case viper.IsSet("prometheus_operator") || generateOnly:
+		output.Println("  ℹ️  Writing a Prometheus scrape config instead of ServiceMonitor/PodMonitor")
+		rendered, err := observability.ScrapeConfig(opts)
+		if err != nil {
+			return err
+		}
+		scrapeConfig = rendered
+	default:
+		output.Printf("  ℹ️  %s API not found; skipping ServiceMonitor/PodMonitor (use --install-prometheus to install the operator, or --prometheus-operator=false for a scrape config)\n",
+			observability.OperatorGroup)
+	}
+
+	manifest, err := observability.Manifests(opts)
+	if err != nil {
+		return err
+	}
+
+	// A scrape config belongs in the configuration of Prometheus, not in
+	// the cluster, so it is always written to a file.
+	if scrapeConfig != "" {
+		if err := writeMonitoringFile(observability.ScrapeConfigFile, scrapeConfig, isDryRun); err != nil {
+			return err
+		}
+		output.Println("  ℹ️  Add its scrape_configs to your Prometheus configuration")
+	}
+	if generateOnly {
+		if err := writeMonitoringFile(observability.ManifestFile, manifest, isDryRun); err != nil {
+			return err
+		}
+		output.Printf("  ℹ️  Apply it with: kubectl apply -f %s\n", filepath.Join(monitoringOutputDir, observability.ManifestFile))
+		return nil
+	}
+
+	if isDryRun {
+		printDryRunApply(manifest)
+		return nil
+	}
+
+	kubectl := k8s.Kubectl("apply", "-f", "-")
+	kubectl.Stdin = strings.NewReader(manifest)
+	kubectl.Stdout = output.Stdout
+	kubectl.Stderr = output.Stderr
+	if err := kubectl.Run(); err != nil {
+		return fmt.Errorf("failed to apply observability resources: %w", err)
+	}
+
+	return nil
+}
+
+// writeMonitoringFile writes a generated file to --monitoring-output-dir.
+func writeMonitoringFile(name, content string, isDryRun bool) error {
+	path := filepath.Join(monitoringOutputDir, name)
+	if isDryRun {
+		output.Printf("[DRY-RUN] Would write %s:\n%s", path, content)
+		return nil
+	}
+	if err := os.MkdirAll(monitoringOutputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", monitoringOutputDir, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	output.Printf("  📄 Wrote %s\n", path)
+	return nil
+}
+
+func installPrometheusStack(helmCmd *helm.HelmCommand, cfg *config.Config) error {
+	if err := helmCmd.RepoAdd(observability.PrometheusRepoName, observability.PrometheusRepoURL); err != nil {
+		return err
+	}
+
+	if err := helmCmd.RepoUpdate(); err != nil {
+		return err
+	}
+
+	opts := &helm.HelmOptions{
+		Namespace: monitoringNamespace,
+		SetString: labelValues(cfg),
+		Version:   observability.PrometheusChartVersion,
+	}
+
+	return helmCmd.Install(prometheusReleaseName(cfg), observability.PrometheusChart, monitoringNamespace, opts)
+}
+
+func prometheusReleaseName(cfg *config.Config) string {
+	return cfg.ReleasePrefix + observability.PrometheusReleaseName
+}
+
+// observabilityState describes the observability resources of this run,
+// keeping a Prometheus release installed by a previous run.
+func observabilityState(cfg *config.Config, previous *state.Observability) *state.Observability {
+	obs := &state.Observability{Namespace: monitoringNamespace}
+	if installPrometheus {
+		obs.PrometheusRelease = prometheusReleaseName(cfg)
+		obs.PrometheusNamespace = monitoringNamespace
+	} else if previous != nil {
+		obs.PrometheusRelease = previous.PrometheusRelease
+		obs.PrometheusNamespace = previous.PrometheusNamespace
+	}
+	return obs
+}
+
+// recordObservabilityState adds the observability resources to an existing
+// installation state.
+func recordObservabilityState(cfg *config.Config) error {
+	st, err := state.Load(cfg.NamespaceAI, cfg.ReleasePrefix)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		return fmt.Errorf("no installation state found in %s; run install first", cfg.NamespaceAI)
+	}
+
+	st.Observability = observabilityState(cfg, st.Observability)
+	st.UpdatedAt = time.Now().UTC()
+
+	return state.Save(cfg.NamespaceAI, st)
+}
+
+// removeObservability deletes the monitors and dashboards created by
+// setupObservability for the install with releasePrefix and the
+// kube-prometheus-stack release if the installer installed it.
+func removeObservability(obs *state.Observability, releasePrefix string, isDryRun bool) error {
+	kinds := "configmaps"
+	present, err := k8s.HasAPIGroup(observability.OperatorGroup)
+	if err != nil {
+		output.Printf("  ⚠️  Could not detect the Prometheus Operator: %v\n", err)
+	}
+	if present {
+		kinds = "servicemonitors.monitoring.coreos.com,podmonitors.monitoring.coreos.com," + kinds
+	}
+
+	args := []string{"delete", kinds, "--all-namespaces", "-l", observability.Selector(releasePrefix), "--ignore-not-found"}
+	if isDryRun {
+		printDryRunKubectl(args...)
+	} else {
+		kubectl := k8s.Kubectl(args...)
+		kubectl.Stdout = output.Stdout
+		kubectl.Stderr = output.Stderr
+		if err := kubectl.Run(); err != nil {
+			return fmt.Errorf("failed to delete observability resources: %w", err)
+		}
+	}
+
+	if obs.PrometheusRelease != "" {
+		helmCmd := helm.NewHelmCommand(isDryRun)
+		if err := helmCmd.Uninstall(obs.PrometheusRelease, obs.PrometheusNamespace); err != nil {
+			return fmt.Errorf("failed to uninstall %s: %w", obs.PrometheusRelease, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const openShiftRouteManifest = `apiVersion: route.openshift.io/v1
+kind: Route
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    app.kubernetes.io/managed-by: envoy-ai-installer
+spec:
+  to:
+    kind: Service
+    name: %s
+  port:
+    targetPort: %s
+`
+
+// resolveOpenShift enables OpenShift mode when the cluster serves the route
+// API, unless --openshift or the openshift config key decided it explicitly.
+func resolveOpenShift(cfg *config.Config) {
+	if viper.IsSet("openshift") {
+		return
+	}
+
+	detected, err := k8s.IsOpenShift()
+	if err != nil {
+		return
+	}
+	cfg.OpenShift = detected
+}
+
+func createOpenShiftRoute(cfg *config.Config, isDryRun bool) error {
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+
+	services, err := clientset.CoreV1().Services(cfg.NamespaceGateway).List(context.Background(), metav1.ListOptions{
+		LabelSelector: envoyProxySelector,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list Envoy services: %w", err)
+	}
+	if len(services.Items) == 0 {
+		output.Println("  ℹ️  No Envoy proxy service yet; create a Gateway and re-run install to expose it with a Route")
+		return nil
+	}
+
+	for _, svc := range services.Items {
+		if len(svc.Spec.Ports) == 0 {
+			continue
+		}
+
+		port := svc.Spec.Ports[0].Name
+		if port == "" {
+			port = fmt.Sprint(svc.Spec.Ports[0].Port)
+		}
+		manifest := fmt.Sprintf(openShiftRouteManifest, svc.Name, svc.Namespace, svc.Name, port)
+
+		if isDryRun {
+			printDryRunApply(manifest)
+			continue
+		}
+
+		cmd := k8s.Kubectl("apply", "-f", "-")
+		cmd.Stdin = strings.NewReader(manifest)
+		cmd.Stdout = output.Stdout
+		cmd.Stderr = output.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to create Route for %s: %w", svc.Name, err)
+		}
+	}
+
+	return nil
+}
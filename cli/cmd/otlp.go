@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/otlp"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// telemetrySession reports the running install or uninstall to the
+// --otel-endpoint collector. It is nil when telemetry is off.
+var telemetrySession *otlp.Session
+
+// startTelemetry starts reporting the run of cmd. Telemetry is opt-in:
+// nothing is sent without --otel-endpoint, and --no-telemetry turns it off
+// even when an endpoint is configured.
+func startTelemetry(cmd *cobra.Command, cfg *config.Config) error {
+	endpoint := viper.GetString("otel_endpoint")
+	if endpoint == "" || viper.GetBool("no_telemetry") {
+		return nil
+	}
+	if err := otlp.ValidateEndpoint(endpoint); err != nil {
+		return usageError(err)
+	}
+
+	session, err := otlp.Start(cmd.Context(), otlp.Options{
+		Endpoint: endpoint,
+		Version:  version.Get().Version,
+		Command:  cmd.Name(),
+		Attributes: map[string]string{
+			"namespace.gateway": cfg.NamespaceGateway,
+			"namespace.ai":      cfg.NamespaceAI,
+			"release_prefix":    cfg.ReleasePrefix,
+			"dry_run":           strconv.FormatBool(viper.GetBool("dry_run")),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	telemetrySession = session
+	return nil
+}
+
+// endTelemetry sends what was recorded. The run has already finished, so
+// an unreachable collector is only a warning.
+func endTelemetry(err error) {
+	if err := telemetrySession.End(err); err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  %v\n", err)
+	}
+	telemetrySession = nil
+}
+
+// recordInstalledCharts reports the chart version of every managed release
+// after a successful installation.
+func recordInstalledCharts(cfg *config.Config) {
+	for _, r := range managedReleases(cfg) {
+		telemetrySession.RecordChart(r.name, r.namespace, r.chart, r.version)
+	}
+}
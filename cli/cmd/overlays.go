@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/overlays"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+)
+
+var overlayFiles = map[string]string{}
+
+// overlayValues returns the values overlays of the enabled profiles for a
+// release, followed by the generated telemetry values. They are passed
+// before any user values files so those can still override them.
+func overlayValues(cfg *config.Config, id string) ([]string, error) {
+	var profiles []string
+	if cfg.Local {
+		profiles = append(profiles, overlays.ProfileLocal)
+	}
+	if cfg.OpenShift {
+		profiles = append(profiles, overlays.ProfileOpenShift)
+	}
+	if cfg.Observability {
+		profiles = append(profiles, overlays.ProfileObservability)
+	}
+
+	var files []string
+	for _, profile := range profiles {
+		data, ok := overlays.Get(profile, id)
+		if !ok {
+			continue
+		}
+
+		file, err := writeOverlay(profile, id, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write the %s values overlay: %w", profile, err)
+		}
+		files = append(files, file)
+	}
+
+	file, err := telemetryValuesFile(cfg, id)
+	if err != nil {
+		return nil, err
+	}
+	if file != "" {
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// writeOverlay writes the overlay of profile for release id to a temporary
+// file, once per run, which is removed when the command exits.
+func writeOverlay(profile, id string, data []byte) (string, error) {
+	key := profile + "/" + id
+	if file, ok := overlayFiles[key]; ok {
+		return file, nil
+	}
+
+	file, err := values.WriteTemp(fmt.Sprintf("envoy-ai-%s-%s-*.yaml", profile, id), data)
+	if err != nil {
+		return "", err
+	}
+	overlayFiles[key] = file
+	return file, nil
+}
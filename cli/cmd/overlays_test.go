@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/overlays"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/telemetry"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+)
+
+func TestOverlayValues(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	resetOverlayFiles(t)
+
+	cfg := &config.Config{
+		Local:     true,
+		OpenShift: true,
+		Telemetry: &telemetry.Options{Endpoint: "otel-collector:4317", Protocol: telemetry.ProtocolGRPC, Insecure: true, SampleRate: 1},
+	}
+	files, err := overlayValues(cfg, "eg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Profiles come in a fixed order, then the telemetry values.
+	want := []string{overlays.ProfileLocal, overlays.ProfileOpenShift, "telemetry"}
+	if len(files) != len(want) {
+		t.Fatalf("got files %v, want overlays %v", files, want)
+	}
+	for i, file := range files {
+		if !strings.HasPrefix(filepath.Base(file), "envoy-ai-"+want[i]+"-eg-") {
+			t.Errorf("file %d is %s, want the %s overlay", i, file, want[i])
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, ok := overlays.Get(want[i], "eg"); ok && string(data) != string(expected) {
+			t.Errorf("%s holds %q, want the embedded overlay", file, data)
+		}
+	}
+
+	again, err := overlayValues(cfg, "eg")
+	if err != nil || strings.Join(again, ",") != strings.Join(files, ",") {
+		t.Errorf("second call returned %v (error %v), want the same files", again, err)
+	}
+
+	values.RemoveTempFiles()
+	if left, _ := filepath.Glob(filepath.Join(dir, "envoy-ai-*")); len(left) > 0 {
+		t.Errorf("temporary files left after RemoveTempFiles: %v", left)
+	}
+}
+
+func TestOverlayValuesWriteError(t *testing.T) {
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+	resetOverlayFiles(t)
+
+	_, err := overlayValues(&config.Config{Local: true}, "eg")
+	if err == nil || !strings.Contains(err.Error(), "local values overlay") {
+		t.Errorf("got error %v, want the failed local overlay", err)
+	}
+}
+
+func resetOverlayFiles(t *testing.T) {
+	t.Helper()
+	overlayFiles = map[string]string{}
+	t.Cleanup(func() {
+		values.RemoveTempFiles()
+		overlayFiles = map[string]string{}
+	})
+}
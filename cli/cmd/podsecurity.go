@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+const podSecurityEnforceLabel = config.PodSecurityLabelPrefix + "enforce"
+
+var podSecurityStandards []string
+
+// formatPodSecurity prints the configured labels as mode=level entries.
+func formatPodSecurity(labels map[string]string) string {
+	entries := make([]string, 0, len(labels))
+	for key, value := range labels {
+		entries = append(entries, strings.TrimPrefix(key, config.PodSecurityLabelPrefix)+"="+value)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// applyPodSecurityLabels creates the installer's namespaces, or updates
+// existing ones, with the Pod Security Admission labels, so that the policy
+// is in place before helm creates any pod.
+func applyPodSecurityLabels(cfg *config.Config, isDryRun bool) error {
+	manifest, err := namespacesManifest(cfg)
+	if err != nil {
+		return err
+	}
+
+	if isDryRun {
+		printDryRunApply(manifest)
+		return nil
+	}
+
+	cmd := k8s.Kubectl("apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(manifest)
+	cmd.Stdout = output.Stdout
+	cmd.Stderr = output.Stderr
+
+	return cmd.Run()
+}
+
+// namespacesManifest returns the installer's namespaces, with the Pod
+// Security Admission labels when they are configured.
+func namespacesManifest(cfg *config.Config) (string, error) {
+	var docs []string
+	for _, namespace := range installNamespaces(cfg) {
+		metadata := map[string]interface{}{"name": namespace}
+		if len(cfg.PodSecurity) > 0 {
+			metadata["labels"] = cfg.PodSecurity
+		}
+		doc, err := marshalYAML(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata":   metadata,
+		})
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, doc)
+	}
+	return strings.Join(docs, "---\n"), nil
+}
+
+// checkPodSecurity reports the enforce level of the installer's namespaces
+// and warns when existing pods, such as those of the installed charts,
+// violate it. With pod_security_standards configured, the level install is
+// about to set is checked instead.
+func checkPodSecurity(client k8s.KubeClient, cfg *config.Config) {
+	for _, namespace := range installNamespaces(cfg) {
+		output.Printf("🔍 Pod Security '%s': ", namespace)
+
+		labels, err := client.GetNamespaceLabels(namespace)
+		if err != nil {
+			output.Printf("⚠️  %v\n", err)
+			continue
+		}
+
+		level := labels[podSecurityEnforceLabel]
+		source := "namespace label"
+		if configured := cfg.PodSecurity[podSecurityEnforceLabel]; configured != "" {
+			level, source = configured, "pod_security_standards"
+		}
+
+		switch {
+		case labels == nil && level == "":
+			output.Println("ℹ️  namespace not created yet, no policy enforced")
+			continue
+		case labels == nil:
+			output.Printf("ℹ️  namespace not created yet, install will enforce %s\n", level)
+			continue
+		case level == "":
+			output.Println("ℹ️  not enforced")
+			continue
+		}
+
+		violations, err := k8s.PodSecurityViolations(namespace, level)
+		if err != nil {
+			output.Printf("⚠️  enforces %s (%s), could not check pods: %v\n", level, source, err)
+			continue
+		}
+		if len(violations) == 0 {
+			output.Printf("✅ enforces %s (%s), no violating pods\n", level, source)
+			continue
+		}
+
+		output.Printf("⚠️  enforces %s (%s), but existing pods violate it\n", level, source)
+		for _, violation := range violations {
+			output.Printf("   %s\n", violation)
+		}
+		output.Println("   New or restarted pods will be rejected and helm upgrades can hang; lower the level or fix the pods' security contexts")
+	}
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/route"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+var backendSecurityPolicyGVR = schema.GroupVersionResource{
+	Group:    aiGatewayCRDGroup,
+	Version:  "v1alpha1",
+	Resource: "backendsecuritypolicies",
+}
+
+var (
+	policyNamespace string
+	policyOptions   route.Policy
+	policyOutput    string
+	policyTimeout   time.Duration
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Manage the BackendSecurityPolicies that hold provider credentials",
+}
+
+var policyAttachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Attach the credentials of a secret to an AIServiceBackend",
+	Long: `Generate and apply the BackendSecurityPolicy that authenticates the AI
+Gateway to the provider behind --backend, with the credentials of
+--credentials-secret:
+
+  provider           --auth                        secret keys
+  openai             api-key                       apiKey
+  anthropic          api-key                       apiKey
+  openai-compatible  api-key                       apiKey
+  azure-openai       api-key (default)             apiKey
+                     client-secret                 client-secret (--client-id, --tenant-id)
+  aws-bedrock        credentials-file (default)    credentials (an AWS credentials file)
+                     oidc                          client-secret (--role-arn, --oidc-issuer, --oidc-client-id)
+
+Before applying, the AIServiceBackend must exist in --namespace, the secret
+must hold the keys the policy reads, and the AI Gateway webhooks must be
+serving. The status conditions of the policy are reported afterwards. -o
+yaml prints the policy instead, without contacting the cluster.`,
+	Example: `  envoy-ai-installer policy attach --backend openai-chat --provider openai --credentials-secret openai-key
+  envoy-ai-installer policy attach --backend bedrock --provider aws-bedrock --auth oidc \
+    --credentials-secret bedrock-oidc --role-arn arn:aws:iam::123456789012:role/ai-gateway \
+    --oidc-issuer https://token.example.com --oidc-client-id ai-gateway --region eu-west-1 -o yaml`,
+	RunE: runPolicyAttach,
+}
+
+func init() {
+	flags := policyAttachCmd.Flags()
+	flags.StringVar(&policyOptions.Backend, "backend", "", "AIServiceBackend the policy applies to")
+	flags.StringVar(&policyOptions.Name, "name", "", "name of the policy (default: the backend name)")
+	flags.StringVar(&policyOptions.Provider, "provider", "",
+		"provider of the backend: "+strings.Join(route.PolicyProviders, ", "))
+	flags.StringVar(&policyOptions.Auth, "auth", "",
+		"authentication method: api-key, client-secret (azure-openai), credentials-file or oidc (aws-bedrock) (default: the provider's first)")
+	flags.StringVar(&policyOptions.CredentialsSecret, "credentials-secret", "", "secret with the provider credentials")
+	flags.StringVar(&policyOptions.Region, "region", "", "AWS region of aws-bedrock (default \""+route.DefaultAWSRegion+"\")")
+	flags.StringVar(&policyOptions.RoleARN, "role-arn", "", "AWS role assumed with the OIDC token (--auth oidc)")
+	flags.StringVar(&policyOptions.OIDCIssuer, "oidc-issuer", "", "issuer URL of the OIDC provider (--auth oidc)")
+	flags.StringVar(&policyOptions.OIDCClientID, "oidc-client-id", "", "client ID at the OIDC provider (--auth oidc)")
+	flags.StringVar(&policyOptions.ClientID, "client-id", "", "client ID of the Azure service principal (--auth client-secret)")
+	flags.StringVar(&policyOptions.TenantID, "tenant-id", "", "tenant ID of the Azure service principal (--auth client-secret)")
+	flags.StringVarP(&policyNamespace, "namespace", "n", "default", "namespace of the backend, policy and credentials secret")
+	flags.StringVarP(&policyOutput, "output", "o", "", "print the policy instead of applying it: yaml")
+	flags.DurationVar(&policyTimeout, "wait-timeout", time.Minute,
+		"how long to wait for the AI Gateway webhooks, and then for the status of the policy")
+
+	policyCmd.AddCommand(policyAttachCmd)
+}
+
+func runPolicyAttach(cmd *cobra.Command, args []string) error {
+	if policyOutput != "" && policyOutput != "yaml" {
+		return usageError(fmt.Errorf("invalid --output %q (expected yaml)", policyOutput))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if policyTimeout, err = bindWaitTimeout(cmd); err != nil {
+		return err
+	}
+
+	policy := policyOptions
+	policy.Namespace = policyNamespace
+	policy.Labels = cfg.Labels
+	if policy.Name == "" {
+		policy.Name = policy.Backend
+	}
+	manifest, err := policy.Manifest()
+	if err != nil {
+		return usageError(err)
+	}
+
+	if policyOutput == "yaml" {
+		fmt.Print(manifest)
+		return nil
+	}
+	if viper.GetBool("dry_run") {
+		printDryRunApply(manifest)
+		return nil
+	}
+
+	cmd.SilenceUsage = true
+	ctx, stop := notifyInterrupt(cmd.Context())
+	defer stop()
+
+	output.Printf("🔑 Attaching %s to AIServiceBackend %s/%s\n", policy.CredentialsSecret, policy.Namespace, policy.Backend)
+	routeName, err := checkPolicyReferences(ctx, policy)
+	if err != nil {
+		return err
+	}
+	if routeName != "" {
+		// Keep the policy with the rest of the route, so that route
+		// delete removes it.
+		labels := map[string]string{route.Label: routeName}
+		for key, value := range policy.Labels {
+			labels[key] = value
+		}
+		policy.Labels = labels
+		if manifest, err = policy.Manifest(); err != nil {
+			return err
+		}
+	}
+	if err := waitAIGatewayWebhooks(ctx, cfg, policyTimeout); err != nil {
+		return err
+	}
+
+	kubectl := k8s.Kubectl("apply", "-f", "-")
+	kubectl.Stdin = strings.NewReader(manifest)
+	kubectl.Stdout = output.Stdout
+	kubectl.Stderr = output.Stderr
+	if err := kubectl.Run(); err != nil {
+		return fmt.Errorf("failed to apply BackendSecurityPolicy %s: %w", policy.Name, err)
+	}
+
+	rejected, err := reportConditions(ctx, policy.Namespace, policyTimeout, []statusResource{
+		{"BackendSecurityPolicy", backendSecurityPolicyGVR, policy.Name},
+	})
+	if err != nil {
+		return err
+	}
+	if rejected {
+		return &ExitError{Code: ExitVerification, Err: fmt.Errorf("BackendSecurityPolicy %s was applied but not accepted", policy.Name)}
+	}
+	output.Printf("\n✅ AIServiceBackend %s authenticates with %s\n", policy.Backend, policy.CredentialsSecret)
+	return nil
+}
+
+// checkPolicyReferences checks that the backend and the credentials secret
+// of a policy exist, and returns the route the backend belongs to, if it
+// was created by route add.
+func checkPolicyReferences(ctx context.Context, policy route.Policy) (string, error) {
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		return "", err
+	}
+	dynamicClient, err := k8s.NewDynamicClient()
+	if err != nil {
+		return "", err
+	}
+
+	backend, err := dynamicClient.Resource(aiServiceBackendGVR).Namespace(policy.Namespace).Get(ctx, policy.Backend, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return "", fmt.Errorf("AIServiceBackend %s/%s not found; create it with 'envoy-ai-installer route add'", policy.Namespace, policy.Backend)
+	case err != nil:
+		return "", fmt.Errorf("failed to read AIServiceBackend %s/%s: %w", policy.Namespace, policy.Backend, err)
+	}
+
+	if err := checkCredentialsSecret(ctx, clientset, policy.Namespace, policy.CredentialsSecret, policy.SecretKeys()); err != nil {
+		return "", err
+	}
+	return backend.GetLabels()[route.Label], nil
+}
+
+// checkCredentialsSecret checks that a secret holds the keys a
+// BackendSecurityPolicy reads, and tells how to create it otherwise.
+func checkCredentialsSecret(ctx context.Context, clientset kubernetes.Interface, namespace, name string, keys []string) error {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return fmt.Errorf("secret %s/%s not found; create it with: %s", namespace, name, createSecretCommand(namespace, name, keys))
+	case err != nil:
+		return fmt.Errorf("failed to read secret %s/%s: %w", namespace, name, err)
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if len(secret.Data[key]) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	found := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		found = append(found, key)
+	}
+	sort.Strings(found)
+	if len(found) == 0 {
+		found = []string{"none"}
+	}
+	return fmt.Errorf("secret %s/%s has no %s key (found: %s); recreate it with: %s",
+		namespace, name, strings.Join(missing, ", "), strings.Join(found, ", "), createSecretCommand(namespace, name, keys))
+}
+
+// createSecretCommand is the kubectl command creating a secret with keys.
+func createSecretCommand(namespace, name string, keys []string) string {
+	args := []string{"kubectl create secret generic", name, "-n", namespace}
+	for _, key := range keys {
+		if key == route.AWSCredentialsKey {
+			args = append(args, "--from-file="+key+"=$HOME/.aws/credentials")
+			continue
+		}
+		args = append(args, "--from-literal="+key+"=<"+strings.ToLower(key)+">")
+	}
+	return strings.Join(args, " ")
+}
@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const (
+	envoyGatewayAdminPort = 19000
+	portForwardRetryDelay = 2 * time.Second
+)
+
+var (
+	portForwardComponent  string
+	portForwardLocalPort  int
+	portForwardRemotePort int
+	portForwardAddresses  []string
+	portForwardGateway    string
+)
+
+type forwardTarget struct {
+	description string
+	namespace   string
+	selector    string
+	remotePort  int32
+	portName    string
+}
+
+var portForwardCmd = &cobra.Command{
+	Use:   "port-forward",
+	Short: "Forward a local port to the Envoy proxy or the Envoy Gateway admin port",
+	Long: `Forward a local port to the Envoy proxy Service of the installed Gateway,
+for clusters without a LoadBalancer (kind, minikube, ...).
+
+Use --component admin to reach the Envoy Gateway controller admin port
+instead. The forward is re-established automatically when the target pod
+restarts and runs until interrupted.`,
+	RunE: runPortForward,
+}
+
+func init() {
+	portForwardCmd.Flags().StringVar(&portForwardComponent, "component", "proxy",
+		"what to forward to: proxy or admin")
+	portForwardCmd.Flags().IntVarP(&portForwardLocalPort, "local-port", "p", 0,
+		"local port to listen on (default 8080 for proxy, 19000 for admin)")
+	portForwardCmd.Flags().IntVar(&portForwardRemotePort, "remote-port", 0,
+		"service port to forward to (default: the first port of the service)")
+	portForwardCmd.Flags().StringSliceVar(&portForwardAddresses, "address", []string{"localhost"},
+		"addresses to listen on (comma separated)")
+	portForwardCmd.Flags().StringVar(&portForwardGateway, "gateway", "",
+		"name of the Gateway whose proxy to forward to (default: the first one found)")
+}
+
+func runPortForward(cmd *cobra.Command, args []string) error {
+	if portForwardComponent != "proxy" && portForwardComponent != "admin" {
+		return usageError(fmt.Errorf("unknown component %q (expected proxy or admin)", portForwardComponent))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	restConfig, err := k8s.RESTConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	ctx, stop := notifyInterrupt(context.Background())
+	defer stop()
+
+	target, err := resolveForwardTarget(ctx, clientset, cfg)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		output.Println("ℹ️  No Envoy proxy service found.")
+		output.Printf("   Create a Gateway first, then check the services in %s.\n", cfg.NamespaceGateway)
+		return nil
+	}
+
+	localPort := portForwardLocalPort
+	if localPort == 0 {
+		localPort = 8080
+		if portForwardComponent == "admin" {
+			localPort = envoyGatewayAdminPort
+		}
+	}
+
+	output.Printf("🔌 Forwarding to %s\n", target.description)
+
+	connected := false
+	for {
+		pod, port, err := pickForwardPod(ctx, clientset, target)
+		if err == nil {
+			err = forwardPod(ctx, restConfig, clientset, pod, localPort, port, func() {
+				if !connected {
+					for _, addr := range portForwardAddresses {
+						output.Printf("   http://%s:%d -> %s:%d\n", addr, localPort, pod.Name, port)
+					}
+					output.Println("   Press Ctrl-C to stop.")
+					connected = true
+				} else {
+					output.Printf("🔁 Reconnected to %s\n", pod.Name)
+				}
+			})
+		}
+
+		if ctx.Err() != nil {
+			output.Println("\n👋 Port-forward stopped")
+			return nil
+		}
+
+		output.Printf("⚠️  %v; retrying in %s\n", err, portForwardRetryDelay)
+		select {
+		case <-time.After(portForwardRetryDelay):
+		case <-ctx.Done():
+			output.Println("\n👋 Port-forward stopped")
+			return nil
+		}
+	}
+}
+
+func resolveForwardTarget(ctx context.Context, clientset kubernetes.Interface, cfg *config.Config) (*forwardTarget, error) {
+	if portForwardComponent == "admin" {
+		port := int32(envoyGatewayAdminPort)
+		if portForwardRemotePort != 0 {
+			port = int32(portForwardRemotePort)
+		}
+		return &forwardTarget{
+			description: fmt.Sprintf("Envoy Gateway admin in %s", cfg.NamespaceGateway),
+			namespace:   cfg.NamespaceGateway,
+			selector:    logComponents["gateway"].selectorFor(cfg),
+			remotePort:  port,
+		}, nil
+	}
+
+	selector := envoyProxySelector
+	if portForwardGateway != "" {
+		selector += "," + owningGatewayLabel + "=" + portForwardGateway
+	}
+
+	services, err := clientset.CoreV1().Services(cfg.NamespaceGateway).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Envoy services: %w", err)
+	}
+	if len(services.Items) == 0 {
+		return nil, nil
+	}
+
+	svc := services.Items[0]
+	if len(svc.Spec.Ports) == 0 {
+		return nil, fmt.Errorf("service %s/%s exposes no ports", svc.Namespace, svc.Name)
+	}
+
+	svcPort := svc.Spec.Ports[0]
+	if portForwardRemotePort != 0 {
+		found := false
+		for _, p := range svc.Spec.Ports {
+			if p.Port == int32(portForwardRemotePort) {
+				svcPort, found = p, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("service %s/%s has no port %d", svc.Namespace, svc.Name, portForwardRemotePort)
+		}
+	}
+
+	target := &forwardTarget{
+		description: fmt.Sprintf("service %s/%s port %d", svc.Namespace, svc.Name, svcPort.Port),
+		namespace:   svc.Namespace,
+		selector:    k8slabels.SelectorFromSet(svc.Spec.Selector).String(),
+		remotePort:  svcPort.Port,
+	}
+
+	// Service ports may map to a named container port, which is resolved
+	// against the pod on every (re)connect.
+	if svcPort.TargetPort.Type == intstr.Int && svcPort.TargetPort.IntVal != 0 {
+		target.remotePort = svcPort.TargetPort.IntVal
+	} else if svcPort.TargetPort.Type == intstr.String {
+		target.remotePort = 0
+		target.portName = svcPort.TargetPort.StrVal
+	}
+
+	return target, nil
+}
+
+func pickForwardPod(ctx context.Context, clientset kubernetes.Interface, target *forwardTarget) (*corev1.Pod, int32, error) {
+	pods, err := clientset.CoreV1().Pods(target.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: target.selector,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil || !podReady(pod) {
+			continue
+		}
+
+		if target.remotePort != 0 {
+			return pod, target.remotePort, nil
+		}
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == target.portName {
+					return pod, p.ContainerPort, nil
+				}
+			}
+		}
+		return nil, 0, fmt.Errorf("pod %s has no port named %q", pod.Name, target.portName)
+	}
+
+	return nil, 0, fmt.Errorf("no ready pods match %s in %s", target.selector, target.namespace)
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// forwardPod runs a port-forward to the pod until the connection is lost or
+// ctx is cancelled. onReady is called once the local listener is up.
+func forwardPod(ctx context.Context, restConfig *rest.Config, clientset kubernetes.Interface,
+	pod *corev1.Pod, localPort int, remotePort int32, onReady func()) error {
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to set up port-forward: %w", err)
+	}
+
+	url := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(pod.Namespace).Name(pod.Name).
+		SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+
+	forwarder, err := portforward.NewOnAddresses(dialer, portForwardAddresses, ports, stopCh, readyCh, io.Discard, output.Stderr)
+	if err != nil {
+		return fmt.Errorf("failed to set up port-forward: %w", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-readyCh:
+		onReady()
+	case err := <-errCh:
+		return fmt.Errorf("port-forward to %s failed: %w", pod.Name, err)
+	case <-ctx.Done():
+		close(stopCh)
+		<-errCh
+		return nil
+	}
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			err = fmt.Errorf("connection closed")
+		}
+		return fmt.Errorf("lost connection to %s: %w", pod.Name, err)
+	case <-ctx.Done():
+		close(stopCh)
+		<-errCh
+		return nil
+	}
+}
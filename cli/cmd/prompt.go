@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/ui"
+	"github.com/spf13/viper"
+)
+
+func prompter() *ui.Prompter {
+	return ui.NewPrompter(viper.GetBool("yes") || viper.GetBool("non_interactive"))
+}
+
+// confirmDestructive lists actions and the kube context they target, and
+// asks for confirmation unless --yes or --non-interactive is set.
+func confirmDestructive(actions []string) error {
+	kubeContext := k8s.CurrentContext()
+	if kubeContext == "" {
+		kubeContext = "(none; in-cluster or default configuration)"
+	}
+
+	return prompter().ConfirmActions("⚠️  The following changes may cause downtime:", actions,
+		"Kube context: "+kubeContext)
+}
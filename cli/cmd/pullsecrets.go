@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+)
+
+var (
+	imagePullSecrets []string
+	dockerConfigJSON string
+)
+
+// imagePullSecretValues returns the Helm overrides that add the configured
+// pull secrets to the workloads of the release with the given id.
+func imagePullSecretValues(cfg *config.Config, id string) []string {
+	var format string
+	switch id {
+	case "eg":
+		format = "global.imagePullSecrets[%d].name=%s"
+	case "aieg":
+		format = "controller.imagePullSecrets[%d].name=%s"
+	case redisReleaseName:
+		// Bitnami charts take plain secret names.
+		format = "global.imagePullSecrets[%d]=%s"
+	default:
+		return nil
+	}
+
+	values := make([]string, 0, len(cfg.ImagePullSecrets))
+	for i, name := range cfg.ImagePullSecrets {
+		values = append(values, fmt.Sprintf(format, i, name))
+	}
+	return values
+}
+
+// loadDockerConfigJSON reads a Docker config file (as written by docker
+// login) to be stored in a kubernetes.io/dockerconfigjson secret.
+func loadDockerConfigJSON(path string) (redact.Secret, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Docker config: %w", err)
+	}
+
+	var dockerConfig struct {
+		Auths map[string]json.RawMessage `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &dockerConfig); err != nil {
+		return "", fmt.Errorf("invalid Docker config %s: %w", path, err)
+	}
+	if len(dockerConfig.Auths) == 0 {
+		return "", fmt.Errorf("docker config %s has no registry credentials under \"auths\"", path)
+	}
+
+	return redact.Secret(data), nil
+}
+
+// installNamespaces returns the namespaces that run installed workloads: the
+// gateway namespace, which also hosts the Envoy proxies, and the AI Gateway
+// namespace.
+func installNamespaces(cfg *config.Config) []string {
+	if cfg.NamespaceAI == cfg.NamespaceGateway {
+		return []string{cfg.NamespaceGateway}
+	}
+	return []string{cfg.NamespaceGateway, cfg.NamespaceAI}
+}
+
+// createPullSecret creates or updates the first configured pull secret from
+// the Docker config in every namespace that runs installed workloads.
+func createPullSecret(cfg *config.Config, dockerConfig redact.Secret, isDryRun bool) error {
+	manifest, err := pullSecretManifest(cfg, dockerConfig, isDryRun)
+	if err != nil {
+		return err
+	}
+
+	if isDryRun {
+		printDryRunApply(manifest)
+		return nil
+	}
+
+	cmd := k8s.Kubectl("apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(manifest)
+	cmd.Stdout = output.Stdout
+	cmd.Stderr = output.Stderr
+
+	return cmd.Run()
+}
+
+// pullSecretManifest returns the installer's namespaces and the pull secret
+// in each of them. With redacted the Docker config is left out.
+func pullSecretManifest(cfg *config.Config, dockerConfig redact.Secret, redacted bool) (string, error) {
+	name := cfg.ImagePullSecrets[0]
+
+	// Keep the Pod Security labels, which a later apply would otherwise
+	// remove.
+	namespaces, err := namespacesManifest(cfg)
+	if err != nil {
+		return "", err
+	}
+	docs := []string{namespaces}
+
+	for _, namespace := range installNamespaces(cfg) {
+		data := base64.StdEncoding.EncodeToString([]byte(dockerConfig.Reveal()))
+		if redacted {
+			data = "<redacted>"
+		}
+
+		doc, err := marshalYAML(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "kubernetes.io/dockerconfigjson",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]string{
+					"app.kubernetes.io/managed-by": "envoy-ai-installer",
+				},
+			},
+			"data": map[string]string{".dockerconfigjson": data},
+		})
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, doc)
+	}
+	return strings.Join(docs, "---\n"), nil
+}
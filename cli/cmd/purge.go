@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// purgeGroups are the API groups of the CRDs uninstall --purge-crds
+// deletes: those of the AI Gateway and of Envoy Gateway, which only the
+// uninstalled controllers serve.
+var purgeGroups = []string{aiGatewayCRDGroup, "gateway.envoyproxy.io"}
+
+// gatewayAPIGroups are the groups of the Gateway API CRDs. Other
+// controllers may implement them, so they are only purged with
+// --include-gateway-api.
+var gatewayAPIGroups = []string{"gateway.networking.k8s.io", "gateway.networking.x-k8s.io"}
+
+var crdGVR = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// purgeTimeout is how long the deleted custom resources may take to go
+// away before they are reported as stuck behind their finalizers.
+const purgeTimeout = time.Minute
+
+var (
+	purgeCRDs         bool
+	includeGatewayAPI bool
+	purgeForce        bool
+)
+
+// purgeCRD is a CRD to delete and its remaining custom resources.
+type purgeCRD struct {
+	name    string
+	gvr     schema.GroupVersionResource
+	objects []unstructured.Unstructured
+}
+
+func purgeGroupList() []string {
+	if includeGatewayAPI {
+		return append(slices.Clone(purgeGroups), gatewayAPIGroups...)
+	}
+	return purgeGroups
+}
+
+// collectPurgePlan lists the CRDs of groups and their custom resources in
+// every namespace.
+func collectPurgePlan(ctx context.Context, client dynamic.Interface, groups []string) ([]purgeCRD, error) {
+	crds, err := client.Resource(crdGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CRDs: %w", err)
+	}
+
+	var plan []purgeCRD
+	for _, crd := range crds.Items {
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		if !slices.Contains(groups, group) {
+			continue
+		}
+		plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+		p := purgeCRD{
+			name: crd.GetName(),
+			gvr:  schema.GroupVersionResource{Group: group, Version: crdListVersion(&crd), Resource: plural},
+		}
+
+		if p.gvr.Version != "" {
+			list, err := client.Resource(p.gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+			switch {
+			case apierrors.IsNotFound(err):
+				// Not established: there is nothing to delete.
+			case err != nil:
+				return nil, fmt.Errorf("failed to list %s: %w", p.name, err)
+			default:
+				p.objects = list.Items
+			}
+		}
+		plan = append(plan, p)
+	}
+
+	sort.Slice(plan, func(i, j int) bool { return plan[i].name < plan[j].name })
+	return plan, nil
+}
+
+// crdListVersion is the version the custom resources of a CRD are read
+// with: its storage version, or else the first one it serves.
+func crdListVersion(crd *unstructured.Unstructured) string {
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	served := ""
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(version, "name")
+		if storage, _, _ := unstructured.NestedBool(version, "storage"); storage {
+			return name
+		}
+		if ok, _, _ := unstructured.NestedBool(version, "served"); ok && served == "" {
+			served = name
+		}
+	}
+	return served
+}
+
+func objectRef(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetKind() + " " + obj.GetName()
+	}
+	return obj.GetKind() + " " + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// purgeActions lists what purging plan deletes, custom resources first.
+func purgeActions(plan []purgeCRD) []string {
+	var actions []string
+	for _, p := range plan {
+		for i := range p.objects {
+			actions = append(actions, "delete "+objectRef(&p.objects[i]))
+		}
+	}
+	for _, p := range plan {
+		actions = append(actions, "delete CRD "+p.name)
+	}
+	return actions
+}
+
+// purgeCustomResources deletes the CRDs of the AI Gateway and Envoy
+// Gateway, after the custom resources that the uninstalled controllers
+// would otherwise never finalize.
+func purgeCustomResources(ctx context.Context, isDryRun bool) error {
+	client, err := k8s.NewDynamicClient()
+	if err != nil {
+		return err
+	}
+	groups := purgeGroupList()
+	plan, err := collectPurgePlan(ctx, client, groups)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		output.Printf("ℹ️  No CRDs of %s left to purge\n", strings.Join(groups, ", "))
+		return nil
+	}
+
+	actions := purgeActions(plan)
+	if isDryRun {
+		for _, action := range actions {
+			output.Printf("[DRY-RUN] Would %s\n", action)
+		}
+		if purgeForce {
+			output.Printf("[DRY-RUN] Would remove the finalizers of custom resources still present after %s\n", purgeTimeout)
+		}
+		return nil
+	}
+
+	output.Printf("\n🧹 %d CRDs of %s remain, with %d custom resources\n",
+		len(plan), strings.Join(groups, ", "), len(actions)-len(plan))
+	if err := confirmDestructive(actions); err != nil {
+		return err
+	}
+
+	if err := deleteCustomResources(ctx, client, plan, purgeForce, purgeTimeout); err != nil {
+		return err
+	}
+	for _, p := range plan {
+		if err := client.Resource(crdGVR).Delete(ctx, p.name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete CRD %s: %w", p.name, err)
+		}
+		output.Printf("  ✅ Deleted CRD %s\n", p.name)
+	}
+	return nil
+}
+
+// deleteCustomResources deletes the custom resources of plan and waits for
+// them to go away. Resources still present after timeout are stuck behind
+// finalizers that no controller will remove any more: with force their
+// finalizers are removed, otherwise they are reported.
+func deleteCustomResources(ctx context.Context, client dynamic.Interface, plan []purgeCRD, force bool, timeout time.Duration) error {
+	for _, p := range plan {
+		for i := range p.objects {
+			obj := &p.objects[i]
+			err := client.Resource(p.gvr).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete %s: %w", objectRef(obj), err)
+			}
+		}
+	}
+
+	stuck, err := waitCustomResourcesGone(ctx, client, plan, timeout)
+	if err != nil || len(stuck) == 0 {
+		return err
+	}
+	if !force {
+		var refs []string
+		for _, s := range stuck {
+			refs = append(refs, fmt.Sprintf("%s (finalizers: %s)", objectRef(s.obj), strings.Join(s.obj.GetFinalizers(), ", ")))
+		}
+		return fmt.Errorf("%d custom resources are stuck deleting after %s: %s; rerun with --force to remove their finalizers",
+			len(stuck), timeout, strings.Join(refs, "; "))
+	}
+
+	for _, s := range stuck {
+		if err := removeFinalizers(ctx, client, s); err != nil {
+			return err
+		}
+	}
+
+	stuck, err = waitCustomResourcesGone(ctx, client, plan, timeout)
+	if err != nil {
+		return err
+	}
+	if len(stuck) > 0 {
+		return fmt.Errorf("%d custom resources are still present after removing their finalizers, e.g. %s", len(stuck), objectRef(stuck[0].obj))
+	}
+	return nil
+}
+
+// stuckResource is a resource that is still present after it was deleted,
+// usually behind finalizers.
+type stuckResource struct {
+	gvr schema.GroupVersionResource
+	obj *unstructured.Unstructured
+}
+
+// removeFinalizers clears the finalizers of a resource whose controller is
+// gone, so that its deletion completes.
+func removeFinalizers(ctx context.Context, client dynamic.Interface, s stuckResource) error {
+	patch := []byte(`{"metadata":{"finalizers":null}}`)
+	_, err := client.Resource(s.gvr).Namespace(s.obj.GetNamespace()).Patch(ctx, s.obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove the finalizers of %s: %w", objectRef(s.obj), err)
+	}
+	output.Printf("  ⚠️  Removed finalizers %s from %s\n", strings.Join(s.obj.GetFinalizers(), ", "), objectRef(s.obj))
+	return nil
+}
+
+// errResourcesRemain is returned while deleted custom resources are still
+// present.
+var errResourcesRemain = errors.New("custom resources remain")
+
+// waitCustomResourcesGone waits up to timeout for the custom resources of
+// plan to be deleted, and returns those still present.
+func waitCustomResourcesGone(parent context.Context, client dynamic.Interface, plan []purgeCRD, timeout time.Duration) ([]stuckResource, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	var stuck []stuckResource
+	err := retryWithBackoff(ctx, 500*time.Millisecond, 5*time.Second, func() error {
+		var err error
+		if stuck, err = remainingCustomResources(ctx, client, plan); err != nil {
+			return err
+		}
+		if len(stuck) > 0 {
+			return errResourcesRemain
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errResourcesRemain):
+		return stuck, nil
+	case err != nil:
+		return nil, err
+	}
+	return nil, nil
+}
+
+// remainingCustomResources returns the custom resources of plan that are
+// still present.
+func remainingCustomResources(ctx context.Context, client dynamic.Interface, plan []purgeCRD) ([]stuckResource, error) {
+	var stuck []stuckResource
+	for _, p := range plan {
+		for i := range p.objects {
+			ref := &p.objects[i]
+			obj, err := client.Resource(p.gvr).Namespace(ref.GetNamespace()).Get(ctx, ref.GetName(), metav1.GetOptions{})
+			switch {
+			case apierrors.IsNotFound(err):
+				continue
+			case err != nil:
+				return nil, fmt.Errorf("failed to read %s: %w", objectRef(ref), err)
+			}
+			stuck = append(stuck, stuckResource{p.gvr, obj})
+		}
+	}
+	return stuck, nil
+}
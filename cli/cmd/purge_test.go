@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var routeGVR = schema.GroupVersionResource{Group: aiGatewayCRDGroup, Version: "v1alpha1", Resource: "aigatewayroutes"}
+
+func TestCollectPurgePlan(t *testing.T) {
+	client := newFinalizingClient(
+		testCRD("aigatewayroutes."+aiGatewayCRDGroup, aiGatewayCRDGroup, "aigatewayroutes"),
+		testCRD("httproutes.gateway.networking.k8s.io", "gateway.networking.k8s.io", "httproutes"),
+		testRoute("default", "chat"),
+		testRoute("team-a", "embeddings", "aigateway.envoyproxy.io/finalizer"),
+	)
+
+	plan, err := collectPurgePlan(context.Background(), client, purgeGroups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 1 || plan[0].name != "aigatewayroutes."+aiGatewayCRDGroup {
+		t.Fatalf("got plan %+v, want only the AI Gateway CRD", plan)
+	}
+	if len(plan[0].objects) != 2 {
+		t.Errorf("got %d custom resources, want the 2 in every namespace", len(plan[0].objects))
+	}
+
+	want := []string{
+		"delete AIGatewayRoute default/chat",
+		"delete AIGatewayRoute team-a/embeddings",
+		"delete CRD aigatewayroutes." + aiGatewayCRDGroup,
+	}
+	if got := purgeActions(plan); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got actions %q, want %q", got, want)
+	}
+}
+
+func TestDeleteCustomResources(t *testing.T) {
+	const finalizer = "aigateway.envoyproxy.io/finalizer"
+
+	tests := []struct {
+		name       string
+		finalizers []string
+		force      bool
+		wantErr    string
+		wantGone   bool
+	}{
+		{name: "no finalizers", wantGone: true},
+		{name: "stuck without force", finalizers: []string{finalizer}, wantErr: "rerun with --force"},
+		{name: "stuck with force", finalizers: []string{finalizer}, force: true, wantGone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := testRoute("default", "chat", tt.finalizers...)
+			client := newFinalizingClient(route)
+			plan := []purgeCRD{{name: "aigatewayroutes." + aiGatewayCRDGroup, gvr: routeGVR, objects: []unstructured.Unstructured{*route}}}
+
+			err := deleteCustomResources(context.Background(), client, plan, tt.force, 50*time.Millisecond)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+			if tt.wantErr != "" && !strings.Contains(err.Error(), finalizer) {
+				t.Errorf("error %q does not name the finalizer", err)
+			}
+
+			_, err = client.Resource(routeGVR).Namespace("default").Get(context.Background(), "chat", metav1.GetOptions{})
+			if gone := apierrors.IsNotFound(err); gone != tt.wantGone {
+				t.Errorf("resource gone = %v, want %v (get error: %v)", gone, tt.wantGone, err)
+			}
+		})
+	}
+}
+
+// newFinalizingClient returns a fake dynamic client that, like the API
+// server, only marks objects with finalizers as deleting, and removes them
+// once their finalizers are cleared.
+func newFinalizingClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdGVR:   "CustomResourceDefinitionList",
+		routeGVR: "AIGatewayRouteList",
+	}, objects...)
+	tracker := client.Tracker()
+
+	client.PrependReactor("delete", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		del := action.(k8stesting.DeleteAction)
+		obj, err := tracker.Get(del.GetResource(), del.GetNamespace(), del.GetName())
+		if err != nil {
+			return false, nil, nil
+		}
+		u := obj.(*unstructured.Unstructured)
+		if len(u.GetFinalizers()) == 0 {
+			return false, nil, nil
+		}
+		now := metav1.Now()
+		u.SetDeletionTimestamp(&now)
+		return true, nil, tracker.Update(del.GetResource(), u, del.GetNamespace())
+	})
+
+	patch := k8stesting.ObjectReaction(tracker)
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		handled, obj, err := patch(action)
+		if err != nil {
+			return handled, obj, err
+		}
+		u := obj.(*unstructured.Unstructured)
+		if u.GetDeletionTimestamp() != nil && len(u.GetFinalizers()) == 0 {
+			err = tracker.Delete(action.GetResource(), action.GetNamespace(), u.GetName())
+		}
+		return handled, obj, err
+	})
+
+	return client
+}
+
+func testCRD(name, group, plural string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"group": group,
+			"names": map[string]interface{}{"plural": plural},
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "storage": true},
+			},
+		},
+	}}
+}
+
+func testRoute(namespace, name string, finalizers ...string) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": routeGVR.GroupVersion().String(),
+		"kind":       "AIGatewayRoute",
+		"metadata":   map[string]interface{}{"namespace": namespace, "name": name},
+	}}
+	route.SetFinalizers(finalizers)
+	return route
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+const (
+	// redisAuthSecretName is the secret holding the password of an external
+	// Redis, in the gateway namespace where the rate limit service runs. It
+	// is prefixed with the release prefix.
+	redisAuthSecretName = "envoy-redis-auth"
+	redisAuthSecretKey  = "password"
+
+	// redisPingTimeout bounds the connection to an external Redis and its
+	// replies.
+	redisPingTimeout = 5 * time.Second
+)
+
+func redisAuthSecret(cfg *config.Config) string {
+	return cfg.ReleasePrefix + redisAuthSecretName
+}
+
+func redisExternalAddress(redis *config.RedisExternal) string {
+	return net.JoinHostPort(redis.Host, strconv.Itoa(redis.Port))
+}
+
+// redisExternalValues returns the Envoy Gateway overrides that point its
+// rate limit service at the external Redis, in place of the in-cluster
+// address of the official values. The rate limit service reads the password
+// from REDIS_AUTH.
+func redisExternalValues(cfg *config.Config, id string) []string {
+	if id != "eg" || cfg.RedisExternal == nil {
+		return nil
+	}
+	values := []string{
+		"config.envoyGateway.rateLimit.backend.type=Redis",
+		"config.envoyGateway.rateLimit.backend.redis.url=" + redisExternalAddress(cfg.RedisExternal),
+	}
+	if cfg.RedisExternal.Password == "" {
+		return values
+	}
+	env := "config.envoyGateway.provider.kubernetes.rateLimitDeployment.container.env[0]."
+	return append(values,
+		env+"name=REDIS_AUTH",
+		env+"valueFrom.secretKeyRef.name="+redisAuthSecret(cfg),
+		env+"valueFrom.secretKeyRef.key="+redisAuthSecretKey,
+	)
+}
+
+// createRedisAuthSecret creates or updates the secret with the password of
+// the external Redis.
+func createRedisAuthSecret(cfg *config.Config, isDryRun bool) error {
+	manifest, err := redisAuthSecretManifest(cfg, isDryRun)
+	if err != nil {
+		return err
+	}
+
+	if isDryRun {
+		printDryRunApply(manifest)
+		return nil
+	}
+
+	cmd := k8s.Kubectl("apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(manifest)
+	cmd.Stdout = output.Stdout
+	cmd.Stderr = output.Stderr
+
+	return cmd.Run()
+}
+
+// redisAuthSecretManifest returns the installer's namespaces, which Envoy
+// Gateway is not installed into yet, and the password secret. With redacted
+// the password is left out.
+func redisAuthSecretManifest(cfg *config.Config, redacted bool) (string, error) {
+	namespaces, err := namespacesManifest(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	data := base64.StdEncoding.EncodeToString([]byte(cfg.RedisExternal.Password.Reveal()))
+	if redacted {
+		data = "<redacted>"
+	}
+	secret, err := marshalYAML(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "Opaque",
+		"metadata": map[string]interface{}{
+			"name":      redisAuthSecret(cfg),
+			"namespace": cfg.NamespaceGateway,
+			"labels": map[string]string{
+				"app.kubernetes.io/managed-by": "envoy-ai-installer",
+			},
+		},
+		"data": map[string]string{redisAuthSecretKey: data},
+	})
+	if err != nil {
+		return "", err
+	}
+	return namespaces + "---\n" + secret, nil
+}
+
+// pingRedis connects to an external Redis, authenticates with its password
+// if there is one, and checks that it answers PING.
+func pingRedis(ctx context.Context, redis *config.RedisExternal) error {
+	ctx, cancel := context.WithTimeout(ctx, redisPingTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", redisExternalAddress(redis))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	reader := bufio.NewReader(conn)
+	if redis.Password != "" {
+		if err := redisCommand(conn, reader, "+OK", "AUTH", redis.Password.Reveal()); err != nil {
+			return fmt.Errorf("AUTH failed: %w", err)
+		}
+	}
+	if err := redisCommand(conn, reader, "+PONG", "PING"); err != nil {
+		return fmt.Errorf("PING failed: %w", err)
+	}
+	return nil
+}
+
+// redisCommand sends a command in the Redis protocol and checks that the
+// reply is want.
+func redisCommand(w io.Writer, reader *bufio.Reader, want string, args ...string) error {
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(w, command.String()); err != nil {
+		return err
+	}
+
+	reply, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	reply = strings.TrimRight(reply, "\r\n")
+	switch {
+	case reply == want:
+		return nil
+	case strings.HasPrefix(reply, "-"):
+		return errors.New(strings.TrimPrefix(reply, "-"))
+	}
+	return fmt.Errorf("unexpected reply %q", reply)
+}
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/observability"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// installerManifestFile holds the objects install creates itself rather
+// than through a chart.
+const installerManifestFile = "installer.yaml"
+
+var (
+	renderOutputDir   string
+	renderKubeVersion string
+	renderAPIVersions []string
+)
+
+var renderCmd = &cobra.Command{
+	Use:     "render",
+	Aliases: []string{"template"},
+	Short:   "Print every Kubernetes object install would create, without a cluster",
+	Long: `Render the charts install would deploy, at their pinned versions and with the
+merged values, along with the objects the installer creates itself: the
+namespaces, the image pull secret, the EnvoyProxy and the observability
+monitors and dashboard.
+
+Nothing is read from or written to the cluster. Charts that look up cluster
+state render as for an empty cluster; describe the target cluster with
+--kube-version and --api-versions. Two objects depend on the cluster and are
+not rendered: the OpenShift Route, created for existing Envoy services, and
+the envoy-ai-installer-state ConfigMap that records the installed releases.
+
+The manifests are printed to stdout, or written to --output-dir with one file
+per release and installer.yaml. Secret data and credential-like values are
+masked as in dry runs.`,
+	RunE: runRender,
+}
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderOutputDir, "output-dir", "o", "",
+		"write one file per release to this directory instead of stdout")
+	renderCmd.Flags().StringVar(&renderKubeVersion, "kube-version", "",
+		"Kubernetes version the charts are rendered for, e.g. v1.30.0 (default: helm's)")
+	renderCmd.Flags().StringSliceVar(&renderAPIVersions, "api-versions", nil,
+		"API versions the cluster serves, e.g. monitoring.coreos.com/v1 (repeatable)")
+
+	renderCmd.Flags().StringSliceVar(&valuesExtra, "values-extra", nil,
+		"additional values files; prefix with gateway=, ai= or redis= to target a single release (repeatable)")
+	renderCmd.Flags().StringSliceVar(&labels, "labels", nil,
+		"labels to add to all created resources, as key=value pairs (repeatable)")
+	renderCmd.Flags().BoolVar(&withRedis, "with-redis", false,
+		"also render the Redis release")
+	renderCmd.Flags().StringVar(&pinTag, "tag", "",
+		"GitHub release tag to pin both Envoy Gateway and AI Gateway to (default: latest stable release)")
+	renderCmd.Flags().StringVar(&envoyGatewayTag, "envoy-gateway-tag", "",
+		"GitHub release tag of envoyproxy/gateway to pin, overriding --tag")
+	renderCmd.Flags().StringVar(&aiGatewayTag, "ai-gateway-tag", "",
+		"GitHub release tag of envoyproxy/ai-gateway to pin, overriding --tag")
+	renderCmd.Flags().BoolVar(&skipCompatCheck, "skip-compat-check", false,
+		"render even if the Envoy Gateway and AI Gateway versions are not a supported pair")
+	renderCmd.Flags().BoolVar(&skipCRDs, "skip-crds", false,
+		"leave out the CRD release and the CRDs of the other charts")
+	renderCmd.Flags().IntSliceVar(&skipSteps, "skip-steps", nil,
+		"leave out the releases of these install steps (2-4)")
+	renderCmd.Flags().StringVar(&dockerConfigJSON, "docker-config-json", "",
+		"Docker config.json to create the first image pull secret from")
+	renderCmd.Flags().BoolVar(&withObservability, "with-observability", false,
+		"also render the observability monitors and dashboard")
+	renderCmd.Flags().BoolVar(&installPrometheus, "install-prometheus", false,
+		"also render kube-prometheus-stack "+observability.PrometheusChartVersion)
+	renderCmd.Flags().StringVar(&monitoringNamespace, "monitoring-namespace", observability.DefaultNamespace,
+		"namespace of Prometheus and Grafana, where the dashboard ConfigMap is created")
+}
+
+// renderedFile is the manifest of one release, or of the installer's own
+// objects.
+type renderedFile struct {
+	name     string
+	manifest string
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	for key, flag := range map[string]string{
+		"values_extra":       "values-extra",
+		"labels":             "labels",
+		"tag":                "tag",
+		"envoy_gateway_tag":  "envoy-gateway-tag",
+		"ai_gateway_tag":     "ai-gateway-tag",
+		"skip_crds":          "skip-crds",
+		"skip_steps":         "skip-steps",
+		"docker_config_json": "docker-config-json",
+		"with_observability": "with-observability",
+	} {
+		viper.BindPFlag(key, cmd.Flags().Lookup(flag))
+	}
+	if err := checkSkipSteps(); err != nil {
+		return err
+	}
+
+	// Without --output-dir stdout carries the manifests only; progress,
+	// warnings and helm's own output go to stderr.
+	manifests := io.Writer(os.Stdout)
+	if renderOutputDir == "" {
+		defer output.MessagesToStderr()()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	var dockerConfig redact.Secret
+	if path := viper.GetString("docker_config_json"); path != "" {
+		if dockerConfig, err = loadDockerConfigJSON(path); err != nil {
+			return err
+		}
+	}
+	if err := fetchValuesExtra(cfg); err != nil {
+		return err
+	}
+
+	output.Println("📄 Rendering Envoy AI Gateway manifests")
+	if err := resolveTags(cmd.Context(), cfg); err != nil {
+		return err
+	}
+	if err := checkInstallCompatibility(cmd.Context(), cfg); err != nil {
+		return err
+	}
+
+	files, err := renderManifests(cfg, dockerConfig)
+	if err != nil {
+		return err
+	}
+
+	if renderOutputDir == "" {
+		for _, file := range files {
+			fmt.Fprint(manifests, file.manifest)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(renderOutputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to write manifests: %w", err)
+	}
+	for _, file := range files {
+		path := filepath.Join(renderOutputDir, file.name)
+		if err := os.WriteFile(path, []byte(file.manifest), 0o644); err != nil {
+			return fmt.Errorf("failed to write manifests: %w", err)
+		}
+		output.Printf("  ✅ %s\n", path)
+	}
+	output.Printf("\n✅ Wrote %d files to %s\n", len(files), renderOutputDir)
+	return nil
+}
+
+// renderManifests renders the installer's own objects and every release
+// install would deploy, in install order.
+func renderManifests(cfg *config.Config, dockerConfig redact.Secret) ([]renderedFile, error) {
+	installer, err := installerManifests(cfg, dockerConfig)
+	if err != nil {
+		return nil, err
+	}
+	files := []renderedFile{{installerManifestFile, installer}}
+
+	releases, err := resolveReleases(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	helmCmd := helm.NewHelmCommand(false)
+	if err := helmCmd.RepoAdd("envoyproxy", "oci://docker.io/envoyproxy"); err != nil {
+		return nil, err
+	}
+	if withRedis {
+		if err := helmCmd.RepoAdd("bitnami", "https://charts.bitnami.com/bitnami"); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Observability && installPrometheus {
+		if err := helmCmd.RepoAdd(observability.PrometheusRepoName, observability.PrometheusRepoURL); err != nil {
+			return nil, err
+		}
+	}
+	if err := helmCmd.RepoUpdate(); err != nil {
+		return nil, err
+	}
+
+	steps := map[string]int{"eg": stepEnvoyGateway, "aieg-crd": stepAIGatewayCRDs, "aieg": stepAIGatewayController}
+	for _, r := range releases {
+		if step, ok := steps[r.id]; ok && skippedStep(step) != "" {
+			output.Printf("  ⏭️  %s: %s\n", r.name, skippedStep(step))
+			continue
+		}
+
+		opts := r.helmOptions()
+		opts.IncludeCRDs = !viper.GetBool("skip_crds")
+		manifest, err := renderRelease(helmCmd, r.managedRelease, opts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, renderedFile{r.name + ".yaml", manifest})
+	}
+
+	if cfg.Observability && installPrometheus {
+		r := managedRelease{
+			id:        observability.PrometheusReleaseName,
+			name:      prometheusReleaseName(cfg),
+			namespace: monitoringNamespace,
+			chart:     observability.PrometheusChart,
+			version:   observability.PrometheusChartVersion,
+		}
+		manifest, err := renderRelease(helmCmd, r, &helm.HelmOptions{
+			Namespace:   monitoringNamespace,
+			SetString:   labelValues(cfg),
+			Version:     r.version,
+			IncludeCRDs: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, renderedFile{r.name + ".yaml", manifest})
+	}
+
+	return files, nil
+}
+
+// renderRelease renders a chart with helm template for the cluster
+// described by --kube-version and --api-versions.
+func renderRelease(helmCmd *helm.HelmCommand, r managedRelease, opts *helm.HelmOptions) (string, error) {
+	opts.KubeVersion = renderKubeVersion
+	opts.APIVersions = renderAPIVersions
+
+	manifest, err := helmCmd.Template(r.name, r.chart, r.namespace, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", r.name, err)
+	}
+	return documents(redactManifest(manifest)), nil
+}
+
+// installerManifests returns the objects install applies with kubectl
+// rather than through a chart.
+func installerManifests(cfg *config.Config, dockerConfig redact.Secret) (string, error) {
+	var docs []string
+
+	namespaces, err := namespacesManifest(cfg)
+	if dockerConfig != "" {
+		namespaces, err = pullSecretManifest(cfg, dockerConfig, true)
+	}
+	if err != nil {
+		return "", err
+	}
+	docs = append(docs, namespaces)
+
+	if needsEnvoyProxy(cfg) {
+		manifest, err := envoyProxyManifest(cfg)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, manifest)
+	}
+
+	if cfg.Observability {
+		opts := observability.Options{
+			NamespaceGateway: cfg.NamespaceGateway,
+			Namespace:        monitoringNamespace,
+			ReleasePrefix:    cfg.ReleasePrefix,
+			Labels:           cfg.Labels,
+			Monitors:         installPrometheus,
+		}
+		if installPrometheus {
+			opts.PrometheusRelease = prometheusReleaseName(cfg)
+		}
+		for _, v := range renderAPIVersions {
+			if strings.HasPrefix(v, observability.OperatorGroup+"/") {
+				opts.Monitors = true
+			}
+		}
+		if !opts.Monitors {
+			output.Printf("  ℹ️  Leaving out ServiceMonitor/PodMonitor; pass --api-versions %s/v1 if the cluster serves them\n",
+				observability.OperatorGroup)
+		}
+
+		manifest, err := observability.Manifests(opts)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, manifest)
+	}
+
+	if cfg.OpenShift && openShiftRoute {
+		output.Println("  ℹ️  Leaving out the OpenShift Route, which install creates for the Envoy services in the cluster")
+	}
+
+	for i, doc := range docs {
+		docs[i] = documents(doc)
+	}
+	return documents(redactManifest(strings.Join(docs, ""))), nil
+}
+
+// documents starts a YAML stream with a document separator, so that
+// manifests can be concatenated.
+func documents(manifest string) string {
+	return "---\n" + strings.TrimPrefix(manifest, "---\n")
+}
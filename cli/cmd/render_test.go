@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/spf13/viper"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// fakeHelmTemplate renders, for helm template, a ConfigMap describing the
+// arguments it got and a Secret, as a chart would.
+const fakeHelmTemplate = `#!/bin/sh
+[ "$1" = template ] || exit 0
+release=$2 chart=$3 values=0 crds=false
+shift 3
+while [ $# -gt 0 ]; do
+	case "$1" in
+	-n) namespace=$2; shift ;;
+	--version) version=$2; shift ;;
+	--kube-version) kube=$2; shift ;;
+	-f) values=$((values + 1)); shift ;;
+	--include-crds) crds=true ;;
+	--*) shift ;;
+	esac
+	shift
+done
+cat <<MANIFEST
+---
+# Source: $chart/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: $release-config
+  namespace: $namespace
+data:
+  chart: $chart
+  version: "$version"
+  valuesFiles: "$values"
+  includeCRDs: "$crds"
+  kubeVersion: "$kube"
+---
+# Source: $chart/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: $release-token
+  namespace: $namespace
+data:
+  token: c2VjcmV0
+MANIFEST
+`
+
+func TestRenderManifestsGolden(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake helm is a shell script")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "helm"), []byte(fakeHelmTemplate), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TMPDIR", t.TempDir())
+	resetOverlayFiles(t)
+
+	useOfficialValues(t, "deployment:\n  envoyGateway:\n    replicas: 1\n")
+
+	savedKubeVersion := renderKubeVersion
+	renderKubeVersion = "v1.30.0"
+	t.Cleanup(func() { renderKubeVersion = savedKubeVersion })
+
+	cfg := &config.Config{
+		NamespaceGateway: "envoy-gateway-system",
+		NamespaceAI:      "envoy-ai-gateway-system",
+		GatewayTag:       "v1.4.0",
+		AIGatewayTag:     "v0.3.0",
+		Local:            true,
+		Labels:           map[string]string{"team": "ai"},
+	}
+	files, err := renderManifests(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join("testdata", "render")
+	want := []string{installerManifestFile, "eg.yaml", "aieg-crd.yaml", "aieg.yaml"}
+	if len(files) != len(want) {
+		t.Fatalf("got %d files, want %v", len(files), want)
+	}
+	for i, file := range files {
+		if file.name != want[i] {
+			t.Errorf("file %d is %s, want %s", i, file.name, want[i])
+		}
+		path := filepath.Join(dir, file.name)
+		if *update {
+			if err := os.WriteFile(path, []byte(file.manifest), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		golden, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: no golden file (run go test -update): %v", file.name, err)
+		}
+		if file.manifest != string(golden) {
+			t.Errorf("%s differs from the golden file; got:\n%s\nwant:\n%s", file.name, file.manifest, golden)
+		}
+	}
+}
+
+// useOfficialValues serves values and its checksum as the official Envoy
+// Gateway values file.
+func useOfficialValues(t *testing.T, values string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha256") {
+			fmt.Fprintf(w, "%x  values.yaml\n", sha256.Sum256([]byte(values)))
+			return
+		}
+		fmt.Fprint(w, values)
+	}))
+	t.Cleanup(server.Close)
+	viper.Set("values_url", server.URL+"/values.yaml")
+	t.Cleanup(func() { viper.Set("values_url", "") })
+}
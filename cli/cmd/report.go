@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/report"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const reportMaxFileSize = 5 << 20
+
+var (
+	reportOutputDir string
+	reportLogLines  int64
+	reportRedact    bool
+)
+
+var gatewayAPIResources = []string{
+	"gateways.gateway.networking.k8s.io",
+	"httproutes.gateway.networking.k8s.io",
+	"grpcroutes.gateway.networking.k8s.io",
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Collect a diagnostics bundle for bug reports",
+	Long: `Collect everything needed to troubleshoot an Envoy AI Gateway
+installation into a timestamped archive:
+
+- CLI, helm and cluster versions
+- status and values of every managed Helm release
+- recent controller and Envoy Gateway pod logs
+- events from the gateway and AI namespaces
+- AI Gateway and Gateway API resources
+- the output of 'doctor'
+
+Secret data, bearer tokens and API keys are redacted unless --redact=false
+is given.`,
+	RunE: runReport,
+}
+
+func init() {
+	reportCmd.Flags().StringVarP(&reportOutputDir, "output-dir", "o", ".",
+		"directory to write the report archive to")
+	reportCmd.Flags().Int64Var(&reportLogLines, "log-lines", 500,
+		"number of recent log lines to collect per container")
+	reportCmd.Flags().BoolVar(&reportRedact, "redact", true,
+		"redact secret data, bearer tokens and API keys")
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	output.Println("🩺 Collecting Envoy AI Gateway diagnostics")
+	output.Printf("  Namespace (Gateway): %s\n", cfg.NamespaceGateway)
+	output.Printf("  Namespace (AI):      %s\n", cfg.NamespaceAI)
+	if !reportRedact {
+		output.Println("  ⚠️  Redaction disabled: the report may contain credentials")
+	}
+	output.Println()
+
+	now := time.Now()
+	bundle := report.NewBundle(now, reportMaxFileSize)
+	var problems []string
+
+	ctx := cmd.Context()
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	collect := func(label string, fn func() error) {
+		output.Printf("🔍 %-22s ", label+":")
+		if err := fn(); err != nil {
+			output.Println("⚠️  incomplete")
+			problems = append(problems, fmt.Sprintf("%s: %v", label, err))
+			return
+		}
+		output.Println("✅ collected")
+	}
+
+	collect("Versions", func() error { return collectVersions(ctx, clientset, bundle) })
+	collect("Helm releases", func() error { return collectReleases(cfg, bundle) })
+	collect("Resources", func() error { return collectResources(cfg, bundle) })
+	if clientset != nil {
+		collect("Pod logs", func() error { return collectLogs(ctx, clientset, cfg, bundle) })
+		collect("Events", func() error { return collectEvents(ctx, clientset, cfg, bundle) })
+	}
+	collect("Doctor", func() error { return collectDoctor(bundle) })
+
+	if len(problems) > 0 {
+		bundle.AddString("errors.txt", strings.Join(problems, "\n")+"\n")
+	}
+
+	path := filepath.Join(reportOutputDir, report.FileName(now))
+	if err := bundle.Write(path); err != nil {
+		return fmt.Errorf("failed to write report archive: %w", err)
+	}
+
+	output.Printf("\n✅ Report written to %s (%d files)\n", path, len(bundle.Files))
+	if reportRedact {
+		output.Println("   Secrets were redacted, but please review the archive before sharing it.")
+	}
+	return nil
+}
+
+func redactText(s string) string {
+	if !reportRedact {
+		return s
+	}
+	return redact.Text(s)
+}
+
+func redactYAML(s string) string {
+	if !reportRedact {
+		return s
+	}
+	redacted, err := redact.YAML(s)
+	if err != nil {
+		// Fall back to text redaction rather than leaking unparsable input.
+		return redact.Text(s)
+	}
+	return redacted
+}
+
+func collectVersions(ctx context.Context, clientset kubernetes.Interface, bundle *report.Bundle) error {
+	info := version.Get()
+	var b strings.Builder
+	fmt.Fprintf(&b, "CLI version: %s\n", info.Version)
+	fmt.Fprintf(&b, "Git commit:  %s\n", info.Commit)
+	fmt.Fprintf(&b, "Build time:  %s\n", info.BuildTime)
+	fmt.Fprintf(&b, "Go version:  %s\n", info.GoVersion)
+	fmt.Fprintf(&b, "Platform:    %s\n", info.Platform)
+
+	var errs []string
+
+	helmVersion, err := helm.NewHelmCommand(false).Version()
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("helm version: %v", err))
+	}
+	fmt.Fprintf(&b, "Helm:        %s\n", strings.TrimSpace(helmVersion))
+
+	if clientset != nil {
+		server, err := clientset.Discovery().ServerVersion()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cluster version: %v", err))
+		} else {
+			fmt.Fprintf(&b, "Kubernetes:  %s (%s)\n", server.GitVersion, server.Platform)
+		}
+	}
+
+	bundle.AddString("versions.txt", b.String())
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func collectReleases(cfg *config.Config, bundle *report.Bundle) error {
+	helmCmd := helm.NewHelmCommand(false)
+
+	var errs []string
+	for _, r := range append(managedReleases(cfg), redisRelease(cfg)) {
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", r.name, err))
+			continue
+		}
+		if rel == nil {
+			bundle.AddString(fmt.Sprintf("releases/%s/status.txt", r.name), "not installed\n")
+			continue
+		}
+
+		status, err := helmCmd.Status(r.name, r.namespace)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s status: %v", r.name, err))
+		} else {
+			bundle.AddString(fmt.Sprintf("releases/%s/status.txt", r.name), redactText(status))
+		}
+
+		values, err := helmCmd.GetValues(r.name, r.namespace)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s values: %v", r.name, err))
+		} else {
+			bundle.AddString(fmt.Sprintf("releases/%s/values.yaml", r.name), redactYAML(values))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func collectResources(cfg *config.Config, bundle *report.Bundle) error {
+	var errs []string
+
+	get := func(file string, args ...string) {
+		output, err := k8s.Kubectl(append([]string{"get"}, args...)...).Output()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", file, err))
+			return
+		}
+		bundle.AddString(file, redactYAML(string(output)))
+	}
+
+	get("resources/gatewayclasses.yaml", "gatewayclasses.gateway.networking.k8s.io", "-o", "yaml", "--ignore-not-found")
+	for _, ns := range uniqueNamespaces(cfg) {
+		get(fmt.Sprintf("resources/%s/aigateway.yaml", ns),
+			strings.Join(aiGatewayResources, ","), "-n", ns, "-o", "yaml", "--ignore-not-found")
+		get(fmt.Sprintf("resources/%s/gateway-api.yaml", ns),
+			strings.Join(gatewayAPIResources, ","), "-n", ns, "-o", "yaml", "--ignore-not-found")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func collectLogs(ctx context.Context, clientset kubernetes.Interface, cfg *config.Config, bundle *report.Bundle) error {
+	var errs []string
+
+	for _, name := range []string{"controller", "gateway"} {
+		component := logComponents[name]
+		namespace := component.namespace(cfg)
+
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: component.selectorFor(cfg),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("list %s pods: %v", name, err))
+			continue
+		}
+
+		for _, pod := range pods.Items {
+			for _, container := range pod.Spec.Containers {
+				limit := int64(reportMaxFileSize)
+				opts := &corev1.PodLogOptions{
+					Container:  container.Name,
+					TailLines:  &reportLogLines,
+					LimitBytes: &limit,
+				}
+
+				data, err := clientset.CoreV1().Pods(namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("logs %s/%s: %v", pod.Name, container.Name, err))
+					continue
+				}
+
+				bundle.AddString(fmt.Sprintf("logs/%s/%s/%s.log", name, pod.Name, container.Name),
+					redactText(string(data)))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func collectEvents(ctx context.Context, clientset kubernetes.Interface, cfg *config.Config, bundle *report.Bundle) error {
+	var errs []string
+
+	for _, ns := range uniqueNamespaces(cfg) {
+		events, err := clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", ns, err))
+			continue
+		}
+
+		items := events.Items
+		sort.Slice(items, func(i, j int) bool {
+			return eventTime(items[i]).Before(eventTime(items[j]))
+		})
+
+		var b strings.Builder
+		for _, e := range items {
+			fmt.Fprintf(&b, "%s  %-7s  %-20s  %s/%s  %s\n",
+				eventTime(e).UTC().Format(time.RFC3339), e.Type, e.Reason,
+				strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name, e.Message)
+		}
+		bundle.AddString(fmt.Sprintf("events/%s.txt", ns), redactText(b.String()))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func eventTime(e corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+// collectDoctor runs the doctor command in a child process so its output can
+// be captured as-is.
+func collectDoctor(bundle *report.Bundle) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"doctor",
+		"--namespace-gateway", viper.GetString("namespace_gateway"),
+		"--namespace-ai", viper.GetString("namespace_ai"),
+	}
+	if prefix := viper.GetString("release_prefix"); prefix != "" {
+		args = append(args, "--release-prefix", prefix)
+	}
+	if len(cfgFiles) > 0 {
+		args = append(args, "--config", strings.Join(cfgFiles, ","))
+	}
+	if configEnv != "" {
+		args = append(args, "--env", configEnv)
+	}
+	if kc := viper.GetString("kubeconfig"); kc != "" {
+		args = append(args, "--kubeconfig", kc)
+	}
+	if ctx := viper.GetString("kube_context"); ctx != "" {
+		args = append(args, "--context", ctx)
+	}
+
+	// doctor exits non-zero when a check fails, which is exactly when its
+	// output is most useful, so the exit status is ignored.
+	output, _ := exec.Command(self, args...).CombinedOutput()
+	bundle.AddString("doctor.txt", redactText(string(output)))
+	return nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/report"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReportRedaction(t *testing.T) {
+	saved := reportRedact
+	t.Cleanup(func() { reportRedact = saved })
+
+	tests := []struct {
+		name       string
+		redact     bool
+		redactFunc func(string) string
+		in         string
+		want       string
+	}{
+		{name: "values", redact: true, redactFunc: redactYAML, in: "auth:\n  password: hunter2\n", want: "auth:\n  password: '[REDACTED]'\n"},
+		{name: "unparsable values", redact: true, redactFunc: redactYAML, in: "password: [hunter2\n", want: "password: [REDACTED]\n"},
+		{name: "logs", redact: true, redactFunc: redactText, in: "Authorization: Bearer abc123\n", want: "Authorization: Bearer [REDACTED]\n"},
+		{name: "--redact=false", redactFunc: redactYAML, in: "auth:\n  password: hunter2\n", want: "auth:\n  password: hunter2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportRedact = tt.redact
+			if got := tt.redactFunc(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectLogsReleasePrefix(t *testing.T) {
+	cfg := &config.Config{NamespaceGateway: "envoy-gateway-system", NamespaceAI: "envoy-ai-gateway-system", ReleasePrefix: "prod-"}
+	pod := func(namespace, name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		pod(cfg.NamespaceAI, "prod-controller", map[string]string{"app.kubernetes.io/name": "ai-gateway-controller", "app.kubernetes.io/instance": "prod-aieg"}),
+		pod(cfg.NamespaceAI, "dev-controller", map[string]string{"app.kubernetes.io/name": "ai-gateway-controller", "app.kubernetes.io/instance": "dev-aieg"}),
+		pod(cfg.NamespaceGateway, "prod-gateway", map[string]string{"control-plane": "envoy-gateway", "app.kubernetes.io/instance": "prod-eg"}),
+		pod(cfg.NamespaceGateway, "gateway", map[string]string{"control-plane": "envoy-gateway", "app.kubernetes.io/instance": "eg"}),
+	)
+
+	bundle := report.NewBundle(time.Now(), 0)
+	if err := collectLogs(context.Background(), clientset, cfg, bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for name := range bundle.Files {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	want := []string{"logs/controller/prod-controller/main.log", "logs/gateway/prod-gateway/main.log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got logs %v, want only those of the prod- releases %v", got, want)
+	}
+}
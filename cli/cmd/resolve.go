@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+)
+
+// resolvedRelease is a release as install would deploy it: its pinned
+// chart version and the values and overrides passed to helm.
+type resolvedRelease struct {
+	managedRelease
+	values    []string
+	set       []string
+	setString []string
+}
+
+// helmOptions returns the helm options install uses for the release.
+func (r resolvedRelease) helmOptions() *helm.HelmOptions {
+	return &helm.HelmOptions{
+		Namespace: r.namespace,
+		Values:    r.values,
+		Set:       r.set,
+		SetString: r.setString,
+		Version:   r.version,
+	}
+}
+
+// resolveReleases resolves the releases install would deploy, and Redis
+// with --with-redis. The official values file is fetched on the way.
+func resolveReleases(cfg *config.Config) ([]resolvedRelease, error) {
+	values, err := releaseValues(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := managedReleases(cfg)
+	if withRedis {
+		releases = append(releases, redisRelease(cfg))
+	}
+
+	resolved := make([]resolvedRelease, 0, len(releases))
+	for _, r := range releases {
+		set, setString, err := releaseOverrides(cfg, r.id)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, resolvedRelease{
+			managedRelease: r,
+			values:         values[r.id],
+			set:            set,
+			setString:      setString,
+		})
+	}
+	return resolved, nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var resourceLimits []string
+
+// resourcePrefixes maps each release to the chart values that hold the
+// resources of its containers.
+var resourcePrefixes = map[string][]string{
+	"eg":             {"deployment.envoyGateway.resources"},
+	"aieg":           {"controller.resources"},
+	redisReleaseName: {"master.resources", "replica.resources"},
+}
+
+// resourceValues returns the Helm overrides that apply the configured
+// resource limits and requests to the containers of a release.
+func resourceValues(cfg *config.Config, id string) ([]string, error) {
+	if cfg.Resources == nil {
+		return nil, nil
+	}
+
+	var overrides []string
+	for _, prefix := range resourcePrefixes[id] {
+		_, setString, err := values.SetArgs(prefix, cfg.Resources)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource limits: %w", err)
+		}
+		overrides = append(overrides, setString...)
+	}
+	return overrides, nil
+}
+
+func formatResources(resources *corev1.ResourceRequirements) string {
+	var parts []string
+	for _, list := range []struct {
+		name      string
+		resources corev1.ResourceList
+	}{{"limits", resources.Limits}, {"requests", resources.Requests}} {
+		var quantities []string
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if q, ok := list.resources[name]; ok {
+				quantities = append(quantities, fmt.Sprintf("%s=%s", name, q.String()))
+			}
+		}
+		if len(quantities) > 0 {
+			parts = append(parts, list.name+" "+strings.Join(quantities, ","))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
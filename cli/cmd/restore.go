@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/backup"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var restoreForce bool
+
+var chartRepos = map[string]string{
+	"envoyproxy": "oci://docker.io/envoyproxy",
+	"bitnami":    "https://charts.bitnami.com/bitnami",
+}
+
+var releaseUpstreams = map[string]string{
+	"eg":       "gateway-helm",
+	"aieg-crd": "ai-gateway-crds-helm",
+	"aieg":     "ai-gateway-helm",
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup-archive>",
+	Short: "Reinstall from a previously captured backup",
+	Long: `Reinstall Envoy AI Gateway releases from an archive created by 'backup'.
+
+Each release is reinstalled with the chart version and values recorded in
+the backup. If the backed-up chart versions differ from the latest upstream
+versions the restore is aborted unless --force is given.
+
+Custom resources captured in the archive are not re-applied automatically;
+they can be found under resources/ inside the archive.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreForce, "force", false,
+		"restore even if backed-up chart versions differ from upstream")
+	restoreCmd.Flags().BoolVar(&forceUnlock, "force-unlock", false,
+		"take the installation lock over even if another installer holds it")
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	isDryRun := viper.GetBool("dry_run")
+
+	archive, err := backup.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	meta := archive.Metadata
+
+	output.Println("♻️  Restoring Envoy AI Gateway from backup")
+	output.Printf("  Backup:      %s\n", args[0])
+	output.Printf("  Created:     %s\n", meta.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	output.Printf("  CLI Version: %s\n", meta.CLIVersion)
+	output.Printf("  Dry Run:     %v\n", isDryRun)
+
+	if len(meta.Releases) == 0 {
+		return fmt.Errorf("backup does not contain any releases")
+	}
+
+	cfg.ReleasePrefix = meta.ReleasePrefix
+	known := map[string]managedRelease{}
+	for _, r := range append(managedReleases(cfg), redisRelease(cfg)) {
+		known[r.name] = r
+	}
+
+	if err := checkBackupVersions(cmd.Context(), meta.Releases, known); err != nil {
+		if !restoreForce {
+			return err
+		}
+		output.Println("⚠️  Continuing because --force was given")
+	}
+
+	unlock, err := lockInstallation(cmd, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	helmCmd := helm.NewHelmCommand(isDryRun)
+	addedRepos := map[string]bool{}
+
+	for i, rel := range meta.Releases {
+		managed, ok := known[rel.Name]
+		if !ok {
+			return fmt.Errorf("backup contains unknown release %q", rel.Name)
+		}
+		chart := managed.chart
+
+		output.Printf("\n📋 Step %d/%d: Restoring %s (%s %s)...\n",
+			i+1, len(meta.Releases), rel.Name, rel.Chart, rel.Version)
+
+		repo := strings.SplitN(chart, "/", 2)[0]
+		if !addedRepos[repo] {
+			if err := helmCmd.RepoAdd(repo, chartRepos[repo]); err != nil {
+				return err
+			}
+			if err := helmCmd.RepoUpdate(); err != nil {
+				return err
+			}
+			addedRepos[repo] = true
+		}
+
+		valuesFile, err := writeBackupValues(archive, rel)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(valuesFile)
+
+		opts := &helm.HelmOptions{
+			Namespace: rel.Namespace,
+			Values:    []string{valuesFile},
+			Version:   rel.Version,
+		}
+
+		if err := helmCmd.Install(rel.Name, chart, rel.Namespace, opts); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", rel.Name, err)
+		}
+	}
+
+	output.Println("\n✅ Restore complete!")
+	return nil
+}
+
+func checkBackupVersions(ctx context.Context, releases []backup.Release, known map[string]managedRelease) error {
+	charts, err := upstream.GetUpstreamCharts(ctx, upstream.FetchOptions{})
+	if len(charts) == 0 && err != nil {
+		output.Printf("⚠️  Could not verify upstream versions: %v\n", err)
+		return nil
+	}
+
+	latest := map[string]string{}
+	for _, chart := range charts {
+		if chart.Chart != "" {
+			latest[chart.Chart] = chart.Version
+		}
+	}
+
+	var mismatches []string
+	for _, rel := range releases {
+		upstreamVersion, ok := latest[releaseUpstreams[known[rel.Name].id]]
+		if !ok || upstreamVersion == rel.Version {
+			continue
+		}
+		mismatches = append(mismatches,
+			fmt.Sprintf("%s: backup %s, upstream %s", rel.Name, rel.Version, upstreamVersion))
+	}
+
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	output.Println("\n⚠️  Backed-up chart versions differ from the latest upstream versions:")
+	for _, m := range mismatches {
+		output.Printf("   %s\n", m)
+	}
+
+	return fmt.Errorf("backed-up chart versions differ from upstream (use --force to restore anyway)")
+}
+
+func writeBackupValues(archive *backup.Archive, rel backup.Release) (string, error) {
+	data, ok := archive.Files[rel.ValuesFile]
+	if !ok {
+		return "", fmt.Errorf("backup is missing values for %s", rel.Name)
+	}
+
+	return values.WriteTemp(fmt.Sprintf("envoy-ai-restore-%s-*.yaml", rel.Name), data)
+}
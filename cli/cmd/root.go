@@ -1,21 +1,54 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
 )
 
 var (
-	cfgFile    string
-	dryRun     bool
-	skipClean  bool
-	verbose    bool
-	namespaceGW string
-	namespaceAI string
+	cfgFiles       []string
+	configEnv      string
+	configProfile  string
+	dryRun         bool
+	skipClean      bool
+	verbose        bool
+	assumeYes      bool
+	nonInteractive bool
+	noColor        bool
+	noEmoji        bool
+	logFilePath    string
+	debugLog       bool
+	logRetention   int
+	otelEndpoint   string
+	noTelemetry    bool
+	updateCheck    bool
+	noCache        bool
+	refreshCache   bool
+	cacheTTL       time.Duration
+	networkTimeout time.Duration
+	commandTimeout time.Duration
+	helmTimeout    time.Duration
+	caBundle       string
+	namespaceGW    string
+	namespaceAI    string
+	releasePrefix  string
+	kubeconfigPath string
+	kubeContext    string
 )
 
 var rootCmd = &cobra.Command{
@@ -27,43 +60,254 @@ It automatically fetches the latest upstream releases and provides
 a seamless installation experience with sensible defaults and
 full customization options.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := config.Init(cfgFile); err != nil {
+		if isCompletionCommand(cmd) {
+			return nil
+		}
+		if err := applyEnvFlags(cmd); err != nil {
+			return err
+		}
+
+		output.Configure(noColor, noEmoji)
+
+		if err := config.Init(cfgFiles, configEnv, configProfile, currentKubeContext); err != nil {
 			return fmt.Errorf("failed to initialize config: %w", err)
 		}
+		// The config subcommands, except view, must work on an invalid
+		// config so that it can be inspected and fixed.
+		if cmd.Parent() != configCmd || cmd == configShowCmd {
+			cfg, err := config.Check()
+			if err != nil {
+				return err
+			}
+			if cmd == installCmd {
+				printConfigWarnings(cfg)
+			}
+		}
+
+		if err := openLog(); err != nil {
+			return err
+		}
+
+		if err := httpclient.Configure(httpclient.Options{
+			Timeout:  viper.GetDuration("network_timeout"),
+			CABundle: viper.GetString("ca_bundle"),
+		}); err != nil {
+			return err
+		}
+
+		upstream.ConfigureCache(upstream.CacheOptions{
+			Dir:      upstream.DefaultCacheDir(),
+			TTL:      viper.GetDuration("cache_ttl"),
+			Disabled: viper.GetBool("no_cache"),
+			Refresh:  viper.GetBool("refresh"),
+		})
+
+		kubeconfig := resolveKubeconfig()
+		k8s.Configure(kubeconfig, viper.GetString("kube_context"))
+		helm.SetKubeConfig(kubeconfig, viper.GetString("kube_context"))
+
+		applyCommandTimeout(cmd)
+		helm.SetTimeout(cmd.Context(), viper.GetDuration("helm_timeout"))
 		return nil
 	},
 }
 
+// defaultCommandTimeout bounds a command unless --timeout says otherwise.
+const defaultCommandTimeout = 15 * time.Minute
+
+var (
+	// commandContext is the context of the running command, with the
+	// deadline of --timeout, for work that is not handed a context, such
+	// as downloads.
+	commandContext = context.Background()
+	// cancelCommandTimeout releases the deadline set by
+	// applyCommandTimeout.
+	cancelCommandTimeout context.CancelFunc = func() {}
+)
+
+// applyCommandTimeout puts the deadline of --timeout on the context of cmd,
+// which steps, waits, HTTP requests and helm commands all run under. A
+// fleet install passes --timeout on to the install of each cluster
+// instead, as the fleet as a whole may take much longer.
+func applyCommandTimeout(cmd *cobra.Command) {
+	timeout := viper.GetDuration("timeout")
+	if timeout > 0 && !(cmd == installCmd && fleetFile != "") {
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		cmd.SetContext(ctx)
+		cancelCommandTimeout = cancel
+	}
+	commandContext = cmd.Context()
+}
+
+// openLog starts the transcript requested with --log-file or --debug-log.
+func openLog() error {
+	path := viper.GetString("log_file")
+	if path == "" && !viper.GetBool("debug_log") {
+		return nil
+	}
+
+	var dir string
+	if home, err := os.UserHomeDir(); err == nil {
+		dir = filepath.Join(home, ".envoy-ai-installer", "logs")
+	} else if path == "" {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	if _, err := output.OpenLog(output.LogOptions{
+		Path: path,
+		Dir:  dir,
+		Keep: viper.GetInt("log_retention"),
+	}); err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	output.Debugf("envoy-ai-installer %s: %s", version.Get(), strings.Join(os.Args, " "))
+	return nil
+}
+
+// currentKubeContext returns the kube context commands will use, from
+// --context or the current-context of the kubeconfig, to select the contexts
+// section of the config file.
+func currentKubeContext() string {
+	k8s.Configure(resolveKubeconfig(), viper.GetString("kube_context"))
+	return k8s.CurrentContext()
+}
+
+// resolveKubeconfig returns the --kubeconfig flag, falling back to the
+// KUBECONFIG environment variable so that helm and kubectl always target the
+// same cluster. A KUBECONFIG holding several files cannot be expressed as a
+// single --kubeconfig path, so it is left for the tools to merge themselves.
+func resolveKubeconfig() string {
+	if path := viper.GetString("kubeconfig"); path != "" {
+		return path
+	}
+
+	env := os.Getenv("KUBECONFIG")
+	if strings.Contains(env, string(os.PathListSeparator)) {
+		return ""
+	}
+	return env
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.SetHelpTemplate(rootCmd.HelpTemplate() + exitCodesHelp)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", 
-		"config file (default is $HOME/.envoy-ai-installer/config.yaml)")
+	rootCmd.PersistentFlags().StringSliceVar(&cfgFiles, "config", nil,
+		"config files, merged in order (default is $HOME/.envoy-ai-installer/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&configEnv, "env", "",
+		"environment whose config.<env>.yaml, next to the config file, is merged last (e.g. prod)")
+	rootCmd.PersistentFlags().StringVar(&configProfile, "profile", "",
+		"config file profile to apply on top of its top-level keys (e.g. dev, staging, prod)")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false,
 		"simulate what would be executed without making changes")
 	rootCmd.PersistentFlags().BoolVar(&skipClean, "skip-clean", false,
 		"skip cleaning up previous installations")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
 		"enable verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false,
+		"answer yes to all confirmation prompts")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false,
+		"never prompt for confirmation (implies --yes)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false,
+		"disable colors and emoji in output (also set by NO_COLOR, and when stdout is not a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false,
+		"replace emoji in output with ASCII labels such as [OK] and [FAIL], keeping colors")
+	rootCmd.PersistentFlags().StringVar(&logFilePath, "log-file", "",
+		"write a full transcript, including helm and kubectl commands and their output, to this file")
+	rootCmd.PersistentFlags().BoolVar(&debugLog, "debug-log", false,
+		"write the transcript to ~/.envoy-ai-installer/logs/<timestamp>.log unless --log-file is set")
+	rootCmd.PersistentFlags().IntVar(&logRetention, "log-retention", 10,
+		"how many log files to keep (0 keeps all)")
+	rootCmd.PersistentFlags().StringVar(&otelEndpoint, "otel-endpoint", "",
+		"OpenTelemetry gRPC endpoint (host:port or URL) to send install and uninstall spans and metrics to")
+	rootCmd.PersistentFlags().BoolVar(&noTelemetry, "no-telemetry", false,
+		"never send spans or metrics, even if --otel-endpoint is configured")
+	rootCmd.PersistentFlags().BoolVar(&updateCheck, "update-check", false,
+		"after each command, tell when a newer installer release is out (checked at most once a day)")
 	rootCmd.PersistentFlags().StringVar(&namespaceGW, "namespace-gateway", "envoy-gateway-system",
 		"kubernetes namespace for Envoy Gateway")
 	rootCmd.PersistentFlags().StringVar(&namespaceAI, "namespace-ai", "envoy-ai-gateway-system",
 		"kubernetes namespace for Envoy AI Gateway")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig", "",
+		"path to the kubeconfig file to use (default is $KUBECONFIG)")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "",
+		"kubeconfig context to use")
+	rootCmd.PersistentFlags().StringVar(&releasePrefix, "release-prefix", "",
+		"prefix prepended to all Helm release names (e.g. prod- yields prod-eg)")
+
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false,
+		"do not read or write the GitHub release cache")
+	rootCmd.PersistentFlags().BoolVar(&refreshCache, "refresh", false,
+		"ignore the cache TTL and revalidate GitHub release lookups")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", upstream.DefaultCacheTTL,
+		"how long GitHub release lookups are cached")
+	rootCmd.PersistentFlags().DurationVar(&networkTimeout, "network-timeout", httpclient.DefaultTimeout,
+		"timeout for each GitHub API and values file request (0 for none)")
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", defaultCommandTimeout,
+		"upper bound on the whole command, after which it stops in the step that is running (0 for none)")
+	rootCmd.PersistentFlags().DurationVar(&helmTimeout, "helm-timeout", helm.DefaultTimeout,
+		"how long each helm upgrade and uninstall waits for hooks and resources (helm --timeout)")
+	rootCmd.PersistentFlags().StringVar(&caBundle, "ca-bundle", "",
+		"PEM file of extra CA certificates for HTTPS requests (for TLS-intercepting proxies)")
 
 	viper.BindPFlag("dry_run", rootCmd.PersistentFlags().Lookup("dry-run"))
 	viper.BindPFlag("skip_clean", rootCmd.PersistentFlags().Lookup("skip-clean"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	viper.BindPFlag("yes", rootCmd.PersistentFlags().Lookup("yes"))
+	viper.BindPFlag("non_interactive", rootCmd.PersistentFlags().Lookup("non-interactive"))
 	viper.BindPFlag("namespace_gateway", rootCmd.PersistentFlags().Lookup("namespace-gateway"))
 	viper.BindPFlag("namespace_ai", rootCmd.PersistentFlags().Lookup("namespace-ai"))
+	viper.BindPFlag("kubeconfig", rootCmd.PersistentFlags().Lookup("kubeconfig"))
+	viper.BindPFlag("kube_context", rootCmd.PersistentFlags().Lookup("context"))
+	viper.BindPFlag("release_prefix", rootCmd.PersistentFlags().Lookup("release-prefix"))
+	viper.BindPFlag("no_cache", rootCmd.PersistentFlags().Lookup("no-cache"))
+	viper.BindPFlag("refresh", rootCmd.PersistentFlags().Lookup("refresh"))
+	viper.BindPFlag("cache_ttl", rootCmd.PersistentFlags().Lookup("cache-ttl"))
+	viper.BindPFlag("network_timeout", rootCmd.PersistentFlags().Lookup("network-timeout"))
+	viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+	viper.BindPFlag("helm_timeout", rootCmd.PersistentFlags().Lookup("helm-timeout"))
+	viper.BindPFlag("ca_bundle", rootCmd.PersistentFlags().Lookup("ca-bundle"))
+	viper.BindPFlag("log_file", rootCmd.PersistentFlags().Lookup("log-file"))
+	viper.BindPFlag("debug_log", rootCmd.PersistentFlags().Lookup("debug-log"))
+	viper.BindPFlag("log_retention", rootCmd.PersistentFlags().Lookup("log-retention"))
+	viper.BindPFlag("otel_endpoint", rootCmd.PersistentFlags().Lookup("otel-endpoint"))
+	viper.BindPFlag("no_telemetry", rootCmd.PersistentFlags().Lookup("no-telemetry"))
+	viper.BindPFlag("update_check", rootCmd.PersistentFlags().Lookup("update-check"))
 
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(versionsCmd)
+	rootCmd.AddCommand(checkUpdateCmd)
+	rootCmd.AddCommand(selfUpdateCmd)
 	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(adoptCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(renderCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(endpointsCmd)
+	rootCmd.AddCommand(portForwardCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(stateCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(observabilityCmd)
+	rootCmd.AddCommand(gatewayClassCmd)
+	rootCmd.AddCommand(routeCmd)
+	rootCmd.AddCommand(policyCmd)
+	rootCmd.AddCommand(uninstallCmd)
 }
 
 func initConfig() {
-	if cfgFile != "" {
-		viper.SetConfigFile(cfgFile)
+	if len(cfgFiles) > 0 {
+		viper.SetConfigFile(cfgFiles[0])
 	} else {
 		home, err := os.UserHomeDir()
 		if err == nil {
@@ -78,7 +322,21 @@ func initConfig() {
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	defer output.Close()
+	defer values.RemoveTempFiles()
+	defer handleInterrupts()()
+
+	classifyUsageErrors(rootCmd)
+	registerCompletions(rootCmd)
+	cmd, err := rootCmd.ExecuteC()
+	cancelCommandTimeout()
+	endTelemetry(err)
+	if err != nil {
+		output.Debugf("error: %v", err)
+		return err
+	}
+	notifyUpdate(cmd)
+	return nil
 }
 
 func GetRootCmd() *cobra.Command {
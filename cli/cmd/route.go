@@ -0,0 +1,496 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/route"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	aiGatewayRouteGVR = schema.GroupVersionResource{
+		Group:    aiGatewayCRDGroup,
+		Version:  "v1alpha1",
+		Resource: "aigatewayroutes",
+	}
+	aiServiceBackendGVR = schema.GroupVersionResource{
+		Group:    aiGatewayCRDGroup,
+		Version:  "v1alpha1",
+		Resource: "aiservicebackends",
+	}
+)
+
+var (
+	routeNamespace     string
+	routeAllNamespaces bool
+	routeOptions       route.Options
+	routeOutput        string
+	routeTimeout       time.Duration
+)
+
+var routeCmd = &cobra.Command{
+	Use:   "route",
+	Short: "Scaffold and manage AIGatewayRoutes to model providers",
+}
+
+var routeAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Create an AIGatewayRoute and AIServiceBackend for a provider",
+	Long: `Generate and apply the resources that route the requests for one or more
+models through a Gateway to a provider:
+
+- a Backend and, for https endpoints, a BackendTLSPolicy (Envoy Gateway)
+- an AIServiceBackend speaking the provider's API
+- a BackendSecurityPolicy with the credentials of --credentials-secret
+- an AIGatewayRoute attached to --gateway, matching the --model names
+
+Providers: ` + strings.Join(route.Providers, ", ") + `. azure-openai and
+openai-compatible need --base-url. The secret holds the API key under the key
+apiKey, or for aws-bedrock an AWS credentials file under the key credentials.
+
+Before applying, the secret and the Gateway must exist in --namespace, and the
+AI Gateway webhooks must be serving. The status conditions of the route and
+its backend are reported afterwards. -o yaml prints the manifests instead, for
+GitOps, without contacting the cluster.`,
+	Example: `  envoy-ai-installer route add --name openai-chat --provider openai --model gpt-4o \
+    --credentials-secret openai-key --gateway my-gateway
+  envoy-ai-installer route add --name local --provider openai-compatible \
+    --base-url http://vllm.models:8000/v1 --model llama-3.1-8b --gateway my-gateway -o yaml`,
+	RunE: runRouteAdd,
+}
+
+var routeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the AIGatewayRoutes, their models and status",
+	RunE:  runRouteList,
+}
+
+var routeDeleteCmd = &cobra.Command{
+	Use:   "delete NAME",
+	Short: "Delete a route created by route add and its resources",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRouteDelete,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{routeAddCmd, routeListCmd, routeDeleteCmd} {
+		cmd.Flags().StringVarP(&routeNamespace, "namespace", "n", "default",
+			"namespace of the routes, their Gateway and credentials secret")
+	}
+
+	flags := routeAddCmd.Flags()
+	flags.StringVar(&routeOptions.Name, "name", "", "name of the route and its resources")
+	flags.StringVar(&routeOptions.Provider, "provider", "",
+		"provider preset: "+strings.Join(route.Providers, ", "))
+	flags.StringSliceVar(&routeOptions.Models, "model", nil, "model names to route to the provider (repeatable)")
+	flags.StringVar(&routeOptions.Gateway, "gateway", "", "Gateway to attach the route to")
+	flags.StringVar(&routeOptions.CredentialsSecret, "credentials-secret", "",
+		"secret with the provider credentials (optional for openai-compatible)")
+	flags.StringVar(&routeOptions.BaseURL, "base-url", "",
+		"endpoint of azure-openai and openai-compatible providers, e.g. https://my-resource.openai.azure.com")
+	flags.StringVar(&routeOptions.Region, "region", route.DefaultAWSRegion, "AWS region of aws-bedrock")
+	flags.StringVar(&routeOptions.APIVersion, "api-version", route.DefaultAzureAPIVersion, "API version of azure-openai")
+	flags.StringVarP(&routeOutput, "output", "o", "", "print the manifests instead of applying them: yaml")
+	flags.DurationVar(&routeTimeout, "wait-timeout", time.Minute,
+		"how long to wait for the AI Gateway webhooks, and then for the status of the route")
+
+	routeListCmd.Flags().BoolVarP(&routeAllNamespaces, "all-namespaces", "A", false, "list the routes of every namespace")
+
+	routeCmd.AddCommand(routeAddCmd, routeListCmd, routeDeleteCmd)
+}
+
+func runRouteAdd(cmd *cobra.Command, args []string) error {
+	if routeOutput != "" && routeOutput != "yaml" {
+		return usageError(fmt.Errorf("invalid --output %q (expected yaml)", routeOutput))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if routeTimeout, err = bindWaitTimeout(cmd); err != nil {
+		return err
+	}
+
+	opts := routeOptions
+	opts.Namespace = routeNamespace
+	opts.Labels = cfg.Labels
+	if opts.Provider != route.ProviderAWSBedrock && cmd.Flags().Changed("region") {
+		return usageError(fmt.Errorf("--region only applies to provider %s", route.ProviderAWSBedrock))
+	}
+	if opts.Provider != route.ProviderAzureOpenAI && cmd.Flags().Changed("api-version") {
+		return usageError(fmt.Errorf("--api-version only applies to provider %s", route.ProviderAzureOpenAI))
+	}
+	manifest, err := route.Manifests(opts)
+	if err != nil {
+		return usageError(err)
+	}
+
+	if routeOutput == "yaml" {
+		fmt.Print(manifest)
+		return nil
+	}
+	if viper.GetBool("dry_run") {
+		printDryRunApply(manifest)
+		return nil
+	}
+
+	cmd.SilenceUsage = true
+	ctx, stop := notifyInterrupt(cmd.Context())
+	defer stop()
+
+	output.Printf("🛣️  Adding route %s/%s (%s: %s)\n", opts.Namespace, opts.Name, opts.Provider, strings.Join(opts.Models, ", "))
+	if err := checkRouteReferences(ctx, opts); err != nil {
+		return err
+	}
+	if err := waitAIGatewayWebhooks(ctx, cfg, routeTimeout); err != nil {
+		return err
+	}
+
+	kubectl := k8s.Kubectl("apply", "-f", "-")
+	kubectl.Stdin = strings.NewReader(manifest)
+	kubectl.Stdout = output.Stdout
+	kubectl.Stderr = output.Stderr
+	if err := kubectl.Run(); err != nil {
+		return fmt.Errorf("failed to apply route %s: %w", opts.Name, err)
+	}
+
+	if err := reportRouteStatus(ctx, opts); err != nil {
+		return err
+	}
+	output.Printf("\n✅ Requests to %s with model %s now go to %s\n", opts.Gateway, strings.Join(opts.Models, " or "), opts.Provider)
+	return nil
+}
+
+// checkRouteReferences checks that the Gateway and the credentials secret
+// of a route exist, as the route would be applied but never work.
+func checkRouteReferences(ctx context.Context, opts route.Options) error {
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := k8s.NewDynamicClient()
+	if err != nil {
+		return err
+	}
+
+	var problems []error
+	if opts.CredentialsSecret != "" {
+		if err := checkCredentialsSecret(ctx, clientset, opts.Namespace, opts.CredentialsSecret, opts.Policy().SecretKeys()); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	_, err = dynamicClient.Resource(gatewayGVR).Namespace(opts.Namespace).Get(ctx, opts.Gateway, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		problems = append(problems, fmt.Errorf("gateway %s/%s not found; the route must be in the namespace of its Gateway", opts.Namespace, opts.Gateway))
+	case err != nil:
+		problems = append(problems, fmt.Errorf("failed to read gateway %s/%s: %w", opts.Namespace, opts.Gateway, err))
+	}
+
+	if len(problems) > 0 {
+		return &ExitError{Code: ExitFailure, Err: errors.Join(problems...)}
+	}
+	return nil
+}
+
+// reportRouteStatus waits for the AI Gateway controller to report on the
+// route and its backend, prints their conditions and fails when one was
+// not accepted.
+func reportRouteStatus(ctx context.Context, opts route.Options) error {
+	rejected, err := reportConditions(ctx, opts.Namespace, routeTimeout, []statusResource{
+		{"AIServiceBackend", aiServiceBackendGVR, opts.Name},
+		{"AIGatewayRoute", aiGatewayRouteGVR, opts.Name},
+	})
+	if err != nil {
+		return err
+	}
+	if rejected {
+		return &ExitError{Code: ExitVerification, Err: fmt.Errorf("route %s was applied but not accepted", opts.Name)}
+	}
+	return nil
+}
+
+// statusResource is an AI Gateway resource whose status is reported.
+type statusResource struct {
+	kind string
+	gvr  schema.GroupVersionResource
+	name string
+}
+
+// reportConditions waits up to timeout for the AI Gateway controller to
+// report on resources, prints their conditions and tells whether one was
+// rejected.
+func reportConditions(parent context.Context, namespace string, timeout time.Duration, resources []statusResource) (bool, error) {
+	dynamicClient, err := k8s.NewDynamicClient()
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	output.Println("\n📋 Status")
+	rejected := false
+	for _, r := range resources {
+		conditions, err := waitConditions(ctx, dynamicClient.Resource(r.gvr).Namespace(namespace), r.name)
+		switch {
+		case err != nil:
+			output.Printf("  ⚠️  %s %s: %v\n", r.kind, r.name, err)
+		case len(conditions) == 0:
+			output.Printf("  ⚠️  %s %s: no status reported after %s; is the AI Gateway controller running?\n", r.kind, r.name, timeout)
+		}
+		for _, c := range conditions {
+			icon := "✅"
+			if !c.ok() {
+				icon = "❌"
+				rejected = true
+			}
+			output.Printf("  %s %s %s: %s=%s %s\n", icon, r.kind, r.name, c.Type, c.Status, c.detail())
+		}
+	}
+	return rejected, nil
+}
+
+// condition is a status condition of an AI Gateway resource.
+type condition struct {
+	Type, Status, Reason, Message string
+}
+
+// ok reports whether the condition is good news: the AI Gateway sets
+// Accepted or NotAccepted to True, and the Gateway API sets Accepted,
+// Programmed and ResolvedRefs to False when something is wrong.
+func (c condition) ok() bool {
+	switch c.Type {
+	case "NotAccepted":
+		return c.Status != "True"
+	case "Accepted", "Programmed", "ResolvedRefs":
+		return c.Status != "False"
+	}
+	return true
+}
+
+func (c condition) detail() string {
+	switch {
+	case c.Reason != "" && c.Message != "":
+		return fmt.Sprintf("(%s: %s)", c.Reason, c.Message)
+	case c.Reason != "":
+		return "(" + c.Reason + ")"
+	}
+	return ""
+}
+
+func readConditions(obj *unstructured.Unstructured) []condition {
+	items, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	return parseConditions(items)
+}
+
+// parseConditions reads a list of metav1.Condition.
+func parseConditions(items []interface{}) []condition {
+	var conditions []condition
+	for _, item := range items {
+		c, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		str := func(key string) string { s, _ := c[key].(string); return s }
+		conditions = append(conditions, condition{str("type"), str("status"), str("reason"), str("message")})
+	}
+	return conditions
+}
+
+// errNoStatus is returned by waitConditions probes until the controller
+// reports a status.
+var errNoStatus = errors.New("no status yet")
+
+// waitConditions polls an object until it has status conditions or ctx is
+// done. It returns no conditions and no error when ctx ended before any
+// status was reported.
+func waitConditions(ctx context.Context, resource dynamic.ResourceInterface, name string) ([]condition, error) {
+	var conditions []condition
+	err := retryWithBackoff(ctx, time.Second, 5*time.Second, func() error {
+		obj, err := resource.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		conditions = readConditions(obj)
+		if len(conditions) == 0 {
+			return errNoStatus
+		}
+		return nil
+	})
+	if errors.Is(err, errNoStatus) {
+		return nil, nil
+	}
+	return conditions, err
+}
+
+func runRouteList(cmd *cobra.Command, args []string) error {
+	dynamicClient, err := k8s.NewDynamicClient()
+	if err != nil {
+		return err
+	}
+	namespace := routeNamespace
+	if routeAllNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+
+	list, err := dynamicClient.Resource(aiGatewayRouteGVR).Namespace(namespace).List(cmd.Context(), metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("the AIGatewayRoute CRD is not installed; run 'envoy-ai-installer install' first")
+	}
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("failed to list AIGatewayRoutes: %w", err)
+	}
+	if len(list.Items) == 0 {
+		output.Println("ℹ️  No AIGatewayRoutes found; create one with 'envoy-ai-installer route add'")
+		return nil
+	}
+
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+
+	rows := [][]string{{"NAMESPACE", "NAME", "GATEWAYS", "MODELS", "BACKENDS", "STATUS", "MANAGED"}}
+	for i := range items {
+		rows = append(rows, routeRow(&items[i]))
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i < len(row)-1 {
+				fmt.Fprintf(&line, "%-*s  ", widths[i], cell)
+			} else {
+				line.WriteString(cell)
+			}
+		}
+		fmt.Println(line.String())
+	}
+	return nil
+}
+
+// routeRow describes an AIGatewayRoute for route list.
+func routeRow(obj *unstructured.Unstructured) []string {
+	var gateways, models, backends []string
+	parents, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	for _, p := range parents {
+		if ref, ok := p.(map[string]interface{}); ok {
+			gateways = append(gateways, fmt.Sprint(ref["name"]))
+		}
+	}
+
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matches, _, _ := unstructured.NestedSlice(rule, "matches")
+		for _, m := range matches {
+			match, _ := m.(map[string]interface{})
+			headers, _, _ := unstructured.NestedSlice(match, "headers")
+			for _, h := range headers {
+				if header, ok := h.(map[string]interface{}); ok && header["name"] == route.ModelHeader {
+					models = append(models, fmt.Sprint(header["value"]))
+				}
+			}
+		}
+		refs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for _, b := range refs {
+			if ref, ok := b.(map[string]interface{}); ok {
+				backends = append(backends, fmt.Sprint(ref["name"]))
+			}
+		}
+	}
+
+	status := "Unknown"
+	for _, c := range readConditions(obj) {
+		status = c.Type
+		if !c.ok() {
+			status = "NotAccepted"
+			break
+		}
+	}
+
+	managed := "no"
+	if obj.GetLabels()[route.Label] != "" {
+		managed = "yes"
+	}
+	dash := func(items []string) string {
+		if len(items) == 0 {
+			return "-"
+		}
+		return strings.Join(items, ",")
+	}
+	return []string{obj.GetNamespace(), obj.GetName(), dash(gateways), dash(models), dash(backends), status, managed}
+}
+
+func runRouteDelete(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	isDryRun := viper.GetBool("dry_run")
+	selector := route.Label + "=" + name
+	deleteArgs := []string{"delete", strings.Join(route.Kinds, ","), "-n", routeNamespace, "-l", selector, "--ignore-not-found"}
+
+	if !isDryRun {
+		dynamicClient, err := k8s.NewDynamicClient()
+		if err != nil {
+			return err
+		}
+		obj, err := dynamicClient.Resource(aiGatewayRouteGVR).Namespace(routeNamespace).Get(cmd.Context(), name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			cmd.SilenceUsage = true
+			return fmt.Errorf("AIGatewayRoute %s/%s not found", routeNamespace, name)
+		case err != nil:
+			cmd.SilenceUsage = true
+			return fmt.Errorf("failed to read AIGatewayRoute %s/%s: %w", routeNamespace, name, err)
+		case obj.GetLabels()[route.Label] != name:
+			cmd.SilenceUsage = true
+			return fmt.Errorf("AIGatewayRoute %s/%s was not created by 'route add'; delete it with kubectl", routeNamespace, name)
+		}
+
+		if err := confirmDestructive([]string{fmt.Sprintf("delete the resources of route %s in namespace %s (%s)",
+			name, routeNamespace, selector)}); err != nil {
+			return err
+		}
+	}
+
+	if isDryRun {
+		printDryRunKubectl(deleteArgs...)
+		return nil
+	}
+	kubectl := k8s.Kubectl(deleteArgs...)
+	kubectl.Stdout = output.Stdout
+	kubectl.Stderr = output.Stderr
+	if err := kubectl.Run(); err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("failed to delete route %s: %w", name, err)
+	}
+	output.Printf("\n✅ Route %s deleted\n", name)
+	return nil
+}
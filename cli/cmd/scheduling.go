@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+)
+
+// schedulingPrefixes maps each release to the chart values that hold the
+// pod scheduling settings of its workloads. The CRD chart has no pods.
+var schedulingPrefixes = map[string][]string{
+	"eg":             {"deployment.pod"},
+	"aieg":           {"controller"},
+	redisReleaseName: {"master", "replica"},
+}
+
+func hasScheduling(cfg *config.Config) bool {
+	return len(cfg.NodeSelector) > 0 || len(cfg.Tolerations) > 0 || cfg.Affinity != nil
+}
+
+// schedulingValues returns the Helm overrides that apply the configured node
+// selector, tolerations and affinity to the workloads of a release.
+func schedulingValues(cfg *config.Config, id string) (set, setString []string, err error) {
+	if !hasScheduling(cfg) {
+		return nil, nil, nil
+	}
+
+	for _, prefix := range schedulingPrefixes[id] {
+		fields := map[string]interface{}{
+			"nodeSelector": cfg.NodeSelector,
+			"tolerations":  cfg.Tolerations,
+			"affinity":     cfg.Affinity,
+		}
+		s, ss, err := values.SetArgs(prefix, fields)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid scheduling settings: %w", err)
+		}
+		set = append(set, s...)
+		setString = append(setString, ss...)
+	}
+	return set, setString, nil
+}
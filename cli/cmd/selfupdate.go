@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/selfupdate"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var checkUpdateCmd = &cobra.Command{
+	Use:   "check-update",
+	Short: "Check whether a newer release of the installer is available",
+	Long: `Compare the version of this envoy-ai-installer binary with the latest
+release published on GitHub, and print how to update when a newer one is
+available. The lookup uses the same cache as the upstream release lookups.`,
+	RunE: runCheckUpdate,
+}
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace the installer with its latest release",
+	Long: `Download the latest release of envoy-ai-installer for this platform,
+verify it against the checksums published with the release, and replace
+the running binary with it. The previous binary is kept next to the new
+one with a .bak suffix.
+
+Binaries installed by a package manager such as Homebrew are not replaced:
+update them through the package manager instead.`,
+	RunE: runSelfUpdate,
+}
+
+var (
+	checkUpdateOutput string
+	selfUpdateForce   bool
+)
+
+// updateStatus is the JSON output of check-update.
+type updateStatus struct {
+	Current         string    `json:"current"`
+	Latest          string    `json:"latest"`
+	PublishedAt     time.Time `json:"published_at"`
+	URL             string    `json:"url"`
+	DevBuild        bool      `json:"dev_build"`
+	UpdateAvailable bool      `json:"update_available"`
+	UpdateCommand   string    `json:"update_command"`
+}
+
+func init() {
+	checkUpdateCmd.Flags().StringVarP(&checkUpdateOutput, "output", "o", "text",
+		"output format: text or json")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false,
+		"replace the binary even when it is a development build or already up to date")
+}
+
+// updateCommand returns how the running binary is updated: through the
+// package manager that installed it, or with self-update.
+func updateCommand() string {
+	if path, err := selfupdate.Executable(); err == nil {
+		if manager := selfupdate.ManagedBy(path); manager != "" {
+			return manager
+		}
+	}
+	return "envoy-ai-installer self-update"
+}
+
+func runCheckUpdate(cmd *cobra.Command, args []string) error {
+	if checkUpdateOutput != "text" && checkUpdateOutput != "json" {
+		return usageError(fmt.Errorf("unsupported output format %q (expected text or json)", checkUpdateOutput))
+	}
+
+	current := version.Get().Version
+	latest, err := selfupdate.Latest(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to look up the latest installer release: %w", err)
+	}
+
+	check := updateStatus{
+		Current:       current,
+		Latest:        latest.Tag,
+		PublishedAt:   latest.PublishedAt,
+		URL:           latest.URL,
+		DevBuild:      selfupdate.IsDevBuild(current),
+		UpdateCommand: updateCommand(),
+	}
+	check.UpdateAvailable = !check.DevBuild && upstream.IsNewerVersion(latest.Tag, current)
+
+	if checkUpdateOutput == "json" {
+		data, err := json.MarshalIndent(check, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	output.Printf("📦 envoy-ai-installer %s\n", current)
+	output.Printf("🔍 Latest release: %s (published %s)\n", latest.Tag, latest.PublishedAt.Format("2006-01-02"))
+	switch {
+	case check.DevBuild:
+		output.Println("ℹ️  This is a development build, which cannot be compared with releases.")
+		output.Printf("   Install the latest release with: %s --force\n", check.UpdateCommand)
+	case check.UpdateAvailable:
+		output.Printf("⬆️  A newer release is available: %s\n", latest.Tag)
+		output.Printf("   Update with: %s\n", check.UpdateCommand)
+		output.Printf("   Release notes: %s\n", latest.URL)
+	default:
+		output.Println("✅ The installer is up to date")
+	}
+	return nil
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	path, err := selfupdate.Executable()
+	if err != nil {
+		return err
+	}
+	if manager := selfupdate.ManagedBy(path); manager != "" {
+		return fmt.Errorf("%s was installed by a package manager; update it with: %s", path, manager)
+	}
+
+	current := version.Get().Version
+	latest, err := selfupdate.Latest(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to look up the latest installer release: %w", err)
+	}
+
+	output.Printf("📦 envoy-ai-installer %s\n", current)
+	output.Printf("🔍 Latest release: %s\n", latest.Tag)
+	if !selfUpdateForce {
+		if selfupdate.IsDevBuild(current) {
+			return usageError(fmt.Errorf("%s is a development build; pass --force to replace it with %s", current, latest.Tag))
+		}
+		if !upstream.IsNewerVersion(latest.Tag, current) {
+			output.Println("✅ The installer is already up to date")
+			return nil
+		}
+	}
+
+	if viper.GetBool("dry_run") {
+		output.Printf("[DRY-RUN] replace %s with %s\n", path, latest.Tag)
+		return nil
+	}
+
+	if err := prompter().ConfirmActions("⬆️  The installer will be updated:", []string{
+		fmt.Sprintf("download %s %s", latest.Tag, selfupdate.AssetName(runtime.GOOS, runtime.GOARCH)),
+		fmt.Sprintf("replace %s, keeping the current binary as %s.bak", path, path),
+	}); err != nil {
+		return err
+	}
+
+	output.Printf("⬇️  Downloading %s...\n", latest.Tag)
+	backup, err := selfupdate.Update(cmd.Context(), latest, path)
+	if err != nil {
+		return err
+	}
+
+	output.Printf("✅ Updated envoy-ai-installer to %s\n", latest.Tag)
+	output.Printf("   The previous binary was kept as %s\n", backup)
+	return nil
+}
+
+// noticeTimeout bounds the passive update check, which must never hold up
+// the command it follows.
+const noticeTimeout = 3 * time.Second
+
+// notifyUpdate prints a notice on stderr when a newer release of the
+// installer is out. It is opt-in with update_check, looks at most once a
+// day, and stays quiet on errors and machine-readable output.
+func notifyUpdate(cmd *cobra.Command) {
+	if !viper.GetBool("update_check") || isCompletionCommand(cmd) ||
+		cmd == checkUpdateCmd || cmd == selfUpdateCmd {
+		return
+	}
+	if flag := cmd.Flags().Lookup("output"); flag != nil && flag.Value.String() == "json" {
+		return
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), noticeTimeout)
+	defer cancel()
+	latest, err := selfupdate.CheckDaily(ctx, version.Get().Version,
+		filepath.Join(home, ".envoy-ai-installer", "update-check.json"))
+	if err != nil {
+		output.Debugf("update check: %v", err)
+		return
+	}
+	if latest == nil {
+		return
+	}
+
+	fmt.Fprintf(output.Stderr, "\n⬆️  envoy-ai-installer %s is available (you have %s). Update with: %s\n",
+		latest.Tag, version.Get().Version, updateCommand())
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+// Envoy charts are signed keyless from the envoyproxy GitHub Actions
+// workflows.
+const (
+	chartRegistry          = "docker.io"
+	cosignOIDCIssuer       = "https://token.actions.githubusercontent.com"
+	cosignIdentityRegexp   = `^https://github\.com/envoyproxy/`
+	cosignNoSignatureError = "no signatures found"
+)
+
+var (
+	verifySignatures  bool
+	requireSignatures bool
+)
+
+// chartOCIRef returns the OCI reference of a release's chart, or false for
+// charts that are not served from the envoyproxy OCI registry.
+func chartOCIRef(r managedRelease) (string, bool) {
+	name, ok := strings.CutPrefix(r.chart, "envoyproxy/")
+	if !ok || r.version == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%s/envoyproxy/%s:%s", chartRegistry, name, r.version), true
+}
+
+// verifyChartSignatures checks the cosign signatures of the charts about to
+// be installed. Unsigned charts only produce a warning unless
+// --require-signatures is set; invalid signatures always fail.
+func verifyChartSignatures(cfg *config.Config) error {
+	if _, err := exec.LookPath("cosign"); err != nil {
+		if requireSignatures {
+			return &ExitError{Code: ExitPrerequisite, Err: errors.New("--require-signatures needs cosign: https://docs.sigstore.dev/cosign/system_config/installation/")}
+		}
+		output.Println("  ⚠️  cosign not found, chart signatures not verified")
+		return nil
+	}
+
+	releases := managedReleases(cfg)
+	if withRedis {
+		releases = append(releases, redisRelease(cfg))
+	}
+
+	for _, r := range releases {
+		ref, ok := chartOCIRef(r)
+		if !ok {
+			if requireSignatures {
+				return &ExitError{Code: ExitVerification, Err: fmt.Errorf("cannot verify the signature of %s: not an OCI chart", r.chart)}
+			}
+			output.Printf("  ⚠️  %s: not an OCI chart, signature not verified\n", r.chart)
+			continue
+		}
+
+		cmd := exec.Command("cosign", "verify",
+			"--certificate-oidc-issuer", cosignOIDCIssuer,
+			"--certificate-identity-regexp", cosignIdentityRegexp,
+			ref)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			message := strings.TrimSpace(stderr.String())
+			if strings.Contains(message, cosignNoSignatureError) {
+				if requireSignatures {
+					return &ExitError{Code: ExitVerification, Err: fmt.Errorf("%s is not signed (--require-signatures)", ref)}
+				}
+				output.Printf("  ⚠️  %s: no signature published\n", ref)
+				continue
+			}
+			return &ExitError{Code: ExitVerification, Err: fmt.Errorf("signature verification failed for %s: %s", ref, message)}
+		}
+
+		output.Printf("  ✅ %s: signature verified\n", ref)
+	}
+
+	return nil
+}
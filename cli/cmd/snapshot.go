@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotOutputDir string
+	snapshotArchive   bool
+	snapshotRedact    bool
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save the full cluster state of the installation",
+	Long: `Save the state of the Envoy AI Gateway installation as it is running,
+for support tickets and post-mortem analysis:
+
+- the full state of every managed Helm release (helm get all)
+- every resource of the gateway and AI namespaces (kubectl get all)
+
+Unlike 'backup', which keeps what is needed to reinstall, a snapshot is
+meant to be read. It is written to a timestamped directory, or a .tar.gz
+archive with --archive. Secret data, bearer tokens and API keys are
+redacted unless --redact=false is given.`,
+	RunE: runSnapshot,
+}
+
+func init() {
+	snapshotCmd.Flags().StringVarP(&snapshotOutputDir, "output-dir", "o", ".",
+		"directory to write the snapshot to")
+	snapshotCmd.Flags().BoolVar(&snapshotArchive, "archive", false,
+		"write a .tar.gz archive instead of a directory")
+	snapshotCmd.Flags().BoolVar(&snapshotRedact, "redact", true,
+		"redact secret data, bearer tokens and API keys")
+}
+
+func snapshotName(t time.Time) string {
+	return fmt.Sprintf("envoy-ai-snapshot-%s", t.UTC().Format("20060102-150405"))
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	output.Println("📸 Taking a snapshot of the Envoy AI Gateway installation")
+	output.Printf("  Namespace (Gateway): %s\n", cfg.NamespaceGateway)
+	output.Printf("  Namespace (AI):      %s\n", cfg.NamespaceAI)
+	if !snapshotRedact {
+		output.Println("  ⚠️  Redaction disabled: the snapshot may contain credentials")
+	}
+	output.Println()
+
+	now := time.Now()
+	bundle := report.NewBundle(now, 0)
+	var problems []string
+
+	helmCmd := helm.NewHelmCommand(false)
+	for _, r := range append(managedReleases(cfg), redisRelease(cfg)) {
+		output.Printf("🔍 Release %-10s ", r.name)
+
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil {
+			output.Println("⚠️  incomplete")
+			problems = append(problems, fmt.Sprintf("release %s: %v", r.name, err))
+			continue
+		}
+		if rel == nil {
+			output.Println("⏭️  not installed")
+			continue
+		}
+
+		all, err := helmCmd.GetAll(r.name, r.namespace)
+		if err != nil {
+			output.Println("⚠️  incomplete")
+			problems = append(problems, fmt.Sprintf("release %s: %v", r.name, err))
+			continue
+		}
+		if snapshotRedact {
+			all = redact.HelmRelease(all)
+		}
+		bundle.AddString(fmt.Sprintf("releases/%s/all.yaml", r.name), all)
+		output.Printf("✅ %s\n", rel.Chart)
+	}
+
+	for _, ns := range uniqueNamespaces(cfg) {
+		output.Printf("🔍 Resources in '%s': ", ns)
+
+		out, err := k8s.Kubectl("get", "all", "-n", ns, "-o", "yaml").Output()
+		if err != nil {
+			output.Println("⚠️  incomplete")
+			problems = append(problems, fmt.Sprintf("resources in %s: %v", ns, err))
+			continue
+		}
+
+		resources := string(out)
+		if snapshotRedact {
+			if redacted, err := redact.YAML(resources); err == nil {
+				resources = redacted
+			} else {
+				resources = redact.Text(resources)
+			}
+		}
+		bundle.AddString(fmt.Sprintf("namespaces/%s/all.yaml", ns), resources)
+		output.Println("✅ captured")
+	}
+
+	if len(bundle.Files) == 0 {
+		return fmt.Errorf("nothing to snapshot: %s", strings.Join(problems, "; "))
+	}
+	if len(problems) > 0 {
+		bundle.AddString("errors.txt", strings.Join(problems, "\n")+"\n")
+	}
+
+	path := filepath.Join(snapshotOutputDir, snapshotName(now))
+	if snapshotArchive {
+		path += ".tar.gz"
+		err = bundle.Write(path)
+	} else {
+		err = bundle.WriteDir(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	output.Printf("\n✅ Snapshot written to %s (%d files)\n", path, len(bundle.Files))
+	if snapshotRedact {
+		output.Println("   Secrets were redacted, but please review the snapshot before sharing it.")
+	}
+	return nil
+}
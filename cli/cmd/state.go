@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var stateCmd = &cobra.Command{
+	Use:    "state",
+	Short:  "Inspect the installation state recorded in the cluster",
+	Hidden: true,
+}
+
+var stateShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the recorded installation state",
+	RunE:  runStateShow,
+}
+
+func init() {
+	stateCmd.AddCommand(stateShowCmd)
+}
+
+func runStateShow(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	st, err := state.Load(cfg.NamespaceAI, cfg.ReleasePrefix)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		output.Printf("No installation state found in %s/%s\n", cfg.NamespaceAI, state.ConfigMapNameFor(cfg.ReleasePrefix))
+		return nil
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusOutput             string
+	statusResources          bool
+	statusResourceNamespaces []string
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show installed releases, drift and the health of the AI Gateway resources",
+	Long: `Show the state of the installed Envoy AI Gateway releases.
+
+The installation state recorded by 'install' is compared against what is
+currently deployed, and any drift in chart versions or values is reported.
+The AI Gateway CRDs are checked to be established and at the chart version
+of the controller.
+
+The Gateways, HTTPRoutes, AIGatewayRoutes, AIServiceBackends and
+BackendSecurityPolicies of every namespace, or of --resource-namespaces, are
+then listed with their Accepted, Programmed and ResolvedRefs conditions; the
+message of every condition that is not healthy is shown. Kinds whose CRD is
+not installed are skipped. -o json prints the releases and resources with
+their raw conditions.`,
+	RunE: runStatus,
+}
+
+func init() {
+	statusCmd.Flags().StringVarP(&statusOutput, "output", "o", "text", "output format: text or json")
+	statusCmd.Flags().BoolVar(&statusResources, "resources", true,
+		"report the Gateways, routes and AI Gateway resources and their conditions")
+	statusCmd.Flags().StringSliceVar(&statusResourceNamespaces, "resource-namespaces", nil,
+		"namespaces whose resources are reported (default: all namespaces)")
+}
+
+// releaseStatus is a release as deployed, and its drift from the recorded
+// installation.
+type releaseStatus struct {
+	Name      string   `json:"name"`
+	Chart     string   `json:"chart,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	Installed bool     `json:"installed"`
+	Drift     []string `json:"drift,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	if statusOutput != "text" && statusOutput != "json" {
+		return usageError(fmt.Errorf("unsupported output format %q (expected text or json)", statusOutput))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	st, stateErr := state.Load(cfg.NamespaceAI, cfg.ReleasePrefix)
+	helmCmd := helm.NewHelmCommand(false)
+	releases, err := collectReleaseStatus(helmCmd, cfg, st)
+	if err != nil {
+		return err
+	}
+
+	var kinds []kindStatus
+	var resourcesErr error
+	if statusResources {
+		kinds, resourcesErr = collectResourceStatus(cmd.Context(), statusResourceNamespaces)
+	}
+
+	if statusOutput == "json" {
+		report := map[string]interface{}{
+			"namespace_gateway": cfg.NamespaceGateway,
+			"namespace_ai":      cfg.NamespaceAI,
+			"state":             st,
+			"releases":          releases,
+		}
+		if stateErr != nil {
+			report["state_error"] = stateErr.Error()
+		}
+		if statusResources {
+			report["resources"] = kinds
+		}
+		if resourcesErr != nil {
+			report["resources_error"] = resourcesErr.Error()
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	output.Println("📊 Envoy AI Gateway Status")
+	output.Printf("  Namespace (Gateway): %s\n", cfg.NamespaceGateway)
+	output.Printf("  Namespace (AI):      %s\n", cfg.NamespaceAI)
+
+	if stateErr != nil {
+		output.Printf("  ⚠️  %v\n", stateErr)
+	}
+	if st == nil {
+		output.Println("  ℹ️  No installation state recorded; drift detection is unavailable")
+	} else {
+		output.Printf("  Installed:           %s (CLI %s)\n",
+			st.InstalledAt.Local().Format("2006-01-02 15:04:05"), st.CLIVersion)
+		output.Printf("  Last updated:        %s\n", st.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+		if st.Telemetry != nil {
+			output.Printf("  Tracing:             %s (%s, sample rate %g)\n",
+				st.Telemetry.Endpoint, st.Telemetry.Protocol, st.Telemetry.SampleRate)
+		} else {
+			output.Println("  Tracing:             not configured")
+		}
+	}
+	output.Println()
+
+	drifted := false
+	for _, r := range releases {
+		output.Printf("🔍 %-12s ", r.Name+":")
+		switch {
+		case !r.Installed && len(r.Drift) > 0:
+			output.Println("❌ NOT INSTALLED (recorded as installed)")
+		case !r.Installed:
+			output.Println("⏭️  not installed")
+		default:
+			output.Printf("%s %s (%s)\n", releaseStatusIcon(r.Status), r.Chart, r.Status)
+			for _, drift := range r.Drift {
+				output.Printf("   ⚠️  Drift: %s\n", drift)
+			}
+			for _, warning := range r.Warnings {
+				output.Printf("   ⚠️  %s\n", warning)
+			}
+		}
+		if len(r.Drift) > 0 {
+			drifted = true
+		}
+	}
+
+	output.Println()
+	checkAIGatewayCRDs(helmCmd, cfg)
+	checkGatewayClasses(cmd.Context())
+
+	unhealthy := 0
+	if statusResources {
+		output.Println()
+		if resourcesErr != nil {
+			output.Printf("⚠️  Could not list the AI Gateway resources: %v\n", resourcesErr)
+		} else {
+			unhealthy = printResourceStatus(kinds)
+		}
+	}
+
+	output.Println()
+	if st != nil && drifted {
+		output.Println("⚠️  Deployed releases have drifted from the recorded installation.")
+	} else if st != nil {
+		output.Println("✅ Deployed releases match the recorded installation.")
+	}
+	if unhealthy > 0 {
+		output.Printf("❌ %d resources are not healthy; see the conditions above.\n", unhealthy)
+	}
+
+	return nil
+}
+
+// collectReleaseStatus looks up the managed releases and compares them with
+// the recorded installation st, if any.
+func collectReleaseStatus(helmCmd *helm.HelmCommand, cfg *config.Config, st *state.State) ([]releaseStatus, error) {
+	var releases []releaseStatus
+	for _, r := range append(managedReleases(cfg), redisRelease(cfg)) {
+		rs := releaseStatus{Name: r.name}
+
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up release %s: %w", r.name, err)
+		}
+
+		var recorded *state.Release
+		if st != nil {
+			if rec, ok := st.Releases[r.name]; ok {
+				recorded = &rec
+			}
+		}
+
+		if rel == nil {
+			if recorded != nil {
+				rs.Drift = append(rs.Drift, "not installed, recorded as installed")
+			}
+			releases = append(releases, rs)
+			continue
+		}
+		rs.Installed = true
+		rs.Chart = rel.Chart
+		rs.Status = rel.Status
+
+		switch {
+		case st == nil:
+		case recorded == nil:
+			rs.Drift = append(rs.Drift, "release is not recorded in the installation state")
+		default:
+			if recorded.Version != rel.ChartVersion() {
+				rs.Drift = append(rs.Drift, fmt.Sprintf("chart version %s, recorded %s", rel.ChartVersion(), recorded.Version))
+			}
+			values, err := helmCmd.GetValues(r.name, r.namespace)
+			if err != nil {
+				rs.Warnings = append(rs.Warnings, fmt.Sprintf("Could not read values: %v", err))
+			} else if state.HashValues(values) != recorded.ValuesHash {
+				rs.Drift = append(rs.Drift, "values changed since the last install")
+			}
+		}
+		releases = append(releases, rs)
+	}
+	return releases, nil
+}
+
+func releaseStatusIcon(status string) string {
+	if status == "deployed" {
+		return "✅"
+	}
+	return "⚠️ "
+}
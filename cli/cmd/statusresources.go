@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// statusKinds are the resources status reports on, in the order traffic
+// flows through them. The versions are discovered at run time, so that
+// status works with whichever versions of the CRDs are installed.
+var statusKinds = []schema.GroupKind{
+	{Group: "gateway.networking.k8s.io", Kind: "Gateway"},
+	{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute"},
+	{Group: aiGatewayCRDGroup, Kind: "AIGatewayRoute"},
+	{Group: aiGatewayCRDGroup, Kind: "AIServiceBackend"},
+	{Group: aiGatewayCRDGroup, Kind: "BackendSecurityPolicy"},
+}
+
+// keyConditions are the conditions status shows for healthy resources.
+var keyConditions = []string{"Accepted", "Programmed", "ResolvedRefs"}
+
+// kindStatus lists the resources of one kind.
+type kindStatus struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	// Installed is false when the API server does not serve the kind.
+	Installed bool             `json:"installed"`
+	Resources []resourceStatus `json:"resources"`
+}
+
+// resourceStatus is the health of a resource and its raw conditions.
+type resourceStatus struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Healthy   bool   `json:"healthy"`
+	// Conditions are status.conditions, or for routes the conditions of
+	// every parent in Parents.
+	Conditions []interface{} `json:"conditions"`
+	Parents    []interface{} `json:"parents,omitempty"`
+
+	conditions []parentCondition
+}
+
+// parentCondition is a condition, and for routes the Gateway it was set
+// for.
+type parentCondition struct {
+	parent string
+	condition
+}
+
+// collectResourceStatus lists the resources of statusKinds in namespaces,
+// or in every namespace when there are none.
+func collectResourceStatus(ctx context.Context, namespaces []string) ([]kindStatus, error) {
+	resources, err := k8s.DiscoverResources(statusKinds)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := k8s.NewDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var kinds []kindStatus
+	for _, kind := range statusKinds {
+		ks := kindStatus{Group: kind.Group, Kind: kind.Kind, Resources: []resourceStatus{}}
+		gvr, ok := resources[kind]
+		if !ok {
+			kinds = append(kinds, ks)
+			continue
+		}
+		ks.Installed = true
+
+		for _, namespace := range namespaces {
+			list, err := dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+			}
+			for i := range list.Items {
+				ks.Resources = append(ks.Resources, resourceHealth(&list.Items[i]))
+			}
+		}
+		sort.Slice(ks.Resources, func(i, j int) bool {
+			a, b := ks.Resources[i], ks.Resources[j]
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+		kinds = append(kinds, ks)
+	}
+	return kinds, nil
+}
+
+// resourceHealth reads the conditions of a resource. Routes of the Gateway
+// API report their conditions per parent Gateway in status.parents.
+func resourceHealth(obj *unstructured.Unstructured) resourceStatus {
+	rs := resourceStatus{Namespace: obj.GetNamespace(), Name: obj.GetName(), Conditions: []interface{}{}}
+
+	parents, found, _ := unstructured.NestedSlice(obj.Object, "status", "parents")
+	if found {
+		rs.Parents = parents
+		for _, p := range parents {
+			parent, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(parent, "parentRef", "name")
+			raw, _, _ := unstructured.NestedSlice(parent, "conditions")
+			rs.Conditions = append(rs.Conditions, raw...)
+			for _, c := range parseConditions(raw) {
+				rs.conditions = append(rs.conditions, parentCondition{name, c})
+			}
+		}
+	} else {
+		raw, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		rs.Conditions = append(rs.Conditions, raw...)
+		for _, c := range parseConditions(raw) {
+			rs.conditions = append(rs.conditions, parentCondition{condition: c})
+		}
+	}
+
+	rs.Healthy = len(rs.conditions) > 0
+	for _, c := range rs.conditions {
+		if !c.ok() {
+			rs.Healthy = false
+		}
+	}
+	return rs
+}
+
+// printResourceStatus prints the resources of each kind, with the message
+// of every condition that is not healthy, and returns how many resources
+// are not healthy.
+func printResourceStatus(kinds []kindStatus) int {
+	unhealthy := 0
+	for _, ks := range kinds {
+		output.Printf("🔍 %-23s", ks.Kind+":")
+		switch {
+		case !ks.Installed:
+			output.Println("⏭️  CRD not installed")
+			continue
+		case len(ks.Resources) == 0:
+			output.Println("ℹ️  none")
+			continue
+		}
+
+		bad := 0
+		for _, rs := range ks.Resources {
+			if !rs.Healthy {
+				bad++
+			}
+		}
+		if bad == 0 {
+			output.Printf("✅ %d healthy\n", len(ks.Resources))
+		} else {
+			output.Printf("❌ %d of %d not healthy\n", bad, len(ks.Resources))
+		}
+		unhealthy += bad
+
+		for _, rs := range ks.Resources {
+			printResourceHealth(rs)
+		}
+	}
+	return unhealthy
+}
+
+func printResourceHealth(rs resourceStatus) {
+	name := rs.Namespace + "/" + rs.Name
+	if len(rs.conditions) == 0 {
+		output.Printf("   ⚠️  %s: no status reported\n", name)
+		return
+	}
+	if rs.Healthy {
+		var types []string
+		for _, c := range rs.conditions {
+			if c.Status == "True" && slices.Contains(keyConditions, c.Type) && !slices.Contains(types, c.Type) {
+				types = append(types, c.Type)
+			}
+		}
+		if len(types) == 0 {
+			output.Printf("   ✅ %s\n", name)
+			return
+		}
+		output.Printf("   ✅ %s: %s\n", name, strings.Join(types, ", "))
+		return
+	}
+
+	output.Printf("   ❌ %s\n", name)
+	for _, c := range rs.conditions {
+		if c.ok() {
+			continue
+		}
+		parent := ""
+		if c.parent != "" {
+			parent = " (Gateway " + c.parent + ")"
+		}
+		output.Printf("      %s=%s%s %s\n", c.Type, c.Status, parent, c.detail())
+	}
+}
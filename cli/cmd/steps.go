@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// runSteps runs list with a progress display on terminals and prints the
+// summary, also when a step fails. The first interrupt stops the run before
+// the next step; a second one exits immediately.
+func runSteps(cmd *cobra.Command, list []steps.Step) error {
+	ctx, stop := notifyInterrupt(cmd.Context())
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	runner := steps.NewRunner()
+	results, err := runner.Run(ctx, list)
+	steps.PrintSummary(runner.Out, results)
+	telemetrySession.RecordSteps(results)
+	writeStepsFile(results)
+	return stepTimeoutError(results, err)
+}
+
+// stepTimeoutError names the step that was running when the deadline of
+// --timeout passed, or when helm gave up after --helm-timeout, and how long
+// it ran. Other errors are returned as they are.
+func stepTimeoutError(results []steps.Result, err error) error {
+	var helmTimeout *helm.TimeoutError
+	var limit string
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		limit = fmt.Sprintf("--timeout %s", viper.GetDuration("timeout"))
+	case errors.As(err, &helmTimeout):
+		limit = fmt.Sprintf("--helm-timeout %s", viper.GetDuration("helm_timeout"))
+	default:
+		return err
+	}
+
+	for _, result := range results {
+		switch result.Status {
+		case steps.StatusFailed:
+			return fmt.Errorf("step %q timed out after running for %s (%s): %w",
+				result.Name, result.Duration.Round(time.Second), limit, err)
+		case steps.StatusNotRun:
+			return fmt.Errorf("timed out before step %q (%s): %w", result.Name, limit, err)
+		}
+	}
+	return err
+}
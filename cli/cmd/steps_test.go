@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+	"github.com/spf13/viper"
+)
+
+func TestStepTimeoutError(t *testing.T) {
+	viper.Set("timeout", 15*time.Minute)
+	viper.Set("helm_timeout", 5*time.Minute)
+	t.Cleanup(func() {
+		viper.Set("timeout", nil)
+		viper.Set("helm_timeout", nil)
+	})
+
+	deadline := fmt.Errorf("helm upgrade stopped: %w", context.DeadlineExceeded)
+	helmTimeout := &helm.TimeoutError{CommandError: &helm.CommandError{
+		Args:    []string{"upgrade", "--install", "aieg"},
+		Message: "UPGRADE FAILED: timed out waiting for the condition",
+	}}
+	results := []steps.Result{
+		{Name: "Add repositories", Status: steps.StatusDone, Duration: 2 * time.Second},
+		{Name: "Install AI Gateway", Status: steps.StatusFailed, Duration: 4*time.Minute + 59600*time.Millisecond},
+		{Name: "Wait for pods", Status: steps.StatusNotRun},
+	}
+	interrupted := []steps.Result{
+		{Name: "Add repositories", Status: steps.StatusDone, Duration: 2 * time.Second},
+		{Name: "Wait for pods", Status: steps.StatusNotRun},
+	}
+
+	tests := []struct {
+		name    string
+		results []steps.Result
+		err     error
+		want    string
+	}{
+		{
+			name:    "deadline during a step",
+			results: results,
+			err:     deadline,
+			want:    `step "Install AI Gateway" timed out after running for 5m0s (--timeout 15m0s): helm upgrade stopped: context deadline exceeded`,
+		},
+		{
+			name:    "deadline between steps",
+			results: interrupted,
+			err:     context.DeadlineExceeded,
+			want:    `timed out before step "Wait for pods" (--timeout 15m0s): context deadline exceeded`,
+		},
+		{
+			name:    "helm timeout",
+			results: results,
+			err:     helmTimeout,
+			want:    `step "Install AI Gateway" timed out after running for 5m0s (--helm-timeout 5m0s): ` + helmTimeout.Error(),
+		},
+		{
+			name:    "other error",
+			results: results,
+			err:     errors.New("connection refused"),
+			want:    "connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := stepTimeoutError(tt.results, tt.err)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("got error %q, want %q", err, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error %v does not wrap %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+)
+
+var (
+	pinTag          string
+	envoyGatewayTag string
+	aiGatewayTag    string
+)
+
+// pinnedComponent is an upstream project whose release tag pins the chart
+// version of the managed releases in ids. Its versions are the tags of the
+// OCI repository chart, or the GitHub releases of envoyproxy/repo.
+type pinnedComponent struct {
+	name  string
+	repo  string
+	chart string
+	flag  string
+	ids   []string
+	tag   func(cfg *config.Config) string
+}
+
+var pinnedComponents = []pinnedComponent{
+	{"Envoy Gateway", "gateway", "envoyproxy/gateway-helm", "envoy-gateway-tag", []string{"eg"},
+		func(cfg *config.Config) string { return cfg.GatewayTag }},
+	{"AI Gateway", "ai-gateway", "envoyproxy/ai-gateway-helm", "ai-gateway-tag", []string{"aieg-crd", "aieg"},
+		func(cfg *config.Config) string { return cfg.AIGatewayTag }},
+}
+
+// resolvedTags holds the chart version resolveTags found for each component,
+// by repo.
+var resolvedTags = map[string]string{}
+
+// componentOf returns the component whose tag pins release id, and false
+// when no component does, as for redis.
+func componentOf(id string) (pinnedComponent, bool) {
+	for _, c := range pinnedComponents {
+		if slices.Contains(c.ids, id) {
+			return c, true
+		}
+	}
+	return pinnedComponent{}, false
+}
+
+// releaseVersion returns the chart version of release id: its pinned tag,
+// the version resolveTags found, or the development build when the versions
+// were not resolved or no component pins the release.
+func releaseVersion(cfg *config.Config, id string) string {
+	c, ok := componentOf(id)
+	if !ok {
+		return chartVersion
+	}
+	if tag := c.tag(cfg); tag != "" {
+		return tag
+	}
+	if version, ok := resolvedTags[c.repo]; ok {
+		return version
+	}
+	return chartVersion
+}
+
+// resolveTags resolves the chart version of every component before anything
+// is installed: a pinned tag is checked to be published, and otherwise the
+// latest stable version is used. Versions come from the OCI registry the
+// charts are pulled from, with the GitHub releases as a fallback.
+func resolveTags(ctx context.Context, cfg *config.Config) error {
+	resolver := upstream.NewOCIResolver(upstream.DefaultRegistry)
+	for _, c := range pinnedComponents {
+		tag := c.tag(cfg)
+		version, err := resolver.ResolveVersion(ctx, c.chart, "envoyproxy", c.repo, tag)
+		switch {
+		case err != nil && tag == "":
+			return fmt.Errorf("could not resolve the latest %s version (pass --%s to pin one): %w", c.name, c.flag, err)
+		case err != nil && upstream.IsTransient(err):
+			return fmt.Errorf("could not verify %s tag %s: %w", c.name, tag, err)
+		case err != nil:
+			return fmt.Errorf("invalid %s tag: %w", c.name, err)
+		}
+
+		if tag != "" {
+			output.Printf("  %-21s%s (pinned)\n", c.name+":", version)
+			continue
+		}
+		resolvedTags[c.repo] = version
+		output.Printf("  %-21s%s (latest)\n", c.name+":", version)
+	}
+	return nil
+}
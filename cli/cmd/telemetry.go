@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/state"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/telemetry"
+)
+
+var (
+	otlpEndpoint      string
+	otlpProtocol      string
+	otlpInsecure      bool
+	tracingSampleRate float64
+)
+
+// telemetryValues returns the generated telemetry values block for a
+// release, or nil when the release has no telemetry settings.
+func telemetryValues(cfg *config.Config, id string) map[string]interface{} {
+	if cfg.Telemetry == nil {
+		return nil
+	}
+
+	switch id {
+	case "eg":
+		return telemetry.GatewayValues(cfg.Telemetry)
+	case "aieg":
+		return telemetry.AIGatewayValues(cfg.Telemetry)
+	}
+	return nil
+}
+
+// telemetryValuesFile writes the telemetry values of a release to a
+// temporary file so it can be layered like the embedded overlays. It
+// returns "" when the release has no telemetry values.
+func telemetryValuesFile(cfg *config.Config, id string) (string, error) {
+	values := telemetryValues(cfg, id)
+	if values == nil {
+		return "", nil
+	}
+
+	data, err := marshalYAML(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to render the telemetry values: %w", err)
+	}
+	file, err := writeOverlay("telemetry", id, []byte(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to write the telemetry values: %w", err)
+	}
+	return file, nil
+}
+
+func printTelemetry(cfg *config.Config, isDryRun bool) {
+	if cfg.Telemetry == nil {
+		return
+	}
+
+	output.Printf("  Tracing:             %s (%s, sample rate %g)\n",
+		cfg.Telemetry.URL(), cfg.Telemetry.Protocol, cfg.Telemetry.SampleRate)
+	if !isDryRun {
+		return
+	}
+
+	for _, id := range []string{"eg", "aieg"} {
+		data, err := marshalYAML(telemetryValues(cfg, id))
+		if err != nil {
+			continue
+		}
+		r, _ := releaseByID(cfg, id)
+		output.Printf("[DRY-RUN] telemetry values for %s:\n%s", r.name, redactManifest(data))
+	}
+}
+
+func telemetryState(cfg *config.Config) *state.Telemetry {
+	if cfg.Telemetry == nil {
+		return nil
+	}
+	return &state.Telemetry{
+		Endpoint:   cfg.Telemetry.URL(),
+		Protocol:   cfg.Telemetry.Protocol,
+		SampleRate: cfg.Telemetry.SampleRate,
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/observability"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/state"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the releases and resources created by install",
+	Long: `Uninstall the Envoy AI Gateway releases (and Redis, if installed),
+the observability resources and the installer state.
+
+kube-prometheus-stack is only removed when it was installed with
+--install-prometheus.
+
+CRDs, and the custom resources left in them, are kept unless --purge-crds
+is given: the AI Gateway CRD release is left installed, since uninstalling
+it deletes its CRDs. With --purge-crds, the AI Gateway and Envoy Gateway
+CRDs are listed with their remaining resources across namespaces and, once
+confirmed, the resources are deleted before the CRDs and the CRD release
+is uninstalled. Resources whose finalizers the uninstalled
+controllers can no longer remove are reported as stuck; --force removes
+their finalizers. The Gateway API CRDs may be used by other controllers and
+are only deleted with --include-gateway-api.`,
+	Example: `  envoy-ai-installer uninstall
+  envoy-ai-installer uninstall --purge-crds --dry-run
+  envoy-ai-installer uninstall --purge-crds --force --include-gateway-api`,
+	RunE: runUninstall,
+}
+
+func init() {
+	uninstallCmd.Flags().BoolVar(&forceUnlock, "force-unlock", false,
+		"take the installation lock over even if another installer holds it")
+	uninstallCmd.Flags().BoolVar(&purgeCRDs, "purge-crds", false,
+		"after removing the releases, delete the AI Gateway and Envoy Gateway CRDs and their remaining custom resources")
+	uninstallCmd.Flags().BoolVar(&includeGatewayAPI, "include-gateway-api", false,
+		"with --purge-crds, also delete the Gateway API CRDs, which other controllers may depend on")
+	uninstallCmd.Flags().BoolVar(&purgeForce, "force", false,
+		"with --purge-crds, remove the finalizers of custom resources stuck deleting")
+}
+
+func runUninstall(cmd *cobra.Command, args []string) error {
+	if (includeGatewayAPI || purgeForce) && !purgeCRDs {
+		return usageError(fmt.Errorf("--include-gateway-api and --force need --purge-crds"))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	isDryRun := viper.GetBool("dry_run")
+	cmd.SilenceUsage = true
+	if err := startTelemetry(cmd, cfg); err != nil {
+		return err
+	}
+
+	unlock, err := lockInstallation(cmd, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	st, err := state.Load(cfg.NamespaceAI, cfg.ReleasePrefix)
+	if err != nil {
+		output.Printf("⚠️  Could not read installation state: %v\n", err)
+	}
+
+	// Remove in reverse install order so the controllers go before the
+	// CRDs they serve.
+	candidates := []managedRelease{redisRelease(cfg)}
+	all := managedReleases(cfg)
+	for i := len(all) - 1; i >= 0; i-- {
+		candidates = append(candidates, all[i])
+	}
+
+	lookup := helm.NewHelmCommand(false)
+	var releases []managedRelease
+	var actions []string
+	// The CRD chart ships its CRDs as templates, so uninstalling it deletes
+	// them and every custom resource. It is only removed with --purge-crds,
+	// after the custom resources have been listed and confirmed.
+	var crdRelease *managedRelease
+	for _, r := range candidates {
+		rel, err := lookup.FindRelease(r.name, r.namespace)
+		if err != nil {
+			return err
+		}
+		if rel == nil {
+			continue
+		}
+		if r.id == "aieg-crd" {
+			if !purgeCRDs {
+				output.Printf("ℹ️  Keeping release %s and its CRDs; pass --purge-crds to remove them\n", r.name)
+				continue
+			}
+			crdRelease = &r
+			continue
+		}
+		releases = append(releases, r)
+		actions = append(actions, fmt.Sprintf("uninstall release %s in namespace %s", r.name, r.namespace))
+	}
+
+	gatewayClass := ""
+	if st != nil && st.GatewayClass != "" {
+		gatewayClass = st.GatewayClass
+		actions = append([]string{"delete GatewayClass " + gatewayClass + " unless Gateways use it"}, actions...)
+	}
+
+	var obs *state.Observability
+	if st != nil {
+		obs = st.Observability
+	}
+	if obs != nil {
+		actions = append(actions, fmt.Sprintf("delete monitors and dashboards labelled %s", observability.Selector(cfg.ReleasePrefix)))
+		if obs.PrometheusRelease != "" {
+			actions = append(actions, fmt.Sprintf("uninstall release %s in namespace %s", obs.PrometheusRelease, obs.PrometheusNamespace))
+		}
+	}
+	if st != nil {
+		actions = append(actions, fmt.Sprintf("delete installer state %s/%s",
+			cfg.NamespaceAI, state.ConfigMapNameFor(cfg.ReleasePrefix)))
+	}
+
+	if purgeCRDs {
+		actions = append(actions, fmt.Sprintf("delete the remaining CRDs of %s and their custom resources (listed for confirmation)",
+			strings.Join(purgeGroupList(), ", ")))
+		if crdRelease != nil {
+			actions = append(actions, fmt.Sprintf("then uninstall release %s in namespace %s", crdRelease.name, crdRelease.namespace))
+		}
+	}
+
+	if len(actions) == 0 {
+		output.Println("ℹ️  Nothing to uninstall")
+		return nil
+	}
+
+	if !isDryRun {
+		if err := confirmDestructive(actions); err != nil {
+			return err
+		}
+	}
+
+	output.Println("🗑️  Uninstalling Envoy AI Gateway")
+
+	helmCmd := helm.NewHelmCommand(isDryRun)
+	var list []steps.Step
+	if gatewayClass != "" {
+		list = append(list, steps.Step{
+			Name: "Delete GatewayClass " + gatewayClass,
+			Run: func(ctx context.Context) error {
+				return deleteGatewayClass(ctx, gatewayClass, isDryRun)
+			},
+		})
+	}
+	for _, r := range releases {
+		list = append(list, steps.Step{
+			Name: "Uninstall " + r.name,
+			Run: func(ctx context.Context) error {
+				if err := helmCmd.Uninstall(r.name, r.namespace); err != nil {
+					return fmt.Errorf("failed to uninstall %s: %w", r.name, err)
+				}
+				return nil
+			},
+		})
+	}
+
+	if obs != nil {
+		list = append(list, steps.Step{
+			Name: "Remove observability resources",
+			Run: func(ctx context.Context) error {
+				return removeObservability(obs, cfg.ReleasePrefix, isDryRun)
+			},
+		})
+	}
+
+	if st != nil {
+		list = append(list, steps.Step{
+			Name: "Delete installer state",
+			Run: func(ctx context.Context) error {
+				return deleteInstallState(cfg, isDryRun)
+			},
+		})
+	}
+
+	if err := runSteps(cmd, list); err != nil {
+		return err
+	}
+
+	if purgeCRDs {
+		// Outside the steps: the resources to delete are only known once
+		// the releases are gone, and must be confirmed again.
+		if err := purgeCustomResources(cmd.Context(), isDryRun); err != nil {
+			return fmt.Errorf("failed to purge CRDs: %w", err)
+		}
+		if crdRelease != nil {
+			if err := helmCmd.Uninstall(crdRelease.name, crdRelease.namespace); err != nil {
+				return fmt.Errorf("failed to uninstall %s: %w", crdRelease.name, err)
+			}
+		}
+	}
+
+	output.Println("\n✅ Uninstall complete!")
+	return nil
+}
+
+func deleteInstallState(cfg *config.Config, isDryRun bool) error {
+	args := []string{"delete", "configmap", state.ConfigMapNameFor(cfg.ReleasePrefix),
+		"-n", cfg.NamespaceAI, "--ignore-not-found"}
+	if isDryRun {
+		printDryRunKubectl(args...)
+		return nil
+	}
+
+	kubectl := k8s.Kubectl(args...)
+	kubectl.Stdout = output.Stdout
+	kubectl.Stderr = output.Stderr
+	if err := kubectl.Run(); err != nil {
+		return fmt.Errorf("failed to delete installer state: %w", err)
+	}
+	return nil
+}
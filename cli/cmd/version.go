@@ -1,59 +1,289 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
-	"github.com/spf13/cobra"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
 	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
 	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
-)
-
-var (
-	cliVersion = "dev"
-	gitCommit  = "unknown"
-	buildTime  = "unknown"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
-	Short: "Show CLI and upstream component versions",
-	Long: `Display the version of the envoy-ai-installer CLI and the versions
-of upstream components (Envoy Gateway, AI Gateway, etc.) that will be installed.`,
+	Short: "Show CLI, installed and upstream component versions",
+	Long: `Display the version of the envoy-ai-installer CLI, the Kubernetes version of
+the cluster and, for each upstream component (Envoy Gateway, AI Gateway,
+etc.), the version installed in the cluster and the latest available one.
+
+Use --client to show only the CLI and helm versions without contacting the
+cluster or the upstream registries.`,
 	RunE: runVersion,
 }
 
+var (
+	versionNotes      bool
+	versionPreRelease bool
+	versionShort      bool
+	versionClient     bool
+	versionOutput     string
+)
+
+// versionReport is the JSON output of the version command.
+type versionReport struct {
+	version.Info
+	HelmVersion       string             `json:"helm_version,omitempty"`
+	KubernetesVersion string             `json:"kubernetes_version,omitempty"`
+	KubernetesError   string             `json:"kubernetes_error,omitempty"`
+	Components        []componentVersion `json:"components,omitempty"`
+	Error             string             `json:"error,omitempty"`
+}
+
+// componentVersion compares the version of an upstream component installed
+// in the cluster with the latest one. Components that are not installed by a
+// managed release, such as the AI Gateway GitHub release, only have a
+// latest version.
+type componentVersion struct {
+	Component       string `json:"component"`
+	Repository      string `json:"repository"`
+	Release         string `json:"release,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	Installed       string `json:"installed,omitempty"`
+	AppVersion      string `json:"app_version,omitempty"`
+	Status          string `json:"status,omitempty"`
+	Latest          string `json:"latest,omitempty"`
+	Prerelease      bool   `json:"prerelease,omitempty"`
+	UpdateAvailable bool   `json:"update_available"`
+	Error           string `json:"error,omitempty"`
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionNotes, "notes", false,
+		"print the upstream release notes between the installed and the latest versions")
+	versionCmd.Flags().IntVar(&notesMaxLength, "notes-max-length", defaultNotesMaxLength,
+		"truncate each release's notes to this many characters (0 for no limit)")
+	versionCmd.Flags().BoolVar(&versionPreRelease, "pre-release", false,
+		"report the newest upstream releases, including release candidates and other pre-releases")
+	versionCmd.Flags().BoolVar(&versionShort, "short", false,
+		"print only the CLI version, without looking up upstream versions")
+	versionCmd.Flags().BoolVar(&versionClient, "client", false,
+		"show only the CLI and helm versions, without contacting the cluster or upstream registries")
+	versionCmd.Flags().StringVarP(&versionOutput, "output", "o", "text",
+		"output format: text or json")
+}
+
 func runVersion(cmd *cobra.Command, args []string) error {
-	fmt.Println("📦 envoy-ai-installer Version Information")
-	fmt.Println()
-	fmt.Printf("  CLI Version:    %s\n", cliVersion)
-	fmt.Printf("  Git Commit:     %s\n", gitCommit)
-	fmt.Printf("  Build Time:     %s\n", buildTime)
-	fmt.Println()
+	if versionOutput != "text" && versionOutput != "json" {
+		return usageError(fmt.Errorf("unsupported output format %q (expected text or json)", versionOutput))
+	}
+	if versionNotes && versionClient {
+		return usageError(errors.New("--notes fetches release notes and cannot be combined with --client"))
+	}
+	viper.BindPFlag("notes_max_length", cmd.Flags().Lookup("notes-max-length"))
 
-	helmCmd := helm.NewHelmCommand(false)
-	helmVersion, err := helmCmd.Version()
-	if err == nil {
-		fmt.Printf("  Helm Version:   %s", helmVersion)
+	info := version.Get()
+	if versionShort {
+		if versionOutput == "json" {
+			return printVersionJSON(info)
+		}
+		fmt.Println(info.Version)
+		return nil
+	}
+
+	report := versionReport{Info: info}
+	if helmVersion, err := helm.NewHelmCommand(false).Version(); err == nil {
+		report.HelmVersion = strings.TrimSpace(helmVersion)
 	}
 
-	fmt.Println("\n📋 Upstream Component Versions")
-	fmt.Println()
+	var cfg *config.Config
+	if !versionClient {
+		var err error
+		cfg, err = config.Load()
+		if err != nil {
+			return err
+		}
 
-	charts, err := upstream.GetUpstreamCharts()
-	if err != nil {
-		fmt.Printf("  ⚠️  Could not fetch upstream versions: %v\n", err)
+		if kubeVersion, err := k8s.ServerVersion(); err != nil {
+			report.KubernetesError = err.Error()
+		} else {
+			report.KubernetesVersion = kubeVersion
+		}
+
+		report.Components, err = componentVersions(cmd.Context(), cfg)
+		if err != nil {
+			report.Error = err.Error()
+		}
+	}
+
+	if versionOutput == "json" {
+		return printVersionJSON(report)
+	}
+
+	output.Println("📦 envoy-ai-installer Version Information")
+	output.Println()
+	output.Printf("  CLI Version:    %s\n", info.Version)
+	output.Printf("  Git Commit:     %s\n", info.Commit)
+	output.Printf("  Build Time:     %s\n", info.BuildTime)
+	output.Printf("  Go Version:     %s\n", info.GoVersion)
+	output.Printf("  Platform:       %s\n", info.Platform)
+	output.Println()
+
+	if report.HelmVersion != "" {
+		output.Printf("  Helm Version:   %s\n", report.HelmVersion)
+	}
+	if versionClient {
 		return nil
 	}
+	if report.KubernetesError != "" {
+		output.Printf("  Kubernetes:     ⚠️  unknown (%s)\n", report.KubernetesError)
+	} else {
+		output.Printf("  Kubernetes:     %s\n", report.KubernetesVersion)
+	}
+
+	output.Println("\n📋 Component Versions")
+	output.Println()
+	printComponentVersions(report.Components)
+
+	if report.Error != "" {
+		output.Printf("\n  ⚠️  Could not fetch upstream versions: %s\n", report.Error)
+	}
+
+	if versionNotes {
+		printReleaseNotes(cmd.Context(), cfg)
+	}
+
+	return nil
+}
+
+// componentVersions looks up the installed and the latest version of each
+// upstream component. A release that cannot be looked up, for example
+// because the cluster is unreachable, is reported in its Error; the
+// returned error is the upstream lookup failure, if any.
+func componentVersions(ctx context.Context, cfg *config.Config) ([]componentVersion, error) {
+	charts, err := upstream.GetUpstreamCharts(ctx, upstream.FetchOptions{PreRelease: versionPreRelease})
+
+	latest := map[string]upstream.ChartRelease{}
+	var components []componentVersion
+	for _, chart := range charts {
+		if chart.Chart != "" {
+			latest[chart.Chart] = chart
+		}
+	}
+
+	helmCmd := helm.NewHelmCommand(false)
+	for _, r := range managedReleases(cfg) {
+		pinned, _ := componentOf(r.id)
+		c := componentVersion{
+			Component:  releaseUpstreams[r.id],
+			Repository: "envoyproxy/" + pinned.repo,
+			Release:    r.name,
+			Namespace:  r.namespace,
+		}
+		if chart, ok := latest[c.Component]; ok {
+			c.Latest = chart.Version
+			c.Prerelease = chart.Prerelease
+		}
+
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		switch {
+		case err != nil:
+			c.Error = err.Error()
+		case rel == nil:
+			c.Status = "not installed"
+		default:
+			c.Installed = rel.ChartVersion()
+			c.AppVersion = rel.AppVersion
+			c.Status = rel.Status
+			c.UpdateAvailable = c.Latest != "" && upstream.IsNewerVersion(c.Latest, c.Installed)
+		}
+		components = append(components, c)
+	}
 
 	for _, chart := range charts {
-		fmt.Printf("  %s/%s:  %s\n", chart.Owner, chart.Repo, chart.Version)
+		if chart.Chart == "" {
+			components = append(components, componentVersion{
+				Component:  chart.Owner + "/" + chart.Repo,
+				Repository: chart.Owner + "/" + chart.Repo,
+				Latest:     chart.Version,
+				Prerelease: chart.Prerelease,
+			})
+		}
 	}
 
+	return components, err
+}
+
+func printComponentVersions(components []componentVersion) {
+	rows := [][]string{{"COMPONENT", "INSTALLED", "APP VERSION", "LATEST", "STATUS"}}
+	var errs []string
+	for _, c := range components {
+		installed, appVersion, latest := orDash(c.Installed), orDash(c.AppVersion), orDash(c.Latest)
+		if c.Prerelease {
+			latest += " (pre-release)"
+		}
+
+		status := c.Status
+		switch {
+		case c.Error != "":
+			installed, appVersion, status = "?", "?", "⚠️  unknown"
+			if !slices.Contains(errs, c.Error) {
+				errs = append(errs, c.Error)
+			}
+		case c.UpdateAvailable:
+			status = "⬆️  update available"
+		case c.Installed != "" && c.Installed == c.Latest:
+			status = "up to date"
+		}
+		rows = append(rows, []string{c.Component, installed, appVersion, latest, status})
+	}
+
+	widths := make([]int, len(rows[0])-1)
+	for _, row := range rows {
+		for i := range widths {
+			widths[i] = max(widths[i], len(row[i]))
+		}
+	}
+	for _, row := range rows {
+		line := "  "
+		for i, width := range widths {
+			line += fmt.Sprintf("%-*s  ", width, row[i])
+		}
+		output.Println(strings.TrimRight(line+row[len(row)-1], " "))
+	}
+
+	for _, err := range errs {
+		output.Printf("\n  ⚠️  Could not look up installed releases: %s\n", err)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func printVersionJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
 	return nil
 }
 
-func SetVersionInfo(version, commit, buildTime string) {
-	cliVersion = version
-	gitCommit = commit
-	buildTime = buildTime
+// SetVersionInfo records the version information injected into package main
+// with -ldflags and enables the root --version flag.
+func SetVersionInfo(cliVersion, commit, buildTime string) {
+	version.Set(cliVersion, commit, buildTime)
+	rootCmd.Version = version.Get().String()
 }
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+	"github.com/spf13/cobra"
+)
+
+var (
+	versionsLimit              int
+	versionsIncludePrereleases bool
+	versionsSince              string
+	versionsOutput             string
+	versionsTableLimit         int
+)
+
+var versionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "Explore available upstream releases",
+	Long: `List the available versions of the gateway-helm, ai-gateway-crds-helm and
+ai-gateway-helm charts, newest first, marking the installed version and the
+latest stable one. Use 'versions list' for publish dates, JSON output and
+more filters.`,
+	Args: cobra.NoArgs,
+	RunE: runVersions,
+}
+
+var versionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the latest releases of each upstream component",
+	Long: `List the newest GitHub releases of each upstream component with their
+tag, publish date and whether they are pre-releases, to help pick a version
+to upgrade to. Results are cached like the version command's lookups.`,
+	RunE: runVersionsList,
+}
+
+type componentReleases struct {
+	Repository string             `json:"repository"`
+	Releases   []upstream.Release `json:"releases"`
+	Error      string             `json:"error,omitempty"`
+}
+
+func init() {
+	versionsListCmd.Flags().IntVarP(&versionsLimit, "limit", "n", 5,
+		"number of releases to show per component")
+	versionsListCmd.Flags().BoolVar(&versionsIncludePrereleases, "include-prereleases", false,
+		"include pre-releases")
+	versionsListCmd.Flags().StringVar(&versionsSince, "since", "",
+		"only show releases newer than this version (e.g. v1.2.0)")
+	versionsListCmd.Flags().StringVarP(&versionsOutput, "output", "o", "text",
+		"output format: text or json")
+
+	versionsCmd.Flags().IntVarP(&versionsTableLimit, "limit", "n", 10,
+		"number of versions to show per chart (0 for all)")
+	versionsCmd.Flags().BoolVar(&versionsIncludePrereleases, "include-prereleases", false,
+		"include pre-releases")
+
+	versionsCmd.AddCommand(versionsListCmd)
+}
+
+func runVersions(cmd *cobra.Command, args []string) error {
+	if versionsTableLimit < 0 {
+		return usageError(errors.New("--limit must not be negative"))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	helmCmd := helm.NewHelmCommand(false)
+
+	type versionRow struct{ chart, version, status string }
+	rows := []versionRow{{"CHART", "VERSION", "STATUS"}}
+
+	available := map[string][]string{}
+	failures := map[string]error{}
+	for _, r := range managedReleases(cfg) {
+		chart := releaseUpstreams[r.id]
+		c, _ := componentOf(r.id)
+		repo := c.repo
+		if _, done := available[repo]; !done && failures[repo] == nil {
+			available[repo], failures[repo] = upstream.ListAvailableVersions(cmd.Context(), "envoyproxy", repo, versionsIncludePrereleases)
+		}
+		if err := failures[repo]; err != nil {
+			rows = append(rows, versionRow{chart, "-", "⚠️  " + err.Error()})
+			continue
+		}
+
+		versions := available[repo]
+		latest := ""
+		for _, v := range versions {
+			if upstream.IsStableVersion(v) {
+				latest = v
+				break
+			}
+		}
+		installed := ""
+		if rel, err := helmCmd.FindRelease(r.name, r.namespace); err == nil && rel != nil {
+			installed = rel.ChartVersion()
+		}
+
+		shown := slices.Clone(versions)
+		if versionsTableLimit > 0 && len(shown) > versionsTableLimit {
+			shown = shown[:versionsTableLimit]
+		}
+		// The latest stable and installed versions are always listed.
+		for _, v := range []string{latest, installed} {
+			if v != "" && !slices.Contains(shown, v) {
+				shown = append(shown, v)
+			}
+		}
+		if len(shown) == 0 {
+			rows = append(rows, versionRow{chart, "-", "no releases"})
+		}
+
+		for _, v := range shown {
+			var status []string
+			if v == latest {
+				status = append(status, "latest stable")
+			}
+			if v == installed {
+				status = append(status, "installed")
+			}
+			rows = append(rows, versionRow{chart, v, strings.Join(status, ", ")})
+		}
+	}
+
+	chartWidth, versionWidth := 0, 0
+	for _, row := range rows {
+		chartWidth = max(chartWidth, len(row.chart))
+		versionWidth = max(versionWidth, len(row.version))
+	}
+	for _, row := range rows {
+		output.Println(strings.TrimRight(fmt.Sprintf("%-*s  %-*s  %s", chartWidth, row.chart, versionWidth, row.version, row.status), " "))
+	}
+
+	output.Println("\nPin a version with install --envoy-gateway-tag or --ai-gateway-tag.")
+	return nil
+}
+
+func runVersionsList(cmd *cobra.Command, args []string) error {
+	if versionsOutput != "text" && versionsOutput != "json" {
+		return usageError(fmt.Errorf("unsupported output format %q (expected text or json)", versionsOutput))
+	}
+	if versionsLimit < 1 {
+		return fmt.Errorf("--limit must be at least 1")
+	}
+
+	filter := upstream.ReleaseFilter{
+		IncludePrereleases: versionsIncludePrereleases,
+		Since:              versionsSince,
+	}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	var results []componentReleases
+	for _, repo := range upstream.Repositories() {
+		owner, name, _ := strings.Cut(repo, "/")
+		result := componentReleases{Repository: repo}
+
+		releases, err := upstream.ListReleases(cmd.Context(), owner, name, versionsLimit, filter)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Releases = releases
+		results = append(results, result)
+	}
+
+	if versionsOutput == "json" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	for _, result := range results {
+		output.Printf("📋 %s\n", result.Repository)
+		switch {
+		case result.Error != "":
+			output.Printf("  ⚠️  %s\n", result.Error)
+		case len(result.Releases) == 0:
+			output.Println("  No matching releases")
+		}
+		for _, rel := range result.Releases {
+			flag := ""
+			if rel.Prerelease {
+				flag = "  (pre-release)"
+			}
+			output.Printf("  %-24s %s%s\n", rel.Tag, rel.PublishedAt.Local().Format("2006-01-02"), flag)
+		}
+		output.Println()
+	}
+
+	return nil
+}
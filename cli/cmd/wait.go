@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/health"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// bindWaitTimeout binds wait_timeout to the --wait-timeout of cmd, whose
+// default differs between commands, and returns its value.
+func bindWaitTimeout(cmd *cobra.Command) (time.Duration, error) {
+	viper.BindPFlag("wait_timeout", cmd.Flags().Lookup("wait-timeout"))
+	timeout := viper.GetDuration("wait_timeout")
+	if timeout < 0 {
+		return 0, usageError(fmt.Errorf("invalid --wait-timeout %s: must not be negative", timeout))
+	}
+	return timeout, nil
+}
+
+func waitForPods(parent context.Context, cfg *config.Config, timeout, interval time.Duration) error {
+	client, err := k8s.NewKubeClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	// Inside a step with a progress display, stdout is captured and the
+	// step's spinner is already running.
+	spinning := output.ColorEnabled() && ui.IsTerminal(os.Stdout)
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriter(os.Stdout))
+	s.Suffix = " Waiting for pods..."
+	if spinning {
+		s.Start()
+	}
+
+	var last health.Result
+	var lastSuffix string
+	for result := range health.Poll(ctx, client, uniqueNamespaces(cfg), interval) {
+		last = result
+		if result.AllReady() {
+			break
+		}
+
+		done, total := result.Counts()
+		switch {
+		case result.Err != nil:
+			s.Suffix = fmt.Sprintf(" Waiting for pods... (%v)", result.Err)
+		case total == 0:
+			s.Suffix = " Waiting for pods to be scheduled..."
+		default:
+			pending := result.Pending()[0]
+			detail := pending.Phase
+			if pending.Reason != "" {
+				detail = pending.Reason
+			}
+			s.Suffix = fmt.Sprintf(" %d/%d pods ready, waiting for %s/%s (%s)",
+				done, total, pending.Namespace, pending.Name, detail)
+		}
+
+		// Without the spinner, print progress only when it changes.
+		if !spinning && s.Suffix != lastSuffix {
+			output.Println(" " + s.Suffix)
+			lastSuffix = s.Suffix
+		}
+	}
+	s.Stop()
+
+	done, total := last.Counts()
+	if last.AllReady() {
+		output.Printf("  ✅ All %d pods are ready\n", total)
+		return nil
+	}
+
+	if err := parent.Err(); err != nil {
+		return fmt.Errorf("stopped waiting for pods: %w", err)
+	}
+
+	output.Printf("  ❌ %d/%d pods ready after %s\n", done, total, timeout)
+	for _, p := range last.Pending() {
+		detail := p.Phase
+		if p.Reason != "" {
+			detail = p.Reason
+		}
+		output.Printf("     %s/%s: %s (restarts: %d)\n", p.Namespace, p.Name, detail, p.Restarts)
+	}
+	if last.Err != nil {
+		output.Printf("     %v\n", last.Err)
+	}
+
+	return &ExitError{Code: ExitVerification, Err: fmt.Errorf("timed out after %s waiting for pods to become ready", timeout)}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/health"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/helm"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+var watchInterval time.Duration
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch the health of the installed components until they are healthy",
+	Long: `Check the installed releases, their pods and the AI Gateway CRDs every
+--interval, redrawing the screen with the time of each check, like
+'watch kubectl get pods'.
+
+The command exits once every component is healthy: the Envoy Gateway and AI
+Gateway releases deployed, all pods ready and all CRDs established. Press
+Ctrl+C to stop earlier. When stdout is not a terminal, each check is printed
+below the previous one instead.`,
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 5*time.Second,
+		"how often to re-run the checks")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if watchInterval <= 0 {
+		return usageError(fmt.Errorf("invalid --interval %s (expected a positive duration)", watchInterval))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	client, err := k8s.NewKubeClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := notifyInterrupt(cmd.Context())
+	defer stop()
+
+	helmCmd := helm.NewHelmCommand(false)
+	redraw := ui.IsTerminal(os.Stdout)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		frame, healthy := watchFrame(helmCmd, client, cfg, time.Now())
+		if redraw {
+			output.Print(clearScreen)
+		} else {
+			output.Println()
+		}
+		output.Print(frame)
+
+		if healthy {
+			output.Println("\n✅ All components are healthy")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			output.Println("\n⏹️  Stopped watching")
+			cmd.SilenceUsage = true
+			return fmt.Errorf("watch interrupted before all components were healthy: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchFrame runs the checks once and returns their report, and whether
+// every component is healthy.
+func watchFrame(helmCmd *helm.HelmCommand, client k8s.KubeClient, cfg *config.Config, now time.Time) (string, bool) {
+	var b strings.Builder
+	healthy := true
+
+	fmt.Fprintf(&b, "Every %s: envoy-ai-installer watch    %s\n", watchInterval, now.Local().Format("2006-01-02 15:04:05"))
+
+	fmt.Fprintln(&b, "\n📦 Releases")
+	releases := managedReleases(cfg)
+	if rel, err := helmCmd.FindRelease(redisRelease(cfg).name, cfg.NamespaceAI); err == nil && rel != nil {
+		releases = append(releases, redisRelease(cfg))
+	}
+	for _, r := range releases {
+		rel, err := helmCmd.FindRelease(r.name, r.namespace)
+		switch {
+		case err != nil:
+			fmt.Fprintf(&b, "  ⚠️  %-12s %v\n", r.name, err)
+			healthy = false
+		case rel == nil:
+			fmt.Fprintf(&b, "  ❌ %-12s not installed\n", r.name)
+			healthy = false
+		case rel.Status != "deployed":
+			fmt.Fprintf(&b, "  ⚠️  %-12s %s (%s)\n", r.name, rel.Chart, rel.Status)
+			healthy = false
+		default:
+			fmt.Fprintf(&b, "  ✅ %-12s %s (%s)\n", r.name, rel.Chart, rel.Status)
+		}
+	}
+
+	fmt.Fprintln(&b, "\n🐳 Pods")
+	pods := health.Check(client, uniqueNamespaces(cfg))
+	if pods.Err != nil {
+		fmt.Fprintf(&b, "  ⚠️  %v\n", pods.Err)
+	}
+	if len(pods.Pods) == 0 && pods.Err == nil {
+		fmt.Fprintln(&b, "  ⚠️  no pods yet")
+	}
+	for _, p := range pods.Pods {
+		icon := "✅"
+		detail := p.Phase
+		if p.Reason != "" {
+			detail = p.Reason
+		}
+		if !p.Ready && p.Phase != "Succeeded" {
+			icon = "⚠️ "
+		}
+		if p.Restarts > 0 {
+			detail += fmt.Sprintf(" (restarts: %d)", p.Restarts)
+		}
+		fmt.Fprintf(&b, "  %s %s/%s  %s\n", icon, p.Namespace, p.Name, detail)
+	}
+	if !pods.AllReady() {
+		healthy = false
+	}
+
+	fmt.Fprintln(&b, "\n📜 CRDs")
+	crds, err := k8s.GetGroupCRDs(aiGatewayCRDGroup)
+	switch {
+	case err != nil:
+		fmt.Fprintf(&b, "  ⚠️  %v\n", err)
+		healthy = false
+	case len(crds) == 0:
+		fmt.Fprintf(&b, "  ❌ no %s CRD installed\n", aiGatewayCRDGroup)
+		healthy = false
+	default:
+		var pending []string
+		for _, crd := range crds {
+			if !crd.Established {
+				pending = append(pending, crd.Name)
+			}
+		}
+		if len(pending) == 0 {
+			fmt.Fprintf(&b, "  ✅ %d AI Gateway CRDs established\n", len(crds))
+		} else {
+			fmt.Fprintf(&b, "  ⚠️  not established: %s\n", strings.Join(pending, ", "))
+			healthy = false
+		}
+	}
+
+	if !healthy {
+		done, total := pods.Counts()
+		fmt.Fprintf(&b, "\n⏳ Not healthy yet (%d/%d pods ready); checking again in %s, Ctrl+C to stop\n", done, total, watchInterval)
+	}
+	return b.String(), healthy
+}
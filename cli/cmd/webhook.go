@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/config"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+// The wait between two probes of the webhooks doubles from
+// webhookInitialBackoff up to webhookMaxBackoff.
+const (
+	webhookInitialBackoff = time.Second
+	webhookMaxBackoff     = 15 * time.Second
+)
+
+// waitAIGatewayWebhooks waits until every admission webhook served from the
+// AI Gateway namespace can be called: its CA is injected and its Service has
+// ready endpoints. A Ready controller Deployment is not enough, as the
+// webhook certificates and endpoints follow it by a few seconds, and until
+// then the API server rejects AI Gateway resources with "failed calling
+// webhook". Code that creates AI Gateway resources must call this first.
+func waitAIGatewayWebhooks(parent context.Context, cfg *config.Config, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	count := 0
+	lastProblem := ""
+	err := retryWithBackoff(ctx, webhookInitialBackoff, webhookMaxBackoff, func() error {
+		webhooks, err := k8s.GetServiceWebhooks(cfg.NamespaceAI)
+		if err != nil {
+			return err
+		}
+		count = len(webhooks)
+		if problem := webhooksProblem(webhooks); problem != "" {
+			if problem != lastProblem {
+				output.Printf("  Waiting for webhooks: %s\n", problem)
+				lastProblem = problem
+			}
+			return errors.New(problem)
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil && count == 0:
+		output.Printf("  ℹ️  No admission webhooks are served from %s\n", cfg.NamespaceAI)
+		return nil
+	case err == nil:
+		output.Printf("  ✅ All %d admission webhooks are serving\n", count)
+		return nil
+	case parent.Err() != nil:
+		return fmt.Errorf("stopped waiting for webhooks: %w", parent.Err())
+	}
+	return &ExitError{Code: ExitVerification, Err: fmt.Errorf("timed out after %s waiting for the AI Gateway webhooks: %w", timeout, err)}
+}
+
+// webhooksProblem returns why the first webhook that cannot be called yet
+// cannot, or "". Webhooks sharing a Service are probed once.
+func webhooksProblem(webhooks []k8s.AdmissionWebhook) string {
+	probed := map[string]bool{}
+	for _, w := range webhooks {
+		if !w.HasCABundle {
+			return fmt.Sprintf("%s %s has no caBundle yet", w.Kind, w.Configuration)
+		}
+		service := w.Namespace + "/" + w.Service
+		if probed[service] {
+			continue
+		}
+		probed[service] = true
+
+		ready, err := k8s.ReadyEndpoints(w.Namespace, w.Service)
+		if err != nil {
+			return fmt.Sprintf("service %s: %v", service, err)
+		}
+		if ready == 0 {
+			return fmt.Sprintf("service %s has no ready endpoints", service)
+		}
+	}
+	return ""
+}
+
+// retryWithBackoff calls probe until it succeeds or ctx is done, doubling
+// the wait between attempts from initial up to limit. It returns the last
+// error of probe when ctx ends first.
+func retryWithBackoff(ctx context.Context, initial, limit time.Duration, probe func() error) error {
+	backoff := initial
+	for {
+		err := probe()
+		if err == nil {
+			return nil
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, limit)
+	}
+}
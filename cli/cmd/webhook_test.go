@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+)
+
+func TestRetryWithBackoff(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		timeout      time.Duration
+		wantAttempts int
+		wantErr      bool
+		// minElapsed is the sum of the waits between the attempts, with the
+		// backoff doubling from 1ms up to 4ms.
+		minElapsed time.Duration
+	}{
+		{name: "first attempt", failures: 0, timeout: time.Second, wantAttempts: 1},
+		{name: "fails then succeeds", failures: 4, timeout: time.Second, wantAttempts: 5, minElapsed: (1 + 2 + 4 + 4) * time.Millisecond},
+		{name: "never succeeds", failures: 1 << 30, timeout: 30 * time.Millisecond, wantErr: true, minElapsed: 30 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			attempts := 0
+			start := time.Now()
+			err := retryWithBackoff(ctx, time.Millisecond, 4*time.Millisecond, func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return fmt.Errorf("connection refused (attempt %d)", attempts)
+				}
+				return nil
+			})
+			elapsed := time.Since(start)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr && err.Error() != fmt.Sprintf("connection refused (attempt %d)", attempts) {
+				t.Errorf("got error %q, want the last one of %d attempts", err, attempts)
+			}
+			if tt.wantAttempts > 0 && attempts != tt.wantAttempts {
+				t.Errorf("got %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("returned after %s, want at least %s of backoff", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestRetryWithBackoffCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	probeErr := errors.New("webhook not serving")
+
+	attempts := 0
+	err := retryWithBackoff(ctx, time.Hour, time.Hour, func() error {
+		attempts++
+		cancel()
+		return probeErr
+	})
+	if !errors.Is(err, probeErr) || attempts != 1 {
+		t.Errorf("got error %v after %d attempts, want the probe error after 1", err, attempts)
+	}
+}
+
+func TestWebhooksProblemCABundle(t *testing.T) {
+	webhooks := []k8s.AdmissionWebhook{{
+		Kind:          "MutatingWebhookConfiguration",
+		Configuration: "envoy-ai-gateway-gateway-pod-mutator",
+		Namespace:     "envoy-ai-gateway-system",
+		Service:       "ai-gateway-controller",
+	}}
+
+	problem := webhooksProblem(webhooks)
+	if !strings.Contains(problem, "envoy-ai-gateway-gateway-pod-mutator has no caBundle yet") {
+		t.Errorf("got problem %q, want the missing caBundle", problem)
+	}
+	if problem := webhooksProblem(nil); problem != "" {
+		t.Errorf("got problem %q without webhooks", problem)
+	}
+}
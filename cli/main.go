@@ -1,23 +1,33 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/franck-sorel/envoy-ai-unified-installer/cmd"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
 )
 
+// Set with -ldflags -X by the Makefile. When empty, the version, commit and
+// build time come from the build information embedded by the Go toolchain.
 var (
-	version   = "0.1.0"
-	gitCommit = "dev"
-	buildTime = "unknown"
+	version   string
+	gitCommit string
+	buildTime string
 )
 
 func main() {
 	cmd.SetVersionInfo(version, gitCommit, buildTime)
 
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		var exitErr *cmd.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		if path := output.LogPath(); path != "" {
+			fmt.Fprintf(os.Stderr, "Full log: %s\n", path)
+		}
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,77 @@
+// Package archive writes the gzipped tar archives of the backup, report and
+// snapshot commands.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// File is an entry of an archive.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Sorted returns files in the order of their names, so that the same files
+// always make the same archive.
+func Sorted(files map[string][]byte) []File {
+	sorted := make([]File, 0, len(files))
+	for name, data := range files {
+		sorted = append(sorted, File{Name: name, Data: data})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// WriteFile writes files to a new archive at path, creating its directory.
+// Archives hold release values and logs, which can include credentials, so
+// only the owner can read it.
+func WriteFile(path string, files []File, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, files, modTime); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes files to w as a gzipped tar, in order, with modTime as the
+// time of every entry.
+func Write(w io.Writer, files []File, modTime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, file := range files {
+		hdr := &tar.Header{
+			Name:    file.Name,
+			Mode:    0o600,
+			Size:    int64(len(file.Data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+		if _, err := tw.Write(file.Data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,78 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWriteFile(t *testing.T) {
+	modTime := time.Date(2024, 1, 10, 15, 30, 0, 0, time.UTC)
+	files := append([]File{{Name: "metadata.json", Data: []byte("{}")}}, Sorted(map[string][]byte{
+		"releases/eg/values.yaml":   []byte("replicas: 2\n"),
+		"logs/controller/pod.log":   []byte("started\n"),
+		"releases/aieg/values.yaml": nil,
+	})...)
+
+	path := filepath.Join(t.TempDir(), "reports", "report.tar.gz")
+	if err := WriteFile(path, files, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm != 0o600 {
+		t.Errorf("got mode %v, want 0600", perm)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	want := []string{"metadata.json", "logs/controller/pod.log", "releases/aieg/values.yaml", "releases/eg/values.yaml"}
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("got %d entries, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) || hdr.Name != want[i] {
+			t.Fatalf("entry %d is %s, want the order %v", i, hdr.Name, want)
+		}
+		if !hdr.ModTime.Equal(modTime) || hdr.Mode != 0o600 {
+			t.Errorf("%s: got time %s and mode %o", hdr.Name, hdr.ModTime, hdr.Mode)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != string(files[i].Data) {
+			t.Errorf("%s: got %q, want %q", hdr.Name, data, files[i].Data)
+		}
+	}
+}
+
+func TestWriteFileFails(t *testing.T) {
+	if err := WriteFile(t.TempDir(), nil, time.Time{}); err == nil {
+		t.Error("writing over a directory succeeded")
+	}
+}
@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/archive"
+)
+
+const metadataFile = "metadata.json"
+
+type Release struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Chart      string `json:"chart"`
+	Version    string `json:"version"`
+	AppVersion string `json:"app_version"`
+	ValuesFile string `json:"values_file"`
+}
+
+type Metadata struct {
+	CreatedAt        time.Time `json:"created_at"`
+	CLIVersion       string    `json:"cli_version"`
+	NamespaceGateway string    `json:"namespace_gateway"`
+	NamespaceAI      string    `json:"namespace_ai"`
+	ReleasePrefix    string    `json:"release_prefix,omitempty"`
+	Releases         []Release `json:"releases"`
+}
+
+type Archive struct {
+	Metadata Metadata
+	Files    map[string][]byte
+}
+
+func NewArchive(meta Metadata) *Archive {
+	return &Archive{
+		Metadata: meta,
+		Files:    map[string][]byte{},
+	}
+}
+
+func (a *Archive) Add(name string, data []byte) {
+	a.Files[name] = data
+}
+
+func FileName(t time.Time) string {
+	return fmt.Sprintf("envoy-ai-backup-%s.tar.gz", t.UTC().Format("20060102-150405"))
+}
+
+func (a *Archive) Write(path string) error {
+	meta, err := json.MarshalIndent(a.Metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup metadata: %w", err)
+	}
+
+	files := append([]archive.File{{Name: metadataFile, Data: meta}}, archive.Sorted(a.Files)...)
+	return archive.WriteFile(path, files, a.Metadata.CreatedAt)
+}
+
+func Read(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid backup archive: %w", path, err)
+	}
+	defer gz.Close()
+
+	a := &Archive{Files: map[string][]byte{}}
+	foundMetadata := false
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup archive: %w", err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from backup: %w", hdr.Name, err)
+		}
+
+		if hdr.Name == metadataFile {
+			if err := json.Unmarshal(data, &a.Metadata); err != nil {
+				return nil, fmt.Errorf("invalid backup metadata: %w", err)
+			}
+			foundMetadata = true
+			continue
+		}
+		a.Files[hdr.Name] = data
+	}
+
+	if !foundMetadata {
+		return nil, fmt.Errorf("%s does not contain %s", path, metadataFile)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,51 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWriteRead(t *testing.T) {
+	createdAt := time.Date(2024, 1, 10, 15, 30, 0, 0, time.UTC)
+	archive := NewArchive(Metadata{
+		CreatedAt:        createdAt,
+		NamespaceGateway: "envoy-gateway-system",
+		NamespaceAI:      "envoy-ai-gateway-system",
+		Releases:         []Release{{Name: "eg", Namespace: "envoy-gateway-system", ValuesFile: "releases/eg/values.yaml"}},
+	})
+	archive.Add("releases/eg/values.yaml", []byte("apiKey: secret\n"))
+
+	path := filepath.Join(t.TempDir(), "backups", FileName(createdAt))
+	if err := archive.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm != 0o600 {
+		t.Errorf("got mode %v, want 0600", perm)
+	}
+
+	read, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(read.Files["releases/eg/values.yaml"]); got != "apiKey: secret\n" {
+		t.Errorf("got values %q", got)
+	}
+	if !read.Metadata.CreatedAt.Equal(createdAt) || len(read.Metadata.Releases) != 1 {
+		t.Errorf("got metadata %+v", read.Metadata)
+	}
+}
+
+func TestWriteFails(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewArchive(Metadata{}).Write(dir); err == nil {
+		t.Error("writing over a directory succeeded")
+	}
+}
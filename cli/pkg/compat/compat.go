@@ -0,0 +1,215 @@
+package compat
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// DefaultURL serves the latest compatibility table, so new upstream releases
+// are covered without upgrading the installer.
+const DefaultURL = "https://raw.githubusercontent.com/franck-sorel/envoy-ai-unified-installer/main/cli/pkg/compat/compat.json"
+
+//go:embed compat.json
+var embedded []byte
+
+// Entry pairs a range of AI Gateway versions with the Envoy Gateway versions
+// they support. Ranges are space-separated constraints such as
+// ">=1.4.0 <1.5.0"; the operators are >=, >, <=, < and =.
+type Entry struct {
+	AIGateway    string `json:"ai_gateway"`
+	EnvoyGateway string `json:"envoy_gateway"`
+}
+
+type Matrix struct {
+	Entries []Entry `json:"entries"`
+}
+
+type Status string
+
+const (
+	Compatible   Status = "compatible"
+	Incompatible Status = "incompatible"
+	// Unknown means the table has no entry for the AI Gateway version.
+	Unknown Status = "unknown"
+	// Skipped means a version is not a release, e.g. v0.0.0-latest.
+	Skipped Status = "skipped"
+)
+
+type Verdict struct {
+	Status  Status
+	Message string
+	// Supported is the Envoy Gateway range for the AI Gateway version, when
+	// the table has one.
+	Supported string
+}
+
+// Embedded returns the table compiled into the binary.
+func Embedded() (*Matrix, error) {
+	return parse(embedded)
+}
+
+// Load fetches the table from url and falls back to the embedded copy when
+// it cannot be fetched or parsed. It returns the table and where it came
+// from.
+func Load(ctx context.Context, url string) (*Matrix, string, error) {
+	if url != "" {
+		if m, err := fetch(ctx, url); err == nil {
+			return m, url, nil
+		}
+	}
+
+	m, err := Embedded()
+	return m, "embedded", err
+}
+
+func fetch(ctx context.Context, url string) (*Matrix, error) {
+	resp, err := httpclient.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
+
+func parse(data []byte) (*Matrix, error) {
+	var m Matrix
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid compatibility table: %w", err)
+	}
+
+	for _, e := range m.Entries {
+		for _, r := range []string{e.AIGateway, e.EnvoyGateway} {
+			if _, err := parseRange(r); err != nil {
+				return nil, fmt.Errorf("invalid compatibility table: %w", err)
+			}
+		}
+	}
+	return &m, nil
+}
+
+// Check reports whether the Envoy Gateway and AI Gateway chart versions are
+// a supported pair.
+func (m *Matrix) Check(envoyGateway, aiGateway string) Verdict {
+	eg, err := release(envoyGateway)
+	if err != nil {
+		return Verdict{Status: Skipped, Message: fmt.Sprintf("Envoy Gateway %s: %v", envoyGateway, err)}
+	}
+	aieg, err := release(aiGateway)
+	if err != nil {
+		return Verdict{Status: Skipped, Message: fmt.Sprintf("AI Gateway %s: %v", aiGateway, err)}
+	}
+
+	for _, e := range m.Entries {
+		if !matches(e.AIGateway, aieg) {
+			continue
+		}
+
+		if matches(e.EnvoyGateway, eg) {
+			return Verdict{
+				Status:    Compatible,
+				Message:   fmt.Sprintf("AI Gateway %s supports Envoy Gateway %s", aiGateway, envoyGateway),
+				Supported: e.EnvoyGateway,
+			}
+		}
+		return Verdict{
+			Status: Incompatible,
+			Message: fmt.Sprintf("AI Gateway %s requires Envoy Gateway %s, got %s",
+				aiGateway, e.EnvoyGateway, envoyGateway),
+			Supported: e.EnvoyGateway,
+		}
+	}
+
+	return Verdict{
+		Status:  Unknown,
+		Message: fmt.Sprintf("no compatibility data for AI Gateway %s", aiGateway),
+	}
+}
+
+// release parses a released semantic version. Pre-releases and development
+// builds such as v0.0.0-latest are rejected since no range can describe them.
+func release(v string) (*version.Version, error) {
+	parsed, err := version.ParseSemantic(v)
+	if err != nil {
+		return nil, fmt.Errorf("not a semantic version")
+	}
+	if parsed.PreRelease() != "" {
+		return nil, fmt.Errorf("pre-release or development build")
+	}
+	return parsed, nil
+}
+
+type constraint struct {
+	op      string
+	version *version.Version
+}
+
+func parseRange(r string) ([]constraint, error) {
+	fields := strings.Fields(r)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version range")
+	}
+
+	var constraints []constraint
+	for _, field := range fields {
+		op := strings.TrimRight(field, "v0123456789.")
+		if op == "" {
+			op = "="
+		}
+		switch op {
+		case ">=", ">", "<=", "<", "=":
+		default:
+			return nil, fmt.Errorf("invalid operator %q in version range %q", op, r)
+		}
+
+		v, err := version.ParseSemantic(strings.TrimPrefix(field, op))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", r, err)
+		}
+		constraints = append(constraints, constraint{op, v})
+	}
+	return constraints, nil
+}
+
+func matches(r string, v *version.Version) bool {
+	constraints, err := parseRange(r)
+	if err != nil {
+		return false
+	}
+
+	for _, c := range constraints {
+		ok := false
+		switch c.op {
+		case ">=":
+			ok = v.AtLeast(c.version)
+		case ">":
+			ok = v.GreaterThan(c.version)
+		case "<=":
+			ok = !v.GreaterThan(c.version)
+		case "<":
+			ok = v.LessThan(c.version)
+		case "=":
+			ok = v.EqualTo(c.version)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
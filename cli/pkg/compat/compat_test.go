@@ -0,0 +1,110 @@
+package compat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testTable = `{
+  "entries": [
+    {"ai_gateway": ">=0.2.0 <0.3.0", "envoy_gateway": ">=1.4.0 <1.5.0"},
+    {"ai_gateway": "=0.3.0", "envoy_gateway": ">1.4.0 <=1.5.2"}
+  ]
+}`
+
+func TestCheck(t *testing.T) {
+	m, err := parse([]byte(testTable))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		envoyGateway string
+		aiGateway    string
+		want         Status
+		supported    string
+	}{
+		{name: "in range", envoyGateway: "v1.4.2", aiGateway: "v0.2.1", want: Compatible, supported: ">=1.4.0 <1.5.0"},
+		{name: "without v prefix", envoyGateway: "1.4.0", aiGateway: "0.2.0", want: Compatible, supported: ">=1.4.0 <1.5.0"},
+		{name: "upper bound excluded", envoyGateway: "v1.5.0", aiGateway: "v0.2.0", want: Incompatible, supported: ">=1.4.0 <1.5.0"},
+		{name: "below range", envoyGateway: "v1.3.9", aiGateway: "v0.2.0", want: Incompatible, supported: ">=1.4.0 <1.5.0"},
+		{name: "exact AI Gateway version", envoyGateway: "v1.5.2", aiGateway: "v0.3.0", want: Compatible, supported: ">1.4.0 <=1.5.2"},
+		{name: "lower bound excluded", envoyGateway: "v1.4.0", aiGateway: "v0.3.0", want: Incompatible, supported: ">1.4.0 <=1.5.2"},
+		{name: "AI Gateway newer than the table", envoyGateway: "v1.5.0", aiGateway: "v0.3.1", want: Unknown},
+		{name: "AI Gateway older than the table", envoyGateway: "v1.2.0", aiGateway: "v0.1.0", want: Unknown},
+		{name: "development AI Gateway build", envoyGateway: "v1.4.0", aiGateway: "v0.0.0-latest", want: Skipped},
+		{name: "development Envoy Gateway build", envoyGateway: "v0.0.0-latest", aiGateway: "v0.2.0", want: Skipped},
+		{name: "release candidate", envoyGateway: "v1.4.0-rc.1", aiGateway: "v0.2.0", want: Skipped},
+		{name: "not a version", envoyGateway: "v1.4.0", aiGateway: "latest", want: Skipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.Check(tt.envoyGateway, tt.aiGateway)
+			if got.Status != tt.want || got.Supported != tt.supported {
+				t.Errorf("Check(%s, %s) = %s (supported %q), want %s (supported %q): %s",
+					tt.envoyGateway, tt.aiGateway, got.Status, got.Supported, tt.want, tt.supported, got.Message)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":         `entries`,
+		"empty range":      `{"entries": [{"ai_gateway": "", "envoy_gateway": ">=1.4.0"}]}`,
+		"unknown operator": `{"entries": [{"ai_gateway": "~0.2.0", "envoy_gateway": ">=1.4.0"}]}`,
+		"bad version":      `{"entries": [{"ai_gateway": ">=0.2", "envoy_gateway": ">=1.4.0"}]}`,
+	}
+
+	for name, table := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parse([]byte(table)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	if _, err := Embedded(); err != nil {
+		t.Fatalf("embedded table: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/compat.json":
+			w.Write([]byte(testTable))
+		case "/invalid.json":
+			w.Write([]byte(`{"entries": [{"ai_gateway": "~0.2.0"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: srv.URL + "/compat.json", want: srv.URL + "/compat.json"},
+		{url: srv.URL + "/missing.json", want: "embedded"},
+		{url: srv.URL + "/invalid.json", want: "embedded"},
+		{url: "", want: "embedded"},
+	}
+
+	for _, tt := range tests {
+		m, source, err := Load(context.Background(), tt.url)
+		if err != nil || m == nil {
+			t.Fatalf("Load(%q): %v", tt.url, err)
+		}
+		if source != tt.want {
+			t.Errorf("Load(%q) came from %s, want %s", tt.url, source, tt.want)
+		}
+	}
+}
@@ -4,23 +4,121 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/telemetry"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+const (
+	ValuesTargetGateway = "gateway"
+	ValuesTargetAI      = "ai"
+	ValuesTargetRedis   = "redis"
+)
+
+// MinRedisHAReplicas is the fewest Redis replicas Sentinel can fail over
+// with.
+const MinRedisHAReplicas = 2
+
+// DefaultRedisPort is the port of an external Redis unless configured.
+const DefaultRedisPort = 6379
+
+// RedisExternal is an existing Redis that the rate limit service of Envoy
+// Gateway uses instead of one installed with --with-redis.
+type RedisExternal struct {
+	Host     string
+	Port     int
+	Password redact.Secret
+}
+
+// IP families of the gateway Services.
+const (
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+	IPFamilyDual = "dual"
+)
+
+// IPFamilies are the valid values of ip_family.
+var IPFamilies = []string{IPFamilyIPv4, IPFamilyIPv6, IPFamilyDual}
+
+var valuesTargets = []string{ValuesTargetGateway, ValuesTargetAI, ValuesTargetRedis}
+
+// Untargeted values files keep their historical behaviour of being passed to
+// both the Envoy Gateway and AI Gateway controller releases.
+var untargetedValuesTargets = []string{ValuesTargetGateway, ValuesTargetAI}
+
+// targetName matches what could be meant as the target of a values file,
+// as opposed to a path.
+var targetName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
 type Config struct {
 	NamespaceGateway string
 	NamespaceAI      string
 	SkipClean        bool
 	DryRun           bool
-	ValuesExtra      []string
+	ValuesExtra      map[string][]string
+	ReleasePrefix    string
+	Labels           map[string]string
+	ImagePullSecrets []string
+	// NodeSelector, Tolerations and Affinity constrain the nodes the
+	// installed pods are scheduled on.
+	NodeSelector map[string]string
+	Tolerations  []corev1.Toleration
+	Affinity     *corev1.Affinity
+	// PodSecurity holds the Pod Security Admission labels to set on the
+	// installer's namespaces.
+	PodSecurity map[string]string
+	// GatewayTag and AIGatewayTag pin the chart versions of Envoy Gateway
+	// and Envoy AI Gateway to a GitHub release tag; empty installs the
+	// development build.
+	GatewayTag   string
+	AIGatewayTag string
+	// RedisVersion pins the Bitnami Redis chart version; empty installs
+	// the latest.
+	RedisVersion string
+	// RedisHA runs Redis as RedisHAReplicas replicas watched by Sentinel.
+	RedisHA         bool
+	RedisHAReplicas int
+	// RedisExternal is nil unless redis_external_host is set.
+	RedisExternal *RedisExternal
+	// Resources is nil unless resource_limits is set.
+	Resources *corev1.ResourceRequirements
+	// IPFamily is one of IPFamilies, or empty to leave the gateway
+	// Services to the cluster default.
+	IPFamily      string
+	Local         bool
+	OpenShift     bool
+	Observability bool
+	// Telemetry is nil unless an OTLP endpoint is configured.
+	Telemetry *telemetry.Options
 }
 
-func Init(configPath string) error {
+var releasePrefixPattern = regexp.MustCompile(`^[a-z0-9][-a-z0-9.]*$`)
+
+// Init reads the config files and overlays the entry of their contexts map
+// for the kube context returned by currentContext, then the entry of their
+// profiles map for profile. currentContext is only called when the files
+// define contexts, after the profile is applied, so that the kube context
+// can come from a flag, an EAIG_* variable, the profile or the files.
+//
+// configPaths are merged in order, later files overriding earlier ones,
+// and then config.<env>.yaml from the directory of the first file when env
+// is set. Without configPaths the first file is config.yaml in the default
+// directory, which may be missing.
+func Init(configPaths []string, env, profile string, currentContext func() string) error {
 	viper.SetConfigType("yaml")
 
-	if configPath != "" {
-		viper.SetConfigFile(configPath)
+	var overlays []string
+	if len(configPaths) > 0 {
+		viper.SetConfigFile(configPaths[0])
+		overlays = append(overlays, configPaths[1:]...)
 	} else {
 		home, err := os.UserHomeDir()
 		if err == nil {
@@ -30,30 +128,388 @@ func Init(configPath string) error {
 		viper.SetConfigName("config")
 	}
 
-	viper.SetEnvPrefix("EAIG")
-	viper.AutomaticEnv()
+	bindEnv()
 
 	viper.SetDefault("namespace_gateway", "envoy-gateway-system")
 	viper.SetDefault("namespace_ai", "envoy-ai-gateway-system")
 	viper.SetDefault("skip_clean", false)
 	viper.SetDefault("dry_run", false)
+	viper.SetDefault("fetch_retries", 3)
+	viper.SetDefault("notes_max_length", 2000)
+	viper.SetDefault("otlp_protocol", telemetry.ProtocolGRPC)
+	viper.SetDefault("tracing_sample_rate", 1.0)
+	viper.SetDefault("redis_ha_replicas", MinRedisHAReplicas+1)
+	viper.SetDefault("redis_external_port", DefaultRedisPort)
 
+	loadedFiles = nil
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return fmt.Errorf("error reading config file: %w", err)
 		}
+	} else {
+		loadedFiles = append(loadedFiles, viper.ConfigFileUsed())
 	}
 
+	if env != "" {
+		file, err := envFile(configPaths, env)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(configPaths, file) {
+			overlays = append(overlays, file)
+		}
+	}
+	for _, file := range overlays {
+		settings, err := readSettings(file)
+		if err != nil {
+			return err
+		}
+		if err := viper.MergeConfigMap(settings); err != nil {
+			return fmt.Errorf("error reading config file %s: %w", file, err)
+		}
+		loadedFiles = append(loadedFiles, file)
+	}
+
+	if profile != "" {
+		if err := applySections(profile, ""); err != nil {
+			return err
+		}
+	}
+	if hasContexts() {
+		return applySections(profile, currentContext())
+	}
 	return nil
 }
 
-func Load() *Config {
+// loadedFiles are the config files Init read, in merge order.
+var loadedFiles []string
+
+// Files returns the config files that were read, in the order they were
+// merged.
+func Files() []string {
+	return loadedFiles
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z0-9][-A-Za-z0-9_.]*$`)
+
+// envFile returns config.<env>.yaml in the directory of the first config
+// file, or of the default config file. It must exist: a misspelt
+// environment would otherwise silently install with the base settings.
+func envFile(configPaths []string, env string) (string, error) {
+	if !envNamePattern.MatchString(env) {
+		return "", fmt.Errorf("invalid env %q: must consist of alphanumeric characters, '-', '_' or '.'", env)
+	}
+
+	dir := ""
+	if len(configPaths) > 0 {
+		dir = filepath.Dir(configPaths[0])
+	} else {
+		path, err := DefaultPath()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Dir(path)
+	}
+
+	file := filepath.Join(dir, "config."+env+".yaml")
+	if _, err := os.Stat(file); err != nil {
+		return "", fmt.Errorf("config file for env %q not found: %s", env, file)
+	}
+	return file, nil
+}
+
+// readSettings reads the top-level keys of a config file.
+func readSettings(file string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	settings := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %w", file, err)
+	}
+	return settings, nil
+}
+
+// FileOf returns the last config file that sets a top-level key, or "".
+func FileOf(key string) string {
+	key = strings.ToLower(key)
+	for i := len(loadedFiles) - 1; i >= 0; i-- {
+		settings, err := readSettings(loadedFiles[i])
+		if err != nil {
+			continue
+		}
+		for k := range settings {
+			if strings.ToLower(k) == key {
+				return loadedFiles[i]
+			}
+		}
+	}
+	return ""
+}
+
+func Load() (*Config, error) {
+	valuesExtra, err := ParseValuesExtra(listEntries(viper.Get("values_extra")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid values_extra: %w", err)
+	}
+
+	releasePrefix := viper.GetString("release_prefix")
+	if releasePrefix != "" && !releasePrefixPattern.MatchString(releasePrefix) {
+		return nil, fmt.Errorf("invalid release_prefix %q: must consist of lowercase alphanumeric characters, '-' or '.'", releasePrefix)
+	}
+
+	labels, err := ParseLabels(keyValueEntries(viper.Get("global_labels")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid global_labels: %w", err)
+	}
+	flagLabels, err := ParseLabels(listEntries(viper.Get("labels")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid labels: %w", err)
+	}
+	for key, value := range flagLabels {
+		labels[key] = value
+	}
+
+	pullSecrets, err := ParseImagePullSecrets(listEntries(viper.Get("image_pull_secrets")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image_pull_secrets: %w", err)
+	}
+
+	nodeSelector, err := ParseLabels(keyValueEntries(viper.Get("node_selector")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid node_selector: %w", err)
+	}
+	tolerations, err := ParseTolerations(viper.Get("tolerations"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tolerations: %w", err)
+	}
+	affinity, err := ParseAffinity(viper.Get("affinity"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid affinity: %w", err)
+	}
+
+	resources, err := ParseResourceLimits(keyValueEntries(viper.Get("resource_limits")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource_limits: %w", err)
+	}
+
+	podSecurity, err := ParsePodSecurityStandards(keyValueEntries(viper.Get("pod_security_standards")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pod_security_standards: %w", err)
+	}
+
+	redisHAReplicas := viper.GetInt("redis_ha_replicas")
+	if redisHAReplicas < MinRedisHAReplicas {
+		return nil, fmt.Errorf("invalid redis_ha_replicas %d: Sentinel needs at least %d replicas to fail over", redisHAReplicas, MinRedisHAReplicas)
+	}
+
+	var redisExternal *RedisExternal
+	if host := viper.GetString("redis_external_host"); host != "" {
+		port := viper.GetInt("redis_external_port")
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid redis_external_port %d: must be between 1 and 65535", port)
+		}
+		redisExternal = &RedisExternal{
+			Host:     host,
+			Port:     port,
+			Password: redact.Secret(viper.GetString("redis_external_password")),
+		}
+	}
+
+	ipFamily := viper.GetString("ip_family")
+	if ipFamily != "" && !slices.Contains(IPFamilies, ipFamily) {
+		return nil, fmt.Errorf("invalid ip_family %q: must be one of %s", ipFamily, strings.Join(IPFamilies, ", "))
+	}
+
+	gatewayTag, aiGatewayTag := viper.GetString("envoy_gateway_tag"), viper.GetString("ai_gateway_tag")
+	if tag := viper.GetString("tag"); tag != "" {
+		if gatewayTag == "" {
+			gatewayTag = tag
+		}
+		if aiGatewayTag == "" {
+			aiGatewayTag = tag
+		}
+	}
+
+	var tel *telemetry.Options
+	if endpoint := viper.GetString("otlp_endpoint"); endpoint != "" {
+		tel = &telemetry.Options{
+			Endpoint:   endpoint,
+			Protocol:   viper.GetString("otlp_protocol"),
+			SampleRate: viper.GetFloat64("tracing_sample_rate"),
+			Insecure:   viper.GetBool("otlp_insecure"),
+		}
+		if err := tel.Validate(); err != nil {
+			return nil, &ValidationError{Problems: []string{fmt.Sprintf("invalid OTLP settings: %v", err)}}
+		}
+	}
+
 	return &Config{
 		NamespaceGateway: viper.GetString("namespace_gateway"),
 		NamespaceAI:      viper.GetString("namespace_ai"),
 		SkipClean:        viper.GetBool("skip_clean"),
 		DryRun:           viper.GetBool("dry_run"),
-		ValuesExtra:      viper.GetStringSlice("values_extra"),
+		ValuesExtra:      valuesExtra,
+		ReleasePrefix:    releasePrefix,
+		Labels:           labels,
+		ImagePullSecrets: pullSecrets,
+		NodeSelector:     nodeSelector,
+		Tolerations:      tolerations,
+		Affinity:         affinity,
+		GatewayTag:       gatewayTag,
+		AIGatewayTag:     aiGatewayTag,
+		RedisVersion:     viper.GetString("redis_version"),
+		RedisHA:          viper.GetBool("redis_ha"),
+		RedisHAReplicas:  redisHAReplicas,
+		RedisExternal:    redisExternal,
+		Resources:        resources,
+		IPFamily:         ipFamily,
+		PodSecurity:      podSecurity,
+		Local:            viper.GetBool("local"),
+		OpenShift:        viper.GetBool("openshift"),
+		Observability:    viper.GetBool("with_observability"),
+		Telemetry:        tel,
+	}, nil
+}
+
+// ParseValuesExtra routes values files to releases. Entries are either a
+// plain path, which applies to the gateway and ai releases, or
+// "<target>=<path>" to apply the file to a single release. Only a known
+// target, or a word that could be a mistyped one, before the first "=" makes
+// an entry targeted, so paths such as ./env=prod.yaml stay plain.
+func ParseValuesExtra(entries []string) (map[string][]string, error) {
+	result := map[string][]string{}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		target, file, found := strings.Cut(entry, "=")
+		target = strings.TrimSpace(target)
+		if !found || !targetName.MatchString(target) {
+			for _, t := range untargetedValuesTargets {
+				result[t] = append(result[t], entry)
+			}
+			continue
+		}
+
+		file = strings.TrimSpace(file)
+		if !isValuesTarget(target) {
+			return nil, fmt.Errorf("unknown target %q in %q (expected one of: %s; write a file name that contains \"=\" as ./%s)",
+				target, entry, strings.Join(valuesTargets, ", "), entry)
+		}
+		if file == "" {
+			return nil, fmt.Errorf("missing values file in %q", entry)
+		}
+
+		result[target] = append(result[target], file)
+	}
+
+	return result, nil
+}
+
+// ParseLabels parses "key=value" entries and validates them as Kubernetes
+// label keys and values.
+func ParseLabels(entries []string) (map[string]string, error) {
+	labels := map[string]string{}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("label %q must be in key=value format", entry)
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value for label %q: %s", key, strings.Join(errs, "; "))
+		}
+
+		labels[key] = value
+	}
+
+	return labels, nil
+}
+
+// ParseImagePullSecrets validates secret names, dropping empty entries and
+// duplicates.
+func ParseImagePullSecrets(entries []string) ([]string, error) {
+	var names []string
+	seen := map[string]bool{}
+
+	for _, entry := range entries {
+		name := strings.TrimSpace(entry)
+		if name == "" || seen[name] {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid secret name %q: %s", name, strings.Join(errs, "; "))
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func ValuesTargets() []string {
+	return valuesTargets
+}
+
+func isValuesTarget(target string) bool {
+	for _, t := range valuesTargets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// listEntries turns a list setting into entries. It accepts the YAML list of
+// the config file, the slice of a flag and the comma-separated string of an
+// environment variable; a map of targets yields target=entry pairs.
+func listEntries(raw interface{}) []string {
+	switch v := raw.(type) {
+	case nil:
+		return nil
+	case string:
+		return strings.Split(v, ",")
+	case []string:
+		return v
+	case []interface{}:
+		entries := make([]string, 0, len(v))
+		for _, item := range v {
+			entries = append(entries, fmt.Sprint(item))
+		}
+		return entries
+	case map[string]interface{}:
+		targets := make([]string, 0, len(v))
+		for target := range v {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+
+		var entries []string
+		for _, target := range targets {
+			for _, file := range listEntries(v[target]) {
+				entries = append(entries, target+"="+strings.TrimSpace(file))
+			}
+		}
+		return entries
+	default:
+		return []string{fmt.Sprint(v)}
 	}
 }
 
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseValuesExtra(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			name:    "untargeted applies to gateway and ai",
+			entries: []string{"common.yaml"},
+			want:    map[string][]string{"gateway": {"common.yaml"}, "ai": {"common.yaml"}},
+		},
+		{
+			name:    "targeted",
+			entries: []string{"gateway=gw.yaml", "ai=./ai.yaml", "redis = redis.yaml"},
+			want:    map[string][]string{"gateway": {"gw.yaml"}, "ai": {"./ai.yaml"}, "redis": {"redis.yaml"}},
+		},
+		{
+			name:    "order kept per release",
+			entries: []string{"ai=first.yaml", "common.yaml", "ai=last.yaml"},
+			want:    map[string][]string{"gateway": {"common.yaml"}, "ai": {"first.yaml", "common.yaml", "last.yaml"}},
+		},
+		{
+			name:    "path with = is untargeted",
+			entries: []string{"./env=prod.yaml", "/etc/values/tier=1.yaml", `C:\values\env=prod.yaml`},
+			want: map[string][]string{
+				"gateway": {"./env=prod.yaml", "/etc/values/tier=1.yaml", `C:\values\env=prod.yaml`},
+				"ai":      {"./env=prod.yaml", "/etc/values/tier=1.yaml", `C:\values\env=prod.yaml`},
+			},
+		},
+		{
+			name:    "targeted path with =",
+			entries: []string{"ai=env=prod.yaml", "gateway=./a=b.yaml"},
+			want:    map[string][]string{"ai": {"env=prod.yaml"}, "gateway": {"./a=b.yaml"}},
+		},
+		{
+			name:    "blank entries skipped",
+			entries: []string{"", "  "},
+			want:    map[string][]string{},
+		},
+		{
+			name:    "unknown target",
+			entries: []string{"gatway=gw.yaml"},
+			wantErr: `unknown target "gatway" in "gatway=gw.yaml" (expected one of: gateway, ai, redis; write a file name that contains "=" as ./gatway=gw.yaml)`,
+		},
+		{
+			name:    "missing file",
+			entries: []string{"redis="},
+			wantErr: `missing values file in "redis="`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseValuesExtra(tt.entries)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
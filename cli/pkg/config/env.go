@@ -0,0 +1,83 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is prepended to config keys to form their environment variable.
+const EnvPrefix = "EAIG"
+
+var envKeyReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// Keys returns the top-level keys of the config file schema, except
+// profiles and contexts, in schema order.
+func Keys() []string {
+	t := reflect.TypeOf(fileConfig{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("yaml"); key != profilesKey && key != contextsKey {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Value returns the resolved setting of key as the type the config file
+// schema gives it, with durations in their string form.
+func Value(key string) interface{} {
+	field, ok := schemaField(key)
+	if !ok {
+		return viper.Get(key)
+	}
+
+	switch field.Type {
+	case reflect.TypeOf(time.Duration(0)):
+		return viper.GetDuration(key).String()
+	case reflect.TypeOf([]int(nil)):
+		return viper.GetIntSlice(key)
+	}
+	switch field.Type.Kind() {
+	case reflect.Bool:
+		return viper.GetBool(key)
+	case reflect.Int:
+		return viper.GetInt(key)
+	case reflect.Float64:
+		return viper.GetFloat64(key)
+	case reflect.String:
+		return viper.GetString(key)
+	}
+	return viper.Get(key)
+}
+
+func schemaField(key string) (reflect.StructField, bool) {
+	t := reflect.TypeOf(fileConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("yaml") == key {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// EnvVar returns the environment variable of a config key: EAIG_ followed by
+// the key in upper case, with dashes and dots replaced by underscores.
+func EnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// bindEnv binds every config key to its environment variable. AutomaticEnv
+// alone only covers keys viper already knows about from the config file, a
+// default or a flag.
+func bindEnv() {
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(envKeyReplacer)
+	viper.AutomaticEnv()
+
+	for _, key := range Keys() {
+		viper.BindEnv(key, EnvVar(key))
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestValue(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("helm_timeout", 90*time.Second)
+	viper.Set("force_unlock", "true")
+	viper.Set("skip_steps", []string{"1", "3"})
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"helm_timeout", "1m30s"},
+		{"force_unlock", true},
+		{"network_timeout", "0s"},
+		{"log_retention", 0},
+		{"ca_bundle", ""},
+	}
+	for _, tt := range tests {
+		if got := Value(tt.key); got != tt.want {
+			t.Errorf("Value(%q) = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+	if got := Value("skip_steps"); len(got.([]int)) != 2 {
+		t.Errorf("Value(skip_steps) = %#v, want [1 3]", got)
+	}
+}
+
+func TestBindEnv(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	bindEnv()
+	viper.Set("redis_ha_replicas", MinRedisHAReplicas)
+
+	t.Setenv("EAIG_NAMESPACE_AI", "ai-from-env")
+	t.Setenv("EAIG_REDIS_EXTERNAL_HOST", "redis.example")
+	t.Setenv("EAIG_REDIS_EXTERNAL_PORT", "6380")
+	t.Setenv("EAIG_REDIS_HA", "true")
+	t.Setenv("EAIG_LABELS", "team=ai,env=dev")
+	t.Setenv("EAIG_VALUES_EXTRA", "common.yaml,gateway=gateway.yaml,ai=ai.yaml,redis=redis.yaml,./env=prod.yaml")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.NamespaceAI != "ai-from-env" {
+		t.Errorf("NamespaceAI = %q, want ai-from-env", cfg.NamespaceAI)
+	}
+	if cfg.RedisExternal == nil || cfg.RedisExternal.Host != "redis.example" || cfg.RedisExternal.Port != 6380 {
+		t.Errorf("RedisExternal = %+v, want redis.example:6380", cfg.RedisExternal)
+	}
+	if !cfg.RedisHA {
+		t.Error("RedisHA = false, want true from EAIG_REDIS_HA")
+	}
+	if want := map[string]string{"team": "ai", "env": "dev"}; !reflect.DeepEqual(cfg.Labels, want) {
+		t.Errorf("Labels = %v, want %v", cfg.Labels, want)
+	}
+	wantValues := map[string][]string{
+		ValuesTargetGateway: {"common.yaml", "gateway.yaml", "./env=prod.yaml"},
+		ValuesTargetAI:      {"common.yaml", "ai.yaml", "./env=prod.yaml"},
+		ValuesTargetRedis:   {"redis.yaml"},
+	}
+	if !reflect.DeepEqual(cfg.ValuesExtra, wantValues) {
+		t.Errorf("ValuesExtra = %v, want %v", cfg.ValuesExtra, wantValues)
+	}
+
+	// Keys outside the schema are found through the key replacer, which
+	// maps dots and dashes to underscores.
+	if got := viper.GetString("redis-external.host"); got != "redis.example" {
+		t.Errorf("redis-external.host = %q, want the value of EAIG_REDIS_EXTERNAL_HOST", got)
+	}
+	if got := EnvVar("otlp.sample-rate"); got != "EAIG_OTLP_SAMPLE_RATE" {
+		t.Errorf("EnvVar(otlp.sample-rate) = %q, want EAIG_OTLP_SAMPLE_RATE", got)
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath returns the config file read when --config is not given.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".envoy-ai-installer", "config.yaml"), nil
+}
+
+// GetFileValue returns the YAML node stored under a dotted key, such as
+// global_labels.team or profiles.prod.namespace_ai, in the config file.
+func GetFileValue(path, key string) (*yaml.Node, error) {
+	doc, err := loadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node := doc.Content[0]
+	for _, part := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not set in %s", key, path)
+		}
+		_, node = mappingEntry(node, part)
+		if node == nil {
+			return nil, fmt.Errorf("%s is not set in %s", key, path)
+		}
+	}
+	return node, nil
+}
+
+// SetFileValue stores a value under a dotted key in the config file,
+// creating the file, its directory and intermediate maps as needed. The
+// value is parsed as YAML, so "true", "3" and "[a, b]" keep their type.
+// Comments elsewhere in the file are preserved. The change is rejected when
+// the key is unknown or the value has the wrong type.
+func SetFileValue(path, key, raw string) error {
+	doc, err := loadDocument(path)
+	if err != nil {
+		return err
+	}
+
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: raw}
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &parsed); err == nil && len(parsed.Content) > 0 {
+		value = parsed.Content[0]
+	}
+
+	parts := strings.Split(key, ".")
+	node := doc.Content[0]
+	for i, part := range parts[:len(parts)-1] {
+		_, child := mappingEntry(node, part)
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a map", strings.Join(parts[:i+1], "."))
+		}
+		node = child
+	}
+
+	last := parts[len(parts)-1]
+	if i, existing := mappingEntry(node, last); existing != nil {
+		value.LineComment = existing.LineComment
+		node.Content[i+1] = value
+	} else {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: last}, value)
+	}
+
+	return writeDocument(path, doc)
+}
+
+// UnsetFileValue removes a dotted key from the config file. It reports
+// whether the key was set.
+func UnsetFileValue(path, key string) (bool, error) {
+	doc, err := loadDocument(path)
+	if err != nil {
+		return false, err
+	}
+
+	parts := strings.Split(key, ".")
+	node := doc.Content[0]
+	for _, part := range parts[:len(parts)-1] {
+		_, node = mappingEntry(node, part)
+		if node == nil || node.Kind != yaml.MappingNode {
+			return false, nil
+		}
+	}
+
+	i, existing := mappingEntry(node, parts[len(parts)-1])
+	if existing == nil {
+		return false, nil
+	}
+	node.Content = append(node.Content[:i], node.Content[i+2:]...)
+
+	return true, writeDocument(path, doc)
+}
+
+// loadDocument parses the config file, or returns an empty document when it
+// does not exist yet.
+func loadDocument(path string) (*yaml.Node, error) {
+	empty := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return empty, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return empty, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid config file %s: not a map of settings", path)
+	}
+	return &doc, nil
+}
+
+func writeDocument(path string, doc *yaml.Node) error {
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	if problems := schemaProblems(path, out.Bytes()); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// mappingEntry returns the index of key in a mapping node's content and its
+// value, or nil when the key is absent.
+func mappingEntry(node *yaml.Node, key string) (int, *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i, node.Content[i+1]
+		}
+	}
+	return -1, nil
+}
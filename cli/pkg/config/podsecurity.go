@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PodSecurityLabelPrefix prefixes the namespace labels read by Pod Security
+// Admission.
+const PodSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+var (
+	podSecurityModes   = []string{"enforce", "audit", "warn"}
+	podSecurityLevels  = []string{"privileged", "baseline", "restricted"}
+	podSecurityVersion = regexp.MustCompile(`^(latest|v1\.[0-9]+)$`)
+)
+
+// ParsePodSecurityStandards parses "mode=level" entries, such as
+// enforce=restricted, into Pod Security Admission namespace labels. A
+// "mode-version=v1.30" entry pins the policy version of a mode.
+func ParsePodSecurityStandards(entries []string) (map[string]string, error) {
+	labels := map[string]string{}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("%q must be in mode=level format, e.g. enforce=restricted", entry)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		mode, isVersion := strings.CutSuffix(key, "-version")
+		if !contains(podSecurityModes, mode) {
+			return nil, fmt.Errorf("unknown mode %q in %q (expected one of: %s)",
+				key, entry, strings.Join(podSecurityModes, ", "))
+		}
+
+		switch {
+		case isVersion && !podSecurityVersion.MatchString(value):
+			return nil, fmt.Errorf("invalid version %q in %q (expected latest or v1.<minor>)", value, entry)
+		case !isVersion && !contains(podSecurityLevels, value):
+			return nil, fmt.Errorf("unknown level %q in %q (expected one of: %s)",
+				value, entry, strings.Join(podSecurityLevels, ", "))
+		}
+
+		labels[PodSecurityLabelPrefix+key] = value
+	}
+
+	return labels, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
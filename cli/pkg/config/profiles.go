@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	profilesKey = "profiles"
+	contextsKey = "contexts"
+)
+
+// contextExcludedKeys cannot be set by a contexts section: they select the
+// kube context the section is chosen by.
+var contextExcludedKeys = []string{contextsKey, profilesKey, "kube_context", "kubeconfig"}
+
+// activeProfile and activeContext are the sections applied by Init, and
+// profileKeys and contextKeys the top-level keys they set.
+var (
+	activeProfile string
+	activeContext string
+	profileKeys   = map[string]bool{}
+	contextKeys   = map[string]bool{}
+)
+
+// applySections overlays contexts.<kubeContext> and then profiles.<profile>
+// onto the top-level keys of the merged config files, so that an explicit profile
+// wins over the context section and flags and EAIG_* variables still take
+// precedence over both. Either name may be empty; a kube context without a
+// section is not an error. See mergeSettings for how values are combined.
+func applySections(profile, kubeContext string) error {
+	if len(loadedFiles) == 0 {
+		return fmt.Errorf("profile %q requested but no config file was loaded", profile)
+	}
+	settings := map[string]interface{}{}
+	for _, file := range loadedFiles {
+		overlay, err := readSettings(file)
+		if err != nil {
+			return err
+		}
+		settings = mergeSettings(settings, overlay)
+	}
+	file := strings.Join(loadedFiles, ", ")
+
+	contexts, _ := settings[contextsKey].(map[string]interface{})
+	contextSection, err := section(contexts, "context", kubeContext, contextExcludedKeys)
+	if err != nil {
+		return err
+	}
+
+	var profileSection map[string]interface{}
+	if profile != "" {
+		profiles, _ := settings[profilesKey].(map[string]interface{})
+		if _, ok := profiles[profile]; !ok {
+			if len(profiles) == 0 {
+				return fmt.Errorf("unknown profile %q: %s defines no profiles", profile, file)
+			}
+			return fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(sectionNames(profiles), ", "))
+		}
+		if profileSection, err = section(profiles, "profile", profile, []string{profilesKey, contextsKey}); err != nil {
+			return err
+		}
+	}
+
+	merged, err := yaml.Marshal(mergeSettings(mergeSettings(settings, contextSection), profileSection))
+	if err != nil {
+		return err
+	}
+	if err := viper.ReadConfig(bytes.NewReader(merged)); err != nil {
+		return fmt.Errorf("error applying config file sections: %w", err)
+	}
+
+	activeProfile, profileKeys = profile, sectionKeys(profileSection)
+	activeContext, contextKeys = "", sectionKeys(contextSection)
+	if contextSection != nil {
+		activeContext = kubeContext
+	}
+	return nil
+}
+
+// section returns sections[name], checking that it is a map of config keys
+// that sets none of excluded. A missing section is nil.
+func section(sections map[string]interface{}, kind, name string, excluded []string) (map[string]interface{}, error) {
+	raw, ok := sections[name]
+	if !ok || name == "" {
+		return nil, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok && raw != nil {
+		return nil, fmt.Errorf("%s %q must be a map of config keys", kind, name)
+	}
+	for _, key := range excluded {
+		if _, found := m[key]; found {
+			return nil, fmt.Errorf("%s %q cannot set %s", kind, name, key)
+		}
+	}
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m, nil
+}
+
+func sectionKeys(section map[string]interface{}) map[string]bool {
+	keys := make(map[string]bool, len(section))
+	for key := range section {
+		keys[strings.ToLower(key)] = true
+	}
+	return keys
+}
+
+// hasContexts reports whether the config file defines contexts sections.
+func hasContexts() bool {
+	return len(viper.GetStringMap(contextsKey)) > 0
+}
+
+// mergeSettings returns base with override applied: maps are merged key by
+// key, recursively; lists and scalars from override replace the base value,
+// as does a value of a different type. A null in override removes the key,
+// which resets it to its default.
+func mergeSettings(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range override {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		baseMap, baseIsMap := merged[key].(map[string]interface{})
+		if overrideIsMap && baseIsMap {
+			merged[key] = mergeSettings(baseMap, overrideMap)
+			continue
+		}
+
+		merged[key] = value
+	}
+	return merged
+}
+
+func sectionNames(sections map[string]interface{}) []string {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveProfile returns the name of the applied profile, if any.
+func ActiveProfile() string {
+	return activeProfile
+}
+
+// Profiles returns the names of the profiles the config files define.
+func Profiles() []string {
+	return sectionNames(viper.GetStringMap(profilesKey))
+}
+
+// InProfile reports whether the active profile sets the top-level key.
+func InProfile(key string) bool {
+	return profileKeys[strings.ToLower(key)]
+}
+
+// ActiveContext returns the kube context whose contexts section was applied,
+// if any.
+func ActiveContext() string {
+	return activeContext
+}
+
+// InContext reports whether the applied contexts section sets the top-level
+// key.
+func InContext(key string) bool {
+	return contextKeys[strings.ToLower(key)]
+}
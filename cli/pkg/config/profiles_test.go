@@ -0,0 +1,204 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func TestMergeSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     map[string]interface{}
+		override map[string]interface{}
+		want     map[string]interface{}
+	}{
+		{
+			name:     "scalar replaced",
+			base:     map[string]interface{}{"namespace_ai": "ai", "fetch_retries": 3},
+			override: map[string]interface{}{"namespace_ai": "ai-prod"},
+			want:     map[string]interface{}{"namespace_ai": "ai-prod", "fetch_retries": 3},
+		},
+		{
+			name:     "zero scalar still overrides",
+			base:     map[string]interface{}{"with_redis": true, "fetch_retries": 3},
+			override: map[string]interface{}{"with_redis": false, "fetch_retries": 0},
+			want:     map[string]interface{}{"with_redis": false, "fetch_retries": 0},
+		},
+		{
+			name:     "maps merged key by key",
+			base:     map[string]interface{}{"global_labels": map[string]interface{}{"team": "ai", "env": "dev"}},
+			override: map[string]interface{}{"global_labels": map[string]interface{}{"env": "prod", "tier": "1"}},
+			want:     map[string]interface{}{"global_labels": map[string]interface{}{"team": "ai", "env": "prod", "tier": "1"}},
+		},
+		{
+			name: "nested maps merged recursively",
+			base: map[string]interface{}{"resource_limits": map[string]interface{}{
+				"eg": map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+			}},
+			override: map[string]interface{}{"resource_limits": map[string]interface{}{
+				"eg":   map[string]interface{}{"memory": "1Gi"},
+				"aieg": map[string]interface{}{"cpu": "1"},
+			}},
+			want: map[string]interface{}{"resource_limits": map[string]interface{}{
+				"eg":   map[string]interface{}{"cpu": "500m", "memory": "1Gi"},
+				"aieg": map[string]interface{}{"cpu": "1"},
+			}},
+		},
+		{
+			name:     "slices replaced, not appended",
+			base:     map[string]interface{}{"skip_steps": []interface{}{1, 2}},
+			override: map[string]interface{}{"skip_steps": []interface{}{3}},
+			want:     map[string]interface{}{"skip_steps": []interface{}{3}},
+		},
+		{
+			name:     "empty slice clears",
+			base:     map[string]interface{}{"image_pull_secrets": []interface{}{"regcred"}},
+			override: map[string]interface{}{"image_pull_secrets": []interface{}{}},
+			want:     map[string]interface{}{"image_pull_secrets": []interface{}{}},
+		},
+		{
+			name:     "map replaced by another type",
+			base:     map[string]interface{}{"values_extra": map[string]interface{}{"aieg": "a.yaml"}},
+			override: map[string]interface{}{"values_extra": []interface{}{"b.yaml"}},
+			want:     map[string]interface{}{"values_extra": []interface{}{"b.yaml"}},
+		},
+		{
+			name:     "null resets to the default",
+			base:     map[string]interface{}{"namespace_ai": "ai", "tag": "v0.3.0"},
+			override: map[string]interface{}{"tag": nil},
+			want:     map[string]interface{}{"namespace_ai": "ai"},
+		},
+		{
+			name:     "nil override",
+			base:     map[string]interface{}{"namespace_ai": "ai"},
+			override: nil,
+			want:     map[string]interface{}{"namespace_ai": "ai"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := deepCopy(tt.base)
+
+			got := mergeSettings(tt.base, tt.override)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.base, before) {
+				t.Errorf("base modified to %#v", tt.base)
+			}
+		})
+	}
+}
+
+const profilesConfig = `namespace_ai: ai-default
+fetch_retries: 3
+skip_steps: [1, 2]
+global_labels:
+  team: ai
+  env: dev
+profiles:
+  prod:
+    namespace_ai: ai-prod
+    skip_steps: [3]
+    global_labels:
+      env: prod
+  staging:
+    fetch_retries: 5
+`
+
+func TestInitProfilePrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		env     string
+		flag    string
+		want    string
+	}{
+		{name: "top level", want: "ai-default"},
+		{name: "profile over top level", profile: "prod", want: "ai-prod"},
+		{name: "profile without the key", profile: "staging", want: "ai-default"},
+		{name: "env over profile", profile: "prod", env: "ai-env", want: "ai-env"},
+		{name: "flag over env", profile: "prod", env: "ai-env", flag: "ai-flag", want: "ai-flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := initConfig(t, tt.profile); err != nil {
+				t.Fatal(err)
+			}
+			if tt.env != "" {
+				t.Setenv("EAIG_NAMESPACE_AI", tt.env)
+			}
+			if tt.flag != "" {
+				flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+				flags.String("namespace-ai", "", "")
+				flags.Set("namespace-ai", tt.flag)
+				viper.BindPFlag("namespace_ai", flags.Lookup("namespace-ai"))
+			}
+
+			if got := viper.GetString("namespace_ai"); got != tt.want {
+				t.Errorf("namespace_ai = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitProfileMerge(t *testing.T) {
+	if err := initConfig(t, "prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := viper.GetStringMapString("global_labels"); !reflect.DeepEqual(got, map[string]string{"team": "ai", "env": "prod"}) {
+		t.Errorf("global_labels = %v, want team from the top level and env from the profile", got)
+	}
+	if got := viper.GetIntSlice("skip_steps"); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("skip_steps = %v, want the profile's [3]", got)
+	}
+	if got := viper.GetInt("fetch_retries"); got != 3 {
+		t.Errorf("fetch_retries = %d, want the top-level 3", got)
+	}
+	if ActiveProfile() != "prod" || !InProfile("namespace_ai") || InProfile("fetch_retries") {
+		t.Errorf("active profile %q does not report the keys it sets", ActiveProfile())
+	}
+}
+
+func TestInitUnknownProfile(t *testing.T) {
+	err := initConfig(t, "qa")
+	if err == nil || !strings.Contains(err.Error(), `unknown profile "qa" (available: prod, staging)`) {
+		t.Errorf("got error %v, want the available profiles listed", err)
+	}
+}
+
+// initConfig runs Init on profilesConfig with profile.
+func initConfig(t *testing.T, profile string) error {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(profilesConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Init([]string{path}, "", profile, func() string { return "" })
+}
+
+func deepCopy(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = deepCopy(nested)
+		}
+		c[k] = v
+	}
+	return c
+}
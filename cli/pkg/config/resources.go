@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ParseResourceLimits parses "cpu=500m" style entries. cpu and memory (or
+// limits.cpu and limits.memory) set limits; requests.cpu and requests.memory
+// set requests, which default to the limits so that no chart default request
+// can exceed them.
+func ParseResourceLimits(entries []string) (*corev1.ResourceRequirements, error) {
+	limits := corev1.ResourceList{}
+	requests := corev1.ResourceList{}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("resource limit %q must be in key=value format", entry)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		list := limits
+		name, isRequest := strings.CutPrefix(key, "requests.")
+		if isRequest {
+			list = requests
+		} else {
+			name = strings.TrimPrefix(key, "limits.")
+		}
+
+		switch corev1.ResourceName(name) {
+		case corev1.ResourceCPU, corev1.ResourceMemory:
+		default:
+			return nil, fmt.Errorf("unknown resource %q in %q (expected cpu or memory)", name, entry)
+		}
+
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q for %s: %w", value, key, err)
+		}
+		list[corev1.ResourceName(name)] = quantity
+	}
+
+	if len(limits) == 0 && len(requests) == 0 {
+		return nil, nil
+	}
+
+	for name, limit := range limits {
+		request, ok := requests[name]
+		if !ok {
+			requests[name] = limit
+			continue
+		}
+		if request.Cmp(limit) > 0 {
+			return nil, fmt.Errorf("%s request %s exceeds the limit %s", name, request.String(), limit.String())
+		}
+	}
+
+	resources := &corev1.ResourceRequirements{Requests: requests}
+	if len(limits) > 0 {
+		resources.Limits = limits
+	}
+	return resources, nil
+}
+
+// keyValueEntries turns a key=value setting into entries. It accepts the
+// config file form, a map whose nested maps yield dotted keys, as well as the
+// list and comma-separated forms of flags and environment variables.
+func keyValueEntries(raw interface{}) []string {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return listEntries(raw)
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []string
+	for _, key := range keys {
+		if nested, ok := m[key].(map[string]interface{}); ok {
+			for _, entry := range keyValueEntries(nested) {
+				entries = append(entries, key+"."+entry)
+			}
+			continue
+		}
+		entries = append(entries, key+"="+fmt.Sprint(m[key]))
+	}
+	return entries
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ParseTolerations decodes the tolerations config key, a list in the format
+// of a pod's spec.tolerations.
+func ParseTolerations(raw interface{}) ([]corev1.Toleration, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	var tolerations []corev1.Toleration
+	if err := decodeStrict(raw, &tolerations); err != nil {
+		return nil, err
+	}
+
+	for i, t := range tolerations {
+		switch t.Operator {
+		case "", corev1.TolerationOpEqual:
+			if t.Key == "" {
+				return nil, fmt.Errorf("toleration %d: an empty key requires operator Exists", i)
+			}
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				return nil, fmt.Errorf("toleration %d: operator Exists does not take a value", i)
+			}
+		default:
+			return nil, fmt.Errorf("toleration %d: unknown operator %q (expected Equal or Exists)", i, t.Operator)
+		}
+
+		switch t.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return nil, fmt.Errorf("toleration %d: unknown effect %q (expected NoSchedule, PreferNoSchedule or NoExecute)", i, t.Effect)
+		}
+		if t.TolerationSeconds != nil && t.Effect != corev1.TaintEffectNoExecute {
+			return nil, fmt.Errorf("toleration %d: tolerationSeconds requires effect NoExecute", i)
+		}
+	}
+
+	return tolerations, nil
+}
+
+// ParseAffinity decodes the affinity config key, in the format of a pod's
+// spec.affinity.
+func ParseAffinity(raw interface{}) (*corev1.Affinity, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	var affinity corev1.Affinity
+	if err := decodeStrict(raw, &affinity); err != nil {
+		return nil, err
+	}
+	if affinity.NodeAffinity == nil && affinity.PodAffinity == nil && affinity.PodAntiAffinity == nil {
+		return nil, nil
+	}
+	return &affinity, nil
+}
+
+// decodeStrict converts a value read from the config file into a Kubernetes
+// API type, rejecting unknown fields. Field names match case-insensitively,
+// since viper lowercases the keys of nested maps. A string, as read from an
+// environment variable, is first parsed as JSON or YAML.
+func decodeStrict(raw interface{}, out interface{}) error {
+	if s, ok := raw.(string); ok {
+		if err := yaml.Unmarshal([]byte(s), &raw); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(out)
+}
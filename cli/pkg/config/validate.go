@@ -0,0 +1,311 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// fileConfig is the schema of the config file. It is only used to report
+// unknown keys and values of the wrong type with their line; settings are
+// read through viper so that flags and EAIG_* variables apply.
+type fileConfig struct {
+	NamespaceGateway   string                 `yaml:"namespace_gateway"`
+	NamespaceAI        string                 `yaml:"namespace_ai"`
+	ReleasePrefix      string                 `yaml:"release_prefix"`
+	SkipClean          bool                   `yaml:"skip_clean"`
+	DryRun             bool                   `yaml:"dry_run"`
+	SkipCRDs           bool                   `yaml:"skip_crds"`
+	SkipSteps          []int                  `yaml:"skip_steps"`
+	Verbose            bool                   `yaml:"verbose"`
+	Yes                bool                   `yaml:"yes"`
+	NonInteractive     bool                   `yaml:"non_interactive"`
+	Kubeconfig         string                 `yaml:"kubeconfig"`
+	KubeContext        string                 `yaml:"kube_context"`
+	Tag                string                 `yaml:"tag"`
+	EnvoyGatewayTag    string                 `yaml:"envoy_gateway_tag"`
+	AIGatewayTag       string                 `yaml:"ai_gateway_tag"`
+	ValuesExtra        interface{}            `yaml:"values_extra"`
+	ValuesURL          string                 `yaml:"values_url"`
+	ValuesChecksum     string                 `yaml:"values_checksum"`
+	FetchRetries       int                    `yaml:"fetch_retries"`
+	NotesMaxLength     int                    `yaml:"notes_max_length"`
+	GlobalLabels       map[string]string      `yaml:"global_labels"`
+	Labels             interface{}            `yaml:"labels"`
+	ImagePullSecrets   interface{}            `yaml:"image_pull_secrets"`
+	DockerConfigJSON   string                 `yaml:"docker_config_json"`
+	NodeSelector       map[string]string      `yaml:"node_selector"`
+	Tolerations        []interface{}          `yaml:"tolerations"`
+	Affinity           map[string]interface{} `yaml:"affinity"`
+	ResourceLimits     interface{}            `yaml:"resource_limits"`
+	PodSecurity        interface{}            `yaml:"pod_security_standards"`
+	IPFamily           string                 `yaml:"ip_family"`
+	WithRedis          bool                   `yaml:"with_redis"`
+	RedisVersion       string                 `yaml:"redis_version"`
+	RedisHA            bool                   `yaml:"redis_ha"`
+	RedisHAReplicas    int                    `yaml:"redis_ha_replicas"`
+	RedisExternalHost  string                 `yaml:"redis_external_host"`
+	RedisExternalPort  int                    `yaml:"redis_external_port"`
+	RedisExternalPass  string                 `yaml:"redis_external_password"`
+	WithObservability  bool                   `yaml:"with_observability"`
+	Local              bool                   `yaml:"local"`
+	OpenShift          bool                   `yaml:"openshift"`
+	OTLPEndpoint       string                 `yaml:"otlp_endpoint"`
+	OTLPProtocol       string                 `yaml:"otlp_protocol"`
+	OTLPInsecure       bool                   `yaml:"otlp_insecure"`
+	TracingSampleRate  float64                `yaml:"tracing_sample_rate"`
+	PreInstallHook     string                 `yaml:"pre_install_hook"`
+	PostInstallHook    string                 `yaml:"post_install_hook"`
+	NoCache            bool                   `yaml:"no_cache"`
+	Refresh            bool                   `yaml:"refresh"`
+	CacheTTL           time.Duration          `yaml:"cache_ttl"`
+	NetworkTimeout     time.Duration          `yaml:"network_timeout"`
+	Timeout            time.Duration          `yaml:"timeout"`
+	HelmTimeout        time.Duration          `yaml:"helm_timeout"`
+	WaitTimeout        time.Duration          `yaml:"wait_timeout"`
+	CABundle           string                 `yaml:"ca_bundle"`
+	LogFile            string                 `yaml:"log_file"`
+	DebugLog           bool                   `yaml:"debug_log"`
+	LogRetention       int                    `yaml:"log_retention"`
+	OTelEndpoint       string                 `yaml:"otel_endpoint"`
+	NoTelemetry        bool                   `yaml:"no_telemetry"`
+	UpdateCheck        bool                   `yaml:"update_check"`
+	ForceUnlock        bool                   `yaml:"force_unlock"`
+	PrometheusOperator bool                   `yaml:"prometheus_operator"`
+	Profiles           map[string]fileConfig  `yaml:"profiles"`
+	Contexts           map[string]fileConfig  `yaml:"contexts"`
+}
+
+var unknownFieldPattern = regexp.MustCompile(`^line (\d+): field (\S+) not found in type \S+$`)
+
+// ValidationError lists every problem found in the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Check validates the config file, then loads and validates the resolved
+// configuration. All problems are reported together in a ValidationError.
+func Check() (*Config, error) {
+	problems := checkFile()
+
+	cfg, err := Load()
+	if err != nil {
+		return nil, &ValidationError{Problems: append(problems, err.Error())}
+	}
+
+	for _, err := range Validate(cfg) {
+		if !IsWarning(err) {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// checkFile decodes the config files strictly, reporting unknown keys and
+// values of the wrong type with their line.
+func checkFile() []string {
+	var problems []string
+	for _, file := range loadedFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		problems = append(problems, schemaProblems(file, data)...)
+	}
+	return problems
+}
+
+func schemaProblems(file string, data []byte) []string {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var fc fileConfig
+	err := decoder.Decode(&fc)
+
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return nil
+	}
+
+	problems := make([]string, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		if m := unknownFieldPattern.FindStringSubmatch(message); m != nil {
+			message = fmt.Sprintf("line %s: unknown key %q", m[1], m[2])
+			if suggestion := closestKey(m[2]); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+		}
+		problems = append(problems, file+" "+message)
+	}
+	return problems
+}
+
+// FieldError is a problem with the value of one config key.
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + " " + e.Message
+}
+
+// Warning is a setting that is valid but most likely not what was meant.
+type Warning struct {
+	Key     string
+	Message string
+}
+
+func (w *Warning) Error() string {
+	return w.Key + ": " + w.Message
+}
+
+// IsWarning reports whether an error returned by Validate is a Warning.
+func IsWarning(err error) bool {
+	var w *Warning
+	return errors.As(err, &w)
+}
+
+// Validate checks the values that would otherwise only fail deep inside
+// helm or kubectl, and returns every problem found as a *FieldError and
+// every suspicious combination as a *Warning.
+func Validate(cfg *Config) []error {
+	var errs []error
+	invalid := func(key, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(key, format string, args ...interface{}) {
+		errs = append(errs, &Warning{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, ns := range []struct{ key, name string }{
+		{"namespace_gateway", cfg.NamespaceGateway},
+		{"namespace_ai", cfg.NamespaceAI},
+	} {
+		if problems := validation.IsDNS1123Label(ns.name); len(problems) > 0 {
+			invalid(ns.key, "%q: %s", ns.name, strings.Join(problems, "; "))
+		}
+	}
+
+	checked := map[string]bool{}
+	for _, target := range valuesTargets {
+		for _, file := range cfg.ValuesExtra[target] {
+			if checked[file] || values.IsRemote(file) {
+				continue
+			}
+			checked[file] = true
+			if err := readable(file); err != nil {
+				invalid("values_extra", "%s: %v", file, err)
+			}
+		}
+	}
+
+	if path := viper.GetString("docker_config_json"); path != "" {
+		if len(cfg.ImagePullSecrets) == 0 {
+			invalid("docker_config_json", "requires image_pull_secrets to name the secret")
+		}
+		if err := readable(path); err != nil {
+			invalid("docker_config_json", "%s: %v", path, err)
+		}
+	}
+	if path := viper.GetString("ca_bundle"); path != "" {
+		if err := readable(path); err != nil {
+			invalid("ca_bundle", "%s: %v", path, err)
+		}
+	}
+
+	for _, key := range []string{"fetch_retries", "notes_max_length", "log_retention"} {
+		if viper.GetInt(key) < 0 {
+			invalid(key, "must not be negative")
+		}
+	}
+	for _, key := range []string{"network_timeout", "timeout", "helm_timeout", "wait_timeout"} {
+		if viper.GetDuration(key) < 0 {
+			invalid(key, "must not be negative")
+		}
+	}
+
+	if cfg.DryRun && viper.InConfig("dry_run") {
+		warn("dry_run", "set in the config file, so install and uninstall never change the cluster; pass --dry-run instead")
+	}
+	skipSteps := viper.GetIntSlice("skip_steps")
+	if cfg.SkipClean && slices.Contains(skipSteps, 1) {
+		warn("skip_clean", "redundant with skip_steps 1, which also skips the clean step")
+	}
+	if viper.GetBool("skip_crds") && slices.Contains(skipSteps, 3) {
+		warn("skip_crds", "redundant with skip_steps 3; skip_crds already skips the CRD release")
+	}
+
+	return errs
+}
+
+// readable returns why a file cannot be read, or nil.
+func readable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Unwrap(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return errors.New("is a directory")
+	}
+	return nil
+}
+
+// closestKey suggests the config key within two edits of key, if any.
+func closestKey(key string) string {
+	best, bestDistance := "", 3
+	t := reflect.TypeOf(fileConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("yaml")
+		if d := editDistance(key, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,160 @@
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StatusAdded   = "added"
+	StatusRemoved = "removed"
+	StatusChanged = "changed"
+)
+
+type ResourceDiff struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Diff      string `json:"diff,omitempty"`
+}
+
+func (r ResourceDiff) ID() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+type resource struct {
+	kind      string
+	namespace string
+	name      string
+	body      string
+}
+
+func ParseManifests(manifest string) (map[string]resource, error) {
+	resources := map[string]resource{}
+
+	decoder := yaml.NewDecoder(strings.NewReader(manifest))
+	for {
+		var doc map[string]interface{}
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+
+		kind, _ := doc["kind"].(string)
+		metadata, _ := doc["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to normalize %s/%s: %w", kind, name, err)
+		}
+
+		r := resource{kind: kind, namespace: namespace, name: name, body: buf.String()}
+		resources[ResourceDiff{Kind: kind, Namespace: namespace, Name: name}.ID()] = r
+	}
+
+	return resources, nil
+}
+
+func Compare(deployed, target string, context int) ([]ResourceDiff, error) {
+	before, err := ParseManifests(deployed)
+	if err != nil {
+		return nil, fmt.Errorf("deployed manifest: %w", err)
+	}
+	after, err := ParseManifests(target)
+	if err != nil {
+		return nil, fmt.Errorf("target manifest: %w", err)
+	}
+
+	ids := map[string]bool{}
+	for id := range before {
+		ids[id] = true
+	}
+	for id := range after {
+		ids[id] = true
+	}
+
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	var diffs []ResourceDiff
+	for _, id := range sorted {
+		old, inBefore := before[id]
+		cur, inAfter := after[id]
+
+		r := old
+		if !inBefore {
+			r = cur
+		}
+		d := ResourceDiff{Kind: r.kind, Namespace: r.namespace, Name: r.name}
+
+		switch {
+		case !inBefore:
+			d.Status = StatusAdded
+		case !inAfter:
+			d.Status = StatusRemoved
+		case old.body != cur.body:
+			d.Status = StatusChanged
+		default:
+			continue
+		}
+
+		d.Diff = redactedDiff(id, old.body, cur.body, context)
+		diffs = append(diffs, d)
+	}
+
+	return diffs, nil
+}
+
+func Summarize(diffs []ResourceDiff) (added, changed, removed int) {
+	for _, d := range diffs {
+		switch d.Status {
+		case StatusAdded:
+			added++
+		case StatusChanged:
+			changed++
+		case StatusRemoved:
+			removed++
+		}
+	}
+	return added, changed, removed
+}
+
+// redactedDiff returns the unified diff of two resource bodies with Secret
+// data and credential-like values masked. When only masked values changed,
+// it says so instead of showing an empty diff.
+func redactedDiff(id, old, cur string, context int) string {
+	redactedOld, errOld := redact.YAML(old)
+	redactedCur, errCur := redact.YAML(cur)
+	if errOld != nil || errCur != nil {
+		redactedOld, redactedCur = redact.Text(old), redact.Text(cur)
+	}
+
+	if redactedOld == redactedCur && old != cur {
+		return fmt.Sprintf("%s: redacted values changed\n", id)
+	}
+	return Unified(id, redactedOld, redactedCur, context)
+}
@@ -0,0 +1,229 @@
+// Package fleet installs on several clusters listed in a manifest file,
+// one after the other or in parallel, and records how each went.
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/values"
+	"gopkg.in/yaml.v3"
+)
+
+// Target is a cluster of the fleet and the settings that differ from the
+// rest of the fleet. Empty fields keep the value of the command line, the
+// environment or the config file.
+type Target struct {
+	Name             string   `yaml:"name" json:"name"`
+	Context          string   `yaml:"context" json:"context,omitempty"`
+	Kubeconfig       string   `yaml:"kubeconfig" json:"kubeconfig,omitempty"`
+	NamespaceGateway string   `yaml:"namespace_gateway" json:"namespace_gateway,omitempty"`
+	NamespaceAI      string   `yaml:"namespace_ai" json:"namespace_ai,omitempty"`
+	ReleasePrefix    string   `yaml:"release_prefix" json:"release_prefix,omitempty"`
+	Tag              string   `yaml:"tag" json:"tag,omitempty"`
+	EnvoyGatewayTag  string   `yaml:"envoy_gateway_tag" json:"envoy_gateway_tag,omitempty"`
+	AIGatewayTag     string   `yaml:"ai_gateway_tag" json:"ai_gateway_tag,omitempty"`
+	ValuesExtra      []string `yaml:"values_extra" json:"values_extra,omitempty"`
+}
+
+// Manifest lists the clusters of a fleet.
+type Manifest struct {
+	Targets []Target `yaml:"targets"`
+}
+
+// Load reads a fleet manifest. Targets without a name are named after their
+// context or kubeconfig, and relative kubeconfig and values paths are
+// resolved against the directory of the manifest.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet manifest: %w", err)
+	}
+
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid fleet manifest %s: %w", path, err)
+	}
+	if len(m.Targets) == 0 {
+		return nil, fmt.Errorf("fleet manifest %s lists no targets", path)
+	}
+
+	dir := filepath.Dir(path)
+	seen := map[string]bool{}
+	for i := range m.Targets {
+		t := &m.Targets[i]
+		if t.Context == "" && t.Kubeconfig == "" {
+			return nil, fmt.Errorf("fleet manifest %s: target %d has neither a context nor a kubeconfig", path, i+1)
+		}
+		if t.Kubeconfig != "" {
+			t.Kubeconfig = resolvePath(dir, t.Kubeconfig)
+		}
+		for j, file := range t.ValuesExtra {
+			t.ValuesExtra[j] = resolveValuesPath(dir, file)
+		}
+
+		if t.Name == "" {
+			t.Name = t.Context
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimSuffix(filepath.Base(t.Kubeconfig), filepath.Ext(t.Kubeconfig))
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("fleet manifest %s: target %q is listed twice; give the targets distinct names", path, t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return &m, nil
+}
+
+// resolveValuesPath resolves a values file like the --values-extra flag
+// takes it, optionally prefixed with the release it targets.
+func resolveValuesPath(dir, file string) string {
+	if release, path, ok := strings.Cut(file, "="); ok && !strings.ContainsAny(release, `/\.`) {
+		return release + "=" + resolvePath(dir, path)
+	}
+	return resolvePath(dir, file)
+}
+
+func resolvePath(dir, path string) string {
+	if values.IsRemote(path) || filepath.IsAbs(path) {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return filepath.Join(dir, path)
+}
+
+// Status is the outcome of the installation on a target.
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusNotRun    Status = "not run"
+)
+
+// Result records how the installation on a target went.
+type Result struct {
+	Target   Target
+	Status   Status
+	Error    string
+	ExitCode int
+	Start    time.Time
+	Duration time.Duration
+	Steps    []steps.Result
+}
+
+// Outcome is what installing on one target returns: the results of its
+// steps and its error.
+type Outcome struct {
+	Steps    []steps.Result
+	ExitCode int
+	Err      error
+}
+
+// Options configure Run.
+type Options struct {
+	// Concurrency is how many targets are installed at once; at least 1.
+	Concurrency int
+	// FailFast stops starting targets after the first failure. Targets
+	// that are already running are left to finish, as stopping an install
+	// midway would leave the cluster half upgraded.
+	FailFast bool
+	// Install installs on a target, writing its output to out.
+	Install func(ctx context.Context, t Target, out *Writer) Outcome
+	// Out receives the output of all targets, each line prefixed with the
+	// name of its target.
+	Out *Output
+	// OnDone is called as each target finishes.
+	OnDone func(Result)
+}
+
+// Run installs on every target and returns their results in manifest
+// order. Targets not started because of FailFast or ctx are StatusNotRun.
+func Run(ctx context.Context, targets []Target, opts Options) []Result {
+	results := make([]Result, len(targets))
+	for i, t := range targets {
+		results[i] = Result{Target: t, Status: StatusNotRun}
+	}
+
+	concurrency := max(opts.Concurrency, 1)
+	width := 0
+	for _, t := range targets {
+		width = max(width, len(t.Name))
+	}
+
+	var (
+		mu      sync.Mutex
+		failed  bool
+		wg      sync.WaitGroup
+		workers = make(chan struct{}, concurrency)
+	)
+	for i, t := range targets {
+		workers <- struct{}{}
+		mu.Lock()
+		stop := failed && opts.FailFast
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			<-workers
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			out := opts.Out.Writer(t.Name, width)
+			start := time.Now()
+			outcome := opts.Install(ctx, t, out)
+			out.Flush()
+
+			result := Result{
+				Target:   t,
+				Status:   StatusSucceeded,
+				ExitCode: outcome.ExitCode,
+				Start:    start,
+				Duration: time.Since(start),
+				Steps:    outcome.Steps,
+			}
+			if outcome.Err != nil {
+				result.Status = StatusFailed
+				result.Error = outcome.Err.Error()
+			}
+
+			mu.Lock()
+			results[i] = result
+			failed = failed || outcome.Err != nil
+			if opts.OnDone != nil {
+				opts.OnDone(result)
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Failed returns the results of the targets that failed or did not run.
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if r.Status != StatusSucceeded {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
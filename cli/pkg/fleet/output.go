@@ -0,0 +1,96 @@
+package fleet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Output multiplexes the output of targets running at once onto one
+// writer. Lines are written whole and prefixed with the name of their
+// target, so that interleaved output stays attributable.
+type Output struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewOutput returns an Output writing to out.
+func NewOutput(out io.Writer) *Output {
+	return &Output{out: out}
+}
+
+// Println writes a line of the fleet itself, unprefixed.
+func (o *Output) Println(a ...interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fmt.Fprintln(o.out, a...)
+}
+
+// Writer returns the writer of a target, prefixing its lines with name
+// padded to width.
+func (o *Output) Writer(name string, width int) *Writer {
+	return &Writer{output: o, prefix: []byte(fmt.Sprintf("[%-*s] ", width, name))}
+}
+
+// Writer buffers the output of a target until a line is complete.
+type Writer struct {
+	output *Output
+	prefix []byte
+
+	mu      sync.Mutex
+	partial []byte
+	onLine  func(line string)
+}
+
+// OnLine registers a function called with every complete line, without
+// its prefix.
+func (w *Writer) OnLine(fn func(line string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onLine = fn
+}
+
+// Write writes the complete lines of p and keeps the rest until the next
+// newline or Flush.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.partial[:i])
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes a last line that does not end with a newline.
+func (w *Writer) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.writeLine(w.partial)
+		w.partial = nil
+	}
+}
+
+func (w *Writer) writeLine(line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if w.onLine != nil {
+		w.onLine(string(line))
+	}
+
+	buf := make([]byte, 0, len(w.prefix)+len(line)+1)
+	buf = append(buf, w.prefix...)
+	buf = append(buf, line...)
+	buf = append(buf, '\n')
+
+	w.output.mu.Lock()
+	defer w.output.mu.Unlock()
+	w.output.out.Write(buf)
+}
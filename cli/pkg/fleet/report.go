@@ -0,0 +1,130 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+)
+
+// PrintTable prints the outcome of every target.
+func PrintTable(w io.Writer, results []Result) {
+	width := len("CLUSTER")
+	for _, r := range results {
+		width = max(width, len(r.Target.Name))
+	}
+
+	fmt.Fprintf(w, "\n  %-*s  %-9s  %-8s  %s\n", width, "CLUSTER", "STATUS", "DURATION", "DETAILS")
+	for _, r := range results {
+		duration := "-"
+		if r.Status != StatusNotRun {
+			duration = r.Duration.Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "  %-*s  %-9s  %-8s  %s\n", width, r.Target.Name, r.Status, duration, details(r))
+	}
+}
+
+// details describes a result in one line: the error of a failed target,
+// or the steps that ran.
+func details(r Result) string {
+	switch r.Status {
+	case StatusFailed:
+		return r.Error
+	case StatusNotRun:
+		return "not started after an earlier failure"
+	}
+
+	done, skipped := 0, 0
+	for _, s := range r.Steps {
+		switch s.Status {
+		case steps.StatusDone:
+			done++
+		case steps.StatusSkipped:
+			skipped++
+		}
+	}
+	if len(r.Steps) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d steps done, %d skipped", done, skipped)
+}
+
+type reportJSON struct {
+	DryRun    bool         `json:"dry_run"`
+	Started   time.Time    `json:"started"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	NotRun    int          `json:"not_run"`
+	Clusters  []targetJSON `json:"clusters"`
+}
+
+type targetJSON struct {
+	Target
+	Status          Status     `json:"status"`
+	Error           string     `json:"error,omitempty"`
+	ExitCode        int        `json:"exit_code"`
+	Started         *time.Time `json:"started,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	Steps           []stepJSON `json:"steps,omitempty"`
+}
+
+type stepJSON struct {
+	Name            string       `json:"name"`
+	Status          steps.Status `json:"status"`
+	Reason          string       `json:"reason,omitempty"`
+	DurationSeconds float64      `json:"duration_seconds"`
+}
+
+// WriteReport writes the results as JSON to path.
+func WriteReport(path string, started time.Time, dryRun bool, results []Result) error {
+	report := reportJSON{DryRun: dryRun, Started: started.UTC(), Clusters: []targetJSON{}}
+	for _, r := range results {
+		switch r.Status {
+		case StatusSucceeded:
+			report.Succeeded++
+		case StatusFailed:
+			report.Failed++
+		default:
+			report.NotRun++
+		}
+
+		t := targetJSON{
+			Target:          r.Target,
+			Status:          r.Status,
+			Error:           r.Error,
+			ExitCode:        r.ExitCode,
+			DurationSeconds: r.Duration.Seconds(),
+		}
+		if !r.Start.IsZero() {
+			start := r.Start.UTC()
+			t.Started = &start
+		}
+		for _, s := range r.Steps {
+			t.Steps = append(t.Steps, stepJSON{
+				Name:            s.Name,
+				Status:          s.Status,
+				Reason:          s.Reason,
+				DurationSeconds: s.Duration.Seconds(),
+			})
+		}
+		report.Clusters = append(report.Clusters, t)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to write fleet report: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fleet report: %w", err)
+	}
+	return nil
+}
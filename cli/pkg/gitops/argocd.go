@@ -0,0 +1,172 @@
+package gitops
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+const argoCDServer = "https://kubernetes.default.svc"
+
+type argoApplication struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   metadata    `yaml:"metadata"`
+	Spec       argoAppSpec `yaml:"spec"`
+}
+
+type argoAppSpec struct {
+	Project     string          `yaml:"project"`
+	Source      *argoSource     `yaml:"source,omitempty"`
+	Sources     []argoSource    `yaml:"sources,omitempty"`
+	Destination argoDestination `yaml:"destination"`
+	SyncPolicy  argoSyncPolicy  `yaml:"syncPolicy"`
+}
+
+type argoSource struct {
+	RepoURL        string    `yaml:"repoURL"`
+	Chart          string    `yaml:"chart,omitempty"`
+	TargetRevision string    `yaml:"targetRevision"`
+	Ref            string    `yaml:"ref,omitempty"`
+	Helm           *argoHelm `yaml:"helm,omitempty"`
+}
+
+type argoHelm struct {
+	ReleaseName  string                 `yaml:"releaseName"`
+	SkipCrds     bool                   `yaml:"skipCrds,omitempty"`
+	ValueFiles   []string               `yaml:"valueFiles,omitempty"`
+	ValuesObject map[string]interface{} `yaml:"valuesObject,omitempty"`
+}
+
+type argoDestination struct {
+	Server    string `yaml:"server"`
+	Namespace string `yaml:"namespace"`
+}
+
+type argoSyncPolicy struct {
+	SyncOptions []string `yaml:"syncOptions"`
+}
+
+type argoRepositorySecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   metadata          `yaml:"metadata"`
+	StringData map[string]string `yaml:"stringData"`
+}
+
+// renderArgoCD returns an Application per release, and a repository Secret
+// per OCI registry, which Argo CD needs to pull charts from it.
+func renderArgoCD(releases []Release, opts Options) ([]File, error) {
+	var files []File
+
+	var secrets []interface{}
+	for _, repo := range repositories(releases) {
+		if !repo.OCI() {
+			continue
+		}
+		secrets = append(secrets, argoRepositorySecret{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Metadata: metadata{
+				Name:      "envoy-ai-repo-" + repo.Name,
+				Namespace: opts.Namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by":   "envoy-ai-installer",
+					"argocd.argoproj.io/secret-type": "repository",
+				},
+			},
+			StringData: map[string]string{
+				"name":      repo.Name,
+				"type":      "helm",
+				"url":       strings.TrimPrefix(repo.URL, "oci://"),
+				"enableOCI": "true",
+			},
+		})
+	}
+	if len(secrets) > 0 {
+		content, err := encode(secrets...)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: "repositories.yaml", Content: content})
+	}
+
+	for _, r := range releases {
+		chart := argoSource{
+			RepoURL:        strings.TrimPrefix(r.Repository.URL, "oci://"),
+			Chart:          r.Chart,
+			TargetRevision: r.Version,
+			Helm: &argoHelm{
+				ReleaseName: r.Name,
+				SkipCrds:    r.SkipCRDs,
+			},
+		}
+
+		app := argoApplication{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "Application",
+			Metadata: metadata{
+				Name:      r.Name,
+				Namespace: opts.Namespace,
+				Labels:    managedLabels,
+				// Applications created together by an app of apps sync
+				// in waves: a release after those it depends on.
+				Annotations: map[string]string{
+					"argocd.argoproj.io/sync-wave": strconv.Itoa(syncWave(r, releases)),
+				},
+			},
+			Spec: argoAppSpec{
+				Project: opts.Project,
+				Destination: argoDestination{
+					Server:    argoCDServer,
+					Namespace: r.Namespace,
+				},
+				// The AI Gateway CRDs are too large for client-side apply.
+				SyncPolicy: argoSyncPolicy{
+					SyncOptions: []string{"CreateNamespace=true", "ServerSideApply=true"},
+				},
+			},
+		}
+
+		if opts.ValuesFiles && len(r.Values) > 0 {
+			values, err := encodeValues(r.Values)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, File{Path: valuesPath(r.Name), Content: values})
+
+			chart.Helm.ValueFiles = []string{"$values/" + path.Join(opts.GitPath, valuesPath(r.Name))}
+			app.Spec.Sources = []argoSource{chart, {
+				RepoURL:        opts.GitRepo,
+				TargetRevision: opts.GitRevision,
+				Ref:            "values",
+			}}
+		} else {
+			chart.Helm.ValuesObject = r.Values
+			app.Spec.Source = &chart
+		}
+
+		content, err := encode(app)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: r.Name + ".yaml", Content: content})
+	}
+
+	return files, nil
+}
+
+// syncWave orders a release after the releases it depends on.
+func syncWave(r Release, releases []Release) int {
+	wave := 0
+	for _, dep := range r.DependsOn {
+		for _, other := range releases {
+			if other.Name == dep {
+				if w := syncWave(other, releases) + 1; w > wave {
+					wave = w
+				}
+			}
+		}
+	}
+	return wave
+}
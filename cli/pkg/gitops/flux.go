@@ -0,0 +1,160 @@
+package gitops
+
+type fluxHelmRepository struct {
+	APIVersion string                 `yaml:"apiVersion"`
+	Kind       string                 `yaml:"kind"`
+	Metadata   metadata               `yaml:"metadata"`
+	Spec       fluxHelmRepositorySpec `yaml:"spec"`
+}
+
+type fluxHelmRepositorySpec struct {
+	Type     string `yaml:"type,omitempty"`
+	Interval string `yaml:"interval"`
+	URL      string `yaml:"url"`
+}
+
+type fluxHelmRelease struct {
+	APIVersion string              `yaml:"apiVersion"`
+	Kind       string              `yaml:"kind"`
+	Metadata   metadata            `yaml:"metadata"`
+	Spec       fluxHelmReleaseSpec `yaml:"spec"`
+}
+
+type fluxHelmReleaseSpec struct {
+	Interval         string                 `yaml:"interval"`
+	ReleaseName      string                 `yaml:"releaseName"`
+	TargetNamespace  string                 `yaml:"targetNamespace"`
+	StorageNamespace string                 `yaml:"storageNamespace"`
+	Chart            fluxChart              `yaml:"chart"`
+	DependsOn        []fluxReference        `yaml:"dependsOn,omitempty"`
+	Install          fluxInstall            `yaml:"install"`
+	Upgrade          *fluxUpgrade           `yaml:"upgrade,omitempty"`
+	ValuesFrom       []fluxValuesReference  `yaml:"valuesFrom,omitempty"`
+	Values           map[string]interface{} `yaml:"values,omitempty"`
+}
+
+type fluxChart struct {
+	Spec fluxChartSpec `yaml:"spec"`
+}
+
+type fluxChartSpec struct {
+	Chart     string        `yaml:"chart"`
+	Version   string        `yaml:"version"`
+	SourceRef fluxSourceRef `yaml:"sourceRef"`
+}
+
+type fluxSourceRef struct {
+	Kind string `yaml:"kind"`
+	Name string `yaml:"name"`
+}
+
+type fluxReference struct {
+	Name string `yaml:"name"`
+}
+
+type fluxInstall struct {
+	CreateNamespace bool   `yaml:"createNamespace"`
+	CRDs            string `yaml:"crds,omitempty"`
+}
+
+type fluxUpgrade struct {
+	CRDs string `yaml:"crds"`
+}
+
+type fluxValuesReference struct {
+	Kind string `yaml:"kind"`
+	Name string `yaml:"name"`
+}
+
+type configMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   metadata          `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// renderFlux returns a HelmRepository per chart repository and a HelmRelease
+// per release, all in the Flux namespace. With ValuesFiles the values of
+// each release are in a ConfigMap of their own.
+func renderFlux(releases []Release, opts Options) ([]File, error) {
+	var files []File
+
+	var sources []interface{}
+	for _, repo := range repositories(releases) {
+		spec := fluxHelmRepositorySpec{Interval: opts.Interval, URL: repo.URL}
+		if repo.OCI() {
+			spec.Type = "oci"
+		}
+		sources = append(sources, fluxHelmRepository{
+			APIVersion: "source.toolkit.fluxcd.io/v1",
+			Kind:       "HelmRepository",
+			Metadata:   metadata{Name: repo.Name, Namespace: opts.Namespace, Labels: managedLabels},
+			Spec:       spec,
+		})
+	}
+	if len(sources) > 0 {
+		content, err := encode(sources...)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: "repositories.yaml", Content: content})
+	}
+
+	for _, r := range releases {
+		release := fluxHelmRelease{
+			APIVersion: "helm.toolkit.fluxcd.io/v2",
+			Kind:       "HelmRelease",
+			Metadata:   metadata{Name: r.Name, Namespace: opts.Namespace, Labels: managedLabels},
+			Spec: fluxHelmReleaseSpec{
+				Interval:        opts.Interval,
+				ReleaseName:     r.Name,
+				TargetNamespace: r.Namespace,
+				// Keep the Helm release next to its resources, where
+				// install puts it, so the CLI can still inspect it.
+				StorageNamespace: r.Namespace,
+				Chart: fluxChart{Spec: fluxChartSpec{
+					Chart:     r.Chart,
+					Version:   r.Version,
+					SourceRef: fluxSourceRef{Kind: "HelmRepository", Name: r.Repository.Name},
+				}},
+				Install: fluxInstall{CreateNamespace: true},
+			},
+		}
+		for _, dep := range r.DependsOn {
+			release.Spec.DependsOn = append(release.Spec.DependsOn, fluxReference{Name: dep})
+		}
+		if r.SkipCRDs {
+			release.Spec.Install.CRDs = "Skip"
+			release.Spec.Upgrade = &fluxUpgrade{CRDs: "Skip"}
+		}
+
+		if opts.ValuesFiles && len(r.Values) > 0 {
+			values, err := encodeValues(r.Values)
+			if err != nil {
+				return nil, err
+			}
+			name := r.Name + "-values"
+			content, err := encode(configMap{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Metadata:   metadata{Name: name, Namespace: opts.Namespace, Labels: managedLabels},
+				Data:       map[string]string{"values.yaml": values},
+			})
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, File{Path: valuesPath(r.Name), Content: content})
+			release.Spec.ValuesFrom = []fluxValuesReference{{Kind: "ConfigMap", Name: name}}
+		} else {
+			release.Spec.Values = r.Values
+		}
+
+		content, err := encode(release)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: r.Name + ".yaml", Content: content})
+	}
+
+	return files, nil
+}
@@ -0,0 +1,193 @@
+package gitops
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatArgoCD = "argocd"
+	FormatFlux   = "flux"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatArgoCD, FormatFlux}
+
+// Repository is a chart repository, e.g. oci://docker.io/envoyproxy.
+type Repository struct {
+	Name string
+	URL  string
+}
+
+// OCI reports whether the repository is an OCI registry.
+func (r Repository) OCI() bool {
+	return strings.HasPrefix(r.URL, "oci://")
+}
+
+// Release is a Helm release to describe declaratively.
+type Release struct {
+	Name       string
+	Namespace  string
+	Repository Repository
+	Chart      string
+	Version    string
+	// Values are the merged values of the release, as install passes them
+	// to helm.
+	Values map[string]interface{}
+	// SkipCRDs leaves out the crds/ directory of the chart.
+	SkipCRDs bool
+	// DependsOn names the releases that must be ready first.
+	DependsOn []string
+}
+
+// Options controls the generated manifests.
+type Options struct {
+	Format string
+	// ValuesFiles writes the values of each release to a file of its own
+	// instead of inlining them.
+	ValuesFiles bool
+
+	// Namespace is where the Argo CD Applications or the Flux sources and
+	// HelmReleases are created, e.g. argocd or flux-system.
+	Namespace string
+	// Project is the Argo CD project of the Applications.
+	Project string
+	// Interval is how often Flux reconciles, e.g. 10m.
+	Interval string
+
+	// GitRepo, GitRevision and GitPath locate the output directory in git,
+	// for Argo CD Applications that reference separate values files.
+	GitRepo     string
+	GitRevision string
+	GitPath     string
+}
+
+// File is a generated manifest file, with a path relative to the output
+// directory.
+type File struct {
+	Path    string
+	Content string
+}
+
+// Validate checks the options before anything is rendered.
+func (o Options) Validate() error {
+	switch o.Format {
+	case FormatArgoCD:
+		if o.ValuesFiles && o.GitRepo == "" {
+			return fmt.Errorf("Argo CD Applications can only reference separate values files in git: --git-repo is required with --values-files")
+		}
+	case FormatFlux:
+	default:
+		return fmt.Errorf("unsupported format %q (expected %s)", o.Format, strings.Join(Formats, " or "))
+	}
+	return nil
+}
+
+// Render returns the manifests that install releases with Argo CD or Flux.
+// The output only depends on its input: files, documents and keys are
+// always in the same order, so that regenerating them diffs cleanly.
+func Render(releases []Release, opts Options) ([]File, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var files []File
+	var err error
+	switch opts.Format {
+	case FormatArgoCD:
+		files, err = renderArgoCD(releases, opts)
+	case FormatFlux:
+		files, err = renderFlux(releases, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Concat joins files into a single multi-document YAML stream, in order,
+// with a comment naming the file each document came from.
+func Concat(files []File) string {
+	var b strings.Builder
+	for _, file := range files {
+		b.WriteString("---\n# Source: " + file.Path + "\n")
+		b.WriteString(strings.TrimPrefix(file.Content, "---\n"))
+	}
+	return b.String()
+}
+
+// repositories returns the distinct repositories of releases, by name.
+func repositories(releases []Release) []Repository {
+	seen := map[string]bool{}
+	var repos []Repository
+	for _, r := range releases {
+		if seen[r.Repository.Name] {
+			continue
+		}
+		seen[r.Repository.Name] = true
+		repos = append(repos, r.Repository)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	return repos
+}
+
+// valuesPath is the path of the values file of a release.
+func valuesPath(release string) string {
+	return path.Join("values", release+".yaml")
+}
+
+// encode renders documents as a YAML stream. Struct fields keep their
+// declared order and map keys are sorted.
+func encode(documents ...interface{}) (string, error) {
+	var buf bytes.Buffer
+	for _, doc := range documents {
+		buf.WriteString("---\n")
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return "", fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return "", fmt.Errorf("failed to encode manifest: %w", err)
+		}
+	}
+	return buf.String(), nil
+}
+
+// encodeValues renders values as a YAML document, or an empty string when
+// there are none.
+func encodeValues(values map[string]interface{}) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(values); err != nil {
+		return "", fmt.Errorf("failed to encode values: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode values: %w", err)
+	}
+	return buf.String(), nil
+}
+
+type metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// managedLabels marks every generated object as managed by the installer.
+var managedLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "envoy-ai-installer",
+}
@@ -0,0 +1,155 @@
+package gitops
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testReleases are the releases of an install with Redis, at pinned
+// versions.
+var testReleases = []Release{
+	{
+		Name:       "eg",
+		Namespace:  "envoy-gateway-system",
+		Repository: Repository{Name: "envoyproxy", URL: "oci://docker.io/envoyproxy"},
+		Chart:      "gateway-helm",
+		Version:    "v1.4.0",
+		Values: map[string]interface{}{
+			"config": map[string]interface{}{
+				"envoyGateway": map[string]interface{}{
+					"extensionApis": map[string]interface{}{"enableBackend": true},
+				},
+			},
+			"commonLabels": map[string]interface{}{"team": "ai"},
+		},
+	},
+	{
+		Name:       "aieg-crd",
+		Namespace:  "envoy-ai-gateway-system",
+		Repository: Repository{Name: "envoyproxy", URL: "oci://docker.io/envoyproxy"},
+		Chart:      "ai-gateway-crds-helm",
+		Version:    "v0.3.0",
+	},
+	{
+		Name:       "aieg",
+		Namespace:  "envoy-ai-gateway-system",
+		Repository: Repository{Name: "envoyproxy", URL: "oci://docker.io/envoyproxy"},
+		Chart:      "ai-gateway-helm",
+		Version:    "v0.3.0",
+		Values:     map[string]interface{}{"controller": map[string]interface{}{"replicaCount": 2}},
+		DependsOn:  []string{"eg", "aieg-crd"},
+	},
+	{
+		Name:       "envoy-redis",
+		Namespace:  "envoy-ai-gateway-system",
+		Repository: Repository{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"},
+		Chart:      "redis",
+		Version:    "*",
+		Values:     map[string]interface{}{"architecture": "standalone", "auth": map[string]interface{}{"enabled": false}},
+	},
+}
+
+func TestRenderGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		single bool
+	}{
+		{name: "argocd", opts: Options{Format: FormatArgoCD, Namespace: "argocd", Project: "default"}},
+		{name: "argocd-values-files", opts: Options{
+			Format: FormatArgoCD, Namespace: "argocd", Project: "default", ValuesFiles: true,
+			GitRepo: "https://git.example.com/platform/gitops.git", GitRevision: "main", GitPath: "clusters/prod/envoy-ai",
+		}},
+		{name: "argocd-single-file", opts: Options{Format: FormatArgoCD, Namespace: "argocd", Project: "default"}, single: true},
+		{name: "flux", opts: Options{Format: FormatFlux, Namespace: "flux-system", Interval: "10m"}},
+		{name: "flux-values-files", opts: Options{Format: FormatFlux, Namespace: "flux-system", Interval: "10m", ValuesFiles: true}},
+		{name: "flux-single-file", opts: Options{Format: FormatFlux, Namespace: "flux-system", Interval: "10m"}, single: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Render(testReleases, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.single {
+				files = []File{{Path: "envoy-ai-gateway.yaml", Content: Concat(files)}}
+			}
+
+			again, err := Render(testReleases, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.single && !sameFiles(files, again) {
+				t.Error("rendering twice gave different output")
+			}
+
+			checkGolden(t, filepath.Join("testdata", tt.name), files)
+		})
+	}
+}
+
+func sameFiles(a, b []File) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkGolden compares files with the golden files under dir, which must
+// hold no others. With -update it rewrites dir instead.
+func checkGolden(t *testing.T, dir string, files []File) {
+	t.Helper()
+	if *update {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			path := filepath.Join(dir, filepath.FromSlash(file.Path))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(file.Content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+
+	var golden []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			golden = append(golden, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(golden)
+
+	var got []string
+	for _, file := range files {
+		got = append(got, file.Path)
+		want, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			t.Errorf("%s: no golden file (run go test -update): %v", file.Path, err)
+			continue
+		}
+		if file.Content != string(want) {
+			t.Errorf("%s differs from the golden file; got:\n%s\nwant:\n%s", file.Path, file.Content, want)
+		}
+	}
+	if len(got) != len(golden) {
+		t.Errorf("got files %v, want the golden files %v", got, golden)
+	}
+}
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+)
+
+// podDone reports whether the pod needs no further waiting: it is ready, or
+// it belongs to a job that has completed.
+func podDone(p k8s.Pod) bool {
+	return p.Ready || p.Phase == "Succeeded"
+}
+
+type Result struct {
+	Pods []k8s.Pod
+	Err  error
+}
+
+func (r Result) Counts() (done, total int) {
+	for _, p := range r.Pods {
+		if podDone(p) {
+			done++
+		}
+	}
+	return done, len(r.Pods)
+}
+
+func (r Result) AllReady() bool {
+	done, total := r.Counts()
+	return r.Err == nil && total > 0 && done == total
+}
+
+func (r Result) Pending() []k8s.Pod {
+	var pending []k8s.Pod
+	for _, p := range r.Pods {
+		if !podDone(p) {
+			pending = append(pending, p)
+		}
+	}
+	return pending
+}
+
+// Check lists the pods of all namespaces concurrently.
+func Check(client k8s.KubeClient, namespaces []string) Result {
+	results := make([][]k8s.Pod, len(namespaces))
+	errs := make([]error, len(namespaces))
+
+	var wg sync.WaitGroup
+	for i, ns := range namespaces {
+		wg.Add(1)
+		go func(i int, ns string) {
+			defer wg.Done()
+			results[i], errs[i] = client.GetPods(ns, "")
+		}(i, ns)
+	}
+	wg.Wait()
+
+	var result Result
+	for i := range namespaces {
+		if errs[i] != nil && result.Err == nil {
+			result.Err = errs[i]
+		}
+		result.Pods = append(result.Pods, results[i]...)
+	}
+
+	sort.Slice(result.Pods, func(i, j int) bool {
+		if result.Pods[i].Namespace != result.Pods[j].Namespace {
+			return result.Pods[i].Namespace < result.Pods[j].Namespace
+		}
+		return result.Pods[i].Name < result.Pods[j].Name
+	})
+
+	return result
+}
+
+// Poll checks the namespaces every interval and sends each result on the
+// returned channel until ctx is done.
+func Poll(ctx context.Context, client k8s.KubeClient, namespaces []string, interval time.Duration) <-chan Result {
+	results := make(chan Result)
+
+	go func() {
+		defer close(results)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case results <- Check(client, namespaces):
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
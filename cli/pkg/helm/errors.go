@@ -0,0 +1,128 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+)
+
+// ErrCommandFailed is wrapped by the errors of helm commands that ran and
+// failed.
+var ErrCommandFailed = errors.New("helm command failed")
+
+// CommandError is returned when helm ran and failed. Message is the error
+// helm printed, without its "Error: " prefix and with credentials masked.
+type CommandError struct {
+	Args    []string
+	Message string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%v: %v", ErrCommandFailed, e.Err)
+	}
+	return fmt.Sprintf("%v: %s", ErrCommandFailed, e.Message)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CommandError) Is(target error) bool {
+	return target == ErrCommandFailed
+}
+
+// HelmNotFoundError is returned when the helm binary is not in PATH. It
+// wraps exec.ErrNotFound.
+type HelmNotFoundError struct {
+	Err error
+}
+
+func (e *HelmNotFoundError) Error() string {
+	return "helm is not installed or not in PATH: https://helm.sh/docs/intro/install/"
+}
+
+func (e *HelmNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// ReleaseAlreadyExistsError is returned when a release name is taken, e.g.
+// by a release stuck in a pending state or installed outside of helm's
+// upgrade --install.
+type ReleaseAlreadyExistsError struct {
+	Release string
+	*CommandError
+}
+
+// TimeoutError is returned when helm gave up waiting for resources or for
+// the cluster.
+type TimeoutError struct {
+	*CommandError
+}
+
+// ChartNotFoundError is returned when the chart or the requested version of
+// it does not exist in the repository or registry.
+type ChartNotFoundError struct {
+	Chart string
+	*CommandError
+}
+
+var (
+	releaseInUsePattern  = regexp.MustCompile(`cannot re-use a name that is still in use`)
+	timeoutPattern       = regexp.MustCompile(`timed out waiting for the condition|context deadline exceeded|i/o timeout`)
+	chartNotFoundPattern = regexp.MustCompile(`chart ".*" .*not found|: not found$|404 Not Found|manifest unknown|no chart version found`)
+
+	// chartCommands take a chart reference, so that "not found" refers to
+	// the chart rather than to a release.
+	chartCommands = map[string]bool{"upgrade": true, "install": true, "pull": true, "show": true, "template": true}
+)
+
+// commandError classifies a failed helm invocation from the message on
+// its stderr.
+func commandError(args []string, stderr string, err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return &HelmNotFoundError{Err: err}
+	}
+
+	base := &CommandError{Args: args, Message: redact.Text(lastError(stderr)), Err: err}
+	switch {
+	case releaseInUsePattern.MatchString(base.Message):
+		return &ReleaseAlreadyExistsError{CommandError: base}
+	case timeoutPattern.MatchString(base.Message):
+		return &TimeoutError{CommandError: base}
+	case len(args) > 0 && chartCommands[args[0]] && chartNotFoundPattern.MatchString(base.Message):
+		return &ChartNotFoundError{CommandError: base}
+	}
+	return base
+}
+
+// withNames fills in the release and chart of the typed errors of a command
+// that acted on them.
+func withNames(err error, release, chart string) error {
+	var exists *ReleaseAlreadyExistsError
+	if errors.As(err, &exists) {
+		exists.Release = release
+	}
+	var notFound *ChartNotFoundError
+	if errors.As(err, &notFound) {
+		notFound.Chart = chart
+	}
+	return err
+}
+
+// lastError returns the last "Error: " line of helm's stderr, or its last
+// line.
+func lastError(stderr string) string {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if message, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "Error: "); ok {
+			return message
+		}
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -2,64 +2,223 @@ package helm
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
 )
 
 type HelmOptions struct {
-	DryRun     bool
-	Namespace  string
-	Values     []string
-	Version    string
-	ChartRepo  string
+	DryRun    bool
+	Namespace string
+	Values    []string
+	// Set holds typed overrides; SetString overrides are always strings.
+	Set       []string
+	SetString []string
+	Version   string
+	ChartRepo string
+	Force     bool
+	// ReuseValues keeps the values of the deployed release and merges
+	// Values and SetString on top.
+	ReuseValues bool
+	// SkipCRDs leaves out the chart's crds/ directory, for clusters whose
+	// CRDs are managed elsewhere.
+	SkipCRDs bool
+	// IncludeCRDs renders the chart's crds/ directory along with its
+	// templates.
+	IncludeCRDs bool
+	// KubeVersion and APIVersions stand in for the cluster's capabilities
+	// when rendering without one.
+	KubeVersion string
+	APIVersions []string
+	// NoHooks leaves hooks and tests out of a rendered chart, as helm get
+	// manifest does.
+	NoHooks bool
+}
+
+type Release struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Revision   string `json:"revision"`
+	Updated    string `json:"updated"`
+	Status     string `json:"status"`
+	Chart      string `json:"chart"`
+	AppVersion string `json:"app_version"`
+}
+
+var chartVersionPattern = regexp.MustCompile(`^(.+?)-(v?[0-9]+\.[0-9]+.*)$`)
+
+func (r Release) ChartName() string {
+	if m := chartVersionPattern.FindStringSubmatch(r.Chart); m != nil {
+		return m[1]
+	}
+	return r.Chart
+}
+
+func (r Release) ChartVersion() string {
+	if m := chartVersionPattern.FindStringSubmatch(r.Chart); m != nil {
+		return m[2]
+	}
+	return ""
 }
 
+// DefaultTimeout is how long helm waits for hooks and resources unless
+// configured, the default of helm itself.
+const DefaultTimeout = 5 * time.Minute
+
 type HelmCommand struct {
-	dryRun bool
-	output io.Writer
+	dryRun      bool
+	output      io.Writer
+	kubeconfig  string
+	kubeContext string
+	ctx         context.Context
+	timeout     time.Duration
+}
+
+var (
+	defaultKubeconfig  string
+	defaultKubeContext string
+	defaultContext     = context.Background()
+	defaultTimeout     time.Duration
+)
+
+func SetKubeConfig(kubeconfig, context string) {
+	defaultKubeconfig = kubeconfig
+	defaultKubeContext = context
+}
+
+// SetTimeout makes helm commands pass timeout as --timeout to upgrade and
+// uninstall, which wait for hooks and resources, and kills any helm command
+// still running when ctx ends, e.g. at the deadline of the installer
+// command. A zero timeout leaves helm's own default.
+func SetTimeout(ctx context.Context, timeout time.Duration) {
+	defaultContext = ctx
+	defaultTimeout = timeout
 }
 
 func NewHelmCommand(dryRun bool) *HelmCommand {
 	return &HelmCommand{
-		dryRun: dryRun,
-		output: os.Stdout,
+		dryRun:      dryRun,
+		kubeconfig:  defaultKubeconfig,
+		kubeContext: defaultKubeContext,
+		ctx:         defaultContext,
+		timeout:     defaultTimeout,
+	}
+}
+
+// withTimeout appends --timeout to a command that waits, when one is set.
+func (h *HelmCommand) withTimeout(args []string) []string {
+	if h.timeout > 0 {
+		return append(args, "--timeout", h.timeout.String())
+	}
+	return args
+}
+
+// stopped returns the error of a helm command killed because the context
+// of h ended, or nil if it failed on its own.
+func (h *HelmCommand) stopped(args []string) error {
+	if err := h.ctx.Err(); err != nil {
+		return fmt.Errorf("helm %s stopped: %w", args[0], err)
+	}
+	return nil
+}
+
+func (h *HelmCommand) withGlobalFlags(args []string) []string {
+	var global []string
+	if h.kubeconfig != "" {
+		global = append(global, "--kubeconfig", h.kubeconfig)
+	}
+	if h.kubeContext != "" {
+		global = append(global, "--kube-context", h.kubeContext)
 	}
+	return append(args, global...)
+}
+
+func (h *HelmCommand) SetOutput(w io.Writer) {
+	h.output = w
+}
+
+// stdout returns the output set with SetOutput, or output.Stdout.
+func (h *HelmCommand) stdout() io.Writer {
+	if h.output != nil {
+		return h.output
+	}
+	return output.Stdout
 }
 
 func (h *HelmCommand) Execute(args ...string) error {
+	args = h.withGlobalFlags(args)
+
 	if h.dryRun {
-		fmt.Printf("[DRY-RUN] helm %s\n", strings.Join(args, " "))
+		output.Printf("[DRY-RUN] helm %s\n", commandLine(args))
 		return nil
 	}
 
-	cmd := exec.Command("helm", args...)
-	cmd.Stdout = h.output
-	cmd.Stderr = os.Stderr
+	return h.run(args)
+}
+
+// run runs helm with its output scrubbed of credentials and classifies its
+// failure.
+func (h *HelmCommand) run(args []string) error {
+	output.Debugf("helm %s", commandLine(args))
+	cmd := exec.CommandContext(h.ctx, "helm", args...)
+	stdout, stderr := redact.NewWriter(h.stdout()), redact.NewWriter(output.Stderr)
+	var captured bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = io.MultiWriter(stderr, &captured)
 	cmd.Stdin = os.Stdin
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("helm command failed: %w", err)
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	if err != nil {
+		if stopped := h.stopped(args); stopped != nil {
+			return stopped
+		}
+		return commandError(args, captured.String(), err)
 	}
-
 	return nil
 }
 
+// commandLine returns args for printing, with credentials masked.
+func commandLine(args []string) string {
+	return strings.Join(redact.Args(args), " ")
+}
+
 func (h *HelmCommand) ExecuteOutput(args ...string) (string, error) {
+	args = h.withGlobalFlags(args)
+
 	if h.dryRun {
-		fmt.Printf("[DRY-RUN] helm %s\n", strings.Join(args, " "))
+		output.Printf("[DRY-RUN] helm %s\n", commandLine(args))
 		return "", nil
 	}
 
-	cmd := exec.Command("helm", args...)
-	var out bytes.Buffer
+	output.Debugf("helm %s", commandLine(args))
+	cmd := exec.CommandContext(h.ctx, "helm", args...)
+	var out, stderr bytes.Buffer
+	scrubbed := redact.NewWriter(output.Stderr)
 	cmd.Stdout = &out
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = io.MultiWriter(scrubbed, &stderr)
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("helm command failed: %w", err)
+	err := cmd.Run()
+	scrubbed.Flush()
+	output.Debugf("helm output:\n%s", out.String())
+	if err != nil {
+		if stopped := h.stopped(args); stopped != nil {
+			return "", stopped
+		}
+		return "", commandError(args, stderr.String(), err)
 	}
 
 	return out.String(), nil
@@ -86,34 +245,202 @@ func (h *HelmCommand) Install(releaseName, chart, namespace string, opts *HelmOp
 		args = append(args, "-f", v)
 	}
 
+	for _, v := range opts.Set {
+		args = append(args, "--set", v)
+	}
+
+	for _, v := range opts.SetString {
+		args = append(args, "--set-string", v)
+	}
+
+	if opts.Force {
+		args = append(args, "--force")
+	}
+
+	if opts.ReuseValues {
+		args = append(args, "--reuse-values")
+	}
+
+	if opts.SkipCRDs {
+		args = append(args, "--skip-crds")
+	}
+
 	if opts.DryRun {
 		args = append(args, "--dry-run", "--debug")
 	}
 
+	return withNames(h.Execute(h.withTimeout(args)...), releaseName, chart)
+}
+
+func (h *HelmCommand) Lint(chart string, opts *HelmOptions) error {
+	dir, err := os.MkdirTemp("", "envoy-ai-lint-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	pullArgs := []string{"pull", chart, "--untar", "--untardir", dir}
+	if opts.Version != "" {
+		pullArgs = append(pullArgs, "--version", opts.Version)
+	}
+
+	if err := h.Execute(pullArgs...); err != nil {
+		return fmt.Errorf("failed to pull chart %s: %w", chart, withNames(err, "", chart))
+	}
+
+	args := []string{"lint", filepath.Join(dir, path.Base(chart))}
+
+	if opts.Namespace != "" {
+		args = append(args, "--namespace", opts.Namespace)
+	}
+
+	for _, v := range opts.Values {
+		args = append(args, "-f", v)
+	}
+
+	for _, v := range opts.Set {
+		args = append(args, "--set", v)
+	}
+
+	for _, v := range opts.SetString {
+		args = append(args, "--set-string", v)
+	}
+
 	return h.Execute(args...)
 }
 
+func (h *HelmCommand) Template(releaseName, chart, namespace string, opts *HelmOptions) (string, error) {
+	args := []string{"template", releaseName, chart, "-n", namespace}
+
+	if opts.Version != "" {
+		args = append(args, "--version", opts.Version)
+	}
+
+	for _, v := range opts.Values {
+		args = append(args, "-f", v)
+	}
+
+	for _, v := range opts.Set {
+		args = append(args, "--set", v)
+	}
+
+	for _, v := range opts.SetString {
+		args = append(args, "--set-string", v)
+	}
+
+	if opts.IncludeCRDs {
+		args = append(args, "--include-crds")
+	}
+
+	if opts.KubeVersion != "" {
+		args = append(args, "--kube-version", opts.KubeVersion)
+	}
+
+	for _, v := range opts.APIVersions {
+		args = append(args, "--api-versions", v)
+	}
+
+	if opts.NoHooks {
+		args = append(args, "--no-hooks", "--skip-tests")
+	}
+
+	out, err := h.ExecuteOutput(args...)
+	return out, withNames(err, releaseName, chart)
+}
+
 func (h *HelmCommand) Uninstall(releaseName, namespace string) error {
 	if h.dryRun {
-		fmt.Printf("[DRY-RUN] helm uninstall %s -n %s\n", releaseName, namespace)
+		output.Printf("[DRY-RUN] helm uninstall %s -n %s\n", releaseName, namespace)
 		return nil
 	}
 
-	cmd := exec.Command("helm", "uninstall", releaseName, "-n", namespace)
-	cmd.Stdout = h.output
-	cmd.Stderr = os.Stderr
+	return h.run(h.withGlobalFlags(h.withTimeout([]string{"uninstall", releaseName, "-n", namespace})))
+}
 
-	return cmd.Run()
+func (h *HelmCommand) Status(releaseName, namespace string) (string, error) {
+	return h.ExecuteOutput("status", releaseName, "-n", namespace)
 }
 
 func (h *HelmCommand) GetValues(releaseName, namespace string) (string, error) {
-	return h.ExecuteOutput("get", "values", releaseName, "-n", namespace)
+	return h.ExecuteOutput("get", "values", releaseName, "-n", namespace, "-o", "yaml")
+}
+
+func (h *HelmCommand) GetManifest(releaseName, namespace string) (string, error) {
+	return h.ExecuteOutput("get", "manifest", releaseName, "-n", namespace)
+}
+
+func (h *HelmCommand) GetAll(releaseName, namespace string) (string, error) {
+	return h.ExecuteOutput("get", "all", releaseName, "-n", namespace)
 }
 
 func (h *HelmCommand) List(namespace string) (string, error) {
 	return h.ExecuteOutput("list", "-n", namespace)
 }
 
+func (h *HelmCommand) ListReleases(namespace string) ([]Release, error) {
+	return h.listReleases("-n", namespace)
+}
+
+// ListAllReleases lists the releases of every namespace.
+func (h *HelmCommand) ListAllReleases() ([]Release, error) {
+	return h.listReleases("--all-namespaces")
+}
+
+func (h *HelmCommand) listReleases(scope ...string) ([]Release, error) {
+	output, err := h.ExecuteOutput(append(append([]string{"list"}, scope...), "-o", "json")...)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+
+	var releases []Release
+	if err := json.Unmarshal([]byte(output), &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse helm list output: %w", err)
+	}
+
+	return releases, nil
+}
+
+// FindRelease returns release releaseName in namespace whatever its status,
+// so failed and pending releases are found too, or nil when there is none.
+func (h *HelmCommand) FindRelease(releaseName, namespace string) (*Release, error) {
+	releases, err := h.listReleases("-a", "-n", namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range releases {
+		if r.Name == releaseName {
+			return &r, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ShowChart returns the Chart.yaml of chart, e.g. an oci:// reference,
+// without installing it. It returns the context error when ctx ends first.
+func (h *HelmCommand) ShowChart(ctx context.Context, chart string) (string, error) {
+	args := []string{"show", "chart", chart}
+	output.Debugf("helm %s", commandLine(args))
+	cmd := exec.CommandContext(ctx, "helm", args...)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", withNames(commandError(args, stderr.String(), err), "", chart)
+	}
+
+	return out.String(), nil
+}
+
 func (h *HelmCommand) Version() (string, error) {
 	return h.ExecuteOutput("version", "--short")
 }
@@ -121,6 +448,9 @@ func (h *HelmCommand) Version() (string, error) {
 func ValidateHelmInstalled() error {
 	cmd := exec.Command("helm", "version", "--short")
 	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return &HelmNotFoundError{Err: err}
+		}
 		return fmt.Errorf("helm is not installed or not in PATH: %w", err)
 	}
 	return nil
@@ -0,0 +1,100 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeHelm records its arguments to $FAKE_HELM_LOG and then behaves as
+// $FAKE_HELM_MODE says: hang until killed, or give up as helm does at the
+// end of --timeout.
+const fakeHelm = `#!/bin/sh
+echo "$*" >> "$FAKE_HELM_LOG"
+case "$FAKE_HELM_MODE" in
+hang) exec sleep 10 ;;
+timeout)
+	echo 'Error: UPGRADE FAILED: timed out waiting for the condition' >&2
+	exit 1 ;;
+esac
+`
+
+func TestTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake helm is a shell script")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "helm"), []byte(fakeHelm), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Cleanup(func() { SetTimeout(context.Background(), 0) })
+
+	tests := []struct {
+		name     string
+		mode     string
+		timeout  time.Duration
+		wantArgs string
+		check    func(error) bool
+	}{
+		{name: "helm default", wantArgs: "upgrade --install eg oci://docker.io/envoyproxy/gateway-helm -n envoy-gateway-system --create-namespace\n"},
+		{name: "helm timeout", timeout: 2 * time.Minute, wantArgs: "--create-namespace --timeout 2m0s\n"},
+		{
+			name:    "helm gave up",
+			mode:    "timeout",
+			timeout: time.Second,
+			check: func(err error) bool {
+				var timeoutErr *TimeoutError
+				return errors.As(err, &timeoutErr)
+			},
+		},
+		{
+			name:    "command deadline",
+			mode:    "hang",
+			timeout: time.Minute,
+			check: func(err error) bool {
+				return errors.Is(err, context.DeadlineExceeded) && strings.HasPrefix(err.Error(), "helm upgrade stopped")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := filepath.Join(t.TempDir(), "helm.log")
+			t.Setenv("FAKE_HELM_LOG", log)
+			t.Setenv("FAKE_HELM_MODE", tt.mode)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			SetTimeout(ctx, tt.timeout)
+
+			start := time.Now()
+			err := NewHelmCommand(false).Install("eg", "oci://docker.io/envoyproxy/gateway-helm", "envoy-gateway-system", &HelmOptions{})
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("helm returned after %s, want it killed at the deadline", elapsed)
+			}
+			if tt.check == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.check != nil && !tt.check(err) {
+				t.Fatalf("got error %#v, want a %s error", err, tt.name)
+			}
+
+			logged, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(string(logged), tt.wantArgs) {
+				t.Errorf("got args %q, want them to end with %q", logged, tt.wantArgs)
+			}
+			if tt.timeout == 0 && strings.Contains(string(logged), "--timeout") {
+				t.Errorf("got args %q, want helm's own default timeout", logged)
+			}
+		})
+	}
+}
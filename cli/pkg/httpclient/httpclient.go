@@ -0,0 +1,93 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+const DefaultTimeout = 30 * time.Second
+
+type Options struct {
+	// Timeout bounds every request, including reading the response body.
+	// Zero means no limit.
+	Timeout time.Duration
+	// CABundle is a PEM file of extra root CAs, for proxies that intercept
+	// TLS. The system roots are still trusted.
+	CABundle string
+}
+
+var (
+	timeout                     = DefaultTimeout
+	transport http.RoundTripper = newTransport(nil)
+)
+
+// Configure sets up the shared transport. Proxies are taken from
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func Configure(opts Options) error {
+	timeout = opts.Timeout
+
+	if opts.CABundle == "" {
+		transport = newTransport(nil)
+		return nil
+	}
+
+	pem, err := os.ReadFile(opts.CABundle)
+	if err != nil {
+		return fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("CA bundle %s contains no PEM certificates", opts.CABundle)
+	}
+
+	transport = newTransport(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})
+	return nil
+}
+
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	if tlsConfig != nil {
+		t.TLSClientConfig = tlsConfig
+	}
+	return t
+}
+
+// Client returns an HTTP client using the shared transport and timeout.
+// A zero timeout leaves requests unbounded, as http.Client does.
+func Client() *http.Client {
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// Timeout returns the per-request timeout.
+func Timeout() time.Duration {
+	return timeout
+}
+
+// WithTimeout derives a context bounded by the per-request timeout, or
+// only by ctx when there is no limit.
+func WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Get issues a GET request for url bounded by ctx and the per-request
+// timeout.
+func Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Client().Do(req)
+}
@@ -0,0 +1,179 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// http.ProxyFromEnvironment reads the environment once per process, so the
+// proxy variables are set before any test makes a request. Loopback
+// addresses are never proxied, which keeps the test servers reachable.
+func TestMain(m *testing.M) {
+	for _, key := range []string{"HTTP_PROXY", "http_proxy", "https_proxy", "no_proxy", "REQUEST_METHOD"} {
+		os.Unsetenv(key)
+	}
+	os.Setenv("HTTPS_PROXY", "http://proxy.example:3128")
+	os.Setenv("NO_PROXY", "internal.example")
+	os.Exit(m.Run())
+}
+
+// restore puts the shared timeout and transport back after a test
+// reconfigures them.
+func restore(t *testing.T) {
+	t.Helper()
+	savedTimeout, savedTransport := timeout, transport
+	t.Cleanup(func() { timeout, transport = savedTimeout, savedTransport })
+}
+
+// slowServer answers after delay, or gives up once the client has gone.
+func slowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			w.Write([]byte("ok"))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTimeout(t *testing.T) {
+	restore(t)
+	srv := slowServer(t, time.Second)
+
+	if err := Configure(Options{Timeout: 50 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if Timeout() != 50*time.Millisecond {
+		t.Errorf("Timeout() = %v, want 50ms", Timeout())
+	}
+
+	start := time.Now()
+	resp, err := Get(context.Background(), srv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the request to time out")
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("request took %v, want it stopped at the timeout", elapsed)
+	}
+}
+
+func TestNoTimeout(t *testing.T) {
+	restore(t)
+	srv := slowServer(t, 100*time.Millisecond)
+
+	if err := Configure(Options{Timeout: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if Client().Timeout != 0 {
+		t.Errorf("Client().Timeout = %v, want none", Client().Timeout)
+	}
+
+	ctx, cancel := WithTimeout(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("WithTimeout set a deadline with no limit configured")
+	}
+
+	resp, err := Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	restore(t)
+	if err := Configure(Options{Timeout: DefaultTimeout}); err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := Client().Transport.(*http.Transport).Proxy
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://api.github.com/repos/envoyproxy/gateway", want: "http://proxy.example:3128"},
+		{url: "https://internal.example/values.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := proxy(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("proxy = %v, want none", got)
+				}
+				return
+			}
+			if got == nil || got.String() != tt.want {
+				t.Errorf("proxy = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCABundle(t *testing.T) {
+	restore(t)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	// The handshake rejected without the bundle is expected.
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	if err := Configure(Options{Timeout: DefaultTimeout}); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := Get(context.Background(), srv.URL); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the test server's certificate to be untrusted without the CA bundle")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Configure(Options{Timeout: DefaultTimeout, CABundle: bundle}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := Get(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("request with the CA bundle failed: %v", err)
+	}
+	resp.Body.Close()
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Configure(Options{CABundle: empty}); err == nil {
+		t.Error("expected an error for a bundle without certificates")
+	}
+}
@@ -0,0 +1,228 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+)
+
+type Pod struct {
+	Namespace string
+	Name      string
+	Phase     string
+	Ready     bool
+	Restarts  int
+	Reason    string
+}
+
+// NamespaceStatus is the phase of a namespace and, while it is
+// terminating, the messages of the conditions the namespace controller sets
+// about the content it has not deleted yet.
+type NamespaceStatus struct {
+	Phase      string
+	Conditions []string
+}
+
+// Terminating reports whether the namespace is being deleted.
+func (s *NamespaceStatus) Terminating() bool {
+	return s != nil && s.Phase == "Terminating"
+}
+
+// KubeClient is the set of cluster operations the installer needs, so that
+// commands can be exercised against FakeClient instead of a live cluster.
+type KubeClient interface {
+	ClusterInfo() (string, error)
+	GetNamespace(name string) (bool, error)
+	// GetNamespaceLabels returns nil for a namespace that does not exist.
+	GetNamespaceLabels(name string) (map[string]string, error)
+	// GetNamespaceStatus returns nil for a namespace that does not exist.
+	GetNamespaceStatus(name string) (*NamespaceStatus, error)
+	GetPods(namespace, selector string) ([]Pod, error)
+	GetPodDisruptionBudgets(namespace string) ([]PodDisruptionBudget, error)
+	RolloutStatus(namespace, resource string, timeout time.Duration) error
+}
+
+type kubectlClient struct{}
+
+func NewKubectlClient() KubeClient {
+	return kubectlClient{}
+}
+
+func (kubectlClient) ClusterInfo() (string, error) {
+	return run("cluster-info")
+}
+
+func (kubectlClient) GetNamespace(name string) (bool, error) {
+	output, err := run("get", "namespace", name, "--ignore-not-found", "-o", "name")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(output) != "", nil
+}
+
+func (kubectlClient) GetNamespaceLabels(name string) (map[string]string, error) {
+	output, err := run("get", "namespace", name, "--ignore-not-found", "-o", "json")
+	if err != nil || strings.TrimSpace(output) == "" {
+		return nil, err
+	}
+
+	var namespace struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(output), &namespace); err != nil {
+		return nil, fmt.Errorf("failed to parse namespace %s: %w", name, err)
+	}
+	if namespace.Metadata.Labels == nil {
+		return map[string]string{}, nil
+	}
+	return namespace.Metadata.Labels, nil
+}
+
+func (kubectlClient) GetNamespaceStatus(name string) (*NamespaceStatus, error) {
+	output, err := run("get", "namespace", name, "--ignore-not-found", "-o", "json")
+	if err != nil || strings.TrimSpace(output) == "" {
+		return nil, err
+	}
+
+	var namespace struct {
+		Status struct {
+			Phase      string `json:"phase"`
+			Conditions []struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"conditions"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(output), &namespace); err != nil {
+		return nil, fmt.Errorf("failed to parse namespace %s: %w", name, err)
+	}
+
+	status := &NamespaceStatus{Phase: namespace.Status.Phase}
+	for _, c := range namespace.Status.Conditions {
+		if c.Status == "True" {
+			status.Conditions = append(status.Conditions, c.Message)
+		}
+	}
+	return status, nil
+}
+
+func (kubectlClient) GetPods(namespace, selector string) ([]Pod, error) {
+	args := []string{"get", "pods", "-n", namespace, "-o", "json"}
+	if selector != "" {
+		args = append(args, "-l", selector)
+	}
+
+	output, err := run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePods(output)
+}
+
+func (kubectlClient) GetPodDisruptionBudgets(namespace string) ([]PodDisruptionBudget, error) {
+	output, err := run("get", "poddisruptionbudgets", "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var list policyv1.PodDisruptionBudgetList
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod disruption budgets: %w", err)
+	}
+
+	pdbs := make([]PodDisruptionBudget, 0, len(list.Items))
+	for _, item := range list.Items {
+		pdbs = append(pdbs, pdbFromObject(item))
+	}
+	return pdbs, nil
+}
+
+func (kubectlClient) RolloutStatus(namespace, resource string, timeout time.Duration) error {
+	_, err := run("rollout", "status", resource, "-n", namespace, "--timeout", timeout.String())
+	return err
+}
+
+func run(args ...string) (string, error) {
+	cmd := Kubectl(args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("kubectl %s: %s", args[0], msg)
+	}
+
+	return stdout.String(), nil
+}
+
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name              string  `json:"name"`
+			Namespace         string  `json:"namespace"`
+			DeletionTimestamp *string `json:"deletionTimestamp"`
+		} `json:"metadata"`
+		Status struct {
+			Phase      string `json:"phase"`
+			Conditions []struct {
+				Type   string `json:"type"`
+				Status string `json:"status"`
+			} `json:"conditions"`
+			ContainerStatuses []struct {
+				RestartCount int `json:"restartCount"`
+				State        struct {
+					Waiting *struct {
+						Reason string `json:"reason"`
+					} `json:"waiting"`
+				} `json:"state"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+func parsePods(output string) ([]Pod, error) {
+	var list podList
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pods: %w", err)
+	}
+
+	var pods []Pod
+	for _, item := range list.Items {
+		// Terminating pods are on their way out and would only confuse
+		// readiness checks.
+		if item.Metadata.DeletionTimestamp != nil {
+			continue
+		}
+
+		pod := Pod{
+			Namespace: item.Metadata.Namespace,
+			Name:      item.Metadata.Name,
+			Phase:     item.Status.Phase,
+		}
+		for _, c := range item.Status.Conditions {
+			if c.Type == "Ready" && c.Status == "True" {
+				pod.Ready = true
+			}
+		}
+		for _, cs := range item.Status.ContainerStatuses {
+			pod.Restarts += cs.RestartCount
+			if cs.State.Waiting != nil && pod.Reason == "" {
+				pod.Reason = cs.State.Waiting.Reason
+			}
+		}
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
@@ -0,0 +1,218 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const requestTimeout = 30 * time.Second
+
+type clientGoClient struct {
+	clientset kubernetes.Interface
+	host      string
+}
+
+// NewKubeClient returns a KubeClient that talks to the API server directly
+// through client-go, so kubectl is not needed at runtime.
+func NewKubeClient() (KubeClient, error) {
+	config, err := RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return &clientGoClient{clientset: clientset, host: config.Host}, nil
+}
+
+func (c *clientGoClient) ClusterInfo() (string, error) {
+	version, err := c.clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("failed to reach cluster at %s: %w", c.host, unreachable(err))
+	}
+	return fmt.Sprintf("Kubernetes control plane is running at %s (%s)", c.host, version.GitVersion), nil
+}
+
+func (c *clientGoClient) GetNamespace(name string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	_, err := c.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+	return true, nil
+}
+
+func (c *clientGoClient) GetNamespaceLabels(name string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	namespace, err := c.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+	if namespace.Labels == nil {
+		return map[string]string{}, nil
+	}
+	return namespace.Labels, nil
+}
+
+func (c *clientGoClient) GetNamespaceStatus(name string) (*NamespaceStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	namespace, err := c.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+
+	status := &NamespaceStatus{Phase: string(namespace.Status.Phase)}
+	for _, c := range namespace.Status.Conditions {
+		if c.Status == corev1.ConditionTrue {
+			status.Conditions = append(status.Conditions, c.Message)
+		}
+	}
+	return status, nil
+}
+
+func (c *clientGoClient) GetPods(namespace, selector string) ([]Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	list, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in %s: %w", namespace, err)
+	}
+
+	var pods []Pod
+	for _, item := range list.Items {
+		if item.DeletionTimestamp != nil {
+			continue
+		}
+		pods = append(pods, podFromObject(item))
+	}
+	return pods, nil
+}
+
+func (c *clientGoClient) GetPodDisruptionBudgets(namespace string) ([]PodDisruptionBudget, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	list, err := c.clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod disruption budgets in %s: %w", namespace, err)
+	}
+
+	pdbs := make([]PodDisruptionBudget, 0, len(list.Items))
+	for _, item := range list.Items {
+		pdbs = append(pdbs, pdbFromObject(item))
+	}
+	return pdbs, nil
+}
+
+// RolloutStatus waits until the workload, given as kind/name (for example
+// deployment/envoy-gateway), has rolled out all its replicas.
+func (c *clientGoClient) RolloutStatus(namespace, resource string, timeout time.Duration) error {
+	kind, name, found := strings.Cut(resource, "/")
+	if !found {
+		return fmt.Errorf("resource %q must be in kind/name format", resource)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		return c.rolledOut(ctx, namespace, strings.ToLower(kind), name)
+	})
+	if err != nil {
+		return fmt.Errorf("rollout of %s in %s did not complete: %w", resource, namespace, err)
+	}
+	return nil
+}
+
+func (c *clientGoClient) rolledOut(ctx context.Context, namespace, kind, name string) (bool, error) {
+	apps := c.clientset.AppsV1()
+
+	switch kind {
+	case "deployment", "deployments", "deploy":
+		d, err := apps.Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return deploymentRolledOut(d), nil
+	case "statefulset", "statefulsets", "sts":
+		s, err := apps.StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if s.Spec.Replicas != nil {
+			replicas = *s.Spec.Replicas
+		}
+		return s.Status.ObservedGeneration >= s.Generation &&
+			s.Status.UpdatedReplicas == replicas && s.Status.ReadyReplicas == replicas, nil
+	case "daemonset", "daemonsets", "ds":
+		d, err := apps.DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return d.Status.ObservedGeneration >= d.Generation &&
+			d.Status.UpdatedNumberScheduled == d.Status.DesiredNumberScheduled &&
+			d.Status.NumberAvailable == d.Status.DesiredNumberScheduled, nil
+	default:
+		return false, fmt.Errorf("unsupported rollout kind %q", kind)
+	}
+}
+
+func deploymentRolledOut(d *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas
+}
+
+func podFromObject(item corev1.Pod) Pod {
+	pod := Pod{
+		Namespace: item.Namespace,
+		Name:      item.Name,
+		Phase:     string(item.Status.Phase),
+	}
+	for _, c := range item.Status.Conditions {
+		if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+			pod.Ready = true
+		}
+	}
+	for _, cs := range item.Status.ContainerStatuses {
+		pod.Restarts += int(cs.RestartCount)
+		if cs.State.Waiting != nil && pod.Reason == "" {
+			pod.Reason = cs.State.Waiting.Reason
+		}
+	}
+	return pod
+}
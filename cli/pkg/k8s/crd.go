@@ -0,0 +1,226 @@
+package k8s
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CRDVersion is one entry of the spec.versions of a CustomResourceDefinition.
+type CRDVersion struct {
+	Name    string `json:"name" yaml:"name"`
+	Served  bool   `json:"served" yaml:"served"`
+	Storage bool   `json:"storage" yaml:"storage"`
+}
+
+// GetCRDVersions returns the versions of the named CustomResourceDefinition
+// in the cluster, or nil when it is not installed.
+func GetCRDVersions(name string) ([]CRDVersion, error) {
+	out, err := run("get", "crd", name, "-o", "jsonpath={.spec.versions}")
+	if err != nil {
+		if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var versions []CRDVersion
+	if err := json.Unmarshal([]byte(out), &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse versions of CRD %s: %w", name, err)
+	}
+	return versions, nil
+}
+
+// ManifestCRDs returns the versions of every CustomResourceDefinition in a
+// multi-document manifest, such as helm template output, by CRD name.
+func ManifestCRDs(manifest string) (map[string][]CRDVersion, error) {
+	crds := map[string][]CRDVersion{}
+
+	dec := yaml.NewDecoder(strings.NewReader(manifest))
+	for {
+		var doc struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+			Spec struct {
+				Versions []CRDVersion `yaml:"versions"`
+			} `yaml:"spec"`
+		}
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if doc.Kind == "CustomResourceDefinition" && doc.Metadata.Name != "" {
+			crds[doc.Metadata.Name] = doc.Spec.Versions
+		}
+	}
+	return crds, nil
+}
+
+// ServedVersions returns the names of the served versions.
+func ServedVersions(versions []CRDVersion) []string {
+	var names []string
+	for _, v := range versions {
+		if v.Served {
+			names = append(names, v.Name)
+		}
+	}
+	return names
+}
+
+// StorageVersion returns the name of the storage version, or "" if none is
+// marked.
+func StorageVersion(versions []CRDVersion) string {
+	for _, v := range versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// CRDConversion is the spec.conversion of a CustomResourceDefinition.
+type CRDConversion struct {
+	Strategy string             `json:"strategy"`
+	Webhook  *ConversionWebhook `json:"webhook,omitempty"`
+}
+
+// ConversionWebhook is the webhook a CRD with the Webhook strategy calls to
+// convert objects between versions.
+type ConversionWebhook struct {
+	ClientConfig struct {
+		URL      string `json:"url,omitempty"`
+		CABundle string `json:"caBundle,omitempty"`
+		Service  *struct {
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"service,omitempty"`
+	} `json:"clientConfig"`
+}
+
+// GetCRDConversion returns the conversion settings of the named
+// CustomResourceDefinition.
+func GetCRDConversion(name string) (*CRDConversion, error) {
+	out, err := run("get", "crd", name, "-o", "jsonpath={.spec.conversion}")
+	if err != nil {
+		return nil, err
+	}
+
+	conversion := &CRDConversion{Strategy: "None"}
+	if strings.TrimSpace(out) == "" {
+		return conversion, nil
+	}
+	if err := json.Unmarshal([]byte(out), conversion); err != nil {
+		return nil, fmt.Errorf("failed to parse conversion of CRD %s: %w", name, err)
+	}
+	return conversion, nil
+}
+
+// CRD is a CustomResourceDefinition in the cluster and whether the API
+// server serves it yet.
+type CRD struct {
+	Name        string
+	Versions    []CRDVersion
+	Established bool
+}
+
+// GetGroupCRDs returns the CustomResourceDefinitions of an API group in the
+// cluster, sorted by name.
+func GetGroupCRDs(group string) ([]CRD, error) {
+	out, err := run("get", "crd", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Group    string       `json:"group"`
+				Versions []CRDVersion `json:"versions"`
+			} `json:"spec"`
+			Status struct {
+				Conditions []struct {
+					Type   string `json:"type"`
+					Status string `json:"status"`
+				} `json:"conditions"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse CRDs: %w", err)
+	}
+
+	var crds []CRD
+	for _, item := range list.Items {
+		if item.Spec.Group != group {
+			continue
+		}
+		crd := CRD{Name: item.Metadata.Name, Versions: item.Spec.Versions}
+		for _, c := range item.Status.Conditions {
+			if c.Type == "Established" && c.Status == "True" {
+				crd.Established = true
+			}
+		}
+		crds = append(crds, crd)
+	}
+	sort.Slice(crds, func(i, j int) bool { return crds[i].Name < crds[j].Name })
+	return crds, nil
+}
+
+// ReadyEndpoints returns the number of ready addresses behind a Service.
+func ReadyEndpoints(namespace, name string) (int, error) {
+	out, err := run("get", "endpoints", name, "-n", namespace,
+		"-o", "jsonpath={.subsets[*].addresses[*].ip}")
+	if err != nil {
+		return 0, err
+	}
+	return len(strings.Fields(out)), nil
+}
+
+// FilterCRDs returns the CustomResourceDefinitions of a multi-document
+// manifest as a stream of their own, and their names in order.
+func FilterCRDs(manifest string) (string, []string, error) {
+	var buf strings.Builder
+	var names []string
+
+	dec := yaml.NewDecoder(strings.NewReader(manifest))
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+
+		var doc struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+		if err := node.Decode(&doc); err != nil || doc.Kind != "CustomResourceDefinition" || doc.Metadata.Name == "" {
+			continue
+		}
+
+		out, err := yaml.Marshal(&node)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode CRD %s: %w", doc.Metadata.Name, err)
+		}
+		buf.WriteString("---\n")
+		buf.Write(out)
+		names = append(names, doc.Metadata.Name)
+	}
+	return buf.String(), names, nil
+}
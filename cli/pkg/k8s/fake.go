@@ -0,0 +1,84 @@
+package k8s
+
+import (
+	"fmt"
+	"time"
+)
+
+// FakeClient is an in-memory KubeClient for tests. Pods are keyed by
+// namespace; selectors are not evaluated. Namespaces are Active unless
+// Statuses has an entry for them.
+type FakeClient struct {
+	Info          string
+	Namespaces    map[string]bool
+	Labels        map[string]map[string]string
+	Statuses      map[string]*NamespaceStatus
+	Pods          map[string][]Pod
+	PDBs          map[string][]PodDisruptionBudget
+	RolloutErrors map[string]error
+	Err           error
+}
+
+func (f *FakeClient) ClusterInfo() (string, error) {
+	if f.Err != nil {
+		return "", f.Err
+	}
+	return f.Info, nil
+}
+
+func (f *FakeClient) GetNamespace(name string) (bool, error) {
+	if f.Err != nil {
+		return false, f.Err
+	}
+	return f.Namespaces[name], nil
+}
+
+func (f *FakeClient) GetNamespaceLabels(name string) (map[string]string, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if !f.Namespaces[name] {
+		return nil, nil
+	}
+	if f.Labels[name] == nil {
+		return map[string]string{}, nil
+	}
+	return f.Labels[name], nil
+}
+
+func (f *FakeClient) GetNamespaceStatus(name string) (*NamespaceStatus, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if status, ok := f.Statuses[name]; ok {
+		return status, nil
+	}
+	if !f.Namespaces[name] {
+		return nil, nil
+	}
+	return &NamespaceStatus{Phase: "Active"}, nil
+}
+
+func (f *FakeClient) GetPods(namespace, selector string) ([]Pod, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Pods[namespace], nil
+}
+
+func (f *FakeClient) GetPodDisruptionBudgets(namespace string) ([]PodDisruptionBudget, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.PDBs[namespace], nil
+}
+
+func (f *FakeClient) RolloutStatus(namespace, resource string, timeout time.Duration) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	if err, ok := f.RolloutErrors[fmt.Sprintf("%s/%s", namespace, resource)]; ok {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckServiceIPFamilies asks the API server, with a dry run in namespace,
+// whether it accepts a Service with the IP family policy and families. A
+// cluster without a Service CIDR of one of the families rejects it as
+// invalid.
+func CheckServiceIPFamilies(ctx context.Context, namespace string, policy corev1.IPFamilyPolicy, families []corev1.IPFamily) error {
+	clientset, err := NewClientset()
+	if err != nil {
+		return err
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "envoy-ai-installer-ip-family-"},
+		Spec: corev1.ServiceSpec{
+			Type:           corev1.ServiceTypeClusterIP,
+			IPFamilyPolicy: &policy,
+			IPFamilies:     families,
+			Ports:          []corev1.ServicePort{{Port: 80}},
+		},
+	}
+	_, err = clientset.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{
+		DryRun: []string{metav1.DryRunAll},
+	})
+	return err
+}
+
+// NodeIPFamilies returns the IP families of the pod CIDRs that every node
+// has, IPv4 first. It is empty when the nodes carry no pod CIDRs, as with
+// CNIs that allocate pod addresses themselves.
+func NodeIPFamilies(ctx context.Context) ([]corev1.IPFamily, error) {
+	clientset, err := NewClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var common []corev1.IPFamily
+	seen := false
+	for _, node := range nodes.Items {
+		cidrs := node.Spec.PodCIDRs
+		if len(cidrs) == 0 && node.Spec.PodCIDR != "" {
+			cidrs = []string{node.Spec.PodCIDR}
+		}
+		if len(cidrs) == 0 {
+			continue
+		}
+
+		var families []corev1.IPFamily
+		for _, cidr := range cidrs {
+			ip, _, err := net.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			family := corev1.IPv4Protocol
+			if ip.To4() == nil {
+				family = corev1.IPv6Protocol
+			}
+			if !slices.Contains(families, family) {
+				families = append(families, family)
+			}
+		}
+
+		if !seen {
+			common, seen = families, true
+			continue
+		}
+		common = slices.DeleteFunc(common, func(f corev1.IPFamily) bool { return !slices.Contains(families, f) })
+	}
+
+	slices.Sort(common)
+	return common, nil
+}
@@ -0,0 +1,253 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ErrClusterUnreachable is wrapped by errors that mean the cluster cannot be
+// talked to: no usable kubeconfig, or no answer from the API server.
+var ErrClusterUnreachable = errors.New("cluster unreachable")
+
+type unreachableError struct{ err error }
+
+// unreachable marks err as ErrClusterUnreachable, keeping its message.
+func unreachable(err error) error {
+	return &unreachableError{err}
+}
+
+func (e *unreachableError) Error() string        { return e.err.Error() }
+func (e *unreachableError) Unwrap() error        { return e.err }
+func (e *unreachableError) Is(target error) bool { return target == ErrClusterUnreachable }
+
+var (
+	kubeconfig  string
+	kubeContext string
+)
+
+func Configure(kubeconfigPath, context string) {
+	kubeconfig = kubeconfigPath
+	kubeContext = context
+}
+
+func Kubectl(args ...string) *exec.Cmd {
+	var global []string
+	if kubeconfig != "" {
+		global = append(global, "--kubeconfig", kubeconfig)
+	}
+	if kubeContext != "" {
+		global = append(global, "--context", kubeContext)
+	}
+
+	args = append(global, args...)
+	output.Debugf("kubectl %s", strings.Join(redact.Args(args), " "))
+	return exec.Command("kubectl", args...)
+}
+
+func RESTConfig() (*rest.Config, error) {
+	// Inside a pod without explicit flags, use the mounted service account.
+	if kubeconfig == "" && kubeContext == "" && os.Getenv("KUBECONFIG") == "" {
+		if config, err := rest.InClusterConfig(); err == nil {
+			return config, nil
+		}
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeContext != "" {
+		overrides.CurrentContext = kubeContext
+	}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", unreachable(err))
+	}
+
+	return config, nil
+}
+
+func NewClientset() (kubernetes.Interface, error) {
+	config, err := RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return clientset, nil
+}
+
+// NamespaceNames returns the names of the namespaces of the cluster.
+func NamespaceNames(ctx context.Context) ([]string, error) {
+	clientset, err := NewClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		names = append(names, ns.Name)
+	}
+	return names, nil
+}
+
+// ServerVersion returns the Kubernetes version of the API server, such as
+// v1.30.2.
+func ServerVersion() (string, error) {
+	config, err := RESTConfig()
+	if err != nil {
+		return "", err
+	}
+	config = rest.CopyConfig(config)
+	config.Timeout = requestTimeout
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("failed to reach cluster at %s: %w", config.Host, unreachable(err))
+	}
+	return info.GitVersion, nil
+}
+
+func NewDynamicClient() (dynamic.Interface, error) {
+	config, err := RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return client, nil
+}
+
+// HasAPIGroup reports whether the API server serves the named API group.
+func HasAPIGroup(name string) (bool, error) {
+	clientset, err := NewClientset()
+	if err != nil {
+		return false, err
+	}
+
+	groups, err := clientset.Discovery().ServerGroups()
+	if err != nil {
+		return false, fmt.Errorf("failed to discover API groups: %w", err)
+	}
+
+	for _, group := range groups.Groups {
+		if group.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DiscoverResources returns the resources serving kinds, in the preferred
+// version of their group. Kinds the API server does not serve, such as
+// those of CRDs that are not installed, are missing from the result.
+func DiscoverResources(kinds []schema.GroupKind) (map[schema.GroupKind]schema.GroupVersionResource, error) {
+	clientset, err := NewClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := clientset.Discovery().ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API groups: %w", err)
+	}
+	preferred := map[string]string{}
+	for _, group := range groups.Groups {
+		preferred[group.Name] = group.PreferredVersion.GroupVersion
+	}
+
+	resources := map[schema.GroupKind]schema.GroupVersionResource{}
+	lists := map[string]*metav1.APIResourceList{}
+	for _, kind := range kinds {
+		groupVersion, ok := preferred[kind.Group]
+		if !ok {
+			continue
+		}
+		list, ok := lists[groupVersion]
+		if !ok {
+			if list, err = clientset.Discovery().ServerResourcesForGroupVersion(groupVersion); err != nil {
+				return nil, fmt.Errorf("failed to discover the resources of %s: %w", groupVersion, err)
+			}
+			lists[groupVersion] = list
+		}
+
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list.APIResources {
+			// Subresources such as routes/status share the kind.
+			if r.Kind == kind.Kind && !strings.Contains(r.Name, "/") {
+				resources[kind] = gv.WithResource(r.Name)
+				break
+			}
+		}
+	}
+	return resources, nil
+}
+
+// NamespacedResources returns the namespaced resources that can be listed,
+// in the preferred version of their group. Groups whose discovery fails,
+// such as an aggregated API whose server is down, are left out.
+func NamespacedResources() ([]schema.GroupVersionResource, error) {
+	clientset, err := NewClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := clientset.Discovery().ServerPreferredNamespacedResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover resources: %w", err)
+	}
+
+	var resources []schema.GroupVersionResource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") {
+				continue
+			}
+			resources = append(resources, gv.WithResource(r.Name))
+		}
+	}
+	return resources, nil
+}
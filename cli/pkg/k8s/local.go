@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	ProviderKind     = "kind"
+	ProviderMinikube = "minikube"
+)
+
+type LocalCluster struct {
+	Provider string
+	Reason   string
+}
+
+// CurrentContext returns the kubeconfig context that commands will use.
+func CurrentContext() string {
+	if kubeContext != "" {
+		return kubeContext
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+
+	raw, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return ""
+	}
+	return raw.CurrentContext
+}
+
+// Contexts returns the names of the contexts in the kubeconfig, sorted.
+func Contexts() ([]string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+
+	raw, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(raw.Contexts))
+	for name := range raw.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DetectLocalCluster reports whether the cluster is a kind or minikube
+// cluster, based on node provider IDs and labels, falling back to the
+// context name. It returns nil for any other cluster.
+func DetectLocalCluster() *LocalCluster {
+	if clientset, err := NewClientset(); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+
+		if nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err == nil {
+			for _, node := range nodes.Items {
+				if strings.HasPrefix(node.Spec.ProviderID, "kind://") {
+					return &LocalCluster{Provider: ProviderKind, Reason: "node provider ID " + node.Spec.ProviderID}
+				}
+				if _, ok := node.Labels["minikube.k8s.io/name"]; ok {
+					return &LocalCluster{Provider: ProviderMinikube, Reason: "node label minikube.k8s.io/name"}
+				}
+			}
+		}
+	}
+
+	current := CurrentContext()
+	switch {
+	case strings.HasPrefix(current, "kind-"):
+		return &LocalCluster{Provider: ProviderKind, Reason: "context name " + current}
+	case current == "minikube":
+		return &LocalCluster{Provider: ProviderMinikube, Reason: "context name " + current}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Helm records the release that owns an object in these annotations, and
+// adopts objects that carry them on install.
+const (
+	HelmReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	HelmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+	ManagedByLabel                 = "app.kubernetes.io/managed-by"
+)
+
+// Object is the identity and metadata of a Kubernetes object.
+type Object struct {
+	APIVersion  string
+	Kind        string
+	Namespace   string
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+	// Images are the container images of a workload.
+	Images []string
+}
+
+// HelmOwner returns the release that owns the object according to its
+// annotations, or "" for objects created without helm.
+func (o Object) HelmOwner() (release, namespace string) {
+	return o.Annotations[HelmReleaseNameAnnotation], o.Annotations[HelmReleaseNamespaceAnnotation]
+}
+
+// Ref is the object's reference on the kubectl command line, qualified by
+// API group so that kinds of different groups do not collide.
+func (o Object) Ref() string {
+	resource := strings.ToLower(o.Kind)
+	if group, _, ok := strings.Cut(o.APIVersion, "/"); ok {
+		resource += "." + group
+	}
+	return resource + "/" + o.Name
+}
+
+// String names the object for messages.
+func (o Object) String() string {
+	if o.Namespace == "" {
+		return o.Kind + " " + o.Name
+	}
+	return o.Kind + " " + o.Namespace + "/" + o.Name
+}
+
+type objectJSON struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Namespace   string            `json:"namespace"`
+		Name        string            `json:"name"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Template struct {
+			Spec struct {
+				Containers []struct {
+					Image string `json:"image"`
+				} `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+	Items []objectJSON `json:"items"`
+}
+
+func (o objectJSON) object() Object {
+	obj := Object{
+		APIVersion:  o.APIVersion,
+		Kind:        o.Kind,
+		Namespace:   o.Metadata.Namespace,
+		Name:        o.Metadata.Name,
+		Labels:      o.Metadata.Labels,
+		Annotations: o.Metadata.Annotations,
+	}
+	for _, c := range o.Spec.Template.Spec.Containers {
+		obj.Images = append(obj.Images, c.Image)
+	}
+	return obj
+}
+
+// parseObjects reads kubectl get -o json output, a single object or a List.
+func parseObjects(output string) ([]Object, error) {
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+
+	var doc objectJSON
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubectl output: %w", err)
+	}
+	if doc.Kind != "List" && !strings.HasSuffix(doc.Kind, "List") {
+		return []Object{doc.object()}, nil
+	}
+
+	objects := make([]Object, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		objects = append(objects, item.object())
+	}
+	return objects, nil
+}
+
+// ListObjects returns the objects of a kind in all namespaces, filtered by
+// a label selector when one is given.
+func ListObjects(kind, selector string) ([]Object, error) {
+	args := []string{"get", kind, "--all-namespaces", "-o", "json"}
+	if selector != "" {
+		args = append(args, "-l", selector)
+	}
+	out, err := run(args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseObjects(out)
+}
+
+// GetManifestObjects returns the objects of a manifest that exist in the
+// cluster. Namespaced objects without a namespace are looked up in
+// namespace.
+func GetManifestObjects(namespace, manifest string) ([]Object, error) {
+	cmd := Kubectl("get", "-f", "-", "-n", namespace, "-o", "json", "--ignore-not-found")
+	cmd.Stdin = strings.NewReader(manifest)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl get: %s", strings.TrimSpace(stderr.String()))
+	}
+	return parseObjects(string(out))
+}
+
+// SetHelmOwner labels and annotates an object as part of a helm release,
+// so that installing the release takes it over instead of failing on an
+// object that already exists.
+func SetHelmOwner(o Object, release, releaseNamespace string) error {
+	var scope []string
+	if o.Namespace != "" {
+		scope = []string{"-n", o.Namespace}
+	}
+
+	args := append([]string{"label", o.Ref(), "--overwrite", ManagedByLabel + "=Helm"}, scope...)
+	if _, err := run(args...); err != nil {
+		return err
+	}
+	args = append([]string{"annotate", o.Ref(), "--overwrite",
+		HelmReleaseNameAnnotation + "=" + release,
+		HelmReleaseNamespaceAnnotation + "=" + releaseNamespace}, scope...)
+	_, err := run(args...)
+	return err
+}
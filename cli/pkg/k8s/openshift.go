@@ -0,0 +1,8 @@
+package k8s
+
+const openShiftRouteGroup = "route.openshift.io"
+
+// IsOpenShift reports whether the cluster serves the OpenShift route API.
+func IsOpenShift() (bool, error) {
+	return HasAPIGroup(openShiftRouteGroup)
+}
@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+
+	policyv1 "k8s.io/api/policy/v1"
+)
+
+// PodDisruptionBudget holds the parts of a PDB that decide whether it ever
+// allows a voluntary disruption. MinAvailable and MaxUnavailable are empty
+// when unset, an integer or a percentage otherwise.
+type PodDisruptionBudget struct {
+	Namespace          string
+	Name               string
+	MinAvailable       string
+	MaxUnavailable     string
+	ExpectedPods       int32
+	DisruptionsAllowed int32
+}
+
+// Blocking reports whether the budget can never allow an eviction, which
+// stalls node drains and upgrades that need to move pods, and why.
+func (p PodDisruptionBudget) Blocking() (bool, string) {
+	switch {
+	case p.MaxUnavailable == "0" || p.MaxUnavailable == "0%":
+		return true, "maxUnavailable is " + p.MaxUnavailable
+	case p.MinAvailable == "100%":
+		return true, "minAvailable is 100%"
+	}
+
+	minAvailable, err := strconv.Atoi(p.MinAvailable)
+	if err == nil && p.ExpectedPods > 0 && int32(minAvailable) >= p.ExpectedPods {
+		return true, fmt.Sprintf("minAvailable %d equals the replica count (%d)", minAvailable, p.ExpectedPods)
+	}
+	return false, ""
+}
+
+func pdbFromObject(item policyv1.PodDisruptionBudget) PodDisruptionBudget {
+	pdb := PodDisruptionBudget{
+		Namespace:          item.Namespace,
+		Name:               item.Name,
+		ExpectedPods:       item.Status.ExpectedPods,
+		DisruptionsAllowed: item.Status.DisruptionsAllowed,
+	}
+	if item.Spec.MinAvailable != nil {
+		pdb.MinAvailable = item.Spec.MinAvailable.String()
+	}
+	if item.Spec.MaxUnavailable != nil {
+		pdb.MaxUnavailable = item.Spec.MaxUnavailable.String()
+	}
+	return pdb
+}
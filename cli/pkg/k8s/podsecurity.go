@@ -0,0 +1,34 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PodSecurityViolations asks the API server which existing pods in the
+// namespace would violate the given Pod Security Admission enforce level,
+// through a server-side dry run of the namespace label. It returns the
+// server's warnings, one per line.
+func PodSecurityViolations(namespace, level string) ([]string, error) {
+	cmd := Kubectl("label", "namespace", namespace, "--overwrite", "--dry-run=server",
+		"pod-security.kubernetes.io/enforce="+level)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("kubectl label: %s", msg)
+	}
+
+	var warnings []string
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if line = strings.TrimSpace(strings.TrimPrefix(line, "Warning:")); line != "" {
+			warnings = append(warnings, line)
+		}
+	}
+	return warnings, nil
+}
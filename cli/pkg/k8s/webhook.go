@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AdmissionWebhook is a validating or mutating webhook that the API server
+// calls through a Service.
+type AdmissionWebhook struct {
+	// Kind is ValidatingWebhookConfiguration or MutatingWebhookConfiguration.
+	Kind          string
+	Configuration string
+	Name          string
+	Namespace     string
+	Service       string
+	// HasCABundle is whether the API server has the CA to call the webhook
+	// with, which some controllers inject only once they are running.
+	HasCABundle bool
+}
+
+// GetServiceWebhooks returns the admission webhooks served by Services in
+// namespace.
+func GetServiceWebhooks(namespace string) ([]AdmissionWebhook, error) {
+	out, err := run("get", "validatingwebhookconfigurations,mutatingwebhookconfigurations", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Items []struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Webhooks []struct {
+				Name         string `json:"name"`
+				ClientConfig struct {
+					CABundle string `json:"caBundle"`
+					Service  *struct {
+						Namespace string `json:"namespace"`
+						Name      string `json:"name"`
+					} `json:"service"`
+				} `json:"clientConfig"`
+			} `json:"webhooks"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook configurations: %w", err)
+	}
+
+	var webhooks []AdmissionWebhook
+	for _, item := range list.Items {
+		for _, w := range item.Webhooks {
+			service := w.ClientConfig.Service
+			if service == nil || service.Namespace != namespace {
+				continue
+			}
+			webhooks = append(webhooks, AdmissionWebhook{
+				Kind:          item.Kind,
+				Configuration: item.Metadata.Name,
+				Name:          w.Name,
+				Namespace:     service.Namespace,
+				Service:       service.Name,
+				HasCABundle:   w.ClientConfig.CABundle != "",
+			})
+		}
+	}
+	return webhooks, nil
+}
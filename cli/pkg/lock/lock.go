@@ -0,0 +1,355 @@
+// Package lock keeps two installers from changing the same installation at
+// once, with a coordination.k8s.io Lease that the holder renews while it
+// runs.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LeaseName is the name of the Lease, prefixed with the release prefix.
+	LeaseName = "envoy-ai-installer-lock"
+	// DefaultTTL is how long a lock outlives its last renewal.
+	DefaultTTL = time.Minute
+
+	requestTimeout = 30 * time.Second
+)
+
+// ErrNoNamespace is returned when the namespace of the Lease does not exist
+// and Options.CreateNamespace is not set.
+var ErrNoNamespace = errors.New("namespace of the lock does not exist")
+
+// NameFor returns the name of the Lease for a release prefix.
+func NameFor(releasePrefix string) string {
+	return releasePrefix + LeaseName
+}
+
+// Holder is who holds a lock.
+type Holder struct {
+	Identity   string
+	AcquiredAt time.Time
+	RenewedAt  time.Time
+	TTL        time.Duration
+}
+
+// ExpiresAt is when the lock can be taken over unless renewed.
+func (h Holder) ExpiresAt() time.Time {
+	return h.RenewedAt.Add(h.TTL)
+}
+
+// Expired reports whether the holder stopped renewing the lock.
+func (h Holder) Expired(now time.Time) bool {
+	return now.After(h.ExpiresAt())
+}
+
+// HeldError is returned when another installer holds the lock.
+type HeldError struct {
+	Holder Holder
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("locked by %s since %s", e.Holder.Identity, e.Holder.AcquiredAt.Local().Format("2006-01-02 15:04:05 MST"))
+}
+
+// Options configure a Lock.
+type Options struct {
+	Namespace string
+	Name      string
+	// Identity names the holder; it defaults to user@host and the PID.
+	Identity string
+	TTL      time.Duration
+	// CreateNamespace creates the namespace of the Lease if it is missing.
+	CreateNamespace bool
+	// OnLost is called when another installer takes the lock over while it
+	// is held.
+	OnLost func(Holder)
+}
+
+// Lock is a Lease held by this process.
+type Lock struct {
+	client kubernetes.Interface
+	opts   Options
+
+	mu       sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
+	previous *Holder
+}
+
+// New returns a lock on the Lease described by opts.
+func New(client kubernetes.Interface, opts Options) *Lock {
+	if opts.Identity == "" {
+		opts.Identity = Identity()
+	}
+	if opts.TTL == 0 {
+		opts.TTL = DefaultTTL
+	}
+	return &Lock{client: client, opts: opts}
+}
+
+// Identity names this process: user@host and its PID.
+func Identity() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s@%s (pid %d)", name, host, os.Getpid())
+}
+
+// Acquire takes the lock and renews it until Release. A lock held by
+// another installer is taken over once it has expired, or right away with
+// force; otherwise Acquire returns a *HeldError.
+func (l *Lock) Acquire(ctx context.Context, force bool) error {
+	leases := l.client.CoordinationV1().Leases(l.opts.Namespace)
+	now := time.Now()
+
+	reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	lease, err := leases.Get(reqCtx, l.opts.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if err := l.create(reqCtx, now); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("failed to read lock %s/%s: %w", l.opts.Namespace, l.opts.Name, err)
+	default:
+		holder := holderOf(lease)
+		if holder.Identity != "" && holder.Identity != l.opts.Identity && !holder.Expired(now) && !force {
+			return &HeldError{Holder: holder}
+		}
+		if holder.Identity != "" && holder.Identity != l.opts.Identity {
+			l.previous = &holder
+		}
+
+		lease.Spec = l.spec(now, lease.Spec.LeaseTransitions)
+		if _, err := leases.Update(reqCtx, lease, metav1.UpdateOptions{}); err != nil {
+			if apierrors.IsConflict(err) {
+				return l.heldByOther(ctx)
+			}
+			return fmt.Errorf("failed to take lock %s/%s: %w", l.opts.Namespace, l.opts.Name, err)
+		}
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	l.mu.Lock()
+	l.stop, l.done = stop, done
+	l.mu.Unlock()
+	go l.heartbeat(stop, done)
+	return nil
+}
+
+// TookOver returns the holder whose lock Acquire took over, expired or
+// forced, or nil.
+func (l *Lock) TookOver() *Holder {
+	return l.previous
+}
+
+func (l *Lock) create(ctx context.Context, now time.Time) error {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      l.opts.Name,
+			Namespace: l.opts.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "envoy-ai-installer"},
+		},
+		Spec: l.spec(now, nil),
+	}
+
+	_, err := l.client.CoordinationV1().Leases(l.opts.Namespace).Create(ctx, lease, metav1.CreateOptions{})
+	if apierrors.IsNotFound(err) && !l.opts.CreateNamespace {
+		return ErrNoNamespace
+	}
+	if apierrors.IsNotFound(err) {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: l.opts.Namespace}}
+		if _, err := l.client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create namespace %s: %w", l.opts.Namespace, err)
+		}
+		_, err = l.client.CoordinationV1().Leases(l.opts.Namespace).Create(ctx, lease, metav1.CreateOptions{})
+	}
+	if apierrors.IsAlreadyExists(err) {
+		return l.heldByOther(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create lock %s/%s: %w", l.opts.Namespace, l.opts.Name, err)
+	}
+	return nil
+}
+
+// heldByOther reports the holder of a lock another installer took while
+// this one tried to.
+func (l *Lock) heldByOther(ctx context.Context) error {
+	holder, err := Status(ctx, l.client, l.opts.Namespace, l.opts.Name)
+	if err != nil {
+		return err
+	}
+	if holder == nil {
+		return errors.New("lock was released while acquiring it; try again")
+	}
+	return &HeldError{Holder: *holder}
+}
+
+func (l *Lock) spec(now time.Time, transitions *int32) coordinationv1.LeaseSpec {
+	identity := l.opts.Identity
+	seconds := int32(l.opts.TTL / time.Second)
+	at := metav1.NewMicroTime(now)
+	count := int32(0)
+	if transitions != nil {
+		count = *transitions + 1
+	}
+	return coordinationv1.LeaseSpec{
+		HolderIdentity:       &identity,
+		LeaseDurationSeconds: &seconds,
+		AcquireTime:          &at,
+		RenewTime:            &at,
+		LeaseTransitions:     &count,
+	}
+}
+
+// heartbeat renews the lock a few times per TTL, until Release or until
+// another installer takes it over.
+func (l *Lock) heartbeat(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(l.opts.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if holder, lost := l.renew(); lost {
+				if l.opts.OnLost != nil {
+					l.opts.OnLost(holder)
+				}
+				return
+			}
+		}
+	}
+}
+
+// renew extends the lock. It reports whether the lock now belongs to
+// someone else, as it does when the Lease changed since it was read; other
+// failed renewals are retried on the next tick.
+func (l *Lock) renew() (Holder, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	leases := l.client.CoordinationV1().Leases(l.opts.Namespace)
+	lease, err := leases.Get(ctx, l.opts.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return Holder{}, true
+	}
+	if err != nil {
+		return Holder{}, false
+	}
+	if holder := holderOf(lease); holder.Identity != l.opts.Identity {
+		return holder, true
+	}
+
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.RenewTime = &now
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); apierrors.IsConflict(err) {
+		holder, err := Status(ctx, l.client, l.opts.Namespace, l.opts.Name)
+		if err != nil || holder == nil {
+			return Holder{}, true
+		}
+		return *holder, true
+	}
+	return Holder{}, false
+}
+
+// Release stops renewing the lock and deletes it, unless another installer
+// took it over.
+func (l *Lock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	stop, done := l.stop, l.done
+	l.stop = nil
+	l.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+	<-done
+
+	reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	leases := l.client.CoordinationV1().Leases(l.opts.Namespace)
+	lease, err := leases.Get(reqCtx, l.opts.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s/%s: %w", l.opts.Namespace, l.opts.Name, err)
+	}
+	if holderOf(lease).Identity != l.opts.Identity {
+		return nil
+	}
+
+	precondition := metav1.Preconditions{UID: &lease.UID, ResourceVersion: &lease.ResourceVersion}
+	err = leases.Delete(reqCtx, l.opts.Name, metav1.DeleteOptions{Preconditions: &precondition})
+	if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		return fmt.Errorf("failed to release lock %s/%s: %w", l.opts.Namespace, l.opts.Name, err)
+	}
+	return nil
+}
+
+// Status returns the holder of a lock, or nil when nobody holds it.
+func Status(ctx context.Context, client kubernetes.Interface, namespace, name string) (*Holder, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	lease, err := client.CoordinationV1().Leases(namespace).Get(reqCtx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock %s/%s: %w", namespace, name, err)
+	}
+
+	holder := holderOf(lease)
+	if holder.Identity == "" {
+		return nil, nil
+	}
+	return &holder, nil
+}
+
+func holderOf(lease *coordinationv1.Lease) Holder {
+	var h Holder
+	if lease.Spec.HolderIdentity != nil {
+		h.Identity = *lease.Spec.HolderIdentity
+	}
+	if lease.Spec.AcquireTime != nil {
+		h.AcquiredAt = lease.Spec.AcquireTime.Time
+	}
+	if lease.Spec.RenewTime != nil {
+		h.RenewedAt = lease.Spec.RenewTime.Time
+	} else {
+		h.RenewedAt = h.AcquiredAt
+	}
+	if lease.Spec.LeaseDurationSeconds != nil {
+		h.TTL = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return h
+}
@@ -0,0 +1,237 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	testNamespace = "envoy-ai-gateway-system"
+	testName      = "envoy-ai-installer-lock"
+	otherHolder   = "alice@laptop (pid 42)"
+)
+
+func TestAcquire(t *testing.T) {
+	tests := []struct {
+		name       string
+		renewedAgo time.Duration
+		force      bool
+		wantHeld   bool
+	}{
+		{name: "held by another", renewedAgo: 10 * time.Second, wantHeld: true},
+		{name: "stale lock taken over", renewedAgo: 2 * time.Minute},
+		{name: "forced", renewedAgo: 10 * time.Second, force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acquired := time.Now().Add(-time.Hour)
+			client := fake.NewSimpleClientset(testNamespaceObject(), testLease(otherHolder, acquired, time.Now().Add(-tt.renewedAgo)))
+			l := New(client, Options{Namespace: testNamespace, Name: testName, Identity: "bob@ci (pid 7)"})
+
+			err := l.Acquire(context.Background(), tt.force)
+			t.Cleanup(func() { l.Release(context.Background()) })
+
+			var held *HeldError
+			if got := errors.As(err, &held); got != tt.wantHeld {
+				t.Fatalf("got error %v, want held: %v", err, tt.wantHeld)
+			}
+			if tt.wantHeld {
+				if held.Holder.Identity != otherHolder || !held.Holder.AcquiredAt.Equal(acquired) {
+					t.Errorf("got holder %+v, want %s since %s", held.Holder, otherHolder, acquired)
+				}
+				return
+			}
+
+			if previous := l.TookOver(); previous == nil || previous.Identity != otherHolder {
+				t.Errorf("TookOver() = %+v, want %s", previous, otherHolder)
+			}
+			holder, err := Status(context.Background(), client, testNamespace, testName)
+			if err != nil || holder == nil || holder.Identity != "bob@ci (pid 7)" {
+				t.Fatalf("Status() = %+v, %v, want the new holder", holder, err)
+			}
+			lease := getLease(t, client)
+			if *lease.Spec.LeaseTransitions != 2 {
+				t.Errorf("got %d lease transitions, want 2", *lease.Spec.LeaseTransitions)
+			}
+		})
+	}
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	client := fake.NewSimpleClientset(testNamespaceObject())
+	l := New(client, Options{Namespace: testNamespace, Name: testName})
+
+	if err := l.Acquire(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	if l.TookOver() != nil {
+		t.Errorf("TookOver() = %+v for a new lock", l.TookOver())
+	}
+
+	holder, err := Status(context.Background(), client, testNamespace, testName)
+	if err != nil || holder == nil || holder.Identity != Identity() || holder.TTL != DefaultTTL {
+		t.Fatalf("Status() = %+v, %v, want this process holding it for %s", holder, err, DefaultTTL)
+	}
+
+	// A second installer is refused while the lock is held.
+	other := New(client, Options{Namespace: testNamespace, Name: testName, Identity: otherHolder})
+	var held *HeldError
+	if err := other.Acquire(context.Background(), false); !errors.As(err, &held) {
+		t.Fatalf("second Acquire returned %v, want a HeldError", err)
+	}
+
+	if err := l.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoordinationV1().Leases(testNamespace).Get(context.Background(), testName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("lease still exists after Release: %v", err)
+	}
+	if err := l.Release(context.Background()); err != nil {
+		t.Errorf("second Release returned %v", err)
+	}
+}
+
+func TestAcquireMissingNamespace(t *testing.T) {
+	for _, create := range []bool{false, true} {
+		client := fake.NewSimpleClientset()
+		// Like the API server, refuse objects in a namespace that does not
+		// exist.
+		client.PrependReactor("create", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			_, err := client.Tracker().Get(corev1.SchemeGroupVersion.WithResource("namespaces"), "", action.GetNamespace())
+			return err != nil, nil, err
+		})
+
+		l := New(client, Options{Namespace: testNamespace, Name: testName, CreateNamespace: create})
+		err := l.Acquire(context.Background(), false)
+		l.Release(context.Background())
+
+		if create && err != nil {
+			t.Errorf("CreateNamespace: got error %v", err)
+		}
+		if !create && !errors.Is(err, ErrNoNamespace) {
+			t.Errorf("got error %v, want ErrNoNamespace", err)
+		}
+	}
+}
+
+func TestHeartbeatLost(t *testing.T) {
+	client := fake.NewSimpleClientset(testNamespaceObject())
+	lost := make(chan Holder, 1)
+	l := New(client, Options{
+		Namespace: testNamespace,
+		Name:      testName,
+		TTL:       30 * time.Millisecond,
+		OnLost:    func(h Holder) { lost <- h },
+	})
+	if err := l.Acquire(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	lease := getLease(t, client)
+	renewed := lease.Spec.RenewTime.Time
+	time.Sleep(50 * time.Millisecond)
+	if lease = getLease(t, client); !lease.Spec.RenewTime.After(renewed) {
+		t.Errorf("lease not renewed by the heartbeat")
+	}
+
+	identity := otherHolder
+	lease.Spec.HolderIdentity = &identity
+	if _, err := client.CoordinationV1().Leases(testNamespace).Update(context.Background(), lease, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case h := <-lost:
+		if h.Identity != otherHolder {
+			t.Errorf("OnLost got holder %q, want %q", h.Identity, otherHolder)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnLost not called after the lock was taken over")
+	}
+
+	// Release leaves the lock of the new holder alone.
+	if err := l.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if holder := holderOf(getLease(t, client)); holder.Identity != otherHolder {
+		t.Errorf("lease holder is %q after Release, want %q", holder.Identity, otherHolder)
+	}
+}
+
+func TestRenewConflict(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		takeOver  bool
+		wantLost  bool
+		wantOwner string
+	}{
+		{name: "taken over between read and update", err: apierrors.NewConflict(coordinationv1.Resource("leases"), testName, errors.New("modified")), takeOver: true, wantLost: true, wantOwner: otherHolder},
+		{name: "server error", err: apierrors.NewInternalError(errors.New("etcd unavailable"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(testNamespaceObject())
+			l := New(client, Options{Namespace: testNamespace, Name: testName})
+			if err := client.Tracker().Add(testLease(l.opts.Identity, time.Now(), time.Now())); err != nil {
+				t.Fatal(err)
+			}
+
+			client.PrependReactor("update", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+				if tt.takeOver {
+					lease := testLease(otherHolder, time.Now(), time.Now())
+					if err := client.Tracker().Update(coordinationv1.SchemeGroupVersion.WithResource("leases"), lease, testNamespace); err != nil {
+						t.Error(err)
+					}
+				}
+				return true, nil, tt.err
+			})
+
+			holder, lost := l.renew()
+			if lost != tt.wantLost || holder.Identity != tt.wantOwner {
+				t.Errorf("renew() = %q, %v; want %q, %v", holder.Identity, lost, tt.wantOwner, tt.wantLost)
+			}
+		})
+	}
+}
+
+func testNamespaceObject() *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}
+}
+
+func testLease(identity string, acquired, renewed time.Time) *coordinationv1.Lease {
+	seconds := int32(DefaultTTL / time.Second)
+	transitions := int32(1)
+	acquireTime, renewTime := metav1.NewMicroTime(acquired), metav1.NewMicroTime(renewed)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: testName, Namespace: testNamespace},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &identity,
+			LeaseDurationSeconds: &seconds,
+			AcquireTime:          &acquireTime,
+			RenewTime:            &renewTime,
+			LeaseTransitions:     &transitions,
+		},
+	}
+}
+
+func getLease(t *testing.T, client *fake.Clientset) *coordinationv1.Lease {
+	t.Helper()
+	lease, err := client.CoordinationV1().Leases(testNamespace).Get(context.Background(), testName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lease
+}
@@ -0,0 +1,206 @@
+package observability
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	PrometheusRepoName     = "prometheus-community"
+	PrometheusRepoURL      = "https://prometheus-community.github.io/helm-charts"
+	PrometheusChart        = "prometheus-community/kube-prometheus-stack"
+	PrometheusChartVersion = "77.12.0"
+	PrometheusReleaseName  = "kube-prometheus-stack"
+	DefaultNamespace       = "monitoring"
+
+	// OperatorGroup is served by the Prometheus Operator CRDs; monitors are
+	// only created when it is present.
+	OperatorGroup = "monitoring.coreos.com"
+
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedBy      = "envoy-ai-installer"
+	PartOfLabel    = "app.kubernetes.io/part-of"
+	PartOf         = "envoy-ai-observability"
+	// InstanceLabel tells apart the resources of installs with different
+	// release prefixes.
+	InstanceLabel = "app.kubernetes.io/instance"
+
+	// DashboardLabel is the label the Grafana dashboard sidecar watches for.
+	DashboardLabel = "grafana_dashboard"
+
+	dashboardName = "envoy-ai-gateway-dashboard"
+
+	// ManifestFile and ScrapeConfigFile are the files the resources and
+	// the scrape config are written to instead of being applied.
+	ManifestFile     = "envoy-ai-monitoring.yaml"
+	ScrapeConfigFile = "envoy-ai-prometheus-scrape.yaml"
+)
+
+//go:embed dashboard.json
+var dashboard string
+
+type Options struct {
+	NamespaceGateway string
+	Namespace        string
+	ReleasePrefix    string
+	// PrometheusRelease is set as the release label on monitors so the
+	// default selectors of kube-prometheus-stack pick them up.
+	PrometheusRelease string
+	Monitors          bool
+	Labels            map[string]string
+}
+
+// Instance is the InstanceLabel value of the resources of the install with
+// releasePrefix.
+func Instance(releasePrefix string) string {
+	return releasePrefix + PartOf
+}
+
+// Selector matches every resource created by Manifests for the install with
+// releasePrefix, and none of other installs.
+func Selector(releasePrefix string) string {
+	return fmt.Sprintf("%s=%s,%s=%s,%s=%s", ManagedByLabel, ManagedBy, PartOfLabel, PartOf,
+		InstanceLabel, Instance(releasePrefix))
+}
+
+// Manifests renders the monitors (when opts.Monitors is set) and the Grafana
+// dashboard ConfigMap as a multi-document YAML stream.
+func Manifests(opts Options) (string, error) {
+	var docs []interface{}
+
+	docs = append(docs, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]interface{}{
+			"name": opts.Namespace,
+		},
+	})
+
+	if opts.Monitors {
+		docs = append(docs,
+			map[string]interface{}{
+				"apiVersion": OperatorGroup + "/v1",
+				"kind":       "ServiceMonitor",
+				"metadata":   metadata(opts, opts.ReleasePrefix+"envoy-gateway", opts.NamespaceGateway),
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{
+						"matchLabels": map[string]string{"control-plane": "envoy-gateway"},
+					},
+					"endpoints": []map[string]string{
+						{"port": "metrics", "path": "/metrics"},
+					},
+				},
+			},
+			map[string]interface{}{
+				"apiVersion": OperatorGroup + "/v1",
+				"kind":       "PodMonitor",
+				"metadata":   metadata(opts, opts.ReleasePrefix+"envoy-proxy", opts.NamespaceGateway),
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{
+						"matchLabels": map[string]string{
+							"app.kubernetes.io/component":  "proxy",
+							"app.kubernetes.io/managed-by": "envoy-gateway",
+						},
+					},
+					"podMetricsEndpoints": []map[string]string{
+						{"port": "metrics", "path": "/stats/prometheus"},
+						{"port": "aigw-metrics", "path": "/metrics"},
+					},
+				},
+			},
+		)
+	}
+
+	dashboardMeta := metadata(opts, opts.ReleasePrefix+dashboardName, opts.Namespace)
+	dashboardMeta["labels"].(map[string]string)[DashboardLabel] = "1"
+	docs = append(docs, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   dashboardMeta,
+		"data": map[string]string{
+			"envoy-ai-gateway.json": dashboard,
+		},
+	})
+
+	return encode(docs)
+}
+
+// ScrapeConfig renders the scrape_configs of a Prometheus without the
+// Operator that scrape the same targets as the monitors of Manifests, for
+// the user to add to their prometheus.yml.
+func ScrapeConfig(opts Options) (string, error) {
+	namespaces := map[string]interface{}{"names": []string{opts.NamespaceGateway}}
+	keep := func(label, regex string) map[string]interface{} {
+		return map[string]interface{}{"source_labels": []string{label}, "regex": regex, "action": "keep"}
+	}
+	podJob := func(name, port, path string) map[string]interface{} {
+		return map[string]interface{}{
+			"job_name":     opts.ReleasePrefix + name,
+			"metrics_path": path,
+			"kubernetes_sd_configs": []map[string]interface{}{
+				{"role": "pod", "namespaces": namespaces},
+			},
+			"relabel_configs": []map[string]interface{}{
+				keep("__meta_kubernetes_pod_label_app_kubernetes_io_component", "proxy"),
+				keep("__meta_kubernetes_pod_label_app_kubernetes_io_managed_by", "envoy-gateway"),
+				keep("__meta_kubernetes_pod_container_port_name", port),
+			},
+		}
+	}
+
+	config := map[string]interface{}{
+		"scrape_configs": []map[string]interface{}{
+			{
+				"job_name":     opts.ReleasePrefix + "envoy-gateway",
+				"metrics_path": "/metrics",
+				"kubernetes_sd_configs": []map[string]interface{}{
+					{"role": "endpoints", "namespaces": namespaces},
+				},
+				"relabel_configs": []map[string]interface{}{
+					keep("__meta_kubernetes_service_label_control_plane", "envoy-gateway"),
+					keep("__meta_kubernetes_endpoint_port_name", "metrics"),
+				},
+			},
+			podJob("envoy-proxy", "metrics", "/stats/prometheus"),
+			podJob("envoy-ai-gateway-extproc", "aigw-metrics", "/metrics"),
+		},
+	}
+	return encode([]interface{}{config})
+}
+
+func encode(docs []interface{}) (string, error) {
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return "", fmt.Errorf("failed to render observability manifests: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to render observability manifests: %w", err)
+	}
+	return out.String(), nil
+}
+
+func metadata(opts Options, name, namespace string) map[string]interface{} {
+	labels := map[string]string{}
+	for key, value := range opts.Labels {
+		labels[key] = value
+	}
+	labels[ManagedByLabel] = ManagedBy
+	labels[PartOfLabel] = PartOf
+	labels[InstanceLabel] = Instance(opts.ReleasePrefix)
+	if opts.PrometheusRelease != "" {
+		labels["release"] = opts.PrometheusRelease
+	}
+
+	return map[string]interface{}{
+		"name":      name,
+		"namespace": namespace,
+		"labels":    labels,
+	}
+}
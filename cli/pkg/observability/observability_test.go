@@ -0,0 +1,74 @@
+package observability
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSelectorMatchesOnlyItsInstall(t *testing.T) {
+	for _, prefix := range []string{"", "prod-"} {
+		t.Run("prefix "+prefix, func(t *testing.T) {
+			manifests, err := Manifests(Options{
+				NamespaceGateway: "envoy-gateway-system",
+				Namespace:        DefaultNamespace,
+				ReleasePrefix:    prefix,
+				Monitors:         true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			labelled := 0
+			for _, labels := range documentLabels(t, manifests) {
+				if labels == nil {
+					continue
+				}
+				labelled++
+				if !matches(Selector(prefix), labels) {
+					t.Errorf("labels %v do not match Selector(%q)", labels, prefix)
+				}
+				for _, other := range []string{"", "prod-", "staging-"} {
+					if other != prefix && matches(Selector(other), labels) {
+						t.Errorf("labels %v match Selector(%q) of another install", labels, other)
+					}
+				}
+			}
+			if labelled != 3 {
+				t.Errorf("got %d labelled resources, want the 2 monitors and the dashboard", labelled)
+			}
+		})
+	}
+}
+
+func documentLabels(t *testing.T, manifests string) []map[string]string {
+	t.Helper()
+	var all []map[string]string
+	decoder := yaml.NewDecoder(strings.NewReader(manifests))
+	for {
+		var doc struct {
+			Metadata struct {
+				Labels map[string]string `yaml:"labels"`
+			} `yaml:"metadata"`
+		}
+		if err := decoder.Decode(&doc); err == io.EOF {
+			return all
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, doc.Metadata.Labels)
+	}
+}
+
+// matches evaluates a selector of key=value terms, as kubectl -l does.
+func matches(selector string, labels map[string]string) bool {
+	for _, term := range strings.Split(selector, ",") {
+		key, value, _ := strings.Cut(term, "=")
+		if got, ok := labels[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,225 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/steps"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	serviceName = "envoy-ai-installer"
+
+	// exportTimeout bounds each export and the final flush, so that an
+	// unreachable collector delays the installer by seconds, not minutes.
+	exportTimeout = 5 * time.Second
+)
+
+// Options describes the collector the installer reports its own runs to
+// and the run being reported.
+type Options struct {
+	// Endpoint is the OTLP gRPC endpoint: host:port for TLS, or an
+	// http:// or https:// URL.
+	Endpoint string
+	Version  string
+	// Command is the installer command, e.g. install.
+	Command string
+	// Attributes describe the run, e.g. its namespaces.
+	Attributes map[string]string
+}
+
+// Session exports a span for the run with a child span per step, a run
+// counter and duration, and the versions of the installed charts. All
+// methods do nothing on a nil Session, which stands for telemetry being
+// disabled.
+type Session struct {
+	endpoint string
+	start    time.Time
+	attrs    []attribute.KeyValue
+
+	tracer trace.Tracer
+	ctx    context.Context
+	span   trace.Span
+
+	runs     metric.Int64Counter
+	duration metric.Float64Histogram
+	charts   metric.Int64Gauge
+
+	traces  *sdktrace.TracerProvider
+	metrics *sdkmetric.MeterProvider
+}
+
+// ValidateEndpoint checks that endpoint is host:port or an http:// or
+// https:// URL.
+func ValidateEndpoint(endpoint string) error {
+	hostPort := endpoint
+	if scheme, rest, ok := strings.Cut(endpoint, "://"); ok {
+		if scheme != "http" && scheme != "https" {
+			return fmt.Errorf("invalid OTLP endpoint %q: scheme must be http or https", endpoint)
+		}
+		hostPort = strings.TrimSuffix(rest, "/")
+	}
+	if host, port, err := net.SplitHostPort(hostPort); err != nil || host == "" || port == "" {
+		return fmt.Errorf("invalid OTLP endpoint %q: expected host:port", endpoint)
+	}
+	return nil
+}
+
+// Start connects the exporters and starts the span of the run. Connecting
+// does not wait for the collector: export errors are reported by End.
+func Start(ctx context.Context, opts Options) (*Session, error) {
+	if err := ValidateEndpoint(opts.Endpoint); err != nil {
+		return nil, err
+	}
+
+	traceOpts := []otlptracegrpc.Option{otlptracegrpc.WithTimeout(exportTimeout)}
+	metricOpts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithTimeout(exportTimeout)}
+	if strings.Contains(opts.Endpoint, "://") {
+		traceOpts = append(traceOpts, otlptracegrpc.WithEndpointURL(opts.Endpoint))
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithEndpointURL(opts.Endpoint))
+	} else {
+		traceOpts = append(traceOpts, otlptracegrpc.WithEndpoint(opts.Endpoint))
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithEndpoint(opts.Endpoint))
+	}
+
+	traceExporter, err := otlptracegrpc.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	metricExporter, err := otlpmetricgrpc.New(ctx, metricOpts...)
+	if err != nil {
+		traceExporter.Shutdown(ctx)
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	// Export failures are returned by End; the SDK would otherwise print
+	// them to stderr in the middle of the installer's output.
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		output.Debugf("telemetry: %v", err)
+	}))
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", opts.Version),
+	)
+	s := &Session{
+		endpoint: opts.Endpoint,
+		start:    time.Now(),
+		attrs:    []attribute.KeyValue{attribute.String("command", opts.Command)},
+		traces: sdktrace.NewTracerProvider(
+			sdktrace.WithResource(res),
+			sdktrace.WithBatcher(traceExporter),
+		),
+		metrics: sdkmetric.NewMeterProvider(
+			sdkmetric.WithResource(res),
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		),
+	}
+	for key, value := range opts.Attributes {
+		s.attrs = append(s.attrs, attribute.String(key, value))
+	}
+
+	meter := s.metrics.Meter(serviceName)
+	if s.runs, err = meter.Int64Counter("envoy_ai_installer.runs",
+		metric.WithDescription("Installer runs by command and outcome"),
+		metric.WithUnit("{run}")); err != nil {
+		return nil, err
+	}
+	if s.duration, err = meter.Float64Histogram("envoy_ai_installer.run.duration",
+		metric.WithDescription("Duration of installer runs"),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if s.charts, err = meter.Int64Gauge("envoy_ai_installer.chart.installed",
+		metric.WithDescription("Chart versions installed by the last run, as 1 with the version as an attribute"),
+		metric.WithUnit("{release}")); err != nil {
+		return nil, err
+	}
+
+	s.tracer = s.traces.Tracer(serviceName)
+	s.ctx, s.span = s.tracer.Start(ctx, serviceName+" "+opts.Command,
+		trace.WithTimestamp(s.start), trace.WithAttributes(s.attrs...))
+	return s, nil
+}
+
+// RecordSteps adds a span for every step that ran or was skipped, with its
+// timing and outcome.
+func (s *Session) RecordSteps(results []steps.Result) {
+	if s == nil {
+		return
+	}
+
+	for _, result := range results {
+		if result.Status == steps.StatusNotRun {
+			continue
+		}
+
+		_, span := s.tracer.Start(s.ctx, result.Name,
+			trace.WithTimestamp(result.Start),
+			trace.WithAttributes(attribute.String("step.status", string(result.Status))))
+		switch result.Status {
+		case steps.StatusSkipped:
+			span.SetAttributes(attribute.String("step.skip_reason", result.Reason))
+		case steps.StatusFailed:
+			span.SetStatus(codes.Error, "step failed")
+		}
+		span.End(trace.WithTimestamp(result.Start.Add(result.Duration)))
+	}
+}
+
+// RecordChart records the chart version installed as a release.
+func (s *Session) RecordChart(release, namespace, chart, version string) {
+	if s == nil {
+		return
+	}
+
+	s.charts.Record(s.ctx, 1, metric.WithAttributes(
+		attribute.String("release", release),
+		attribute.String("namespace", namespace),
+		attribute.String("chart", chart),
+		attribute.String("chart.version", version),
+	))
+}
+
+// End records the outcome of the run, ends its span and flushes everything
+// to the collector.
+func (s *Session) End(runErr error) error {
+	if s == nil {
+		return nil
+	}
+
+	outcome := "success"
+	if runErr != nil {
+		outcome = "failure"
+		s.span.SetStatus(codes.Error, redact.Text(runErr.Error()))
+	}
+	s.span.SetAttributes(attribute.String("outcome", outcome))
+	s.span.End()
+
+	attrs := metric.WithAttributes(append(s.attrs, attribute.String("outcome", outcome))...)
+	s.runs.Add(s.ctx, 1, attrs)
+	s.duration.Record(s.ctx, time.Since(s.start).Seconds(), attrs)
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	if err := errors.Join(s.traces.Shutdown(ctx), s.metrics.Shutdown(ctx)); err != nil {
+		return fmt.Errorf("failed to export telemetry to %s: %w", s.endpoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/redact"
+)
+
+// LogOptions configures OpenLog.
+type LogOptions struct {
+	// Path is the log file. When empty, a file named after the current
+	// time is created in Dir.
+	Path string
+	Dir  string
+	// Keep is how many log files to keep, the new one included: the newest
+	// files in Dir, or Path and its rotated copies Path.1 to Path.<Keep-1>.
+	// 0 keeps them all.
+	Keep int
+}
+
+// transcript is the log file: every line written to Stdout and Stderr and
+// every Debugf line, timestamped, without escape codes or emoji and with
+// credentials masked.
+type transcript struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+var (
+	logFile atomic.Pointer[transcript]
+	logPath string
+)
+
+// OpenLog starts writing a transcript of the messages of the run, and of
+// the child processes writing to Stdout and Stderr, to a log file, whatever
+// is shown on the terminal. It returns the path of the file.
+func OpenLog(opts LogOptions) (string, error) {
+	path := opts.Path
+	if path == "" {
+		if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+			return "", err
+		}
+		path = filepath.Join(opts.Dir, time.Now().Format("20060102-150405.000")+".log")
+	} else if err := rotate(path, opts.Keep); err != nil {
+		return "", err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if opts.Path == "" {
+		prune(opts.Dir, opts.Keep)
+	}
+
+	logFile.Store(&transcript{file: file})
+	logPath = path
+	return path, nil
+}
+
+// LogPath returns the path of the log file opened by OpenLog, if any. It
+// remains set after Close.
+func LogPath() string {
+	return logPath
+}
+
+// Debugf writes a line to the log file only, e.g. the command lines of
+// child processes. It does nothing without a log file.
+func Debugf(format string, args ...interface{}) {
+	t := logFile.Load()
+	if t == nil {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(fmt.Sprintf(format, args...), "\n"), "\n") {
+		t.writeLine("DEBUG ", line)
+	}
+}
+
+func (t *transcript) writeLine(prefix, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.file, "%s %s%s\n", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"), prefix, redact.Text(line))
+}
+
+func closeLog() {
+	if t := logFile.Swap(nil); t != nil {
+		t.file.Close()
+	}
+}
+
+// logLines passes the complete lines of one stream to the log file.
+type logLines struct {
+	pending []byte
+}
+
+func (w *logLines) Write(p []byte) (int, error) {
+	t := logFile.Load()
+	if t == nil {
+		return len(p), nil
+	}
+
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		t.writeLine("", strings.TrimRight(string(w.pending[:i]), "\r"))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *logLines) flush() {
+	if t := logFile.Load(); t != nil && len(w.pending) > 0 {
+		t.writeLine("", string(w.pending))
+	}
+	w.pending = nil
+}
+
+// rotate moves path to path.1, path.1 to path.2 and so on, dropping the
+// copies beyond keep files.
+func rotate(path string, keep int) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if keep == 1 {
+		return nil
+	}
+
+	oldest := keep - 1
+	if keep == 0 {
+		for oldest = 1; ; oldest++ {
+			if _, err := os.Stat(fmt.Sprintf("%s.%d", path, oldest)); os.IsNotExist(err) {
+				break
+			}
+		}
+	}
+
+	for i := oldest - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return os.Rename(path, path+".1")
+}
+
+// prune removes all but the newest keep log files in dir.
+func prune(dir string, keep int) {
+	if keep == 0 {
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil || len(files) <= keep {
+		return
+	}
+	sort.Strings(files)
+	for _, file := range files[:len(files)-keep] {
+		os.Remove(file)
+	}
+}
@@ -0,0 +1,313 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const esc = 0x1b
+
+var (
+	colorEnabled = true
+	emojiEnabled = true
+)
+
+// labels replace the emoji that carry a status when emoji are disabled.
+// Other emoji are only decoration and are dropped.
+var labels = map[rune]string{
+	'✅': "[OK]",
+	'❌': "[FAIL]",
+	'⚠': "[WARN]",
+	'ℹ': "[INFO]",
+	'⏭': "[SKIP]",
+}
+
+// isTerminal reports whether f is a terminal. Tests replace it.
+var isTerminal = func(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Configure disables color and emoji output when noColor is set, the
+// NO_COLOR environment variable is present and not empty
+// (https://no-color.org) or stdout is not a terminal. noEmoji only replaces
+// the emoji, keeping colors.
+func Configure(noColor, noEmoji bool) {
+	if noColor || os.Getenv("NO_COLOR") != "" || !isTerminal(os.Stdout) {
+		colorEnabled = false
+	}
+	if noEmoji || !colorEnabled {
+		emojiEnabled = false
+	}
+}
+
+// ColorEnabled reports whether output may contain ANSI escape codes.
+func ColorEnabled() bool {
+	return colorEnabled
+}
+
+// EmojiEnabled reports whether output may contain emoji.
+func EmojiEnabled() bool {
+	return emojiEnabled
+}
+
+// Stdout and Stderr receive the messages of the CLI and of the child
+// processes it runs: progress, status lines and warnings. They write to
+// whatever os.Stdout and os.Stderr are at the time, replacing emoji with
+// ASCII labels and, unless color is enabled, stripping ANSI escape codes, and
+// copy the messages to the log file opened by OpenLog.
+//
+// Data, such as JSON and YAML output, manifests, config values and pod logs,
+// must be written to os.Stdout directly so that it reaches pipes unchanged.
+var (
+	Stdout io.Writer = newStream(func() *os.File { return os.Stdout })
+	Stderr io.Writer = newStream(func() *os.File { return os.Stderr })
+)
+
+// Printf writes a message to Stdout.
+func Printf(format string, a ...interface{}) {
+	fmt.Fprintf(Stdout, format, a...)
+}
+
+// Println writes a message to Stdout.
+func Println(a ...interface{}) {
+	fmt.Fprintln(Stdout, a...)
+}
+
+// Print writes a message to Stdout.
+func Print(a ...interface{}) {
+	fmt.Fprint(Stdout, a...)
+}
+
+// stream is Stdout or Stderr. Child processes write to it from the
+// goroutines of os/exec, so writes are serialized.
+type stream struct {
+	mu       sync.Mutex
+	terminal stripWriter
+	lines    logLines
+	log      stripWriter
+}
+
+func newStream(file func() *os.File) *stream {
+	s := &stream{}
+	s.terminal.w = fileWriter(file)
+	s.log.w = &s.lines
+	return s
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if logFile.Load() != nil {
+		s.log.Write(p)
+	}
+	if colorEnabled && emojiEnabled {
+		return s.terminal.w.Write(p)
+	}
+	s.terminal.keepEscapes = colorEnabled
+	return s.terminal.Write(p)
+}
+
+// flush writes what is held back waiting for the rest of an escape sequence
+// or character.
+func (s *stream) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.terminal.pending) > 0 {
+		s.terminal.w.Write(s.terminal.pending)
+		s.terminal.pending = nil
+	}
+	s.log.w.Write(s.log.pending)
+	s.log.pending = nil
+	s.lines.flush()
+}
+
+// MessagesToStderr makes Stdout write to os.Stderr until the returned
+// function is called, for commands whose stdout carries data only.
+func MessagesToStderr() func() {
+	s := Stdout.(*stream)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.terminal.w
+	s.terminal.w = fileWriter(func() *os.File { return os.Stderr })
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.terminal.w = previous
+	}
+}
+
+// fileWriter writes to the file it returns, looked up on every write.
+type fileWriter func() *os.File
+
+func (f fileWriter) Write(p []byte) (int, error) {
+	return f().Write(p)
+}
+
+// Close flushes Stdout and Stderr and closes the log file.
+func Close() {
+	Stdout.(*stream).flush()
+	Stderr.(*stream).flush()
+	closeLog()
+}
+
+// Strip removes ANSI escape sequences from s and replaces emoji, along with
+// the spaces that follow them, with ASCII labels such as [OK] and [FAIL], or
+// nothing for decorative emoji.
+func Strip(s string) string {
+	var w stripWriter
+	return string(w.strip([]byte(s)))
+}
+
+type stripWriter struct {
+	w           io.Writer
+	pending     []byte
+	keepEscapes bool
+	skipSpace   bool
+	// needSpace separates a label from the text that follows it.
+	needSpace bool
+}
+
+// NewStripWriter returns a writer that filters what is written to w as Strip
+// does. Sequences split across writes are held back until they are
+// complete.
+func NewStripWriter(w io.Writer) io.Writer {
+	return &stripWriter{w: w}
+}
+
+func (s *stripWriter) Write(p []byte) (int, error) {
+	s.pending = append(s.pending, p...)
+
+	n := completeLen(s.pending)
+	out := s.strip(s.pending[:n])
+	s.pending = append([]byte(nil), s.pending[n:]...)
+
+	if _, err := s.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *stripWriter) strip(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+
+	for i := 0; i < len(b); {
+		if b[i] == esc {
+			end, _ := sequenceEnd(b, i)
+			if s.keepEscapes {
+				out = append(out, b[i:end]...)
+			}
+			i = end
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b[i:])
+		if isEmoji(r) {
+			if label, ok := labels[r]; ok {
+				if s.needSpace {
+					out = append(out, ' ')
+				}
+				out = append(out, label...)
+				s.needSpace = true
+			}
+			s.skipSpace = true
+			i += size
+			continue
+		}
+		if s.skipSpace && r == ' ' {
+			i += size
+			continue
+		}
+
+		if s.needSpace && r != '\n' && r != '\r' {
+			out = append(out, ' ')
+		}
+		s.skipSpace, s.needSpace = false, false
+		out = append(out, b[i:i+size]...)
+		i += size
+	}
+
+	return out
+}
+
+// completeLen returns how much of b can be filtered now, leaving out a
+// trailing unterminated escape sequence or partial UTF-8 character.
+func completeLen(b []byte) int {
+	n := len(b)
+
+	for i := len(b) - 1; i >= 0 && i >= len(b)-64; i-- {
+		if b[i] == esc {
+			if _, complete := sequenceEnd(b, i); !complete {
+				n = i
+			}
+			break
+		}
+	}
+
+	for i := n - 1; i >= 0 && i >= n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:n]) {
+				n = i
+			}
+			break
+		}
+	}
+
+	return n
+}
+
+// sequenceEnd returns the index after the escape sequence starting at
+// b[start] and whether the sequence is terminated within b. CSI sequences end
+// with a byte in 0x40-0x7e, OSC sequences with BEL or ESC \.
+func sequenceEnd(b []byte, start int) (int, bool) {
+	if start+1 >= len(b) {
+		return len(b), false
+	}
+
+	switch b[start+1] {
+	case '[':
+		for i := start + 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return i + 1, true
+			}
+		}
+	case ']':
+		for i := start + 2; i < len(b); i++ {
+			if b[i] == 0x07 {
+				return i + 1, true
+			}
+			if b[i] == esc && i+1 < len(b) && b[i+1] == '\\' {
+				return i + 2, true
+			}
+		}
+	default:
+		return start + 2, true
+	}
+
+	return len(b), false
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r == 0x200d, r == 0x20e3, r == 0x2139, r == 0xfe0f:
+		return true
+	case r >= 0x2300 && r <= 0x23ff:
+		return true
+	case r >= 0x25a0 && r <= 0x27bf:
+		return true
+	case r >= 0x2b00 && r <= 0x2bff:
+		return true
+	case r >= 0x1f000 && r <= 0x1faff:
+		return true
+	case r >= 0xe0020 && r <= 0xe007f:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"status emoji", "✅ Installed\n", "[OK] Installed\n"},
+		{"variation selector", "⚠️  Could not read state\n", "[WARN] Could not read state\n"},
+		{"decorative emoji", "🚀 Installing Envoy AI Gateway\n", "Installing Envoy AI Gateway\n"},
+		{"label after text", "🔍 kubectl:   ✅ v1.30.0\n", "kubectl:   [OK] v1.30.0\n"},
+		{"label at end of line", "❌\n", "[FAIL]\n"},
+		{"colors", "\x1b[32mready\x1b[0m\n", "ready\n"},
+		{"plain text", "  Namespace (AI):      envoy-ai-gateway-system\n", "  Namespace (AI):      envoy-ai-gateway-system\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Strip(tt.in); got != tt.want {
+				t.Errorf("Strip(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripWriterSplitWrites(t *testing.T) {
+	var out writeBuffer
+	w := NewStripWriter(&out)
+
+	in := []byte("✅ done \x1b[1mnow\x1b[0m\n")
+	for i := range in {
+		w.Write(in[i : i+1])
+	}
+
+	if want := "[OK] done now\n"; string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestStream(t *testing.T) {
+	const message = "✅ Set namespace_gateway to \x1b[1mns\x1b[0m\n"
+
+	tests := []struct {
+		name         string
+		color, emoji bool
+		want         string
+	}{
+		{"decorated", true, true, message},
+		{"no emoji", true, false, "[OK] Set namespace_gateway to \x1b[1mns\x1b[0m\n"},
+		{"plain", false, false, "[OK] Set namespace_gateway to ns\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnabled(t, tt.color, tt.emoji)
+
+			file, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newStream(func() *os.File { return file })
+
+			s.Write([]byte(message))
+			s.flush()
+
+			got, err := os.ReadFile(file.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamLeavesOtherOutputAlone(t *testing.T) {
+	setEnabled(t, false, false)
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newStream(func() *os.File { return file })
+
+	// Data is written to the file directly, around the messages.
+	file.WriteString("ns-✅ok\n")
+	s.Write([]byte("✅ done\n"))
+	file.WriteString("{\"status\": \"⚠️\"}\n")
+
+	got, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ns-✅ok\n[OK] done\n{\"status\": \"⚠️\"}\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMessagesToStderr(t *testing.T) {
+	setEnabled(t, true, true)
+
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedStdout, savedStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	t.Cleanup(func() { os.Stdout, os.Stderr = savedStdout, savedStderr })
+
+	restore := MessagesToStderr()
+	Println("rendering")
+	restore()
+	Println("done")
+
+	for path, want := range map[string]string{stdout.Name(): "done\n", stderr.Name(): "rendering\n"} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: got %q, want %q", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		name             string
+		terminal         bool
+		noColor, noEmoji bool
+		env              string
+		color, emoji     bool
+	}{
+		{name: "terminal", terminal: true, color: true, emoji: true},
+		{name: "not a terminal", terminal: false},
+		{name: "--no-color", terminal: true, noColor: true},
+		{name: "--no-emoji", terminal: true, noEmoji: true, color: true},
+		{name: "NO_COLOR", terminal: true, env: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnabled(t, true, true)
+			saved := isTerminal
+			isTerminal = func(*os.File) bool { return tt.terminal }
+			t.Cleanup(func() { isTerminal = saved })
+			t.Setenv("NO_COLOR", tt.env)
+
+			Configure(tt.noColor, tt.noEmoji)
+
+			if ColorEnabled() != tt.color || EmojiEnabled() != tt.emoji {
+				t.Errorf("color %v, emoji %v; want %v, %v", ColorEnabled(), EmojiEnabled(), tt.color, tt.emoji)
+			}
+		})
+	}
+}
+
+func setEnabled(t *testing.T, color, emoji bool) {
+	t.Helper()
+	savedColor, savedEmoji := colorEnabled, emojiEnabled
+	colorEnabled, emojiEnabled = color, emoji
+	t.Cleanup(func() { colorEnabled, emojiEnabled = savedColor, savedEmoji })
+}
+
+type writeBuffer []byte
+
+func (b *writeBuffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
@@ -0,0 +1,25 @@
+package overlays
+
+import (
+	"embed"
+	"fmt"
+)
+
+const (
+	ProfileLocal         = "local"
+	ProfileOpenShift     = "openshift"
+	ProfileObservability = "observability"
+)
+
+//go:embed local/*.yaml openshift/*.yaml observability/*.yaml
+var files embed.FS
+
+// Get returns the values overlay of a profile for a release id (eg, aieg or
+// envoy-redis). ok is false when the profile has no overlay for the release.
+func Get(profile, release string) (data []byte, ok bool) {
+	data, err := files.ReadFile(fmt.Sprintf("%s/%s.yaml", profile, release))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
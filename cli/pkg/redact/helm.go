@@ -0,0 +1,58 @@
+package redact
+
+import "strings"
+
+// helmSections are the section headers of 'helm get all' output, mapped to
+// whether the section holds YAML. The release header and NOTES are free
+// text.
+var helmSections = map[string]bool{
+	"USER-SUPPLIED VALUES:": true,
+	"COMPUTED VALUES:":      true,
+	"HOOKS:":                true,
+	"MANIFEST:":             true,
+	"NOTES:":                false,
+}
+
+// HelmRelease masks the output of 'helm get all': the values, hooks and
+// manifest sections go through YAML, so that Secret data is masked, and the
+// rest through Text. A section that does not parse falls back to Text.
+func HelmRelease(s string) string {
+	var out strings.Builder
+	var section []string
+	yamlSection := false
+
+	flush := func() {
+		body := strings.Join(section, "\n")
+		section = nil
+		if body == "" {
+			return
+		}
+		// Keep the layout of the section: re-encoding drops the leading
+		// document separator and the blank lines before the next header.
+		trimmed := strings.TrimRight(body, "\n")
+		redacted := Text(trimmed)
+		if yamlSection {
+			if y, err := YAML(trimmed); err == nil {
+				redacted = strings.TrimSuffix(y, "\n")
+				if strings.HasPrefix(trimmed, "---") {
+					redacted = "---\n" + redacted
+				}
+			}
+		}
+		out.WriteString(redacted + body[len(trimmed):] + "\n")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for _, line := range lines {
+		if isYAML, ok := helmSections[line]; ok {
+			flush()
+			out.WriteString(line + "\n")
+			yamlSection = isYAML
+			continue
+		}
+		section = append(section, line)
+	}
+	flush()
+
+	return out.String()
+}
@@ -0,0 +1,136 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const Placeholder = "[REDACTED]"
+
+var (
+	sensitiveKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[-_]?key|apikey|credential|private[-_]?key|authorization)`)
+
+	// Keys such as secretName, tokenPath or imagePullSecrets reference a
+	// credential rather than holding one.
+	referenceKey = regexp.MustCompile(`(?i)(name|ref|path|file|mount|ttl|namespace|secrets)$`)
+)
+
+var textRules = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{
+		regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`),
+		"${1} " + Placeholder,
+	},
+	{
+		regexp.MustCompile(`(?i)((?:x-)?(?:api[-_]?key|apikey|access[-_]?token|auth[-_]?token|token|secret|password|passwd)["']?\s*[:=]\s*["']?)[^\s"',;&]+`),
+		"${1}" + Placeholder,
+	},
+	{
+		regexp.MustCompile(`(\b[a-zA-Z][a-zA-Z0-9+.-]*://[^:/?#@\s]+:)[^/?#@\s]+@`),
+		"${1}" + Placeholder + "@",
+	},
+	{
+		regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35})\b`),
+		Placeholder,
+	},
+}
+
+// Text masks bearer tokens, API keys, key=value credentials and the
+// passwords of URLs in free-form text such as logs.
+func Text(s string) string {
+	for _, rule := range textRules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+// YAML masks Secret data and the values of credential-like keys in a
+// (multi-document) YAML stream, then applies Text to the result.
+func YAML(s string) (string, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(s))
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse YAML: %w", err)
+		}
+
+		redactNode(&doc)
+		if err := encoder.Encode(&doc); err != nil {
+			return "", fmt.Errorf("failed to encode YAML: %w", err)
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return Text(buf.String()), nil
+}
+
+func redactNode(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			redactNode(child)
+		}
+	case yaml.MappingNode:
+		secret := isSecret(node)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			if secret && (key.Value == "data" || key.Value == "stringData") && value.Kind == yaml.MappingNode {
+				for j := 1; j < len(value.Content); j += 2 {
+					mask(value.Content[j])
+				}
+				continue
+			}
+
+			if value.Kind == yaml.ScalarNode && isSensitiveKey(key.Value) {
+				mask(value)
+				continue
+			}
+
+			redactNode(value)
+		}
+	}
+}
+
+func isSecret(node *yaml.Node) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "kind" && node.Content[i+1].Value == "Secret" {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveKey(key string) bool {
+	return sensitiveKey.MatchString(key) && !referenceKey.MatchString(key)
+}
+
+func mask(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode || node.Value == "" || node.Tag == "!!null" || node.Tag == "!!bool" {
+		return
+	}
+	node.Value = Placeholder
+	node.Tag = "!!str"
+	node.Style = 0
+}
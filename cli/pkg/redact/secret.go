@@ -0,0 +1,166 @@
+package redact
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Secret is a credential, such as the contents of a Docker config, that must
+// not appear in output. It prints as *** with the %s and %v verbs and is
+// encoded as *** to JSON and YAML; Reveal returns the value to hand to the
+// program that needs it.
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "***"
+}
+
+func (s Secret) GoString() string {
+	return `redact.Secret("` + s.String() + `")`
+}
+
+// Reveal returns the secret value.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// setFlags take comma-separated key=value assignments.
+var setFlags = map[string]bool{
+	"--set":         true,
+	"--set-string":  true,
+	"--set-json":    true,
+	"--set-literal": true,
+}
+
+// Args masks a command line before it is printed: the values of --set style
+// assignments to credential-like keys, the values of flags such as
+// --password and anything Text recognises. The command itself must be run
+// with the original args.
+func Args(args []string) []string {
+	masked := make([]string, len(args))
+	for i, arg := range args {
+		masked[i] = Text(arg)
+
+		if name, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "--") {
+			switch {
+			case setFlags[name]:
+				masked[i] = name + "=" + maskAssignments(value)
+			case isCredentialFlag(name):
+				masked[i] = name + "=" + Placeholder
+			}
+			continue
+		}
+
+		if i > 0 {
+			switch {
+			case setFlags[args[i-1]]:
+				masked[i] = maskAssignments(arg)
+			case isCredentialFlag(args[i-1]):
+				masked[i] = Placeholder
+			}
+		}
+	}
+	return masked
+}
+
+func isCredentialFlag(arg string) bool {
+	return strings.HasPrefix(arg, "--") && !strings.Contains(arg, "=") && isSensitiveKey(strings.TrimPrefix(arg, "--"))
+}
+
+// maskAssignments masks the values of the credential-like keys in a helm
+// --set list such as "auth.password=x,replicas=2". Commas escaped with a
+// backslash belong to the value.
+func maskAssignments(list string) string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, list[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, list[start:])
+
+	for i, part := range parts {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			continue
+		}
+		if isSensitiveKey(lastSegment(key)) {
+			parts[i] = key + "=" + Placeholder
+		} else {
+			parts[i] = Text(part)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// lastSegment returns the last field of a helm key path, without list
+// indexes: "auth.passwords[0]" gives "passwords".
+func lastSegment(key string) string {
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		key = key[i+1:]
+	}
+	if i := strings.IndexByte(key, '['); i >= 0 {
+		key = key[:i]
+	}
+	return key
+}
+
+// Writer applies Text to each complete line written to it before passing it
+// on, e.g. to scrub the output of a child process. Flush writes what is left
+// of an unterminated last line.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	pending []byte
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+	end := strings.LastIndexByte(string(w.pending), '\n') + 1
+	if end == 0 {
+		return len(p), nil
+	}
+
+	if _, err := io.WriteString(w.w, Text(string(w.pending[:end]))); err != nil {
+		return 0, err
+	}
+	w.pending = append(w.pending[:0], w.pending[end:]...)
+	return len(p), nil
+}
+
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w.w, Text(string(w.pending)))
+	w.pending = nil
+	return err
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/archive"
+)
+
+type Bundle struct {
+	CreatedAt   time.Time
+	MaxFileSize int
+	Files       map[string][]byte
+}
+
+func NewBundle(createdAt time.Time, maxFileSize int) *Bundle {
+	return &Bundle{
+		CreatedAt:   createdAt,
+		MaxFileSize: maxFileSize,
+		Files:       map[string][]byte{},
+	}
+}
+
+// Add stores a file in the bundle, keeping only the last MaxFileSize bytes
+// of oversized files since the most recent output is usually the relevant
+// part.
+func (b *Bundle) Add(name string, data []byte) {
+	if b.MaxFileSize > 0 && len(data) > b.MaxFileSize {
+		note := fmt.Sprintf("[truncated: showing last %d of %d bytes]\n", b.MaxFileSize, len(data))
+		data = append([]byte(note), data[len(data)-b.MaxFileSize:]...)
+	}
+	b.Files[name] = data
+}
+
+func (b *Bundle) AddString(name, data string) {
+	b.Add(name, []byte(data))
+}
+
+func (b *Bundle) Size() int {
+	size := 0
+	for _, data := range b.Files {
+		size += len(data)
+	}
+	return size
+}
+
+func FileName(t time.Time) string {
+	return fmt.Sprintf("envoy-ai-report-%s.tar.gz", t.UTC().Format("20060102-150405"))
+}
+
+// WriteDir writes the files of the bundle under dir instead of into an
+// archive.
+func (b *Bundle) WriteDir(dir string) error {
+	for name, data := range b.Files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Write writes the files of the bundle to a tar.gz archive at path.
+func (b *Bundle) Write(path string) error {
+	return archive.WriteFile(path, archive.Sorted(b.Files), b.CreatedAt)
+}
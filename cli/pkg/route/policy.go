@@ -0,0 +1,209 @@
+package route
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ProviderAnthropic is only a policy provider: routes to Anthropic are not
+// scaffolded by route add.
+const ProviderAnthropic = "anthropic"
+
+// Authentication methods of a BackendSecurityPolicy.
+const (
+	AuthAPIKey          = "api-key"
+	AuthCredentialsFile = "credentials-file"
+	AuthOIDC            = "oidc"
+	AuthClientSecret    = "client-secret"
+)
+
+// Keys the credentials secret must hold, by authentication method.
+const (
+	APIKeyKey         = "apiKey"
+	AWSCredentialsKey = "credentials"
+	ClientSecretKey   = "client-secret"
+)
+
+// defaultAWSProfile is the profile of the credentials file that is used.
+const defaultAWSProfile = "default"
+
+// PolicyProviders lists the providers a BackendSecurityPolicy can be
+// generated for.
+var PolicyProviders = []string{ProviderOpenAI, ProviderAnthropic, ProviderAzureOpenAI, ProviderAWSBedrock, ProviderOpenAICompatible}
+
+// authMethods lists the authentication methods of each provider, the
+// default first.
+var authMethods = map[string][]string{
+	ProviderOpenAI:           {AuthAPIKey},
+	ProviderAnthropic:        {AuthAPIKey},
+	ProviderAzureOpenAI:      {AuthAPIKey, AuthClientSecret},
+	ProviderAWSBedrock:       {AuthCredentialsFile, AuthOIDC},
+	ProviderOpenAICompatible: {AuthAPIKey},
+}
+
+// Policy describes the BackendSecurityPolicy that authenticates the AI
+// Gateway to the provider behind an AIServiceBackend.
+type Policy struct {
+	Name      string
+	Namespace string
+	// Backend is the AIServiceBackend in Namespace the policy targets.
+	Backend  string
+	Provider string
+	// Auth is one of the authentication methods of Provider; empty
+	// selects its default.
+	Auth string
+	// CredentialsSecret is the Secret in Namespace holding the SecretKeys.
+	CredentialsSecret string
+	// Region is the AWS region of Bedrock.
+	Region string
+	// RoleARN, OIDCIssuer and OIDCClientID configure the exchange of an
+	// OIDC token for AWS credentials.
+	RoleARN      string
+	OIDCIssuer   string
+	OIDCClientID string
+	// ClientID and TenantID identify the Azure service principal.
+	ClientID string
+	TenantID string
+	Labels   map[string]string
+}
+
+func (p Policy) auth() string {
+	if p.Auth == "" && len(authMethods[p.Provider]) > 0 {
+		return authMethods[p.Provider][0]
+	}
+	return p.Auth
+}
+
+// Validate checks the policy before it is rendered.
+func (p Policy) Validate() error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("a policy name is required")
+	case p.Backend == "":
+		return fmt.Errorf("a backend is required")
+	case !slices.Contains(PolicyProviders, p.Provider):
+		return fmt.Errorf("unknown provider %q (expected one of %s)", p.Provider, strings.Join(PolicyProviders, ", "))
+	case !slices.Contains(authMethods[p.Provider], p.auth()):
+		return fmt.Errorf("provider %s does not support auth %q (expected one of %s)",
+			p.Provider, p.Auth, strings.Join(authMethods[p.Provider], ", "))
+	case p.CredentialsSecret == "":
+		return fmt.Errorf("a credentials secret is required")
+	}
+
+	auth := p.auth()
+	if p.Region != "" && p.Provider != ProviderAWSBedrock {
+		return fmt.Errorf("a region only applies to provider %s", ProviderAWSBedrock)
+	}
+	oidc := p.RoleARN != "" || p.OIDCIssuer != "" || p.OIDCClientID != ""
+	if auth == AuthOIDC && (p.RoleARN == "" || p.OIDCIssuer == "" || p.OIDCClientID == "") {
+		return fmt.Errorf("auth %s needs a role ARN, an OIDC issuer and an OIDC client ID", AuthOIDC)
+	}
+	if auth != AuthOIDC && oidc {
+		return fmt.Errorf("a role ARN and OIDC settings only apply to auth %s", AuthOIDC)
+	}
+	azure := p.ClientID != "" || p.TenantID != ""
+	if auth == AuthClientSecret && (p.ClientID == "" || p.TenantID == "") {
+		return fmt.Errorf("auth %s needs a client ID and a tenant ID", AuthClientSecret)
+	}
+	if auth != AuthClientSecret && azure {
+		return fmt.Errorf("a client ID and tenant ID only apply to auth %s", AuthClientSecret)
+	}
+	return nil
+}
+
+// SecretKeys returns the keys the credentials secret must hold.
+func (p Policy) SecretKeys() []string {
+	switch p.auth() {
+	case AuthCredentialsFile:
+		return []string{AWSCredentialsKey}
+	case AuthOIDC, AuthClientSecret:
+		return []string{ClientSecretKey}
+	}
+	return []string{APIKeyKey}
+}
+
+// Manifest renders the policy as YAML.
+func (p Policy) Manifest() (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	return encode(p.Name, []interface{}{p.object(p.metadata())})
+}
+
+func (p Policy) object(metadata map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": aiGatewayAPIVersion,
+		"kind":       "BackendSecurityPolicy",
+		"metadata":   metadata,
+		"spec":       p.spec(),
+	}
+}
+
+func (p Policy) spec() map[string]interface{} {
+	spec := map[string]interface{}{
+		"targetRefs": []interface{}{
+			map[string]interface{}{"group": "aigateway.envoyproxy.io", "kind": "AIServiceBackend", "name": p.Backend},
+		},
+	}
+	secretRef := map[string]interface{}{"name": p.CredentialsSecret, "namespace": p.Namespace}
+
+	switch p.Provider {
+	case ProviderAnthropic:
+		spec["type"] = "AnthropicAPIKey"
+		spec["anthropicAPIKey"] = map[string]interface{}{"secretRef": secretRef}
+	case ProviderAzureOpenAI:
+		if p.auth() == AuthClientSecret {
+			spec["type"] = "AzureCredentials"
+			spec["azureCredentials"] = map[string]interface{}{
+				"clientID":        p.ClientID,
+				"tenantID":        p.TenantID,
+				"clientSecretRef": secretRef,
+			}
+			break
+		}
+		spec["type"] = "AzureAPIKey"
+		spec["azureAPIKey"] = map[string]interface{}{"secretRef": secretRef}
+	case ProviderAWSBedrock:
+		region := p.Region
+		if region == "" {
+			region = DefaultAWSRegion
+		}
+		credentials := map[string]interface{}{"region": region}
+		if p.auth() == AuthOIDC {
+			credentials["oidcExchangeToken"] = map[string]interface{}{
+				"awsRoleArn": p.RoleARN,
+				"oidc": map[string]interface{}{
+					"provider":     map[string]interface{}{"issuer": p.OIDCIssuer},
+					"clientID":     p.OIDCClientID,
+					"clientSecret": secretRef,
+				},
+			}
+		} else {
+			credentials["credentialsFile"] = map[string]interface{}{
+				"secretRef": secretRef,
+				"profile":   defaultAWSProfile,
+			}
+		}
+		spec["type"] = "AWSCredentials"
+		spec["awsCredentials"] = credentials
+	default:
+		spec["type"] = "APIKey"
+		spec["apiKey"] = map[string]interface{}{"secretRef": secretRef}
+	}
+	return spec
+}
+
+func (p Policy) metadata() map[string]interface{} {
+	labels := map[string]string{}
+	for key, value := range p.Labels {
+		labels[key] = value
+	}
+	labels[ManagedByLabel] = ManagedBy
+
+	return map[string]interface{}{
+		"name":      p.Name,
+		"namespace": p.Namespace,
+		"labels":    labels,
+	}
+}
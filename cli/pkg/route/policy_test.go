@@ -0,0 +1,105 @@
+package route
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestPolicyManifestGolden(t *testing.T) {
+	base := Policy{
+		Name:              "chat-auth",
+		Namespace:         "default",
+		Backend:           "chat",
+		CredentialsSecret: "chat-credentials",
+		Labels:            map[string]string{"team": "ai"},
+	}
+
+	tests := []struct {
+		golden string
+		policy func(p Policy) Policy
+		keys   []string
+	}{
+		{"openai", func(p Policy) Policy { p.Provider = ProviderOpenAI; return p }, []string{APIKeyKey}},
+		{"openai-compatible", func(p Policy) Policy { p.Provider = ProviderOpenAICompatible; return p }, []string{APIKeyKey}},
+		{"anthropic", func(p Policy) Policy { p.Provider = ProviderAnthropic; return p }, []string{APIKeyKey}},
+		{"azure-openai-api-key", func(p Policy) Policy { p.Provider = ProviderAzureOpenAI; return p }, []string{APIKeyKey}},
+		{"azure-openai-client-secret", func(p Policy) Policy {
+			p.Provider, p.Auth = ProviderAzureOpenAI, AuthClientSecret
+			p.ClientID, p.TenantID = "11111111-2222-3333-4444-555555555555", "66666666-7777-8888-9999-000000000000"
+			return p
+		}, []string{ClientSecretKey}},
+		{"aws-bedrock-credentials-file", func(p Policy) Policy { p.Provider = ProviderAWSBedrock; return p }, []string{AWSCredentialsKey}},
+		{"aws-bedrock-oidc", func(p Policy) Policy {
+			p.Provider, p.Auth, p.Region = ProviderAWSBedrock, AuthOIDC, "eu-west-1"
+			p.RoleARN = "arn:aws:iam::123456789012:role/ai-gateway"
+			p.OIDCIssuer, p.OIDCClientID = "https://issuer.example.com", "ai-gateway"
+			return p
+		}, []string{ClientSecretKey}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			p := tt.policy(base)
+			got, err := p.Manifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keys := p.SecretKeys(); !slices.Equal(keys, tt.keys) {
+				t.Errorf("got secret keys %v, want %v", keys, tt.keys)
+			}
+
+			path := filepath.Join("testdata", "policy", tt.golden+".yaml")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("no golden file (run go test -update): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("manifest differs from %s; got:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	base := Policy{Name: "chat-auth", Namespace: "default", Backend: "chat", CredentialsSecret: "chat-credentials"}
+
+	tests := []struct {
+		name    string
+		policy  func(p Policy) Policy
+		wantErr string
+	}{
+		{"unknown provider", func(p Policy) Policy { p.Provider = "cohere"; return p }, `unknown provider "cohere"`},
+		{"unsupported auth", func(p Policy) Policy { p.Provider, p.Auth = ProviderOpenAI, AuthOIDC; return p }, `does not support auth "oidc"`},
+		{"no secret", func(p Policy) Policy { p.Provider, p.CredentialsSecret = ProviderOpenAI, ""; return p }, "credentials secret is required"},
+		{"region outside bedrock", func(p Policy) Policy { p.Provider, p.Region = ProviderOpenAI, "eu-west-1"; return p }, "region only applies"},
+		{"oidc incomplete", func(p Policy) Policy {
+			p.Provider, p.Auth, p.RoleARN = ProviderAWSBedrock, AuthOIDC, "arn:aws:iam::123456789012:role/ai-gateway"
+			return p
+		}, "needs a role ARN, an OIDC issuer and an OIDC client ID"},
+		{"client secret incomplete", func(p Policy) Policy {
+			p.Provider, p.Auth, p.ClientID = ProviderAzureOpenAI, AuthClientSecret, "client"
+			return p
+		}, "needs a client ID and a tenant ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy(base).Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
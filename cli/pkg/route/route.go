@@ -0,0 +1,292 @@
+// Package route renders the resources that send the requests for a model
+// through the AI Gateway to a provider: an AIGatewayRoute, its
+// AIServiceBackend and credentials policy, and the Envoy Gateway Backend and
+// TLS policy behind them.
+package route
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ProviderOpenAI           = "openai"
+	ProviderAzureOpenAI      = "azure-openai"
+	ProviderAWSBedrock       = "aws-bedrock"
+	ProviderOpenAICompatible = "openai-compatible"
+
+	DefaultAzureAPIVersion = "2025-01-01-preview"
+	DefaultAWSRegion       = "us-east-1"
+
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedBy      = "envoy-ai-installer"
+	// Label carries the name of the route on every resource rendered for
+	// it, so that the route can be listed and deleted as a whole.
+	Label = "envoy-ai-installer/route"
+
+	// ModelHeader is the header the AI Gateway fills with the model of a
+	// request, and routes on.
+	ModelHeader = "x-ai-eg-model"
+
+	aiGatewayAPIVersion    = "aigateway.envoyproxy.io/v1alpha1"
+	envoyGatewayAPIVersion = "gateway.envoyproxy.io/v1alpha1"
+	gatewayAPIGroup        = "gateway.networking.k8s.io"
+)
+
+// Providers lists the supported provider presets.
+var Providers = []string{ProviderOpenAI, ProviderAzureOpenAI, ProviderAWSBedrock, ProviderOpenAICompatible}
+
+// Kinds are the resources a route is made of, as kubectl resource names.
+var Kinds = []string{
+	"aigatewayroutes.aigateway.envoyproxy.io",
+	"backendsecuritypolicies.aigateway.envoyproxy.io",
+	"aiservicebackends.aigateway.envoyproxy.io",
+	"backendtlspolicies.gateway.networking.k8s.io",
+	"backends.gateway.envoyproxy.io",
+}
+
+// Options describe a route to a provider.
+type Options struct {
+	Name      string
+	Namespace string
+	Provider  string
+	// Models are the model names routed to the provider.
+	Models []string
+	// Gateway is the Gateway in Namespace the route attaches to.
+	Gateway string
+	// CredentialsSecret is the Secret in Namespace holding the keys of
+	// Policy().SecretKeys(). Optional for OpenAI-compatible endpoints only.
+	CredentialsSecret string
+	// BaseURL is the endpoint of Azure OpenAI and OpenAI-compatible
+	// providers, e.g. https://my-resource.openai.azure.com.
+	BaseURL string
+	// Region is the AWS region of Bedrock.
+	Region string
+	// APIVersion is the Azure OpenAI API version.
+	APIVersion string
+	Labels     map[string]string
+}
+
+// Validate checks the options before anything is rendered.
+func (o Options) Validate() error {
+	switch {
+	case o.Name == "":
+		return fmt.Errorf("a route name is required")
+	case !slices.Contains(Providers, o.Provider):
+		return fmt.Errorf("unknown provider %q (expected one of %s)", o.Provider, strings.Join(Providers, ", "))
+	case len(o.Models) == 0:
+		return fmt.Errorf("at least one model is required")
+	case o.Gateway == "":
+		return fmt.Errorf("a gateway is required")
+	case o.CredentialsSecret == "" && o.Provider != ProviderOpenAICompatible:
+		return fmt.Errorf("provider %s needs a credentials secret", o.Provider)
+	}
+	needsURL := o.Provider == ProviderAzureOpenAI || o.Provider == ProviderOpenAICompatible
+	if needsURL && o.BaseURL == "" {
+		return fmt.Errorf("provider %s needs a base URL", o.Provider)
+	}
+	if !needsURL && o.BaseURL != "" {
+		return fmt.Errorf("provider %s does not take a base URL", o.Provider)
+	}
+	if _, err := o.endpoint(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type endpoint struct {
+	host string
+	port int
+	tls  bool
+	// prefix is the path of the base URL, e.g. v1.
+	prefix string
+}
+
+// endpoint returns where the provider is reached.
+func (o Options) endpoint() (endpoint, error) {
+	switch o.Provider {
+	case ProviderOpenAI:
+		return endpoint{host: "api.openai.com", port: 443, tls: true}, nil
+	case ProviderAWSBedrock:
+		region := o.Region
+		if region == "" {
+			region = DefaultAWSRegion
+		}
+		return endpoint{host: "bedrock-runtime." + region + ".amazonaws.com", port: 443, tls: true}, nil
+	}
+
+	u, err := url.Parse(o.BaseURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return endpoint{}, fmt.Errorf("invalid base URL %q (expected http(s)://host[:port][/path])", o.BaseURL)
+	}
+	ep := endpoint{host: u.Hostname(), port: 443, tls: u.Scheme == "https", prefix: strings.Trim(u.Path, "/")}
+	if !ep.tls {
+		ep.port = 80
+	}
+	if p := u.Port(); p != "" {
+		if ep.port, err = strconv.Atoi(p); err != nil {
+			return endpoint{}, fmt.Errorf("invalid port in base URL %q", o.BaseURL)
+		}
+	}
+	if o.Provider == ProviderAzureOpenAI && !ep.tls {
+		return endpoint{}, fmt.Errorf("the Azure OpenAI base URL %q must use https", o.BaseURL)
+	}
+	return ep, nil
+}
+
+// Manifests renders the resources of the route as a multi-document YAML
+// stream, in the order they can be applied.
+func Manifests(o Options) (string, error) {
+	if err := o.Validate(); err != nil {
+		return "", err
+	}
+	ep, _ := o.endpoint()
+
+	docs := []interface{}{
+		map[string]interface{}{
+			"apiVersion": envoyGatewayAPIVersion,
+			"kind":       "Backend",
+			"metadata":   o.metadata(),
+			"spec": map[string]interface{}{
+				"endpoints": []interface{}{
+					map[string]interface{}{"fqdn": map[string]interface{}{"hostname": ep.host, "port": ep.port}},
+				},
+			},
+		},
+	}
+
+	if ep.tls {
+		docs = append(docs, map[string]interface{}{
+			"apiVersion": gatewayAPIGroup + "/v1alpha3",
+			"kind":       "BackendTLSPolicy",
+			"metadata":   o.metadata(),
+			"spec": map[string]interface{}{
+				"targetRefs": []interface{}{
+					map[string]interface{}{"group": "gateway.envoyproxy.io", "kind": "Backend", "name": o.Name},
+				},
+				"validation": map[string]interface{}{
+					"wellKnownCACertificates": "System",
+					"hostname":                ep.host,
+				},
+			},
+		})
+	}
+
+	docs = append(docs, map[string]interface{}{
+		"apiVersion": aiGatewayAPIVersion,
+		"kind":       "AIServiceBackend",
+		"metadata":   o.metadata(),
+		"spec": map[string]interface{}{
+			"schema": o.schema(ep),
+			"backendRef": map[string]interface{}{
+				"group": "gateway.envoyproxy.io",
+				"kind":  "Backend",
+				"name":  o.Name,
+			},
+		},
+	})
+
+	if o.CredentialsSecret != "" {
+		docs = append(docs, o.Policy().object(o.metadata()))
+	}
+
+	var matches []interface{}
+	for _, model := range o.Models {
+		matches = append(matches, map[string]interface{}{
+			"headers": []interface{}{
+				map[string]interface{}{"type": "Exact", "name": ModelHeader, "value": model},
+			},
+		})
+	}
+	docs = append(docs, map[string]interface{}{
+		"apiVersion": aiGatewayAPIVersion,
+		"kind":       "AIGatewayRoute",
+		"metadata":   o.metadata(),
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{"group": gatewayAPIGroup, "kind": "Gateway", "name": o.Gateway},
+			},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"matches":     matches,
+					"backendRefs": []interface{}{map[string]interface{}{"name": o.Name}},
+				},
+			},
+		},
+	})
+
+	return encode(o.Name, docs)
+}
+
+// encode renders docs as a multi-document YAML stream.
+func encode(name string, docs []interface{}) (string, error) {
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return "", fmt.Errorf("failed to render %s: %w", name, err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// schema is the API the AIServiceBackend speaks.
+func (o Options) schema(ep endpoint) map[string]interface{} {
+	switch o.Provider {
+	case ProviderAzureOpenAI:
+		version := o.APIVersion
+		if version == "" {
+			version = DefaultAzureAPIVersion
+		}
+		return map[string]interface{}{"name": "AzureOpenAI", "version": version}
+	case ProviderAWSBedrock:
+		return map[string]interface{}{"name": "AWSBedrock"}
+	}
+	schema := map[string]interface{}{"name": "OpenAI"}
+	if ep.prefix != "" {
+		// The OpenAI schema takes the path prefix of the API as its version.
+		schema["version"] = ep.prefix
+	}
+	return schema
+}
+
+// Policy returns the BackendSecurityPolicy of the route, with the default
+// authentication of its provider.
+func (o Options) Policy() Policy {
+	p := Policy{
+		Name:              o.Name,
+		Namespace:         o.Namespace,
+		Backend:           o.Name,
+		Provider:          o.Provider,
+		CredentialsSecret: o.CredentialsSecret,
+		Labels:            o.Labels,
+	}
+	if o.Provider == ProviderAWSBedrock {
+		p.Region = o.Region
+	}
+	return p
+}
+
+func (o Options) metadata() map[string]interface{} {
+	labels := map[string]string{}
+	for key, value := range o.Labels {
+		labels[key] = value
+	}
+	labels[ManagedByLabel] = ManagedBy
+	labels[Label] = o.Name
+
+	return map[string]interface{}{
+		"name":      o.Name,
+		"namespace": o.Namespace,
+		"labels":    labels,
+	}
+}
@@ -0,0 +1,58 @@
+package selfupdate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+)
+
+// NoticeInterval is how often the passive update notice checks for a new
+// release.
+const NoticeInterval = 24 * time.Hour
+
+// noticeState is the last passive check, kept between runs.
+type noticeState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// CheckDaily returns the latest release when it is newer than current,
+// looking it up at most once per NoticeInterval: between lookups it returns
+// nil, so the notice is shown at most once a day. The time of the last
+// lookup is kept in the file at statePath.
+func CheckDaily(ctx context.Context, current, statePath string) (*upstream.Release, error) {
+	if IsDevBuild(current) {
+		return nil, nil
+	}
+
+	var state noticeState
+	if data, err := os.ReadFile(statePath); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	if time.Since(state.CheckedAt) < NoticeInterval {
+		return nil, nil
+	}
+
+	latest, err := Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The notice is best effort: failing to save the state only means the
+	// next run looks again.
+	state = noticeState{CheckedAt: time.Now(), Latest: latest.Tag}
+	if data, err := json.Marshal(state); err == nil {
+		if err := os.MkdirAll(filepath.Dir(statePath), 0o755); err == nil {
+			os.WriteFile(statePath, data, 0o644)
+		}
+	}
+
+	if !upstream.IsNewerVersion(latest.Tag, current) {
+		return nil, nil
+	}
+	return latest, nil
+}
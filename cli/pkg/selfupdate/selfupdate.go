@@ -0,0 +1,213 @@
+package selfupdate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/upstream"
+)
+
+const (
+	// Owner and Repo are the GitHub repository the installer is released
+	// from.
+	Owner = "Franck-Sorel"
+	Repo  = "envoy-ai-unified-installer"
+
+	// ChecksumsAsset is the release asset listing the SHA-256 of every
+	// binary, in the format of sha256sum.
+	ChecksumsAsset = "checksums.txt"
+
+	binaryName = "envoy-ai-installer"
+)
+
+// AssetName returns the name of the release asset holding the binary for
+// goos/goarch, as built by 'make dist'.
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("%s-%s-%s", binaryName, goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Latest returns the latest stable release of the installer. The lookup
+// goes through the upstream release cache.
+func Latest(ctx context.Context) (*upstream.Release, error) {
+	releases, err := upstream.ListReleases(ctx, Owner, Repo, 1, upstream.ReleaseFilter{})
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("no release of %s/%s found", Owner, Repo)
+	}
+	return &releases[0], nil
+}
+
+// IsDevBuild reports whether version is not a release, e.g. a 'go build'
+// from a checkout, which cannot be compared with releases.
+func IsDevBuild(version string) bool {
+	return !upstream.IsNewerVersion(version, "v0.0.0")
+}
+
+// ManagedBy returns the update command of the package manager that
+// installed the executable at path, or an empty string when the binary was
+// installed by hand and can replace itself.
+func ManagedBy(path string) string {
+	slashed := filepath.ToSlash(path)
+	switch {
+	case strings.Contains(slashed, "/Cellar/") || strings.Contains(slashed, "/homebrew/") ||
+		strings.Contains(slashed, "/linuxbrew/"):
+		return "brew upgrade " + binaryName
+	case strings.HasPrefix(slashed, "/nix/store/"):
+		return "your Nix configuration (nix profile upgrade, home-manager or nixos-rebuild)"
+	case strings.Contains(strings.ToLower(slashed), "/scoop/apps/"):
+		return "scoop update " + binaryName
+	case strings.HasPrefix(slashed, "/snap/"):
+		return "snap refresh " + binaryName
+	}
+	return ""
+}
+
+// Executable returns the path of the running binary with symlinks resolved,
+// which is the file an update replaces.
+func Executable() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the running executable: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the running executable: %w", err)
+	}
+	return resolved, nil
+}
+
+// Update replaces the executable at path with the binary of rel for the
+// running platform. The download is verified against the checksums of the
+// release before it is moved into place, and the previous binary is kept
+// next to it with a .bak suffix, whose path is returned.
+func Update(ctx context.Context, rel *upstream.Release, path string) (string, error) {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	binary, ok := rel.FindAsset(name)
+	if !ok {
+		return "", fmt.Errorf("release %s has no binary for %s/%s (expected asset %s)",
+			rel.Tag, runtime.GOOS, runtime.GOARCH, name)
+	}
+	checksums, ok := rel.FindAsset(ChecksumsAsset)
+	if !ok {
+		return "", fmt.Errorf("release %s has no %s: refusing to install an unverified binary", rel.Tag, ChecksumsAsset)
+	}
+
+	want, err := fetchChecksum(ctx, checksums.URL, name)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect %s: %w", path, err)
+	}
+
+	// The new binary is written next to the old one, so that it can be
+	// renamed over it atomically.
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+binaryName+"-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot write to %s: %w", dir, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	got, err := download(ctx, binary.URL, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if got != want {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, want, got)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return "", fmt.Errorf("failed to make %s executable: %w", tmp.Name(), err)
+	}
+
+	backup := path + ".bak"
+	os.Remove(backup)
+	if err := os.Rename(path, backup); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		if restoreErr := os.Rename(backup, path); restoreErr != nil {
+			return "", fmt.Errorf("failed to install the new binary: %w (the previous binary is at %s)", err, backup)
+		}
+		return "", fmt.Errorf("failed to install the new binary: %w", err)
+	}
+	return backup, nil
+}
+
+// fetchChecksum returns the SHA-256 listed for name in the checksums file
+// at url.
+func fetchChecksum(ctx context.Context, url, name string) (string, error) {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", ChecksumsAsset, err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", ChecksumsAsset, err)
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", ChecksumsAsset, name)
+}
+
+// download writes the body at url to w and returns its SHA-256.
+func download(ctx context.Context, url string, w io.Writer) (string, error) {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// get requests url through the shared transport. The binary can be large,
+// so the body is not bounded by the per-request timeout: ctx cancels it.
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := httpclient.Client()
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/k8s"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+const (
+	ConfigMapName = "envoy-ai-installer-state"
+	dataKey       = "state.json"
+)
+
+type Release struct {
+	Namespace  string `json:"namespace"`
+	Chart      string `json:"chart"`
+	Version    string `json:"version"`
+	ValuesHash string `json:"values_hash"`
+	InputHash  string `json:"input_hash"`
+}
+
+// Observability records the monitoring resources created by the installer so
+// that uninstall can remove them.
+type Observability struct {
+	Namespace           string `json:"namespace"`
+	PrometheusRelease   string `json:"prometheus_release,omitempty"`
+	PrometheusNamespace string `json:"prometheus_namespace,omitempty"`
+}
+
+// Telemetry records where the installation exports traces and access logs.
+type Telemetry struct {
+	Endpoint   string  `json:"endpoint"`
+	Protocol   string  `json:"protocol"`
+	SampleRate float64 `json:"sample_rate"`
+}
+
+type State struct {
+	CLIVersion       string             `json:"cli_version"`
+	InstalledAt      time.Time          `json:"installed_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	NamespaceGateway string             `json:"namespace_gateway"`
+	NamespaceAI      string             `json:"namespace_ai"`
+	WithRedis        bool               `json:"with_redis"`
+	ReleasePrefix    string             `json:"release_prefix,omitempty"`
+	Releases         map[string]Release `json:"releases"`
+	Observability    *Observability     `json:"observability,omitempty"`
+	Telemetry        *Telemetry         `json:"telemetry,omitempty"`
+	// GatewayClass is the GatewayClass the installer created, which
+	// uninstall deletes.
+	GatewayClass string `json:"gateway_class,omitempty"`
+}
+
+func HashValues(values string) string {
+	sum := sha256.Sum256([]byte(values))
+	return hex.EncodeToString(sum[:])
+}
+
+func ConfigMapNameFor(releasePrefix string) string {
+	return releasePrefix + ConfigMapName
+}
+
+func Load(namespace, releasePrefix string) (*State, error) {
+	name := ConfigMapNameFor(releasePrefix)
+	cmd := k8s.Kubectl("get", "configmap", name, "-n", namespace,
+		"--ignore-not-found", "-o", "jsonpath={.data.state\\.json}")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installer state: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, nil
+	}
+
+	var st State
+	if err := json.Unmarshal(output, &st); err != nil {
+		return nil, fmt.Errorf("installer state in %s/%s is corrupt: %w", namespace, name, err)
+	}
+
+	return &st, nil
+}
+
+func Save(namespace string, st *State) error {
+	name := ConfigMapNameFor(st.ReleasePrefix)
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode installer state: %w", err)
+	}
+
+	configMap := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels": map[string]string{
+				"app.kubernetes.io/managed-by": "envoy-ai-installer",
+			},
+		},
+		"data": map[string]string{
+			dataKey: string(data),
+		},
+	}
+
+	manifest, err := json.Marshal(configMap)
+	if err != nil {
+		return fmt.Errorf("failed to encode installer state: %w", err)
+	}
+
+	cmd := k8s.Kubectl("apply", "-f", "-")
+	cmd.Stdin = bytes.NewReader(manifest)
+	cmd.Stderr = output.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to write installer state: %w", err)
+	}
+
+	return nil
+}
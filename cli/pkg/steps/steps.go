@@ -0,0 +1,214 @@
+package steps
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/ui"
+)
+
+// Step is one unit of work of a command such as install or uninstall.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context) error
+	// Skip, if set, returns why the step does not need to run, or "" to
+	// run it.
+	Skip func() string
+}
+
+// Status is the outcome of a step.
+type Status string
+
+const (
+	StatusDone    Status = "done"
+	StatusSkipped Status = "skipped"
+	StatusFailed  Status = "failed"
+	StatusNotRun  Status = "not run"
+)
+
+// Result records how a step went, when it started and how long it ran.
+type Result struct {
+	Name     string
+	Status   Status
+	Reason   string
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Runner runs steps in order, stopping at the first failure.
+type Runner struct {
+	// Out receives the step headers and the summary.
+	Out io.Writer
+	// Progress replaces the step headers with a spinner showing the elapsed
+	// time of the running step. The spinner and the output of the step,
+	// printed above it, are written to os.Stdout, which must be a terminal.
+	Progress bool
+}
+
+// isTerminal reports whether f is a terminal. Tests replace it.
+var isTerminal = ui.IsTerminal
+
+// NewRunner returns a Runner on stdout that shows progress when stdout is a
+// terminal and color output is enabled, and plain sequential lines otherwise.
+func NewRunner() *Runner {
+	return &Runner{
+		Out:      output.Stdout,
+		Progress: output.ColorEnabled() && isTerminal(os.Stdout),
+	}
+}
+
+// Run runs steps until one fails or ctx is cancelled. It returns a result for
+// every step, including those that did not run, and the error of the failed
+// step.
+func (r *Runner) Run(ctx context.Context, steps []Step) ([]Result, error) {
+	results := make([]Result, len(steps))
+	for i, step := range steps {
+		results[i] = Result{Name: step.Name, Status: StatusNotRun}
+	}
+
+	for i, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		label := fmt.Sprintf("Step %d/%d: %s", i+1, len(steps), step.Name)
+		results[i].Start = time.Now()
+		if step.Skip != nil {
+			if reason := step.Skip(); reason != "" {
+				fmt.Fprintf(r.Out, "\n⏭️  %s skipped: %s\n", label, reason)
+				results[i].Status, results[i].Reason = StatusSkipped, reason
+				continue
+			}
+		}
+
+		start := time.Now()
+		results[i].Start = start
+		var err error
+		if r.Progress {
+			err = r.runWithProgress(ctx, step, label, start)
+		} else {
+			fmt.Fprintf(r.Out, "\n📋 %s...\n", label)
+			err = step.Run(ctx)
+		}
+		results[i].Duration = time.Since(start)
+
+		if err != nil {
+			results[i].Status = StatusFailed
+			if r.Progress {
+				fmt.Fprintf(r.Out, "❌ %s failed after %s\n", label, round(results[i].Duration))
+			}
+			return results, err
+		}
+		results[i].Status = StatusDone
+		if r.Progress {
+			fmt.Fprintf(r.Out, "✅ %s (%s)\n", label, round(results[i].Duration))
+		}
+	}
+
+	return results, nil
+}
+
+// runWithProgress runs step behind a spinner. os.Stdout and os.Stderr,
+// including the output of child processes, are captured while it runs and
+// copied line by line above the spinner.
+func (r *Runner) runWithProgress(ctx context.Context, step Step, label string, start time.Time) error {
+	terminal := os.Stdout
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriter(terminal))
+	s.PreUpdate = func(s *spinner.Spinner) {
+		s.Suffix = fmt.Sprintf(" %s (%s)", label, time.Since(start).Truncate(time.Second))
+	}
+
+	restore, err := captureOutput(s, terminal)
+	if err != nil {
+		return err
+	}
+	s.Start()
+
+	err = step.Run(ctx)
+
+	restore()
+	s.Stop()
+	return err
+}
+
+// captureOutput points os.Stdout and os.Stderr at pipes whose complete lines
+// are written to out while holding the spinner lock, after erasing the
+// spinner. The returned function restores both and flushes what is left.
+func captureOutput(s *spinner.Spinner, out io.Writer) (func(), error) {
+	var wg sync.WaitGroup
+	var restore []func()
+	done := func() {
+		for _, fn := range restore {
+			fn()
+		}
+		wg.Wait()
+	}
+
+	for _, target := range []**os.File{&os.Stdout, &os.Stderr} {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			done()
+			return nil, err
+		}
+
+		original := *target
+		*target = pw
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer pr.Close()
+			lines := bufio.NewScanner(pr)
+			lines.Buffer(make([]byte, 64*1024), 1024*1024)
+			for lines.Scan() {
+				s.Lock()
+				fmt.Fprintf(out, "\r\033[K%s\n", lines.Text())
+				s.Unlock()
+			}
+			// Drain an overlong line rather than block the writer.
+			io.Copy(io.Discard, pr)
+		}()
+
+		restore = append(restore, func() {
+			*target = original
+			pw.Close()
+		})
+	}
+
+	return done, nil
+}
+
+// PrintSummary writes a table of the steps, their status and duration.
+func PrintSummary(w io.Writer, results []Result) {
+	if len(results) == 0 {
+		return
+	}
+
+	width := len("STEP")
+	for _, result := range results {
+		width = max(width, len(result.Name))
+	}
+
+	fmt.Fprintf(w, "\n  %-*s  %-8s  %s\n", width, "STEP", "STATUS", "DURATION")
+	var total time.Duration
+	for _, result := range results {
+		duration := "-"
+		if result.Status == StatusDone || result.Status == StatusFailed {
+			duration = round(result.Duration).String()
+			total += result.Duration
+		}
+		fmt.Fprintf(w, "  %-*s  %-8s  %s\n", width, result.Name, result.Status, duration)
+	}
+	fmt.Fprintf(w, "  %-*s  %-8s  %s\n", width, "total", "", round(total))
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(100 * time.Millisecond)
+}
@@ -0,0 +1,165 @@
+package steps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+func TestNewRunner(t *testing.T) {
+	saved := isTerminal
+	t.Cleanup(func() { isTerminal = saved })
+
+	// Color is enabled until output.Configure turns it off.
+	if !output.ColorEnabled() {
+		t.Fatal("color output disabled before the test")
+	}
+
+	isTerminal = func(*os.File) bool { return true }
+	if !NewRunner().Progress {
+		t.Error("no progress display on a terminal")
+	}
+	isTerminal = func(*os.File) bool { return false }
+	if NewRunner().Progress {
+		t.Error("progress display when stdout is not a terminal")
+	}
+}
+
+// TestRunPlainLines checks the fallback used when stdout is piped: one
+// header per step, in order, with no spinner or escape codes.
+func TestRunPlainLines(t *testing.T) {
+	list := []Step{
+		{Name: "Clean", Skip: func() string { return "nothing installed" }},
+		{Name: "Install Envoy Gateway", Run: func(ctx context.Context) error { return nil }},
+	}
+
+	var out bytes.Buffer
+	if _, err := (&Runner{Out: &out}).Run(context.Background(), list); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\n⏭️  Step 1/2: Clean skipped: nothing installed\n" +
+		"\n📋 Step 2/2: Install Envoy Gateway...\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+// TestRunWithProgress runs the progress display with stdout on a pipe, where
+// the spinner itself stays off: the output of the step is still copied
+// through and the step ends with its duration instead of a header.
+func TestRunWithProgress(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = saved })
+
+	list := []Step{
+		{Name: "Install Envoy Gateway", Run: func(ctx context.Context) error {
+			fmt.Println("Release \"eg\" has been upgraded")
+			return nil
+		}},
+		{Name: "Install AI Gateway", Run: func(ctx context.Context) error { return errors.New("boom") }},
+	}
+
+	var out bytes.Buffer
+	_, runErr := (&Runner{Out: &out, Progress: true}).Run(context.Background(), list)
+	os.Stdout = saved
+	w.Close()
+	terminal, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if runErr == nil {
+		t.Fatal("expected the second step to fail")
+	}
+	for _, want := range []string{"✅ Step 1/2: Install Envoy Gateway (", "❌ Step 2/2: Install AI Gateway failed after "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "📋") {
+		t.Errorf("progress display printed the plain headers:\n%s", out.String())
+	}
+	if !strings.Contains(string(terminal), "Release \"eg\" has been upgraded\n") {
+		t.Errorf("step output not copied to the terminal: %q", terminal)
+	}
+}
+
+func TestRunDeadline(t *testing.T) {
+	const deadline = 20 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	ran := false
+	list := []Step{
+		{Name: "Add repositories", Run: func(ctx context.Context) error { return nil }},
+		{Name: "Install Envoy Gateway", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		{Name: "Install AI Gateway", Run: func(ctx context.Context) error {
+			ran = true
+			return nil
+		}},
+	}
+
+	var out bytes.Buffer
+	results, err := (&Runner{Out: &out}).Run(ctx, list)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want the deadline", err)
+	}
+	if ran {
+		t.Error("step after the deadline ran")
+	}
+
+	want := []Status{StatusDone, StatusFailed, StatusNotRun}
+	for i, result := range results {
+		if result.Status != want[i] {
+			t.Errorf("step %q: got status %q, want %q", result.Name, result.Status, want[i])
+		}
+	}
+	if d := results[1].Duration; d < deadline {
+		t.Errorf("timed out step ran for %s, want at least %s", d, deadline)
+	}
+
+	var summary bytes.Buffer
+	PrintSummary(&summary, results)
+	if !bytes.Contains(summary.Bytes(), []byte("not run")) {
+		t.Errorf("summary %q does not report the step that did not run", summary.String())
+	}
+}
+
+func TestRunCancelledBeforeStep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	list := []Step{
+		{Name: "Add repositories", Run: func(ctx context.Context) error {
+			cancel()
+			return nil
+		}},
+		{Name: "Install Envoy Gateway", Run: func(ctx context.Context) error {
+			t.Error("step ran after the context was cancelled")
+			return nil
+		}},
+	}
+
+	results, err := (&Runner{Out: &bytes.Buffer{}}).Run(ctx, list)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+	if results[0].Status != StatusDone || results[1].Status != StatusNotRun {
+		t.Errorf("got results %+v, want the first step done and the second not run", results)
+	}
+}
@@ -0,0 +1,199 @@
+package telemetry
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+
+	defaultGRPCPort = 4317
+
+	// samplingDenominator gives the proxy sampling fraction a resolution of
+	// 0.01%.
+	samplingDenominator = 10000
+)
+
+// Options describes the OTLP collector that the gateway and the AI Gateway
+// external processor export to.
+type Options struct {
+	Endpoint   string
+	Protocol   string
+	SampleRate float64
+	Insecure   bool
+}
+
+// Validate checks the endpoint, protocol and sample rate. Endpoints are
+// host:port or a http:// or https:// URL; http:// requires Insecure.
+//
+// The proxies of the installer's EnvoyProxy export over plaintext OTLP/gRPC
+// only, so the HTTP protocol and TLS collectors are rejected rather than
+// configured for the controllers alone.
+func (o *Options) Validate() error {
+	switch o.Protocol {
+	case ProtocolGRPC:
+	case ProtocolHTTP:
+		return fmt.Errorf("OTLP protocol %s is not supported: the Envoy proxies export traces and access logs over OTLP/gRPC only", o.Protocol)
+	default:
+		return fmt.Errorf("unknown OTLP protocol %q (expected %s)", o.Protocol, ProtocolGRPC)
+	}
+	if o.SampleRate < 0 || o.SampleRate > 1 {
+		return fmt.Errorf("tracing sample rate %v must be between 0 and 1", o.SampleRate)
+	}
+
+	scheme, _, _, err := o.parse()
+	if err != nil {
+		return err
+	}
+	if scheme == "http" && !o.Insecure {
+		return fmt.Errorf("endpoint %q is plaintext; pass --otlp-insecure to allow it", o.Endpoint)
+	}
+	if scheme == "https" && o.Insecure {
+		return fmt.Errorf("endpoint %q uses https but --otlp-insecure was given", o.Endpoint)
+	}
+	if !o.Insecure {
+		return fmt.Errorf("endpoint %q would be reached over TLS, which the Envoy proxies cannot export to; use a plaintext collector with --otlp-insecure", o.Endpoint)
+	}
+	return nil
+}
+
+func (o *Options) parse() (scheme, host string, port int, err error) {
+	hostPort := o.Endpoint
+	if strings.Contains(o.Endpoint, "://") {
+		u, err := url.Parse(o.Endpoint)
+		if err != nil {
+			return "", "", 0, fmt.Errorf("invalid OTLP endpoint %q: %w", o.Endpoint, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return "", "", 0, fmt.Errorf("invalid OTLP endpoint %q: scheme must be http or https", o.Endpoint)
+		}
+		if u.Path != "" && u.Path != "/" {
+			return "", "", 0, fmt.Errorf("invalid OTLP endpoint %q: paths are not supported", o.Endpoint)
+		}
+		scheme, hostPort = u.Scheme, u.Host
+	}
+
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		// No port given: use the OTLP/gRPC default.
+		host = strings.Trim(hostPort, "[]")
+		port = defaultGRPCPort
+	} else {
+		port, err = strconv.Atoi(portStr)
+		if err != nil || port < 1 || port > 65535 {
+			return "", "", 0, fmt.Errorf("invalid OTLP endpoint %q: bad port %q", o.Endpoint, portStr)
+		}
+	}
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		return "", "", 0, fmt.Errorf("invalid OTLP endpoint %q: missing or invalid host", o.Endpoint)
+	}
+
+	return scheme, host, port, nil
+}
+
+// URL returns the endpoint as a URL, the form OTel SDKs expect.
+func (o *Options) URL() string {
+	_, host, port, _ := o.parse()
+	scheme := "https"
+	if o.Insecure {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// GatewayValues returns envoyproxy/gateway-helm values that send the Envoy
+// Gateway controller metrics to the collector. The controller does not emit
+// traces.
+func GatewayValues(o *Options) map[string]interface{} {
+	_, host, port, _ := o.parse()
+
+	return map[string]interface{}{
+		"config": map[string]interface{}{
+			"envoyGateway": map[string]interface{}{
+				"telemetry": map[string]interface{}{
+					"metrics": map[string]interface{}{
+						"sinks": []interface{}{
+							map[string]interface{}{
+								"type": "OpenTelemetry",
+								"openTelemetry": map[string]interface{}{
+									"host":     host,
+									"port":     port,
+									"protocol": o.Protocol,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// AIGatewayValues returns envoyproxy/ai-gateway-helm values that configure
+// the OTel SDK of the external processor through the standard environment
+// variables.
+func AIGatewayValues(o *Options) map[string]interface{} {
+	env := []interface{}{
+		envVar("OTEL_EXPORTER_OTLP_ENDPOINT", o.URL()),
+		envVar("OTEL_EXPORTER_OTLP_PROTOCOL", ProtocolGRPC),
+		envVar("OTEL_TRACES_SAMPLER", "parentbased_traceidratio"),
+		envVar("OTEL_TRACES_SAMPLER_ARG", strconv.FormatFloat(o.SampleRate, 'f', -1, 64)),
+	}
+	if o.Insecure {
+		env = append(env, envVar("OTEL_EXPORTER_OTLP_INSECURE", "true"))
+	}
+
+	return map[string]interface{}{
+		"extProc": map[string]interface{}{
+			"extraEnvVars": env,
+		},
+	}
+}
+
+// EnvoyProxyTelemetry returns the spec.telemetry block of an EnvoyProxy that
+// exports proxy traces and access logs to the collector. Envoy exports both
+// over OTLP/gRPC in plaintext, which Validate requires; access logs are still
+// written to stdout as well.
+func EnvoyProxyTelemetry(o *Options) map[string]interface{} {
+	_, host, port, _ := o.parse()
+	collector := map[string]interface{}{"host": host, "port": port}
+
+	return map[string]interface{}{
+		"tracing": map[string]interface{}{
+			"samplingFraction": map[string]interface{}{
+				"numerator":   int(o.SampleRate*samplingDenominator + 0.5),
+				"denominator": samplingDenominator,
+			},
+			"provider": map[string]interface{}{
+				"type": "OpenTelemetry",
+				"host": host,
+				"port": port,
+			},
+		},
+		"accessLog": map[string]interface{}{
+			"settings": []interface{}{
+				map[string]interface{}{
+					"sinks": []interface{}{
+						map[string]interface{}{
+							"type": "File",
+							"file": map[string]interface{}{"path": "/dev/stdout"},
+						},
+						map[string]interface{}{
+							"type":          "OpenTelemetry",
+							"openTelemetry": collector,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func envVar(name, value string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "value": value}
+}
@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		protocol string
+		insecure bool
+		wantErr  string
+	}{
+		{name: "plaintext host:port", endpoint: "otel-collector:4317", protocol: ProtocolGRPC, insecure: true},
+		{name: "plaintext URL", endpoint: "http://otel-collector:4317", protocol: ProtocolGRPC, insecure: true},
+		{name: "http protocol", endpoint: "otel-collector:4318", protocol: ProtocolHTTP, insecure: true, wantErr: "OTLP/gRPC only"},
+		{name: "unknown protocol", endpoint: "otel-collector:4317", protocol: "udp", insecure: true, wantErr: "unknown OTLP protocol"},
+		{name: "https URL", endpoint: "https://otel-collector:4317", protocol: ProtocolGRPC, wantErr: "over TLS"},
+		{name: "TLS host:port", endpoint: "otel-collector:4317", protocol: ProtocolGRPC, wantErr: "over TLS"},
+		{name: "http URL without insecure", endpoint: "http://otel-collector:4317", protocol: ProtocolGRPC, wantErr: "pass --otlp-insecure"},
+		{name: "https URL with insecure", endpoint: "https://otel-collector:4317", protocol: ProtocolGRPC, insecure: true, wantErr: "uses https"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Options{Endpoint: tt.endpoint, Protocol: tt.protocol, Insecure: tt.insecure, SampleRate: 1}
+			err := o.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvoyProxyTelemetry(t *testing.T) {
+	o := &Options{Endpoint: "http://otel-collector", Protocol: ProtocolGRPC, Insecure: true, SampleRate: 0.25}
+	spec := EnvoyProxyTelemetry(o)
+
+	tracing := spec["tracing"].(map[string]interface{})
+	provider := tracing["provider"].(map[string]interface{})
+	if provider["host"] != "otel-collector" || provider["port"] != defaultGRPCPort {
+		t.Errorf("got tracing provider %v, want otel-collector:%d", provider, defaultGRPCPort)
+	}
+	fraction := tracing["samplingFraction"].(map[string]interface{})
+	if fraction["numerator"] != 2500 || fraction["denominator"] != samplingDenominator {
+		t.Errorf("got sampling fraction %v, want 2500/%d", fraction, samplingDenominator)
+	}
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+
+	"golang.org/x/term"
+)
+
+// ErrAborted is returned when the user declines a confirmation.
+var ErrAborted = errors.New("aborted by user")
+
+// ErrNotInteractive is returned when a confirmation is needed but there is
+// no terminal to ask on.
+var ErrNotInteractive = errors.New("confirmation required but stdin is not a terminal; pass --yes to proceed without prompting")
+
+// Prompter asks yes/no questions on In and writes them to Out.
+type Prompter struct {
+	In  io.Reader
+	Out io.Writer
+	// Interactive reports whether In is a terminal. Without one, Confirm
+	// fails rather than wait for an answer that never comes.
+	Interactive bool
+	// AssumeYes answers every question with yes, for automation.
+	AssumeYes bool
+}
+
+// NewPrompter returns a Prompter on stdin and stdout.
+func NewPrompter(assumeYes bool) *Prompter {
+	return &Prompter{
+		In:          os.Stdin,
+		Out:         output.Stdout,
+		Interactive: IsTerminal(os.Stdin),
+		AssumeYes:   assumeYes,
+	}
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Confirm asks question and reports whether the answer was y or yes. An
+// empty answer, n, no or end of input is a no; any other answer is asked
+// again.
+func (p *Prompter) Confirm(question string) (bool, error) {
+	if p.AssumeYes {
+		return true, nil
+	}
+	if !p.Interactive {
+		return false, ErrNotInteractive
+	}
+
+	in := bufio.NewReader(p.In)
+	for {
+		fmt.Fprintf(p.Out, "%s [y/N]: ", question)
+
+		answer, err := in.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(p.Out)
+			return false, nil
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true, nil
+		case "", "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.Out, "Please answer y or n.")
+	}
+}
+
+// ConfirmActions lists the actions about to be taken under title, followed
+// by details such as the target cluster, and asks for confirmation. It
+// returns ErrAborted when the user declines.
+func (p *Prompter) ConfirmActions(title string, actions []string, details ...string) error {
+	fmt.Fprintf(p.Out, "\n%s\n", title)
+	for _, action := range actions {
+		fmt.Fprintf(p.Out, "   - %s\n", action)
+	}
+	for _, detail := range details {
+		fmt.Fprintf(p.Out, "   %s\n", detail)
+	}
+	fmt.Fprintln(p.Out)
+
+	ok, err := p.Confirm("Are you sure?")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAborted
+	}
+	return nil
+}
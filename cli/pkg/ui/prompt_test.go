@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    bool
+		prompts int
+	}{
+		{name: "y", input: "y\n", want: true, prompts: 1},
+		{name: "Y", input: "Y\n", want: true, prompts: 1},
+		{name: "yes", input: " yes \n", want: true, prompts: 1},
+		{name: "YES without newline", input: "YES", want: true, prompts: 1},
+		{name: "n", input: "n\n", prompts: 1},
+		{name: "no", input: "No\n", prompts: 1},
+		{name: "empty is the default no", input: "\n", prompts: 1},
+		{name: "end of input", input: "", prompts: 1},
+		{name: "invalid then yes", input: "sure\ny\n", want: true, prompts: 2},
+		{name: "invalid then no", input: "maybe\nyep\nn\n", prompts: 3},
+		{name: "invalid then end of input", input: "maybe\n", prompts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := &Prompter{In: strings.NewReader(tt.input), Out: &out, Interactive: true}
+
+			got, err := p.Confirm("Proceed?")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Confirm() = %v, want %v", got, tt.want)
+			}
+			if prompts := strings.Count(out.String(), "Proceed? [y/N]: "); prompts != tt.prompts {
+				t.Errorf("asked %d times, want %d:\n%s", prompts, tt.prompts, out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmWithoutTerminal(t *testing.T) {
+	p := &Prompter{In: strings.NewReader("y\n"), Out: &bytes.Buffer{}}
+	if _, err := p.Confirm("Proceed?"); !errors.Is(err, ErrNotInteractive) {
+		t.Errorf("got error %v, want ErrNotInteractive", err)
+	}
+
+	p.AssumeYes = true
+	if ok, err := p.Confirm("Proceed?"); err != nil || !ok {
+		t.Errorf("Confirm() with AssumeYes = %v, %v, want yes", ok, err)
+	}
+}
+
+func TestConfirmActions(t *testing.T) {
+	var out bytes.Buffer
+	p := &Prompter{In: strings.NewReader("\n"), Out: &out, Interactive: true}
+
+	err := p.ConfirmActions("This will:", []string{"uninstall release aieg"}, "Context: kind-dev")
+	if !errors.Is(err, ErrAborted) {
+		t.Errorf("got error %v, want ErrAborted", err)
+	}
+	for _, want := range []string{"This will:", "   - uninstall release aieg", "   Context: kind-dev", "Are you sure? [y/N]: "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package upstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultCacheTTL = time.Hour
+
+// CacheOptions controls the on-disk cache of GitHub release lookups.
+type CacheOptions struct {
+	Dir string
+	TTL time.Duration
+	// Disabled bypasses the cache entirely: nothing is read or written.
+	Disabled bool
+	// Refresh ignores the TTL and always asks GitHub, still revalidating
+	// with the cached ETag and updating the cache.
+	Refresh bool
+}
+
+var cacheOptions = CacheOptions{Dir: DefaultCacheDir(), TTL: DefaultCacheTTL}
+
+type cacheEntry struct {
+	ETag      string       `json:"etag,omitempty"`
+	FetchedAt time.Time    `json:"fetched_at"`
+	Release   ChartRelease `json:"release"`
+	// Releases and Complete are used by ListReleases; Complete means every
+	// release of the repository was fetched.
+	Releases []Release `json:"releases,omitempty"`
+	Complete bool      `json:"complete,omitempty"`
+}
+
+// DefaultCacheDir returns ~/.envoy-ai-installer/cache, or an empty string
+// (no caching) when the home directory is unknown.
+func DefaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".envoy-ai-installer", "cache")
+}
+
+func ConfigureCache(opts CacheOptions) {
+	cacheOptions = opts
+}
+
+func (o CacheOptions) enabled() bool {
+	return !o.Disabled && o.Dir != ""
+}
+
+func (o CacheOptions) path(owner, repo string) string {
+	return filepath.Join(o.Dir, fmt.Sprintf("release-%s-%s.json", owner, strings.ReplaceAll(repo, "/", "-")))
+}
+
+func (o CacheOptions) load(owner, repo string) *cacheEntry {
+	if !o.enabled() {
+		return nil
+	}
+
+	return readEntry(o.path(owner, repo))
+}
+
+func readEntry(path string) *cacheEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// CachedTags returns the release tags of owner/repo found in the cache,
+// newest version first, however old the entries are. It never makes a
+// request, so it suits shell completion.
+func CachedTags(owner, repo string) []string {
+	opts := cacheOptions
+	if !opts.enabled() {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var tags []string
+	add := func(tag string) {
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	if entry := opts.load("list-"+owner, repo); entry != nil {
+		for _, rel := range entry.Releases {
+			add(rel.Tag)
+		}
+	}
+	for _, key := range []string{owner, "pre-" + owner} {
+		if entry := opts.load(key, repo); entry != nil {
+			add(entry.Release.Version)
+		}
+	}
+	pinned, _ := filepath.Glob(opts.path("tag-"+owner, repo+"-*"))
+	for _, path := range pinned {
+		if entry := readEntry(path); entry != nil {
+			add(entry.Release.Version)
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return compareTags(tags[i], tags[j]) > 0 })
+	return tags
+}
+
+func (o CacheOptions) fresh(entry *cacheEntry) bool {
+	return entry != nil && !o.Refresh && time.Since(entry.FetchedAt) < o.TTL
+}
+
+func (o CacheOptions) save(owner, repo string, entry *cacheEntry) error {
+	if !o.enabled() {
+		return nil
+	}
+
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write through a temp file so concurrent runs never read a partial
+	// entry.
+	tmp, err := os.CreateTemp(o.Dir, ".release-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), o.path(owner, repo))
+}
@@ -0,0 +1,167 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+const latestETag = `"v1.4.0"`
+
+// latestServer serves the latest release of envoyproxy/gateway with an
+// ETag, answering a matching If-None-Match with 304 Not Modified. When
+// limited is set, every request is rate-limited instead.
+type latestServer struct {
+	requests    atomic.Int32
+	revalidated atomic.Int32
+	limited     atomic.Bool
+}
+
+func newLatestServer(t *testing.T) *latestServer {
+	t.Helper()
+	t.Setenv("GITHUB_TOKEN", "")
+	s := &latestServer{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		switch {
+		case s.limited.Load():
+			w.Header().Set("X-RateLimit-Limit", "60")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "API rate limit exceeded"}`)
+		case r.URL.Path != "/repos/envoyproxy/gateway/releases/latest":
+			http.NotFound(w, r)
+		case r.Header.Get("If-None-Match") == latestETag:
+			s.revalidated.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", latestETag)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"tag_name": "v1.4.0",
+				"assets":   []map[string]string{{"name": "gateway-helm-v1.4.0.tgz", "browser_download_url": "https://example.com/gateway-helm-v1.4.0.tgz"}},
+			})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	base, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitHubAPIURL = base
+	t.Cleanup(func() { gitHubAPIURL = nil })
+	return s
+}
+
+func useCache(t *testing.T, opts CacheOptions) {
+	t.Helper()
+	ConfigureCache(opts)
+	t.Cleanup(func() { ConfigureCache(CacheOptions{Dir: DefaultCacheDir(), TTL: DefaultCacheTTL}) })
+}
+
+func fetchLatest(t *testing.T) *ChartRelease {
+	t.Helper()
+	rel, err := FetchLatestRelease(context.Background(), "envoyproxy", "gateway", FetchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Version != "v1.4.0" {
+		t.Fatalf("got version %q, want v1.4.0", rel.Version)
+	}
+	return rel
+}
+
+func TestFetchLatestReleaseCache(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            CacheOptions
+		wantRequests    int32
+		wantRevalidated int32
+		wantFile        bool
+	}{
+		{name: "fresh entry is used", opts: CacheOptions{TTL: time.Hour}, wantRequests: 1, wantFile: true},
+		{name: "stale entry is revalidated", opts: CacheOptions{TTL: time.Nanosecond}, wantRequests: 2, wantRevalidated: 1, wantFile: true},
+		{name: "refresh revalidates", opts: CacheOptions{TTL: time.Hour, Refresh: true}, wantRequests: 2, wantRevalidated: 1, wantFile: true},
+		{name: "disabled", opts: CacheOptions{TTL: time.Hour, Disabled: true}, wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newLatestServer(t)
+			tt.opts.Dir = t.TempDir()
+			useCache(t, tt.opts)
+
+			fetchLatest(t)
+			first := tt.opts.load("envoyproxy", "gateway")
+			time.Sleep(time.Millisecond)
+			fetchLatest(t)
+
+			if got := server.requests.Load(); got != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", got, tt.wantRequests)
+			}
+			if got := server.revalidated.Load(); got != tt.wantRevalidated {
+				t.Errorf("got %d revalidations, want %d", got, tt.wantRevalidated)
+			}
+
+			entry := tt.opts.load("envoyproxy", "gateway")
+			if (entry != nil) != tt.wantFile {
+				t.Fatalf("cache entry written = %v, want %v", entry != nil, tt.wantFile)
+			}
+			if entry == nil {
+				return
+			}
+			if entry.ETag != latestETag {
+				t.Errorf("cached ETag %q, want %q", entry.ETag, latestETag)
+			}
+			if renewed := entry.FetchedAt.After(first.FetchedAt); renewed != (tt.wantRevalidated > 0) {
+				t.Errorf("fetch time renewed = %v, want it renewed only on revalidation", renewed)
+			}
+		})
+	}
+}
+
+func TestFetchLatestReleaseRateLimited(t *testing.T) {
+	server := newLatestServer(t)
+	dir := t.TempDir()
+	useCache(t, CacheOptions{Dir: dir, TTL: time.Nanosecond})
+
+	var stderr bytes.Buffer
+	saved := output.Stderr
+	output.Stderr = &stderr
+	t.Cleanup(func() { output.Stderr = saved })
+
+	server.limited.Store(true)
+	_, err := FetchLatestRelease(context.Background(), "envoyproxy", "gateway", FetchOptions{})
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.Limit != 60 {
+		t.Fatalf("got error %v without a cache, want a RateLimitError of 60 requests", err)
+	}
+
+	server.limited.Store(false)
+	fetchLatest(t)
+
+	server.limited.Store(true)
+	time.Sleep(time.Millisecond)
+	fetchLatest(t)
+	if !strings.Contains(stderr.String(), "rate limit exceeded (resets at ") {
+		t.Errorf("got warnings %q, want the stale entry used with the reset time", stderr.String())
+	}
+
+	if _, err := os.Stat(CacheOptions{Dir: dir}.path("envoyproxy", "gateway")); err != nil {
+		t.Errorf("cache entry lost after the rate-limited request: %v", err)
+	}
+}
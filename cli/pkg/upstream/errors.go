@@ -0,0 +1,221 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"github.com/google/go-github/v55/github"
+)
+
+var (
+	tokenOnce sync.Once
+	tokenErr  error
+)
+
+// ValidateToken checks GITHUB_TOKEN against the rate limit endpoint, which
+// does not count against the limit. The result is cached for the rest of the
+// run; without a token there is nothing to validate.
+func ValidateToken(ctx context.Context) error {
+	tokenOnce.Do(func() {
+		if os.Getenv("GITHUB_TOKEN") == "" {
+			return
+		}
+
+		ctx, cancel := httpclient.WithTimeout(ctx)
+		defer cancel()
+
+		_, _, err := GetGitHubClient(ctx).RateLimits(ctx)
+		if isUnauthorized(err) {
+			tokenErr = errInvalidToken
+		}
+	})
+	return tokenErr
+}
+
+// RateLimit is the state of the GitHub core API rate limit.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	// Authenticated reports whether GITHUB_TOKEN is set.
+	Authenticated bool
+}
+
+// GetRateLimit returns the core API rate limit of the GitHub client used
+// for release lookups. The request does not count against the limit.
+func GetRateLimit(ctx context.Context) (*RateLimit, error) {
+	ctx, cancel := httpclient.WithTimeout(ctx)
+	defer cancel()
+
+	limits, _, err := GetGitHubClient(ctx).RateLimits(ctx)
+	if isUnauthorized(err) {
+		return nil, errInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	core := limits.GetCore()
+	return &RateLimit{
+		Limit:         core.Limit,
+		Remaining:     core.Remaining,
+		Reset:         core.Reset.Time,
+		Authenticated: os.Getenv("GITHUB_TOKEN") != "",
+	}, nil
+}
+
+var errInvalidToken = errors.New("GITHUB_TOKEN is invalid or expired (GitHub returned 401 Unauthorized); " +
+	"create a new token or unset GITHUB_TOKEN to use anonymous access")
+
+// RateLimitError is returned when GitHub rate-limits a request and there is
+// no cached result to fall back on. It is transient: the request succeeds
+// again once the limit resets.
+type RateLimitError struct {
+	// Limit is the number of requests allowed per hour; 0 for the secondary
+	// rate limit, which has none.
+	Limit int
+	// Reset is when requests are allowed again, if GitHub said so.
+	Reset time.Time
+	// Secondary reports GitHub's secondary rate limit on bursts of requests.
+	Secondary bool
+	Err       error
+}
+
+func (e *RateLimitError) Error() string {
+	var msg string
+	if e.Secondary {
+		msg = "GitHub API secondary rate limit hit"
+		if !e.Reset.IsZero() {
+			msg += fmt.Sprintf("; retry after %s (%s)", time.Until(e.Reset).Round(time.Second), e.Reset.Local().Format("15:04:05 MST"))
+		}
+		if os.Getenv("GITHUB_TOKEN") == "" {
+			msg += ". Setting GITHUB_TOKEN makes this less likely"
+		}
+		return msg
+	}
+
+	msg = fmt.Sprintf("GitHub API rate limit of %d requests per hour exceeded; it resets at %s",
+		e.Limit, e.Reset.Local().Format("15:04:05 MST"))
+	if os.Getenv("GITHUB_TOKEN") == "" {
+		msg += ". Set GITHUB_TOKEN to raise the limit to 5000 requests per hour"
+	}
+	return msg
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// RepoNotFoundError is returned when GitHub has no such repository, or for
+// the latest release, no published release in it.
+type RepoNotFoundError struct {
+	Owner string
+	Repo  string
+	Err   error
+}
+
+func (e *RepoNotFoundError) Error() string {
+	return fmt.Sprintf("GitHub repository %s/%s not found or has no releases", e.Owner, e.Repo)
+}
+
+func (e *RepoNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// AssetNotFoundError is returned when a release has no asset whose name
+// contains one of Keywords, the names chart packages are recognised by.
+type AssetNotFoundError struct {
+	Owner    string
+	Repo     string
+	Tag      string
+	Keywords []string
+}
+
+func (e *AssetNotFoundError) Error() string {
+	return fmt.Sprintf("no chart asset found in %s/%s release %s (looked for asset names containing %s)",
+		e.Owner, e.Repo, e.Tag, strings.Join(e.Keywords, ", "))
+}
+
+// NetworkError is returned when GitHub could not be reached or did not
+// answer in time. It is transient, or a proxy or CA bundle problem.
+type NetworkError struct {
+	Owner string
+	Repo  string
+	Err   error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("cannot reach the GitHub API to fetch releases of %s/%s: %v", e.Owner, e.Repo, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient reports whether err is a rate limit or network failure, which
+// is worth retrying later, rather than a problem with the request.
+func IsTransient(err error) bool {
+	var rateErr *RateLimitError
+	var netErr *NetworkError
+	return errors.As(err, &rateErr) || errors.As(err, &netErr)
+}
+
+// friendlyError turns go-github errors into the error types above, or an
+// actionable message for an invalid token; other errors are wrapped with
+// the repository name.
+func friendlyError(owner, repo string, err error) error {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return &RateLimitError{Limit: rateErr.Rate.Limit, Reset: rateErr.Rate.Reset.Time, Err: err}
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		limitErr := &RateLimitError{Secondary: true, Err: err}
+		if retry := abuseErr.GetRetryAfter(); retry > 0 {
+			limitErr.Reset = time.Now().Add(retry)
+		}
+		return limitErr
+	}
+
+	if isUnauthorized(err) {
+		return errInvalidToken
+	}
+
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.StatusCode == http.StatusNotFound {
+		return &RepoNotFoundError{Owner: owner, Repo: repo, Err: err}
+	}
+
+	if isNetworkError(err) {
+		return &NetworkError{Owner: owner, Repo: repo, Err: err}
+	}
+
+	return fmt.Errorf("failed to fetch releases of %s/%s: %w", owner, repo, err)
+}
+
+// isNetworkError reports whether err comes from the transport rather than
+// from a GitHub response. Cancellation by the user is not one.
+func isNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func isUnauthorized(err error) bool {
+	var respErr *github.ErrorResponse
+	return errors.As(err, &respErr) && respErr.Response != nil &&
+		respErr.Response.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,179 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFriendlyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		check   func(error) bool
+		want    string
+	}{
+		{
+			name: "rate limit",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-RateLimit-Limit", "60")
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message": "API rate limit exceeded"}`)
+			},
+			check: func(err error) bool {
+				var rateErr *RateLimitError
+				return errors.As(err, &rateErr) && !rateErr.Secondary && rateErr.Limit == 60 && IsTransient(err)
+			},
+			want: "rate limit of 60 requests per hour exceeded",
+		},
+		{
+			name: "secondary rate limit",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit",
+					"documentation_url": "https://docs.github.com/rest/overview/resources-in-the-rest-api#secondary-rate-limits"}`)
+			},
+			check: func(err error) bool {
+				var rateErr *RateLimitError
+				return errors.As(err, &rateErr) && rateErr.Secondary && !rateErr.Reset.IsZero() && IsTransient(err)
+			},
+			want: "secondary rate limit hit; retry after",
+		},
+		{
+			name: "unauthorized",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+			},
+			check: func(err error) bool { return errors.Is(err, errInvalidToken) && !IsTransient(err) },
+			want:  "GITHUB_TOKEN is invalid or expired",
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			},
+			check: func(err error) bool {
+				var notFound *RepoNotFoundError
+				return errors.As(err, &notFound) && !IsTransient(err)
+			},
+			want: "envoyproxy/gateway not found or has no releases",
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message": "Server Error"}`, http.StatusInternalServerError)
+			},
+			check: func(err error) bool { return !IsTransient(err) },
+			want:  "failed to fetch releases of envoyproxy/gateway",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useGitHub(t, "", tt.handler)
+
+			_, err := FetchLatestRelease(context.Background(), "envoyproxy", "gateway", FetchOptions{})
+			if err == nil || !tt.check(err) {
+				t.Fatalf("got error %#v, want a %s error", err, tt.name)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %q, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetworkError(t *testing.T) {
+	server := useGitHub(t, "", func(w http.ResponseWriter, r *http.Request) {})
+	server.Close()
+
+	_, err := FetchLatestRelease(context.Background(), "envoyproxy", "gateway", FetchOptions{})
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || !IsTransient(err) {
+		t.Fatalf("got error %#v, want a NetworkError", err)
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		valid      bool
+		wantErr    error
+		wantChecks int32
+	}{
+		{name: "no token", wantChecks: 0},
+		{name: "valid token", token: "good", valid: true, wantChecks: 1},
+		{name: "invalid token", token: "expired", wantErr: errInvalidToken, wantChecks: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checks, lookups atomic.Int32
+			useGitHub(t, tt.token, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/rate_limit" {
+					checks.Add(1)
+					if !tt.valid {
+						http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+						return
+					}
+					fmt.Fprint(w, `{"resources": {"core": {"limit": 5000, "remaining": 4999}}}`)
+					return
+				}
+				lookups.Add(1)
+				fmt.Fprint(w, `{"tag_name": "v1.4.0", "assets": [{"name": "gateway-helm.tgz", "browser_download_url": "https://example.com/gateway-helm.tgz"}]}`)
+			})
+
+			for i := 0; i < 2; i++ {
+				_, err := FetchLatestRelease(context.Background(), "envoyproxy", "gateway", FetchOptions{})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("lookup %d: got error %v, want %v", i+1, err, tt.wantErr)
+				}
+			}
+
+			if got := checks.Load(); got != tt.wantChecks {
+				t.Errorf("token checked %d times, want %d", got, tt.wantChecks)
+			}
+			wantLookups := int32(2)
+			if tt.wantErr != nil {
+				wantLookups = 0
+			}
+			if got := lookups.Load(); got != wantLookups {
+				t.Errorf("got %d release lookups, want %d", got, wantLookups)
+			}
+		})
+	}
+}
+
+// useGitHub points the GitHub client at a server with handler, with the
+// cache disabled and GITHUB_TOKEN set to token, which is validated afresh.
+func useGitHub(t *testing.T, token string, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	t.Setenv("GITHUB_TOKEN", token)
+	useCache(t, CacheOptions{Disabled: true})
+
+	tokenOnce, tokenErr = sync.Once{}, nil
+	t.Cleanup(func() { tokenOnce, tokenErr = sync.Once{}, nil })
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	base, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitHubAPIURL = base
+	t.Cleanup(func() { gitHubAPIURL = nil })
+	return server
+}
@@ -0,0 +1,64 @@
+package upstream
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxNotesReleases bounds how many releases ReleaseNotes walks back when the
+// installed version is far behind.
+const maxNotesReleases = 50
+
+// ReleaseNotes returns the stable releases of owner/repo newer than from, up
+// to and including to, newest first. An empty to means the latest release.
+// When from is empty or not a stable version (such as a v0.0.0-latest
+// development build) only the target release is returned.
+func ReleaseNotes(ctx context.Context, owner, repo, from, to string) ([]Release, error) {
+	if _, ok := parseVersion(from); !ok {
+		from = ""
+	}
+	target, hasTarget := versionCore(to)
+
+	n := maxNotesReleases
+	if from == "" && !hasTarget {
+		n = 1
+	}
+
+	releases, err := ListReleases(ctx, owner, repo, n, ReleaseFilter{Since: from})
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []Release
+	for _, rel := range releases {
+		if hasTarget {
+			version, ok := versionCore(rel.Tag)
+			if !ok || compareVersions(version, target) > 0 {
+				continue
+			}
+		}
+		notes = append(notes, rel)
+		if from == "" {
+			break
+		}
+	}
+
+	return notes, nil
+}
+
+// TruncateNotes shortens notes to at most max characters, cutting at a line
+// break where possible. A max of zero or less disables truncation.
+func TruncateNotes(notes string, max int) (string, bool) {
+	notes = strings.TrimSpace(strings.ReplaceAll(notes, "\r\n", "\n"))
+	if max <= 0 || utf8.RuneCountInString(notes) <= max {
+		return notes, false
+	}
+
+	runes := []rune(notes)
+	cut := string(runes[:max])
+	if i := strings.LastIndex(cut, "\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut), true
+}
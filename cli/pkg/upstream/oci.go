@@ -0,0 +1,351 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+)
+
+const (
+	DefaultRegistry = "docker.io"
+
+	// dockerHubAPI serves the registry API for docker.io references.
+	dockerHubAPI = "registry-1.docker.io"
+)
+
+// OCIResolver finds chart versions from the tags of OCI repositories, which is
+// where the Envoy charts are published.
+type OCIResolver struct {
+	// Registry is the registry host of the chart references, e.g. docker.io.
+	Registry string
+	// Endpoint overrides the base URL of the registry API. It defaults to
+	// https://<Registry>, or https://registry-1.docker.io for docker.io.
+	Endpoint string
+	Client   *http.Client
+}
+
+// NewOCIResolver returns a resolver for registry that uses the shared HTTP
+// client.
+func NewOCIResolver(registry string) *OCIResolver {
+	return &OCIResolver{Registry: registry, Client: httpclient.Client()}
+}
+
+func (r *OCIResolver) endpoint() string {
+	switch {
+	case r.Endpoint != "":
+		return strings.TrimSuffix(r.Endpoint, "/")
+	case r.Registry == DefaultRegistry:
+		return "https://" + dockerHubAPI
+	default:
+		return "https://" + r.Registry
+	}
+}
+
+// LatestRelease returns the highest stable semver tag of repository (e.g.
+// envoyproxy/gateway-helm). Results share the release cache with the GitHub
+// lookups.
+func (r *OCIResolver) LatestRelease(ctx context.Context, repository string) (*ChartRelease, error) {
+	opts := cacheOptions
+	cacheKey := "oci-" + r.Registry
+	cached := opts.load(cacheKey, repository)
+	if opts.fresh(cached) {
+		return &cached.Release, nil
+	}
+
+	tags, err := r.ListTags(ctx, repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s/%s: %w", r.Registry, repository, err)
+	}
+
+	tag, ok := latestStable(tags)
+	if !ok {
+		return nil, fmt.Errorf("no stable version tag found for %s/%s", r.Registry, repository)
+	}
+
+	owner, chart := repository, repository
+	if i := strings.LastIndex(repository, "/"); i >= 0 {
+		owner, chart = repository[:i], repository[i+1:]
+	}
+
+	release := &ChartRelease{
+		Owner:   owner,
+		Repo:    chart,
+		Chart:   chart,
+		Version: tag,
+		URL:     fmt.Sprintf("oci://%s/%s", r.Registry, repository),
+	}
+
+	entry := &cacheEntry{FetchedAt: time.Now(), Release: *release}
+	if err := opts.save(cacheKey, repository, entry); err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  Could not update release cache: %v\n", err)
+	}
+
+	return release, nil
+}
+
+// ResolveVersion returns the chart version to install from repository: tag,
+// once checked to be one of its tags, or its highest stable version when tag
+// is empty. When the registry cannot be reached, the GitHub releases of
+// owner/repo, whose tags are the chart versions, are used instead.
+func (r *OCIResolver) ResolveVersion(ctx context.Context, repository, owner, repo, tag string) (string, error) {
+	if tag == "" {
+		chart, ociErr := r.LatestRelease(ctx, repository)
+		if ociErr == nil {
+			return chart.Version, nil
+		}
+		releases, err := ListReleases(ctx, owner, repo, 1, ReleaseFilter{})
+		switch {
+		case err != nil:
+			return "", fmt.Errorf("%v; GitHub fallback: %w", ociErr, err)
+		case len(releases) == 0:
+			return "", fmt.Errorf("%v; GitHub fallback: no releases of %s/%s", ociErr, owner, repo)
+		}
+		return releases[0].Tag, nil
+	}
+
+	tags, ociErr := r.ListTags(ctx, repository)
+	if ociErr == nil {
+		if slices.Contains(tags, tag) {
+			return tag, nil
+		}
+		return "", fmt.Errorf("no version %q of %s/%s (newest versions: %s)",
+			tag, r.Registry, repository, strings.Join(newestStable(tags, tagNotFoundReleases), ", "))
+	}
+	if _, err := FetchLatestRelease(ctx, owner, repo, FetchOptions{Tag: tag}); err != nil {
+		return "", fmt.Errorf("failed to list tags of %s/%s: %v; GitHub fallback: %w", r.Registry, repository, ociErr, err)
+	}
+	return tag, nil
+}
+
+// ListTags returns all tags of repository, following pagination. Registries
+// that require a token for anonymous pulls (such as Docker Hub) are handled
+// through the Bearer challenge of the first response.
+func (r *OCIResolver) ListTags(ctx context.Context, repository string) ([]string, error) {
+	ctx, cancel := httpclient.WithTimeout(ctx)
+	defer cancel()
+
+	next := fmt.Sprintf("%s/v2/%s/tags/list?n=1000", r.endpoint(), repository)
+	token := ""
+	var tags []string
+
+	for next != "" {
+		resp, err := r.get(ctx, next, token)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && token == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+
+			token, err = r.fetchToken(ctx, challenge)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = decodeResponse(resp, &page)
+		link := resp.Header.Get("Link")
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, page.Tags...)
+		next, err = nextPage(next, link)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tags, nil
+}
+
+func (r *OCIResolver) get(ctx context.Context, url, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = httpclient.Client()
+	}
+	return client.Do(req)
+}
+
+// fetchToken answers a `Bearer realm="...",service="...",scope="..."`
+// challenge with an anonymous token request.
+func (r *OCIResolver) fetchToken(ctx context.Context, challenge string) (string, error) {
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry requires authentication but sent no token realm")
+	}
+
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm %q: %w", realm, err)
+	}
+	query := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	resp, err := r.get(ctx, tokenURL.String(), "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := decodeResponse(resp, &body); err != nil {
+		return "", fmt.Errorf("failed to get registry token: %w", err)
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("registry token response contained no token")
+}
+
+func decodeResponse(resp *http.Response, v interface{}) error {
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+func parseChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return params
+	}
+	for _, m := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	return params
+}
+
+// nextPage resolves the `<url>; rel="next"` Link header against the current
+// page URL. It returns an empty string on the last page.
+func nextPage(current, link string) (string, error) {
+	if !strings.Contains(link, `rel="next"`) {
+		return "", nil
+	}
+
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("invalid Link header %q", link)
+	}
+
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return "", fmt.Errorf("invalid Link header %q: %w", link, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// latestStable returns the highest tag of the form [v]MAJOR.MINOR.PATCH,
+// ignoring pre-releases such as v0.0.0-latest.
+func latestStable(tags []string) (string, bool) {
+	var best string
+	var bestVersion [3]int
+	found := false
+
+	for _, tag := range tags {
+		version, ok := parseVersion(tag)
+		if !ok {
+			continue
+		}
+		if !found || compareVersions(version, bestVersion) > 0 {
+			best, bestVersion, found = tag, version, true
+		}
+	}
+
+	return best, found
+}
+
+// newestStable returns up to n of the highest stable semver tags, highest
+// first.
+func newestStable(tags []string, n int) []string {
+	var stable []string
+	for _, tag := range tags {
+		if _, ok := parseVersion(tag); ok {
+			stable = append(stable, tag)
+		}
+	}
+	sort.SliceStable(stable, func(i, j int) bool { return compareTags(stable[i], stable[j]) > 0 })
+	return stable[:min(n, len(stable))]
+}
+
+// parseVersion parses a [v]MAJOR.MINOR.PATCH tag, rejecting pre-releases.
+func parseVersion(tag string) ([3]int, bool) {
+	var version [3]int
+
+	core := strings.TrimPrefix(tag, "v")
+	if i := strings.Index(core, "+"); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return version, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (len(part) > 1 && part[0] == '0') {
+			return version, false
+		}
+		version[i] = n
+	}
+
+	return version, true
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] > b[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,133 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestResolveVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry bool
+		github   bool
+		tag      string
+		want     string
+		wantErr  string
+	}{
+		{name: "latest from registry", registry: true, github: true, want: "v1.4.1"},
+		{name: "pinned tag in registry", registry: true, tag: "v1.3.0", want: "v1.3.0"},
+		{name: "pre-release tag in registry", registry: true, tag: "v1.5.0-rc.1", want: "v1.5.0-rc.1"},
+		{name: "unknown tag", registry: true, github: true, tag: "v9.9.9", wantErr: "newest versions: v1.4.1, v1.4.0, v1.3.0"},
+		{name: "latest from GitHub", github: true, want: "v1.4.0"},
+		{name: "pinned tag on GitHub", github: true, tag: "v1.3.0", want: "v1.3.0"},
+		{name: "unknown tag on GitHub", github: true, tag: "v9.9.9", wantErr: "newest releases: v1.5.0-rc.1, v1.4.0, v1.3.0"},
+		{name: "both unreachable", wantErr: "GitHub fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := fakeRegistry(t, tt.registry, []string{"v0.0.0-latest", "v1.3.0", "v1.4.0"}, []string{"v1.4.1", "v1.5.0-rc.1"})
+			fakeGitHub(t, tt.github, []fakeRelease{
+				{Tag: "v1.5.0-rc.1", Prerelease: true},
+				{Tag: "v1.4.0"},
+				{Tag: "v1.3.0"},
+			})
+
+			got, err := resolver.ResolveVersion(context.Background(), "envoyproxy/gateway-helm", "envoyproxy", "gateway", tt.tag)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got version %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeRegistry serves the tags of envoyproxy/gateway-helm in two pages,
+// behind an anonymous token challenge like docker.io's. When up is false,
+// every request fails.
+func fakeRegistry(t *testing.T, up bool, page1, page2 []string) *OCIResolver {
+	t.Helper()
+	ConfigureCache(CacheOptions{Disabled: true})
+	t.Cleanup(func() { ConfigureCache(CacheOptions{Dir: DefaultCacheDir(), TTL: DefaultCacheTTL}) })
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case !up:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:envoyproxy/gateway-helm:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": "anonymous"})
+		case r.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="registry",scope="repository:envoyproxy/gateway-helm:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path != "/v2/envoyproxy/gateway-helm/tags/list":
+			http.NotFound(w, r)
+		case r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/envoyproxy/gateway-helm/tags/list?n=1000&last=v1.4.0>; rel="next"`)
+			json.NewEncoder(w).Encode(map[string]interface{}{"tags": page1})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"tags": page2})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return &OCIResolver{Registry: DefaultRegistry, Endpoint: server.URL, Client: server.Client()}
+}
+
+type fakeRelease struct {
+	Tag        string `json:"tag_name"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// fakeGitHub points the GitHub client at a server with the releases of
+// envoyproxy/gateway, newest first. When up is false, every request fails.
+func fakeGitHub(t *testing.T, up bool, releases []fakeRelease) {
+	t.Helper()
+	t.Setenv("GITHUB_TOKEN", "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch path := r.URL.Path; {
+		case path == "/repos/envoyproxy/gateway/releases":
+			json.NewEncoder(w).Encode(releases)
+		case strings.HasPrefix(path, "/repos/envoyproxy/gateway/releases/tags/"):
+			tag := strings.TrimPrefix(path, "/repos/envoyproxy/gateway/releases/tags/")
+			for _, rel := range releases {
+				if rel.Tag == tag {
+					json.NewEncoder(w).Encode(rel)
+					return
+				}
+			}
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	base, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitHubAPIURL = base
+	t.Cleanup(func() { gitHubAPIURL = nil })
+}
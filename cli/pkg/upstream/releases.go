@@ -0,0 +1,275 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
+	"github.com/google/go-github/v55/github"
+)
+
+const releasesPerPage = 100
+
+// Release is a single GitHub release of an upstream repository.
+type Release struct {
+	Tag         string    `json:"tag"`
+	PublishedAt time.Time `json:"published_at"`
+	Prerelease  bool      `json:"prerelease"`
+	URL         string    `json:"url"`
+	Notes       string    `json:"notes,omitempty"`
+	Assets      []Asset   `json:"assets,omitempty"`
+}
+
+// Asset is a file attached to a GitHub release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Size int    `json:"size"`
+}
+
+// FindAsset returns the asset of rel called name.
+func (rel Release) FindAsset(name string) (Asset, bool) {
+	for _, asset := range rel.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// ReleaseFilter narrows the releases returned by ListReleases.
+type ReleaseFilter struct {
+	IncludePrereleases bool
+	// Since keeps only releases with a higher semver than this version.
+	Since string
+}
+
+// Validate checks that Since is a version.
+func (f ReleaseFilter) Validate() error {
+	if f.Since == "" {
+		return nil
+	}
+	if _, ok := versionCore(f.Since); !ok {
+		return fmt.Errorf("invalid since version %q (expected MAJOR.MINOR.PATCH)", f.Since)
+	}
+	return nil
+}
+
+func (f ReleaseFilter) matches(rel Release) bool {
+	if rel.Prerelease && !f.IncludePrereleases {
+		return false
+	}
+	if f.Since == "" {
+		return true
+	}
+
+	since, ok := versionCore(f.Since)
+	if !ok {
+		return true
+	}
+	version, ok := versionCore(rel.Tag)
+	return ok && compareVersions(version, since) > 0
+}
+
+// versionCore parses the MAJOR.MINOR.PATCH part of a tag, so pre-releases
+// compare like the release they lead up to.
+func versionCore(tag string) ([3]int, bool) {
+	core, _, _ := strings.Cut(tag, "-")
+	return parseVersion(core)
+}
+
+// ListReleases returns up to n of the newest releases of owner/repo that
+// match filter, or all of them when n is 0, paging through the GitHub API as
+// needed. The pages fetched
+// are cached like FetchLatestRelease results, so later calls for the same
+// or fewer releases are served from disk until the TTL expires.
+func ListReleases(ctx context.Context, owner, repo string, n int, filter ReleaseFilter) ([]Release, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	opts := cacheOptions
+	cacheKey := "list-" + owner
+	cached := opts.load(cacheKey, repo)
+	if opts.fresh(cached) {
+		if matched, done := filterReleases(cached.Releases, n, filter); done || cached.Complete {
+			return matched, nil
+		}
+	}
+
+	if err := ValidateToken(ctx); err != nil {
+		return nil, err
+	}
+
+	client := GetGitHubClient(ctx)
+	ctx, cancel := httpclient.WithTimeout(ctx)
+	defer cancel()
+
+	entry := &cacheEntry{FetchedAt: time.Now()}
+	listOpts := &github.ListOptions{PerPage: releasesPerPage}
+	for {
+		page, resp, err := client.Repositories.ListReleases(ctx, owner, repo, listOpts)
+		if err != nil {
+			if reset, limited := rateLimitReset(err); limited && cached != nil {
+				fmt.Fprintf(output.Stderr, "⚠️  GitHub API rate limit exceeded (resets at %s); using cached %s/%s releases from %s\n",
+					reset.Local().Format("15:04:05"), owner, repo, cached.FetchedAt.Local().Format("2006-01-02 15:04"))
+				matched, _ := filterReleases(cached.Releases, n, filter)
+				return matched, nil
+			}
+			return nil, friendlyError(owner, repo, err)
+		}
+
+		for _, rel := range page {
+			if rel.GetDraft() {
+				continue
+			}
+			release := Release{
+				Tag:         rel.GetTagName(),
+				PublishedAt: rel.GetPublishedAt().Time,
+				Prerelease:  rel.GetPrerelease(),
+				URL:         rel.GetHTMLURL(),
+				Notes:       rel.GetBody(),
+			}
+			for _, asset := range rel.Assets {
+				release.Assets = append(release.Assets, Asset{
+					Name: asset.GetName(),
+					URL:  asset.GetBrowserDownloadURL(),
+					Size: asset.GetSize(),
+				})
+			}
+			entry.Releases = append(entry.Releases, release)
+		}
+
+		if resp.NextPage == 0 {
+			entry.Complete = true
+			break
+		}
+		if _, done := filterReleases(entry.Releases, n, filter); done {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	if err := opts.save(cacheKey, repo, entry); err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  Could not update release cache: %v\n", err)
+	}
+
+	matched, _ := filterReleases(entry.Releases, n, filter)
+	return matched, nil
+}
+
+// ListAvailableVersions returns the tags of all releases of owner/repo,
+// newest version first. Pre-releases are only included with
+// includePreRelease.
+func ListAvailableVersions(ctx context.Context, owner, repo string, includePreRelease bool) ([]string, error) {
+	releases, err := ListReleases(ctx, owner, repo, 0, ReleaseFilter{IncludePrereleases: includePreRelease})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]string, 0, len(releases))
+	for _, rel := range releases {
+		tags = append(tags, rel.Tag)
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return compareTags(tags[i], tags[j]) > 0 })
+	return tags, nil
+}
+
+// IsStableVersion reports whether tag is a [v]MAJOR.MINOR.PATCH release,
+// not a pre-release.
+func IsStableVersion(tag string) bool {
+	_, ok := parseVersion(tag)
+	return ok
+}
+
+// IsNewerVersion reports whether tag a is a later version than tag b.
+func IsNewerVersion(a, b string) bool {
+	return compareTags(a, b) > 0
+}
+
+// compareTags orders release tags by version. A pre-release sorts before the
+// release it leads up to, and tags that are not versions before all
+// versions.
+func compareTags(a, b string) int {
+	versionA, okA := versionCore(a)
+	versionB, okB := versionCore(b)
+	switch {
+	case okA && !okB:
+		return 1
+	case !okA && okB:
+		return -1
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	}
+	if c := compareVersions(versionA, versionB); c != 0 {
+		return c
+	}
+
+	_, preA, _ := strings.Cut(a, "-")
+	_, preB, _ := strings.Cut(b, "-")
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return comparePrerelease(preA, preB)
+}
+
+// comparePrerelease compares pre-release identifiers as semver does: dot
+// separated parts in order, numbers numerically, so rc.10 follows rc.2.
+func comparePrerelease(a, b string) int {
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, errA := strconv.Atoi(partsA[i])
+		numB, errB := strconv.Atoi(partsB[i])
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = numA - numB
+		case errA == nil:
+			c = -1
+		case errB == nil:
+			c = 1
+		default:
+			c = strings.Compare(partsA[i], partsB[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return len(partsA) - len(partsB)
+}
+
+// filterReleases returns the first n releases matching filter and whether n
+// were found.
+func filterReleases(releases []Release, n int, filter ReleaseFilter) ([]Release, bool) {
+	matched := []Release{}
+	for _, rel := range releases {
+		if !filter.matches(rel) {
+			continue
+		}
+		matched = append(matched, rel)
+		if len(matched) == n {
+			return matched, true
+		}
+	}
+	return matched, false
+}
+
+// Repositories returns the GitHub repositories of the upstream components,
+// as owner/repo.
+func Repositories() []string {
+	var repos []string
+	for _, up := range upstreams {
+		repos = append(repos, up.owner+"/"+up.repo)
+	}
+	return repos
+}
@@ -2,60 +2,281 @@ package upstream
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/output"
 	"github.com/google/go-github/v55/github"
 	"golang.org/x/oauth2"
 )
 
 type ChartRelease struct {
-	Owner   string
-	Repo    string
+	Owner string
+	Repo  string
+	// Chart is the OCI chart name (e.g. gateway-helm); empty for GitHub
+	// releases that do not correspond to a chart.
+	Chart   string
 	Version string
 	URL     string
+	// Notes, PublishedAt and Prerelease are only known for GitHub releases.
+	Notes       string
+	PublishedAt time.Time
+	Prerelease  bool
 }
 
-func GetGitHubClient() *github.Client {
+// FetchOptions selects the release FetchLatestRelease returns.
+type FetchOptions struct {
+	// PreRelease returns the newest release even if it is a pre-release.
+	// GitHub's latest release endpoint never returns one, so the release
+	// list is used instead.
+	PreRelease bool
+	// Tag returns the release with this tag instead, failing with the
+	// newest tags when there is none.
+	Tag string
+}
+
+// preReleasePageSize is the number of releases listed to find the newest
+// one; only drafts are skipped, so the first page is enough.
+const preReleasePageSize = 10
+
+// gitHubAPIURL overrides the base URL of the GitHub API; tests point it at a
+// fake server.
+var gitHubAPIURL *url.URL
+
+// GetGitHubClient returns a GitHub client built on the shared HTTP client, so
+// it honours proxy settings, the CA bundle and the network timeout.
+func GetGitHubClient(ctx context.Context) *github.Client {
+	httpClient := httpclient.Client()
+
 	token := os.Getenv("GITHUB_TOKEN")
 	if token != "" {
-		ctx := context.Background()
 		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-		tc := oauth2.NewClient(ctx, ts)
-		return github.NewClient(tc)
+		tc := oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, httpClient), ts)
+		tc.Timeout = httpClient.Timeout
+		httpClient = tc
+	}
+
+	client := github.NewClient(httpClient)
+	if gitHubAPIURL != nil {
+		client.BaseURL = gitHubAPIURL
+	}
+	return client
+}
+
+// FetchLatestRelease returns the latest release of owner/repo, with
+// PreRelease the newest one, or with Tag the release of that tag. Results are cached on disk for the configured
+// TTL and revalidated with their ETag once stale. When GitHub rate-limits the
+// request a cached result is used, however old, with a warning. A warning is
+// also printed whenever a pre-release is selected.
+func FetchLatestRelease(ctx context.Context, owner, repo string, fetch FetchOptions) (*ChartRelease, error) {
+	var chart *ChartRelease
+	var err error
+	if fetch.Tag != "" {
+		chart, err = fetchReleaseByTag(ctx, owner, repo, fetch.Tag)
+	} else {
+		chart, err = fetchLatestRelease(ctx, owner, repo, fetch)
+	}
+	if err == nil && chart.Prerelease {
+		fmt.Fprintf(output.Stderr, "⚠️  %s/%s %s is a pre-release; do not use it in production\n", owner, repo, chart.Version)
 	}
-	return github.NewClient(nil)
+	return chart, err
 }
 
-func FetchLatestRelease(owner, repo string) (*ChartRelease, error) {
-	client := GetGitHubClient()
-	ctx := context.Background()
+func fetchLatestRelease(ctx context.Context, owner, repo string, fetch FetchOptions) (*ChartRelease, error) {
+	path := fmt.Sprintf("repos/%s/%s/releases/latest", owner, repo)
+	cacheKey := owner
+	if fetch.PreRelease {
+		path = fmt.Sprintf("repos/%s/%s/releases?per_page=%d", owner, repo, preReleasePageSize)
+		cacheKey = "pre-" + owner
+	}
 
-	rel, _, err := client.Repositories.GetLatestRelease(ctx, owner, repo)
+	opts := cacheOptions
+	cached := opts.load(cacheKey, repo)
+	if opts.fresh(cached) {
+		return &cached.Release, nil
+	}
+
+	if err := ValidateToken(ctx); err != nil {
+		return nil, err
+	}
+
+	client := GetGitHubClient(ctx)
+	ctx, cancel := httpclient.WithTimeout(ctx)
+	defer cancel()
+
+	req, err := client.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	rel := new(github.RepositoryRelease)
+	var page []*github.RepositoryRelease
+	var resp *github.Response
+	if fetch.PreRelease {
+		resp, err = client.Do(ctx, req, &page)
+	} else {
+		resp, err = client.Do(ctx, req, rel)
+	}
+	if resp != nil && resp.StatusCode == http.StatusNotModified && cached != nil {
+		cached.FetchedAt = time.Now()
+		if err := opts.save(cacheKey, repo, cached); err != nil {
+			fmt.Fprintf(output.Stderr, "⚠️  Could not update release cache: %v\n", err)
+		}
+		return &cached.Release, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch latest release for %s/%s: %w", owner, repo, err)
+		if reset, limited := rateLimitReset(err); limited && cached != nil {
+			fmt.Fprintf(output.Stderr, "⚠️  GitHub API rate limit exceeded (resets at %s); using cached %s/%s release from %s\n",
+				reset.Local().Format("15:04:05"), owner, repo, cached.FetchedAt.Local().Format("2006-01-02 15:04"))
+			return &cached.Release, nil
+		}
+		return nil, friendlyError(owner, repo, err)
+	}
+
+	if fetch.PreRelease {
+		rel = newestRelease(page)
+		if rel == nil {
+			return nil, fmt.Errorf("no releases found for %s/%s", owner, repo)
+		}
 	}
 
 	url := findChartAsset(rel)
 	if url == "" {
-		return nil, fmt.Errorf("no chart asset found for %s/%s", owner, repo)
+		return nil, &AssetNotFoundError{Owner: owner, Repo: repo, Tag: rel.GetTagName(), Keywords: chartAssetKeywords}
+	}
+
+	chart := &ChartRelease{
+		Owner:       owner,
+		Repo:        repo,
+		Version:     rel.GetTagName(),
+		URL:         url,
+		Notes:       rel.GetBody(),
+		PublishedAt: rel.GetPublishedAt().Time,
+		Prerelease:  rel.GetPrerelease(),
+	}
+
+	entry := &cacheEntry{ETag: resp.Header.Get("ETag"), FetchedAt: time.Now(), Release: *chart}
+	if err := opts.save(cacheKey, repo, entry); err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  Could not update release cache: %v\n", err)
 	}
 
-	return &ChartRelease{
-		Owner:   owner,
-		Repo:    repo,
-		Version: rel.GetTagName(),
-		URL:     url,
-	}, nil
+	return chart, nil
 }
 
-func findChartAsset(rel *github.RepositoryRelease) string {
-	keywords := []string{"helm", "chart", ".tgz", "tar.gz"}
+// fetchReleaseByTag returns the release of owner/repo tagged tag, cached
+// like the latest release. Its chart asset is optional: the tag only pins the
+// chart version, so the release page is used when there is none.
+func fetchReleaseByTag(ctx context.Context, owner, repo, tag string) (*ChartRelease, error) {
+	opts := cacheOptions
+	cacheKey, cacheRepo := "tag-"+owner, repo+"-"+tag
+	cached := opts.load(cacheKey, cacheRepo)
+	if opts.fresh(cached) {
+		return &cached.Release, nil
+	}
+
+	if err := ValidateToken(ctx); err != nil {
+		return nil, err
+	}
+
+	client := GetGitHubClient(ctx)
+	requestCtx, cancel := httpclient.WithTimeout(ctx)
+	defer cancel()
+
+	rel, resp, err := client.Repositories.GetReleaseByTag(requestCtx, owner, repo, tag)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, tagNotFound(ctx, owner, repo, tag)
+	}
+	if err != nil {
+		if reset, limited := rateLimitReset(err); limited && cached != nil {
+			fmt.Fprintf(output.Stderr, "⚠️  GitHub API rate limit exceeded (resets at %s); using cached %s/%s %s release from %s\n",
+				reset.Local().Format("15:04:05"), owner, repo, tag, cached.FetchedAt.Local().Format("2006-01-02 15:04"))
+			return &cached.Release, nil
+		}
+		return nil, friendlyError(owner, repo, err)
+	}
+
+	url := findChartAsset(rel)
+	if url == "" {
+		url = rel.GetHTMLURL()
+	}
 
+	chart := &ChartRelease{
+		Owner:       owner,
+		Repo:        repo,
+		Version:     rel.GetTagName(),
+		URL:         url,
+		Notes:       rel.GetBody(),
+		PublishedAt: rel.GetPublishedAt().Time,
+		Prerelease:  rel.GetPrerelease(),
+	}
+
+	entry := &cacheEntry{FetchedAt: time.Now(), Release: *chart}
+	if err := opts.save(cacheKey, cacheRepo, entry); err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  Could not update release cache: %v\n", err)
+	}
+
+	return chart, nil
+}
+
+// tagNotFoundReleases is the number of releases suggested when a tag does
+// not exist.
+const tagNotFoundReleases = 5
+
+func tagNotFound(ctx context.Context, owner, repo, tag string) error {
+	releases, err := ListReleases(ctx, owner, repo, tagNotFoundReleases, ReleaseFilter{IncludePrereleases: true})
+	if err != nil || len(releases) == 0 {
+		return fmt.Errorf("no release tagged %q in %s/%s", tag, owner, repo)
+	}
+
+	tags := make([]string, 0, len(releases))
+	for _, rel := range releases {
+		tags = append(tags, rel.Tag)
+	}
+	return fmt.Errorf("no release tagged %q in %s/%s (newest releases: %s)", tag, owner, repo, strings.Join(tags, ", "))
+}
+
+// newestRelease returns the first release that is not a draft. GitHub lists
+// releases newest first.
+func newestRelease(releases []*github.RepositoryRelease) *github.RepositoryRelease {
+	for _, rel := range releases {
+		if !rel.GetDraft() {
+			return rel
+		}
+	}
+	return nil
+}
+
+func rateLimitReset(err error) (time.Time, bool) {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return rateErr.Rate.Reset.Time, true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return time.Now().Add(abuseErr.GetRetryAfter()), true
+	}
+
+	return time.Time{}, false
+}
+
+// chartAssetKeywords identify the chart package among the assets of a
+// release.
+var chartAssetKeywords = []string{"helm", "chart", ".tgz", "tar.gz"}
+
+func findChartAsset(rel *github.RepositoryRelease) string {
 	for _, asset := range rel.Assets {
 		name := asset.GetName()
-		for _, keyword := range keywords {
+		for _, keyword := range chartAssetKeywords {
 			if strings.Contains(strings.ToLower(name), keyword) {
 				return asset.GetBrowserDownloadURL()
 			}
@@ -65,32 +286,82 @@ func findChartAsset(rel *github.RepositoryRelease) string {
 	return ""
 }
 
-func GetUpstreamCharts() ([]ChartRelease, error) {
-	upstreams := []struct {
-		owner string
-		repo  string
-	}{
-		{"envoyproxy", "gateway"},
-		{"envoyproxy", "ai-gateway-helm"},
-		{"envoyproxy", "ai-gateway-crds-helm"},
-		{"envoyproxy", "ai-gateway"},
-	}
+// upstreamChart is a component whose version is reported by the version
+// command. Charts are resolved from their OCI repository first, with the
+// GitHub release of owner/repo as a fallback.
+type upstreamChart struct {
+	chart string
+	owner string
+	repo  string
+}
+
+var upstreams = []upstreamChart{
+	{"gateway-helm", "envoyproxy", "gateway"},
+	{"ai-gateway-helm", "envoyproxy", "ai-gateway-helm"},
+	{"ai-gateway-crds-helm", "envoyproxy", "ai-gateway-crds-helm"},
+	{"", "envoyproxy", "ai-gateway"},
+}
+
+// GetUpstreamCharts resolves the latest version of every upstream component.
+// With PreRelease, versions come from the GitHub releases: OCI tags do not
+// say whether they are pre-releases, and the v0.0.0-latest development tags
+// would otherwise win.
+func GetUpstreamCharts(ctx context.Context, fetch FetchOptions) ([]ChartRelease, error) {
+	resolver := NewOCIResolver(DefaultRegistry)
 
 	var charts []ChartRelease
-	var errors []string
+	var failures []string
 
 	for _, up := range upstreams {
-		chart, err := FetchLatestRelease(up.owner, up.repo)
+		chart, err := resolveUpstream(ctx, resolver, up, fetch)
 		if err != nil {
-			errors = append(errors, err.Error())
+			// Rate limit and token errors are the same for every repository.
+			if !contains(failures, err.Error()) {
+				failures = append(failures, err.Error())
+			}
 			continue
 		}
 		charts = append(charts, *chart)
 	}
 
-	if len(errors) > 0 {
-		return charts, fmt.Errorf("errors fetching upstream charts:\n%s", strings.Join(errors, "\n"))
+	if len(failures) > 0 {
+		return charts, fmt.Errorf("errors fetching upstream charts:\n%s", strings.Join(failures, "\n"))
 	}
 
 	return charts, nil
 }
+
+func resolveUpstream(ctx context.Context, resolver *OCIResolver, up upstreamChart, fetch FetchOptions) (*ChartRelease, error) {
+	if up.chart == "" {
+		return FetchLatestRelease(ctx, up.owner, up.repo, fetch)
+	}
+	if fetch.PreRelease {
+		chart, err := FetchLatestRelease(ctx, up.owner, up.repo, fetch)
+		if err != nil {
+			return nil, err
+		}
+		chart.Chart = up.chart
+		return chart, nil
+	}
+
+	chart, ociErr := resolver.LatestRelease(ctx, up.owner+"/"+up.chart)
+	if ociErr == nil {
+		return chart, nil
+	}
+
+	chart, err := FetchLatestRelease(ctx, up.owner, up.repo, fetch)
+	if err != nil {
+		return nil, fmt.Errorf("%v; GitHub fallback: %w", ociErr, err)
+	}
+	chart.Chart = up.chart
+	return chart, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package values
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+)
+
+// fetched maps each downloaded reference to its temporary file, so a file
+// used by several releases is only downloaded once per run.
+var fetched = map[string]string{}
+
+// IsRemote reports whether ref is a values file that Fetch must download
+// before Helm can read it.
+func IsRemote(ref string) bool {
+	return IsS3(ref) || IsVault(ref)
+}
+
+// Fetch downloads an s3:// or vault:// values file to a temporary file and
+// returns its path. The file is removed by RemoveTempFiles.
+func Fetch(ctx context.Context, ref string) (string, error) {
+	if file, ok := fetched[ref]; ok {
+		return file, nil
+	}
+
+	var fetch func(context.Context, string, io.Writer) error
+	switch {
+	case IsS3(ref):
+		fetch = fetchS3
+	case IsVault(ref):
+		fetch = fetchVault
+	default:
+		return "", fmt.Errorf("unsupported values file URL %q", ref)
+	}
+
+	tmpFile, err := CreateTemp("envoy-ai-values-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+
+	ctx, cancel := httpclient.WithTimeout(ctx)
+	defer cancel()
+
+	if err := fetch(ctx, ref, tmpFile); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to download %s: %w", ref, err)
+	}
+
+	fetched[ref] = tmpFile.Name()
+	return tmpFile.Name(), nil
+}
@@ -0,0 +1,109 @@
+package values
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+)
+
+const s3Scheme = "s3://"
+
+// IsS3 reports whether ref is an s3://bucket/key reference.
+func IsS3(ref string) bool {
+	return strings.HasPrefix(ref, s3Scheme)
+}
+
+// fetchS3 downloads an s3://bucket/key object to w. Credentials and the
+// region come from the standard AWS chain (environment, shared config and
+// credentials files, SSO, web identity, container and instance roles).
+// S3-compatible stores are reached by setting AWS_ENDPOINT_URL_S3 (or
+// AWS_ENDPOINT_URL); they are addressed path-style.
+func fetchS3(ctx context.Context, ref string, w io.Writer) error {
+	bucket, key, err := parseS3URL(ref)
+	if err != nil {
+		return err
+	}
+
+	client, err := newS3Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	obj, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+	defer obj.Body.Close()
+
+	_, err = io.Copy(w, obj.Body)
+	return err
+}
+
+// CheckS3Credentials resolves credentials from the AWS chain and returns
+// where they came from.
+func CheckS3Credentials(ctx context.Context) (string, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := httpclient.WithTimeout(ctx)
+	defer cancel()
+
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("no valid AWS credentials: %w", err)
+	}
+	return creds.Source, nil
+}
+
+func parseS3URL(ref string) (string, string, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "s3" {
+		return "", "", fmt.Errorf("invalid S3 URL %q (expected s3://bucket/key)", ref)
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q (expected s3://bucket/key)", ref)
+	}
+	return u.Host, key, nil
+}
+
+func loadAWSConfig(ctx context.Context) (aws.Config, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithHTTPClient(httpclient.Client()))
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		// Buckets outside us-east-1 answer with a redirect naming their
+		// region; set AWS_REGION for those.
+		cfg.Region = "us-east-1"
+	}
+	return cfg, nil
+}
+
+func newS3Client(ctx context.Context) (*s3.Client, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	customEndpoint := os.Getenv("AWS_ENDPOINT_URL_S3") != "" || os.Getenv("AWS_ENDPOINT_URL") != ""
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		// MinIO and most other S3-compatible stores do not support
+		// virtual-hosted bucket addressing.
+		o.UsePathStyle = customEndpoint
+	}), nil
+}
@@ -0,0 +1,98 @@
+package values
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetArgs flattens v into Helm --set arguments under prefix, such as
+// "controller.tolerations[0].key=gpu". v is encoded with its JSON field names,
+// so Kubernetes API types keep their manifest names. Strings are returned
+// separately, to be passed with --set-string, so that values such as "true"
+// or "1" keep their type. Empty maps and lists are skipped.
+func SetArgs(prefix string, v interface{}) (set, setString []string, err error) {
+	plain, err := Plain(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	flatten(prefix, plain, &set, &setString)
+	return set, setString, nil
+}
+
+// Plain converts v to the maps, lists and scalars it would decode to from a
+// values file.
+func Plain(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode values: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var plain interface{}
+	if err := decoder.Decode(&plain); err != nil {
+		return nil, fmt.Errorf("failed to encode values: %w", err)
+	}
+	return numbers(plain), nil
+}
+
+// numbers replaces the json.Numbers left by the decoder with integers where
+// possible, so they are rendered without quotes.
+func numbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = numbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = numbers(value)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		n, _ := v.Float64()
+		return n
+	}
+	return v
+}
+
+func flatten(path string, v interface{}, set, setString *[]string) {
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			flatten(path+"."+escapeKey(key), v[key], set, setString)
+		}
+	case []interface{}:
+		for i, value := range v {
+			flatten(fmt.Sprintf("%s[%d]", path, i), value, set, setString)
+		}
+	case string:
+		*setString = append(*setString, path+"="+escapeValue(v))
+	default:
+		*set = append(*set, fmt.Sprintf("%s=%v", path, v))
+	}
+}
+
+// escapeKey escapes the characters Helm treats as path separators, as in
+// label keys like kubernetes.io/hostname.
+func escapeKey(key string) string {
+	return strings.NewReplacer(`\`, `\\`, ".", `\.`, "[", `\[`, "=", `\=`, ",", `\,`).Replace(key)
+}
+
+func escapeValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`).Replace(value)
+}
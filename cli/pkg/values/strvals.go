@@ -0,0 +1,193 @@
+package values
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplySet applies Helm --set arguments, such as those built by SetArgs, to
+// vals. With asString the values are kept as strings, as --set-string does;
+// otherwise true, false, null and numbers are typed. Keys and values use
+// Helm's escapes: \. \[ \= \, and \\.
+func ApplySet(vals map[string]interface{}, args []string, asString bool) error {
+	for _, arg := range args {
+		for _, assignment := range splitUnescaped(arg, ',') {
+			if err := applyOne(vals, assignment, asString); err != nil {
+				return fmt.Errorf("invalid --set %q: %w", arg, err)
+			}
+		}
+	}
+	return nil
+}
+
+// pathPart is one segment of a --set key: a map key, or a list index when
+// index is not negative.
+type pathPart struct {
+	key   string
+	index int
+}
+
+func applyOne(vals map[string]interface{}, assignment string, asString bool) error {
+	parts := splitUnescaped(assignment, '=')
+	if len(parts) < 2 {
+		return fmt.Errorf("missing =")
+	}
+	key := parts[0]
+	raw := unescape(assignment[len(key)+1:])
+
+	path, err := parsePath(key)
+	if err != nil {
+		return err
+	}
+
+	var value interface{} = raw
+	if !asString {
+		value = typedValue(raw)
+	}
+
+	_, err = setPath(vals, path, value)
+	return err
+}
+
+// parsePath splits a --set key such as a.b\.c[0].d into its parts.
+func parsePath(key string) ([]pathPart, error) {
+	var parts []pathPart
+	var current strings.Builder
+	haveKey := false
+
+	flush := func() {
+		if haveKey {
+			parts = append(parts, pathPart{key: current.String(), index: -1})
+		}
+		current.Reset()
+		haveKey = false
+	}
+
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; c {
+		case '\\':
+			if i+1 < len(key) {
+				i++
+			}
+			current.WriteByte(key[i])
+			haveKey = true
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(key[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in %q", key)
+			}
+			index, err := strconv.Atoi(key[i+1 : i+end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index in %q", key)
+			}
+			parts = append(parts, pathPart{index: index})
+			i += end
+		default:
+			current.WriteByte(c)
+			haveKey = true
+		}
+	}
+	flush()
+
+	if len(parts) == 0 || parts[0].index >= 0 {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+	return parts, nil
+}
+
+// setPath sets the value at path below node, creating maps and growing
+// lists as needed, and returns the updated node.
+func setPath(node interface{}, path []pathPart, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	part := path[0]
+	if part.index >= 0 {
+		list, _ := node.([]interface{})
+		for len(list) <= part.index {
+			list = append(list, nil)
+		}
+		child, err := setPath(list[part.index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		list[part.index] = child
+		return list, nil
+	}
+
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+	}
+	child, err := setPath(m[part.key], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	if child == nil && len(path) == 1 {
+		delete(m, part.key)
+		return m, nil
+	}
+	m[part.key] = child
+	return m, nil
+}
+
+// typedValue converts a --set value the way Helm does for booleans, null
+// and integers. Decimal numbers are kept as numbers as well, since the
+// installer only passes them for numeric settings.
+func typedValue(raw string) interface{} {
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	// Like Helm, keep zero-padded numbers such as 007 as strings.
+	if len(raw) > 1 && raw[0] == '0' && raw[1] != '.' {
+		return raw
+	}
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return n
+	}
+	if strings.ContainsAny(raw, "iInN") {
+		return raw
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return f
+	}
+	return raw
+}
+
+// splitUnescaped splits s on sep, ignoring separators escaped with a
+// backslash. The escapes are kept.
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package values
+
+import (
+	"os"
+	"sync"
+)
+
+// tempFiles are the temporary values files of this run. They can hold
+// secrets, so RemoveTempFiles deletes them when the command exits.
+var (
+	tempMu    sync.Mutex
+	tempFiles []string
+)
+
+// CreateTemp creates a temporary values file, like os.CreateTemp, that only
+// the current user can read, and tracks it for RemoveTempFiles.
+func CreateTemp(pattern string) (*os.File, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	tempMu.Lock()
+	tempFiles = append(tempFiles, f.Name())
+	tempMu.Unlock()
+	return f, nil
+}
+
+// WriteTemp writes data to a new file from CreateTemp and returns its path.
+// The file is removed again when writing it fails.
+func WriteTemp(pattern string, data []byte) (string, error) {
+	f, err := CreateTemp(pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// RemoveTempFiles removes every file created by CreateTemp, and forgets the
+// downloads that Fetch kept in them.
+func RemoveTempFiles() {
+	tempMu.Lock()
+	defer tempMu.Unlock()
+
+	for _, file := range tempFiles {
+		os.Remove(file)
+	}
+	tempFiles = nil
+	clear(fetched)
+}
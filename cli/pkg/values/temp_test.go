@@ -0,0 +1,34 @@
+package values
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestRemoveTempFiles(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	f, err := CreateTemp("envoy-ai-values-*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	fetched["vault://secret/data/gateway#values"] = f.Name()
+
+	info, err := os.Stat(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm != 0o600 {
+		t.Errorf("got mode %v, want 0600", perm)
+	}
+
+	RemoveTempFiles()
+	if _, err := os.Stat(f.Name()); !os.IsNotExist(err) {
+		t.Errorf("temporary file still exists (stat error: %v)", err)
+	}
+	if len(fetched) != 0 {
+		t.Errorf("Fetch still returns the removed files: %v", fetched)
+	}
+}
@@ -0,0 +1,54 @@
+package values
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Merge loads the values files in order and deep-merges them the way Helm
+// does: maps are merged recursively and any other value from a later file
+// replaces the earlier one. A null is kept as nil, as it is for Helm, which
+// removes the key from the chart's defaults only when it renders the chart.
+func Merge(files []string) (map[string]interface{}, error) {
+	merged := map[string]interface{}{}
+
+	for _, file := range files {
+		current, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMaps(merged, current)
+	}
+
+	return merged, nil
+}
+
+// Load reads a single values file. An empty file yields an empty map.
+func Load(file string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file %s: %w", file, err)
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("invalid values file %s: %w", file, err)
+	}
+	return values, nil
+}
+
+func mergeMaps(dst, src map[string]interface{}) map[string]interface{} {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[key] = mergeMaps(dstMap, srcMap)
+			continue
+		}
+
+		dst[key] = value
+	}
+	return dst
+}
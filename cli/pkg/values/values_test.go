@@ -0,0 +1,82 @@
+package values
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "maps merged recursively",
+			files: []string{"controller:\n  logLevel: info\n  replicas: 1\n", "controller:\n  logLevel: debug\n"},
+			want:  map[string]interface{}{"controller": map[string]interface{}{"logLevel": "debug", "replicas": 1}},
+		},
+		{
+			name:  "lists replaced",
+			files: []string{"args: [--a, --b]\n", "args: [--c]\n"},
+			want:  map[string]interface{}{"args": []interface{}{"--c"}},
+		},
+		{
+			name:  "map replaced by a scalar",
+			files: []string{"resources:\n  limits:\n    cpu: 1\n", "resources: {}\n", "service: {type: ClusterIP}\n", "service: LoadBalancer\n"},
+			want:  map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": 1}}, "service": "LoadBalancer"},
+		},
+		{
+			name:  "null kept to unset the chart default",
+			files: []string{"podLabels:\n  team: ai\nnodeSelector:\n  disk: ssd\n", "podLabels:\n  team: null\nnodeSelector: null\n"},
+			want:  map[string]interface{}{"podLabels": map[string]interface{}{"team": nil}, "nodeSelector": nil},
+		},
+		{
+			name:  "value after null",
+			files: []string{"replicas: null\n", "replicas: 3\n"},
+			want:  map[string]interface{}{"replicas": 3},
+		},
+		{
+			name:  "empty file",
+			files: []string{"replicas: 2\n", ""},
+			want:  map[string]interface{}{"replicas": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var files []string
+			for i, data := range tt.files {
+				path := filepath.Join(dir, string(rune('a'+i))+".yaml")
+				if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, path)
+			}
+
+			got, err := Merge(files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(path, []byte("replicas: [1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Merge([]string{path}); err == nil {
+		t.Error("invalid values file merged")
+	}
+	if _, err := Merge([]string{path + ".missing"}); err == nil {
+		t.Error("missing values file merged")
+	}
+}
@@ -0,0 +1,173 @@
+package values
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/franck-sorel/envoy-ai-unified-installer/pkg/httpclient"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	vaultScheme = "vault://"
+
+	// vaultValuesKey holds a complete values document when a secret stores
+	// the YAML as a single string.
+	vaultValuesKey = "values"
+
+	defaultVaultK8sMount    = "kubernetes"
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// IsVault reports whether ref is a vault://path/to/secret reference.
+func IsVault(ref string) bool {
+	return strings.HasPrefix(ref, vaultScheme)
+}
+
+// fetchVault reads the secret at vault://<path> from VAULT_ADDR and writes it
+// to w as a values file. The path is the API path below /v1, so KV v2
+// secrets include the data/ segment (vault://secret/data/envoy/values). A
+// secret with a single "values" key is written verbatim; otherwise its
+// key/value pairs become the values.
+func fetchVault(ctx context.Context, ref string, w io.Writer) error {
+	path := strings.Trim(strings.TrimPrefix(ref, vaultScheme), "/")
+	if path == "" {
+		return fmt.Errorf("invalid Vault URL %q (expected vault://path/to/secret)", ref)
+	}
+
+	addr := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	token, err := vaultToken(ctx, addr)
+	if err != nil {
+		return err
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := vaultRequest(ctx, http.MethodGet, addr+"/v1/"+path, token, nil, &secret); err != nil {
+		return err
+	}
+
+	data := secret.Data
+	// KV v2 nests the secret under data.data, next to data.metadata.
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = inner
+		}
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("secret %s has no data", path)
+	}
+
+	if doc, ok := data[vaultValuesKey].(string); ok && len(data) == 1 {
+		_, err := io.WriteString(w, doc)
+		return err
+	}
+
+	out, err := yaml.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// vaultToken returns VAULT_TOKEN; when VAULT_K8S_ROLE is set, a token from
+// the Kubernetes auth method using the pod's service account; and otherwise
+// the token saved by `vault login` in ~/.vault-token.
+func vaultToken(ctx context.Context, addr string) (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	if role := os.Getenv("VAULT_K8S_ROLE"); role != "" {
+		return vaultKubernetesLogin(ctx, addr, role)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			if token := strings.TrimSpace(string(data)); token != "" {
+				return token, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no Vault token: set VAULT_TOKEN, run 'vault login', or set VAULT_K8S_ROLE for Kubernetes auth")
+}
+
+func vaultKubernetesLogin(ctx context.Context, addr, role string) (string, error) {
+	mount := os.Getenv("VAULT_K8S_MOUNT")
+	if mount == "" {
+		mount = defaultVaultK8sMount
+	}
+
+	jwt, err := os.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("kubernetes auth needs a service account token: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"role": role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var login struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	url := fmt.Sprintf("%s/v1/auth/%s/login", addr, strings.Trim(mount, "/"))
+	if err := vaultRequest(ctx, http.MethodPost, url, "", body, &login); err != nil {
+		return "", fmt.Errorf("vault kubernetes login as role %q failed: %w", role, err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault kubernetes login as role %q returned no token", role)
+	}
+	return login.Auth.ClientToken, nil
+}
+
+func vaultRequest(ctx context.Context, method, url, token string, body []byte, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&vaultErr)
+		if len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("vault returned HTTP %d: %s", resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("vault returned HTTP %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,76 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Info describes the build of the installer.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s %s)", i.Version, i.Commit, i.BuildTime, i.GoVersion, i.Platform)
+}
+
+var injected Info
+
+// Set records the version, commit and build time injected into package main
+// with -ldflags -X. Empty values are taken from the build information the Go
+// toolchain embeds in the binary.
+func Set(version, commit, buildTime string) {
+	injected = Info{Version: version, Commit: commit, BuildTime: buildTime}
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return resolve(injected, debug.ReadBuildInfo)
+}
+
+// resolve fills in the fields of info that were not injected: the module
+// version for 'go install' builds, the VCS revision and time that 'go build'
+// records in a git checkout, and the Go version and platform of the binary.
+func resolve(info Info, readBuildInfo func() (*debug.BuildInfo, bool)) Info {
+	info.GoVersion = runtime.Version()
+	info.Platform = runtime.GOOS + "/" + runtime.GOARCH
+
+	if build, ok := readBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+
+		settings := make(map[string]string)
+		for _, s := range build.Settings {
+			settings[s.Key] = s.Value
+		}
+		if info.Commit == "" && settings["vcs.revision"] != "" {
+			info.Commit = settings["vcs.revision"]
+			if settings["vcs.modified"] == "true" {
+				info.Commit += "-dirty"
+			}
+		}
+		if info.BuildTime == "" {
+			info.BuildTime = settings["vcs.time"]
+		}
+		if build.GoVersion != "" {
+			info.GoVersion = build.GoVersion
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
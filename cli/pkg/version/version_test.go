@@ -0,0 +1,84 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	goInstall := &debug.BuildInfo{
+		GoVersion: "go1.26.0",
+		Main:      debug.Module{Path: "github.com/franck-sorel/envoy-ai-unified-installer", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2026-01-02T03:04:05Z"},
+		},
+	}
+	dirtyCheckout := &debug.BuildInfo{
+		GoVersion: "go1.26.0",
+		Main:      debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "def456"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		injected Info
+		build    *debug.BuildInfo
+		want     Info
+	}{
+		{
+			name:     "ldflags win over the build information",
+			injected: Info{Version: "v2.0.0", Commit: "1234567", BuildTime: "2026-10-01T00:00:00Z"},
+			build:    goInstall,
+			want:     Info{Version: "v2.0.0", Commit: "1234567", BuildTime: "2026-10-01T00:00:00Z", GoVersion: "go1.26.0"},
+		},
+		{
+			name:     "partial ldflags",
+			injected: Info{Version: "v2.0.0"},
+			build:    goInstall,
+			want:     Info{Version: "v2.0.0", Commit: "abc123", BuildTime: "2026-01-02T03:04:05Z", GoVersion: "go1.26.0"},
+		},
+		{
+			name:  "go install",
+			build: goInstall,
+			want:  Info{Version: "v1.2.3", Commit: "abc123", BuildTime: "2026-01-02T03:04:05Z", GoVersion: "go1.26.0"},
+		},
+		{
+			name:  "go build in a modified checkout",
+			build: dirtyCheckout,
+			want:  Info{Version: "dev", Commit: "def456-dirty", BuildTime: "unknown", GoVersion: "go1.26.0"},
+		},
+		{
+			name: "no build information",
+			want: Info{Version: "dev", Commit: "unknown", BuildTime: "unknown", GoVersion: runtime.Version()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolve(tt.injected, func() (*debug.BuildInfo, bool) {
+				return tt.build, tt.build != nil
+			})
+
+			tt.want.Platform = runtime.GOOS + "/" + runtime.GOARCH
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	saved := injected
+	t.Cleanup(func() { injected = saved })
+
+	Set("v3.0.0", "fedcba9", "2026-10-16T00:00:00Z")
+	got := Get()
+	if got.Version != "v3.0.0" || got.Commit != "fedcba9" || got.BuildTime != "2026-10-16T00:00:00Z" {
+		t.Errorf("Get() = %+v, want the values passed to Set", got)
+	}
+}